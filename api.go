@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The body of every failed JSON API response.
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSONHTTPHandler is the JSON counterpart of ErrorHTTPHandler.
+// Handlers return the status code and a value to marshal as the response body (nil for no body).
+// Errors are reported as an apiError using the same HTTPError status code mapping.
+func JSONHTTPHandler(h func(http.ResponseWriter, *http.Request) (int, any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, v, err := h(w, r)
+		if err != nil {
+			sc = errorStatusCode(r, err)
+			v = apiError{Code: sc, Message: err.Error()}
+		}
+		writeJSON(w, sc, v)
+	}
+}
+
+// writeJSON marshals v before writing any headers so that a marshaling failure can still be reported.
+func writeJSON(w http.ResponseWriter, sc int, v any) {
+	if v == nil {
+		w.WriteHeader(sc)
+		return
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		sc = http.StatusInternalServerError
+		b, _ = json.Marshal(apiError{Code: sc, Message: err.Error()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(sc)
+	w.Write(append(b, '\n'))
+}
+
+// encodeJSON writes v as the response body of an ErrorHTTPHandler that negotiated application/json.
+func encodeJSON(w http.ResponseWriter, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(v)
+}
+
+// apiTimer is a CountDown in an API response. When humanize is set its machine fields get plain english companions,
+// so that scripts don't each have to turn a timestamp into "3 days ago".
+type apiTimer struct {
+	CountDown
+	humanize bool
+}
+
+// The only language that the humanizer speaks, so it's what every request negotiates.
+const humanizeLanguage = "en"
+
+// humanizeRequested reports whether r wants apiTimers humanized, which they are unless it has ?humanize=false.
+// Humanized responses say which language they're in.
+func humanizeRequested(w http.ResponseWriter, r *http.Request) (bool, error) {
+	humanize := true
+	if v := r.URL.Query().Get("humanize"); v != "" {
+		var err error
+		if humanize, err = strconv.ParseBool(v); err != nil {
+			return false, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'humanize': %w", err)}
+		}
+	}
+	if humanize {
+		w.Header().Set("Content-Language", humanizeLanguage)
+	}
+	return humanize, nil
+}
+
+// newAPITimers is apiTimer for a list of timers, which is never nil so that it marshals as [].
+func newAPITimers(timers []CountDown, humanize bool) []apiTimer {
+	ts := make([]apiTimer, 0, len(timers))
+	for _, c := range timers {
+		ts = append(ts, apiTimer{c, humanize})
+	}
+	return ts
+}
+
+// MarshalJSON is CountDown's JSON, with nextDueHuman, sinceLastHuman and frequencyHuman added when humanized.
+// Each is left out when the field that it describes is.
+func (t apiTimer) MarshalJSON() ([]byte, error) {
+	if !t.humanize {
+		return t.CountDown.MarshalJSON()
+	}
+	type countDown CountDown // Drops the methods so that CountDown.MarshalJSON isn't promoted.
+	now := clock.Now()
+	v := struct {
+		countDown
+		NextDue        time.Time `json:"nextDue,omitzero"`
+		NextDueHuman   string    `json:"nextDueHuman,omitempty"`
+		SinceLastHuman string    `json:"sinceLastHuman,omitempty"`
+		FrequencyHuman string    `json:"frequencyHuman,omitempty"`
+	}{countDown: countDown(t.CountDown), NextDue: t.NextDue(), FrequencyHuman: t.schedule(humanizeLanguage)}
+	if !v.NextDue.IsZero() {
+		v.NextDueHuman = locale(humanizeLanguage).humanizeRelative(v.NextDue, now)
+	}
+	if !t.LastTime.IsZero() {
+		v.SinceLastHuman = locale(humanizeLanguage).humanizeRelative(t.LastTime, now)
+	}
+	return json.Marshal(v)
+}
+
+// decodeTimer reads a CountDown from a JSON request body.
+func decodeTimer(r *http.Request) (CountDown, error) {
+	var c CountDown
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		return c, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing timer JSON: %w", err)}
+	}
+	return c, nil
+}
+
+// registerAPI adds the JSON API routes to m, mirroring the HTML routes.
+func (s *Server) registerAPI(m *routes) {
+	m.HandleFunc("GET /api/v1/timers", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		humanize, err := humanizeRequested(w, r)
+		if err != nil {
+			return 0, nil, err
+		}
+		timers, err := s.listTimers(r.Context())
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, newAPITimers(timers, humanize), nil
+	}))
+
+	m.HandleFunc("GET /api/v1/timers/{id}", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		humanize, err := humanizeRequested(w, r)
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := timerID(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		c, err := s.getTimer(r.Context(), id)
+		return http.StatusOK, apiTimer{c, humanize}, err
+	}))
+
+	m.HandleFunc("POST /api/v1/timers", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		humanize, err := humanizeRequested(w, r)
+		if err != nil {
+			return 0, nil, err
+		}
+		c, err := decodeTimer(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		key, err := idempotencyKey(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		created, err := s.createTimerOnce(r.Context(), key, &c)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		w.Header().Set("Location", "/api/v1/timers/"+strconv.FormatInt(c.Id, 10))
+		if !created {
+			return http.StatusOK, apiTimer{c, humanize}, nil // A retry, c is the timer from the first request.
+		}
+		return http.StatusCreated, apiTimer{c, humanize}, nil
+	}))
+
+	m.HandleFunc("PUT /api/v1/timers/{id}", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		humanize, err := humanizeRequested(w, r)
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := timerID(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		c, err := decodeTimer(r)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		// The path decides which timer is updated, not the body.
+		c.Id = id
+		var conflict versionConflictError
+		if err := s.updateTimer(r.Context(), &c); errors.As(err, &conflict) {
+			// What the client would have overwritten, for it to make the change again to.
+			return http.StatusConflict, apiTimer{conflict.Current, humanize}, nil
+		} else if err != nil {
+			return 0, nil, err
+		}
+		// Read back rather than echo the body, which doesn't have what updateTimer leaves alone, like the history.
+		c, err = s.getTimer(r.Context(), id)
+		return http.StatusOK, apiTimer{c, humanize}, err
+	}))
+
+	m.HandleFunc("DELETE /api/v1/timers/{id}", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		id, err := timerID(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, s.deleteTimer(r.Context(), id)
+	}))
+
+	m.HandleFunc("POST /api/v1/timers/{id}/reset", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		humanize, err := humanizeRequested(w, r)
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := timerID(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := s.resetTimer(r.Context(), id, clock.Now(), CompletionDone, ""); err != nil {
+			return 0, nil, err
+		}
+		c, err := s.getTimer(r.Context(), id)
+		return http.StatusOK, apiTimer{c, humanize}, err
+	}))
+
+	m.HandleFunc("GET "+instanceStatsPath, JSONHTTPHandler(s.instanceStatsHandler))
+}
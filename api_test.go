@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveAPI sends a request with an optional JSON body to the server's mux and returns the recorded response.
+func serveAPI(t *testing.T, s *Server, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	return w
+}
+
+// decodeResponse unmarshals a JSON response body into v.
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", ct)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", w.Body.String(), err)
+	}
+}
+
+// TestCountDownJSON tests that timers marshal with RFC3339 timestamps and the computed next due time
+func TestCountDownJSON(t *testing.T) {
+	lastTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := CountDown{Id: 7, Name: "Water plants", LastTime: lastTime, Frequency: 24 * time.Hour}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	expected := `{"id":7,"name":"Water plants","description":"","lastTime":"2025-01-02T03:04:05Z","frequency":86400000000000,"nextDue":"2025-01-03T03:04:05Z"}`
+	if string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+
+	// Timers that were never done leave out lastTime entirely.
+	b, err = json.Marshal(CountDown{Id: 8})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if strings.Contains(string(b), "lastTime") {
+		t.Errorf("Expected no lastTime for a zero time, got %s", b)
+	}
+}
+
+// TestAPIListAndGet tests GET /api/v1/timers and GET /api/v1/timers/{id}
+func TestAPIListAndGet(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+
+	// An empty database is an empty list, not null.
+	w := serveAPI(t, s, "GET", "/api/v1/timers", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+	if strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("Expected an empty list, got %q", w.Body.String())
+	}
+
+	testTimers := insertTestData(t, db)
+
+	var timers []CountDown
+	decodeResponse(t, serveAPI(t, s, "GET", "/api/v1/timers", ""), &timers)
+	if len(timers) != len(testTimers) {
+		t.Fatalf("Expected %d timers, got %d", len(testTimers), len(timers))
+	}
+	for i := range timers {
+		if timers[i].Name != testTimers[i].Name {
+			t.Errorf("Expected timer name %q, got %q", testTimers[i].Name, timers[i].Name)
+		}
+	}
+
+	var c CountDown
+	decodeResponse(t, serveAPI(t, s, "GET", fmt.Sprintf("/api/v1/timers/%d", testTimers[0].Id), ""), &c)
+	if c.Id != testTimers[0].Id || c.Frequency != testTimers[0].Frequency {
+		t.Errorf("Expected timer %+v, got %+v", testTimers[0], c)
+	}
+}
+
+// TestAPICreateUpdateDelete walks a timer through its lifecycle over the JSON API
+func TestAPICreateUpdateDelete(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+
+	// Create
+	w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Oil change","description":"Car","lastTime":"2025-01-02T00:00:00Z","frequency":86400000000000}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var created CountDown
+	decodeResponse(t, w, &created)
+	if created.Id == 0 || created.Name != "Oil change" {
+		t.Errorf("Unexpected created timer: %+v", created)
+	}
+	if loc := w.Header().Get("Location"); loc != fmt.Sprintf("/api/v1/timers/%d", created.Id) {
+		t.Errorf("Unexpected Location header: %q", loc)
+	}
+
+	// Update
+	path := fmt.Sprintf("/api/v1/timers/%d", created.Id)
+	w = serveAPI(t, s, "PUT", path, `{"name":"Oil change","description":"Truck","frequency":172800000000000,"version":1}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	var updated CountDown
+	decodeResponse(t, w, &updated)
+	// What's stored, including what the body can't change.
+	if updated.Description != "Truck" || updated.CreatedAt.IsZero() || updated.Version != 2 {
+		t.Errorf("Expected the stored timer back, got %+v", updated)
+	}
+	var fetched CountDown
+	decodeResponse(t, serveAPI(t, s, "GET", path, ""), &fetched)
+	if fetched.Description != "Truck" || fetched.Frequency != 48*time.Hour || !fetched.LastTime.IsZero() {
+		t.Errorf("Update was not persisted: %+v", fetched)
+	}
+
+	// Reset
+	w = serveAPI(t, s, "POST", path+"/reset", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	decodeResponse(t, w, &fetched)
+	if time.Since(fetched.LastTime) > time.Minute {
+		t.Errorf("Reset did not update lastTime: %v", fetched.LastTime)
+	}
+
+	// Delete
+	w = serveAPI(t, s, "DELETE", path, "")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status NoContent, got %v", w.Code)
+	}
+	if w = serveAPI(t, s, "GET", path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected NotFound after delete, got %v", w.Code)
+	}
+}
+
+// TestAPIErrors tests that failures come back as JSON objects with the HTTPError status code
+func TestAPIErrors(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+
+	tests := []struct {
+		name, method, target, body string
+		expectedStatus             int
+	}{
+		{"unknown timer", "GET", "/api/v1/timers/999", "", http.StatusNotFound},
+		{"bad id", "GET", "/api/v1/timers/abc", "", http.StatusBadRequest},
+		{"bad json", "POST", "/api/v1/timers", `{"name":`, http.StatusBadRequest},
+		{"update unknown timer", "PUT", "/api/v1/timers/999", `{"name":"x"}`, http.StatusNotFound},
+		{"delete unknown timer", "DELETE", "/api/v1/timers/999", "", http.StatusNotFound},
+		{"reset unknown timer", "POST", "/api/v1/timers/999/reset", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAPI(t, s, tt.method, tt.target, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			var e apiError
+			decodeResponse(t, w, &e)
+			if e.Code != tt.expectedStatus || e.Message == "" {
+				t.Errorf("Unexpected error body: %+v", e)
+			}
+		})
+	}
+}
+
+// TestAPIReferenceURL tests that the reference URL round trips and is validated over the JSON API
+func TestAPIReferenceURL(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+
+	w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Descale kettle","referenceUrl":"https://example.com/kettle"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var c CountDown
+	decodeResponse(t, w, &c)
+	decodeResponse(t, serveAPI(t, s, "GET", fmt.Sprintf("/api/v1/timers/%d", c.Id), ""), &c)
+	if c.ReferenceURL != "https://example.com/kettle" {
+		t.Errorf("Expected the reference URL to be stored, got %q", c.ReferenceURL)
+	}
+
+	w = serveAPI(t, s, "PUT", fmt.Sprintf("/api/v1/timers/%d", c.Id), `{"name":"Descale kettle","referenceUrl":"javascript:alert(1)"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected BadRequest, got %v", w.Code)
+	}
+}
+
+// TestAPIFrequencyUnits tests creating timers with calendar units, as text, and with the nanoseconds of older clients
+func TestAPIFrequencyUnits(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+
+	w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Rent","lastTime":"2025-01-31T00:00:00Z","frequencyValue":1,"frequencyUnit":"month"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var rent CountDown
+	decodeResponse(t, w, &rent)
+	if rent.Frequency != 30*24*time.Hour || !rent.NextDue().Equal(time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a month, due on the last day of February, got %+v", rent)
+	}
+
+	w = serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Oil change","frequency":7776000000000000}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var oil CountDown
+	decodeResponse(t, w, &oil)
+	if oil.FrequencyValue != 3 || oil.FrequencyUnit != UnitMonth {
+		t.Errorf("Expected 90 days to be 3 months, got %+v", oil)
+	}
+
+	// A one-time timer is never due again.
+	w = serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Renew passport","lastTime":"2025-01-31T00:00:00Z"}`)
+	if w.Code != http.StatusCreated || strings.Contains(w.Body.String(), "nextDue") {
+		t.Errorf("Expected a timer without nextDue, got %v: %s", w.Code, w.Body.String())
+	}
+
+	w = serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Gym","frequency":"2 weeks"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var gym CountDown
+	decodeResponse(t, w, &gym)
+	if gym.FrequencyValue != 2 || gym.FrequencyUnit != UnitWeek || gym.Frequency != 14*24*time.Hour {
+		t.Errorf("Expected text to be read as 2 weeks, got %+v", gym)
+	}
+
+	for _, body := range []string{
+		`{"name":"Bad","frequency":"0 days"}`,
+		`{"name":"Bad","frequencyValue":2,"frequencyUnit":"fortnight"}`,
+		`{"name":"Bad","frequencyValue":0,"frequencyUnit":"week"}`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status Bad Request, got %v: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestAPIHumanize tests the humanized companions of the machine fields, and leaving them out with ?humanize=false
+func TestAPIHumanize(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+	s := &Server{db: setupTestDB(t)}
+
+	w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Gym","lastTime":"2025-03-02T12:00:00Z","frequency":"1 week"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var created CountDown
+	decodeResponse(t, w, &created)
+	target := fmt.Sprintf("/api/v1/timers/%d", created.Id)
+
+	w = serveAPI(t, s, "GET", target, "")
+	var human map[string]any
+	decodeResponse(t, w, &human)
+	for field, expected := range map[string]string{
+		"nextDueHuman":   "in 4 days",
+		"sinceLastHuman": "3 days ago",
+		"frequencyHuman": "every week",
+	} {
+		if human[field] != expected {
+			t.Errorf("Expected %s %q, got %q", field, expected, human[field])
+		}
+	}
+	if lang := w.Header().Get("Content-Language"); lang != "en" {
+		t.Errorf("Expected Content-Language en, got %q", lang)
+	}
+
+	w = serveAPI(t, s, "GET", "/api/v1/timers?humanize=false", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "Human") {
+		t.Errorf("Expected no humanized fields, got %s", w.Body.String())
+	}
+
+	if w := serveAPI(t, s, "GET", target+"?humanize=sometimes", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+}
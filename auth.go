@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// requireToken makes every request that can change timers, everything under /admin/, the instance stats and the lists of
+// dashboards present the -api-token as a bearer token. Other reads stay open so that the dashboard can be viewed by
+// anyone on the network.
+// Without a token nothing is checked.
+func (s *Server) requireToken(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+		// The lists of dashboards have their tokens, which would let anyone see their timers for good.
+		private := strings.HasPrefix(r.URL.Path, "/admin/") || r.URL.Path == instanceStatsPath ||
+			r.URL.Path == "/api/v1/dashboards" || r.URL.Path == dashboardListPath
+		if s.apiToken == "" || (read && !private) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="countup"`)
+			unauthorized(w, r, errors.New("This server needs an API token for this request"))
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.apiToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="countup", error="invalid_token"`)
+			unauthorized(w, r, errors.New("Wrong API token"))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// unauthorized fails with a 401 in the same shape as the route's other errors.
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	failRequest(w, r, http.StatusUnauthorized, err)
+}
+
+// failRequest fails from outside of a handler, as JSON for the API and plain text like ErrorHTTPHandler otherwise.
+func failRequest(w http.ResponseWriter, r *http.Request, code int, err error) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		writeJSON(w, code, apiError{Code: code, Message: err.Error()})
+		return
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// refuseWrites fails every request that could change something with a 503 when the server is read-only.
+// sqlite would refuse the writes anyway, this is so that the reason is clear.
+func (s *Server) refuseWrites(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.readOnly && r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+			failRequest(w, r, http.StatusServiceUnavailable, errors.New("This server is read-only, its database was migrated by a newer version"))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
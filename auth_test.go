@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRequireToken tests that changes need the API token while reads stay open
+func TestRequireToken(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	s := &Server{db: db, apiToken: "s3cret"}
+	timerPath := fmt.Sprintf("/timer/%d", testTimers[0].Id)
+	apiPath := fmt.Sprintf("/api/v1/timers/%d", testTimers[0].Id)
+
+	tests := []struct {
+		name, method, target, authorization string
+		expectedStatus                      int
+		expectedChallenge                   string
+	}{
+		{"read without token", "GET", "/", "", http.StatusOK, ""},
+		{"api read without token", "GET", "/api/v1/timers", "", http.StatusOK, ""},
+		{"dashboards without token", "GET", "/api/v1/dashboards", "", http.StatusUnauthorized, `Bearer realm="countup"`},
+		{"dashboard list without token", "GET", "/dashboards/list", "", http.StatusUnauthorized, `Bearer realm="countup"`},
+		{"dashboard list with token", "GET", "/dashboards/list", "Bearer s3cret", http.StatusOK, ""},
+		{"dashboards page without token", "GET", "/dashboards", "", http.StatusOK, ""},
+		{"admin read without token", "GET", "/admin/env", "", http.StatusUnauthorized, `Bearer realm="countup"`},
+		{"admin read with token", "GET", "/admin/env", "Bearer s3cret", http.StatusOK, ""},
+		{"missing token", "POST", timerPath + "/reset", "", http.StatusUnauthorized, `Bearer realm="countup"`},
+		{"not a bearer token", "DELETE", timerPath, "Basic czNjcmV0", http.StatusUnauthorized, `Bearer realm="countup"`},
+		{"wrong token", "POST", timerPath + "/reset", "Bearer nope", http.StatusUnauthorized, `Bearer realm="countup", error="invalid_token"`},
+		{"api wrong token", "PUT", apiPath, "Bearer nope", http.StatusUnauthorized, `Bearer realm="countup", error="invalid_token"`},
+		{"valid token", "POST", timerPath + "/reset", "Bearer s3cret", http.StatusOK, ""},
+		{"api valid token", "POST", apiPath + "/reset", "Bearer s3cret", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			s.mux().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if challenge := w.Header().Get("WWW-Authenticate"); challenge != tt.expectedChallenge {
+				t.Errorf("Expected WWW-Authenticate %q, got %q", tt.expectedChallenge, challenge)
+			}
+			if w.Code == http.StatusUnauthorized && strings.HasPrefix(tt.target, "/api/") {
+				var e apiError
+				decodeResponse(t, w, &e)
+				if e.Code != http.StatusUnauthorized {
+					t.Errorf("Unexpected error body: %+v", e)
+				}
+			}
+		})
+	}
+
+	// Without a token everything is open, as before.
+	req := httptest.NewRequest("DELETE", fmt.Sprintf("/timer/%d", testTimers[1].Id), nil)
+	w := httptest.NewRecorder()
+	(&Server{db: db}).mux().ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status NoContent without a token configured, got %v", w.Code)
+	}
+}
+
+// TestAPITokenInPages tests that the live pages give htmx the API token to send, and that the static ones, which are
+// shared as dashboards, don't
+func TestAPITokenInPages(t *testing.T) {
+	s := &Server{db: setupTestDB(t), apiToken: "s3cret"}
+	var err error
+	if s.templates, s.staticTemplates, _, err = overrideTemplates("", template.FuncMap{"apiToken": func() string { return s.apiToken }}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if body := w.Body.String(); !strings.Contains(body, `<meta name="api-token" content="s3cret">`) || !strings.Contains(body, `hx-headers='js:`) {
+		t.Errorf("Expected the homepage to have the API token for htmx, got %s", body)
+	}
+
+	var buf strings.Builder
+	if err := s.renderStatic(t.Context(), &buf, "homepage", homePageData{Empty: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("Expected a static page to leave out the API token, got %s", buf.String())
+	}
+}
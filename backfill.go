@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// POST /timer/{id}/history adds several past completions at once, for when a timer starts tracking something that's
+// been done for a while. A batch goes in whole or not at all, so a typo in one of the times leaves nothing half added.
+
+// A time within this of one already in the history, or of another in the same batch, is taken to be that one
+// entered twice.
+const backfillDuplicateWindow = time.Minute
+
+// The most completions that one request can add.
+const maxBackfill = 1000
+
+// parseBackfill reads the times to add, as the repeated completed_at form field or a JSON array, each one read by
+// parseDoneTime. Empty form fields are left out, so the form can have more of them than are filled in.
+func parseBackfill(w http.ResponseWriter, r *http.Request) ([]time.Time, error) {
+	var texts []string
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == "application/json" {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&texts); err != nil {
+			return nil, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing the completions JSON, expected an array of times: %w", err)}
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing form : %w", err)}
+		}
+		texts = slices.DeleteFunc(r.Form["completed_at"], func(s string) bool { return s == "" })
+	}
+	switch {
+	case len(texts) == 0:
+		return nil, httpError{http.StatusBadRequest, errors.New("No times to add, expected at least one completed_at")}
+	case len(texts) > maxBackfill:
+		return nil, httpError{http.StatusBadRequest, fmt.Errorf("At most %d times can be added at once, not %d", maxBackfill, len(texts))}
+	}
+	times := make([]time.Time, len(texts))
+	for i, text := range texts {
+		var err error
+		if times[i], err = parseDoneTime(text); err != nil {
+			return nil, err
+		}
+	}
+	return times, nil
+}
+
+// backfillTimer records that the timer with id was done at each of times, all in the past, and moves its lasttime on
+// to the latest of them if that's later. They aren't known to have been due then, so their streaks go by the
+// schedule alone.
+func (s *Server) backfillTimer(ctx context.Context, id int64, times []time.Time) error {
+	c, err := s.getTimer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.Finished() {
+		return finishedError(c)
+	}
+	existing, err := s.listCompletions(ctx, id)
+	if err != nil {
+		return err
+	}
+	taken := make([]time.Time, 0, len(existing)+len(times))
+	for _, done := range existing {
+		taken = append(taken, done.CompletedAt)
+	}
+	for _, t := range times {
+		for _, other := range taken {
+			if d := t.Sub(other); d > -backfillDuplicateWindow && d < backfillDuplicateWindow {
+				return httpError{http.StatusBadRequest, fmt.Errorf("%s is already in the history, it's within a minute of %s",
+					t.In(location).Format("Mon Jan 2, 2006 3:04 PM"), other.In(location).Format("3:04 PM"))}
+			}
+		}
+		taken = append(taken, t)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, t := range times {
+		if err := addCompletion(ctx, tx, id, CompletionDone, t, time.Time{}, ""); err != nil {
+			return err
+		}
+		if t.After(c.LastTime) {
+			c.LastTime, c.PausedFor = t, 0
+		}
+	}
+	result, err := tx.ExecContext(ctx, `UPDATE timer SET lasttime = ?, paused_for = ?, completions = completions + ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		nullTime(c.LastTime), c.PausedFor, len(times), updatedAt(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkOneRow(result, id); err != nil {
+		return err
+	}
+	if err := storeStreak(ctx, tx, c); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	c.Completions += len(times)
+	s.emit(EventUpdated, c)
+	return nil
+}
+
+// backfillHandler adds the completions and responds with the timer's history, like GET /timer/{id}/history. Its
+// timerBackfilled/{id} event has the rest of the timer's page load again, but not the history a second time as
+// timerUpdate/{id} would.
+func (s *Server) backfillHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	times, err := parseBackfill(w, r)
+	if err != nil {
+		return err
+	}
+	if err := s.backfillTimer(r.Context(), id, times); err != nil {
+		return err
+	}
+	w.Header().Set("HX-Trigger", "timerBackfilled/"+r.PathValue("id")+", timersChanged")
+	return s.historyHandler(w, r)
+}
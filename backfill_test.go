@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBackfill tests adding past completions from the form and as JSON, and that a batch with a bad time adds nothing
+func TestBackfill(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	s := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Floss","frequency":"1 day","lastTime":"2025-03-05T21:00:00Z"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	post := func(body, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/timer/1/history", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	history := func() []completion {
+		completions, err := s.listCompletions(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return completions
+	}
+
+	// Older than the last time, with a blank field left over.
+	form := url.Values{"completed_at": {"2025-03-01T21:00", "", "2025-03-03T21:30"}}
+	w := post(form.Encode(), "application/x-www-form-urlencoded")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Mon Mar 3, 2025 9:30 PM") || w.Header().Get("HX-Trigger") != "timerBackfilled/1, timersChanged" {
+		t.Errorf("Expected the history fragment with the new times and an HX-Trigger, got %v %s", w.Header(), w.Body.String())
+	}
+	c, err := s.getTimer(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2025, 3, 5, 21, 0, 0, 0, time.UTC); !c.LastTime.Equal(expected) || c.TimesDone != 3 {
+		t.Errorf("Expected the last time to stay %v with 3 done, got %v with %d", expected, c.LastTime, c.TimesDone)
+	}
+
+	// Newer than the last time, which moves on to it.
+	if w := post(`["2025-03-09T08:00:00Z", "2025-03-08T08:00:00Z"]`, "application/json"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if c, err = s.getTimer(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2025, 3, 9, 8, 0, 0, 0, time.UTC); !c.LastTime.Equal(expected) || c.TimesDone != 5 {
+		t.Errorf("Expected the last time to move on to %v with 5 done, got %v with %d", expected, c.LastTime, c.TimesDone)
+	}
+
+	for _, test := range []struct {
+		name, body, contentType string
+	}{
+		{"future", `["2025-02-01T08:00:00Z", "2025-03-11T08:00:00Z"]`, "application/json"},
+		{"duplicate", `["2025-02-01T08:00:00Z", "2025-03-09T08:00:30Z"]`, "application/json"},
+		{"duplicate in the batch", `["2025-02-01T08:00:00Z", "2025-02-01T08:00:59Z"]`, "application/json"},
+		{"unparseable", `["2025-02-01T08:00:00Z", "last tuesday"]`, "application/json"},
+		{"not an array", `{"completed_at": "2025-02-01T08:00:00Z"}`, "application/json"},
+		{"empty", "completed_at=", "application/x-www-form-urlencoded"},
+	} {
+		if w := post(test.body, test.contentType); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected a Bad Request, got %v: %s", test.name, w.Code, w.Body.String())
+		}
+	}
+	if got := len(history()); got != 5 {
+		t.Errorf("Expected nothing added by the bad batches, got %d completions", got)
+	}
+	if w := serveAPI(t, s, "POST", "/timer/99/history", `["2025-02-01T08:00:00Z"]`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing timer to be Not Found, got %v: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// GET /timer/{id}/badge.svg is a shields.io style badge of a timer, to embed in notes or a README: its name on the
+// left, and when it was done or how overdue it is on the right, colored by how urgent it is. Badges are only ever in
+// English, like the ones they sit next to.
+
+// The badge colors, shields.io's.
+const (
+	badgeGreen  = "#4c1"
+	badgeYellow = "#dfb317"
+	badgeRed    = "#e05d44"
+	badgeBlue   = "#007ec6" // Timers that are never due.
+	badgeGrey   = "#9f9f9f"
+)
+
+// badgeUnits abbreviates durationUnits, so that a badge says "2d" rather than "2 days".
+var badgeUnits = map[string]string{"year": "y", "month": "mo", "week": "w", "day": "d", "hour": "h", "minute": "m"}
+
+// shortDuration is humanizeDuration abbreviated for a badge, e.g. "2d", and empty for less than a minute.
+func shortDuration(d time.Duration) string {
+	d = d.Abs()
+	for _, u := range durationUnits {
+		if d < u.size-u.size/20 {
+			continue
+		}
+		return fmt.Sprintf("%d%s", int(math.Round(float64(d)/float64(u.size))), badgeUnits[u.name])
+	}
+	return ""
+}
+
+// badge is the two halves of a badge.
+type badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// timerBadge is c's badge as of now: how overdue it is once it's overdue and when it was last done otherwise.
+func timerBadge(c CountDown, now time.Time) badge {
+	b := badge{Label: c.Name, Color: badgeBlue}
+	switch {
+	case c.Finished():
+		return badge{c.Name, "finished", badgeGrey}
+	case c.Paused():
+		return badge{c.Name, "paused", badgeGrey}
+	case c.state(now) == stateOverdue:
+		return badge{c.Name, strings.TrimSpace("overdue " + shortDuration(now.Sub(c.NextDue()))), badgeRed}
+	case c.state(now) == stateDueSoon:
+		b.Color = badgeYellow
+	case c.repeats():
+		b.Color = badgeGreen
+	}
+	switch ago := shortDuration(now.Sub(c.LastTime)); {
+	case c.LastTime.IsZero():
+		b.Message = "not done yet"
+	case ago == "":
+		b.Message = "done just now"
+	default:
+		b.Message = "done " + ago + " ago"
+	}
+	return b
+}
+
+// badgeTextWidth approximates how wide s is in pixels in 11px Verdana, the badge's font, since the server can't
+// measure it. It's close enough for text that's mostly letters and digits.
+func badgeTextWidth(s string) int {
+	var w float64
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("ijlI|!.,:;'", r):
+			w += 3.2
+		case strings.ContainsRune(" frt()[]-", r):
+			w += 4.3
+		case strings.ContainsRune("mwMW@", r):
+			w += 10.5
+		case unicode.IsUpper(r):
+			w += 7.5
+		default:
+			w += 6.9
+		}
+	}
+	return int(math.Ceil(w))
+}
+
+// SVG is the badge as a flat shields.io style image, with each half padded 5px either side of its text.
+func (b badge) SVG() string {
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+	lw, mw := badgeTextWidth(b.Label)+10, badgeTextWidth(b.Message)+10
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[3]d" height="20" role="img" aria-label="%[1]s: %[2]s">
+  <title>%[1]s: %[2]s</title>
+  <linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+  <clipPath id="r"><rect width="%[3]d" height="20" rx="3" fill="#fff"/></clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[4]d" height="20" fill="#555"/>
+    <rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/>
+    <rect width="%[3]d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%[7]d" y="14">%[1]s</text>
+    <text x="%[8]d" y="14">%[2]s</text>
+  </g>
+</svg>
+`, label, message, lw+mw, lw, mw, b.Color, lw/2, lw+mw/2)
+}
+
+// badgeHandler serves a timer's badge, with ?label= in place of its name. A timer that doesn't exist gets a grey
+// badge that says so along with the 404, so that the page it's embedded in shows why.
+func (s *Server) badgeHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	label := r.URL.Query().Get("label")
+
+	code, b := http.StatusOK, badge{}
+	c, err := s.getTimer(r.Context(), id)
+	switch {
+	case err == nil:
+		b = timerBadge(c, clock.Now())
+	case errorStatusCode(r, err) == http.StatusNotFound:
+		code, b = http.StatusNotFound, badge{"timer", "not found", badgeGrey}
+	default:
+		return err
+	}
+	if label != "" {
+		b.Label = label
+	}
+
+	// Badges are fetched by image proxies and caches that would otherwise keep showing how long ago it was done.
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.WriteHeader(code)
+	_, err = fmt.Fprint(w, b.SVG())
+	return err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wellFormed fails t unless svg parses as XML all the way through.
+func wellFormed(t *testing.T, svg string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Expected well-formed XML, got %v: %s", err, svg)
+		}
+	}
+}
+
+// TestShortDuration tests the abbreviated durations of badges
+func TestShortDuration(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		30 * time.Second:    "",
+		5 * time.Minute:     "5m",
+		47 * time.Hour:      "2d",
+		-5 * 24 * time.Hour: "5d",
+		10 * 24 * time.Hour: "1w",
+		40 * 24 * time.Hour: "1mo",
+	} {
+		if got := shortDuration(d); got != expected {
+			t.Errorf("Expected %v to be %q, got %q", d, expected, got)
+		}
+	}
+}
+
+// TestBadge tests that a timer's badge turns from green to yellow to red as it comes due, that it's well-formed SVG
+// with ?label= in it, and that a timer that doesn't exist gets a grey badge
+func TestBadge(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	clock = fixedClock(start)
+
+	s := &Server{db: setupTestDB(t)}
+	c := CountDown{Name: `Water "plants" & <ferns>`, LastTime: start, Frequency: 10 * 24 * time.Hour}
+	if err := s.createTimer(t.Context(), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		after    time.Duration
+		color    string
+		expected string
+	}{
+		{2 * 24 * time.Hour, badgeGreen, "done 2d ago"},
+		{9*24*time.Hour + 12*time.Hour, badgeYellow, "done 1w ago"},
+		{10*24*time.Hour - time.Minute, badgeYellow, "done 1w ago"},
+		{15 * 24 * time.Hour, badgeRed, "overdue 5d"},
+	} {
+		clock = fixedClock(start.Add(test.after))
+		w := serveAPI(t, s, "GET", "/timer/1/badge.svg", "")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/svg+xml" || w.Header().Get("Cache-Control") != "no-cache" {
+			t.Fatalf("Expected an uncached SVG, got %v %v: %s", w.Code, w.Header(), w.Body.String())
+		}
+		body := w.Body.String()
+		wellFormed(t, body)
+		if !strings.Contains(body, `fill="`+test.color+`"`) || !strings.Contains(body, ">"+test.expected+"</text>") {
+			t.Errorf("After %v, expected %q in %s, got %s", test.after, test.expected, test.color, body)
+		}
+	}
+
+	body := serveAPI(t, s, "GET", "/timer/1/badge.svg?label=ferns", "").Body.String()
+	wellFormed(t, body)
+	if !strings.Contains(body, ">ferns</text>") {
+		t.Errorf("Expected the label in the badge, got %s", body)
+	}
+
+	w := serveAPI(t, s, "GET", "/timer/2/badge.svg", "")
+	wellFormed(t, w.Body.String())
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `fill="`+badgeGrey+`"`) || !strings.Contains(w.Body.String(), ">not found</text>") {
+		t.Errorf("Expected a grey not found badge, got %v: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestBadgeTextWidth tests that wide text makes a wider badge than narrow text of the same length
+func TestBadgeTextWidth(t *testing.T) {
+	if narrow, wide := badgeTextWidth("illl"), badgeTextWidth("MWmw"); narrow >= wide {
+		t.Errorf("Expected illl narrower than MWmw, got %d and %d", narrow, wide)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import "time"
+
+// Listed by when they're due, GET /?sort=nextdue, the homepage's timers are in sections like a task app's: overdue,
+// due today, this week, later and the ones that aren't ever due. Days are calendar ones in -timezone, with weeks
+// starting on -week-start. The order by when they're due already keeps each section together. The default order,
+// the most urgent first, has the ones that are due soon in a section of their own after the overdue ones, since that's
+// where it puts them whatever day they're due.
+
+// dueBucket is a section of the homepage's list, by when its timers are due.
+type dueBucket string
+
+const (
+	bucketOverdue    dueBucket = "Overdue"
+	bucketDueSoon    dueBucket = "Due soon" // Only in the default order, see urgencyBucket.
+	bucketToday      dueBucket = "Today"
+	bucketThisWeek   dueBucket = "This week"
+	bucketLater      dueBucket = "Later"
+	bucketNoSchedule dueBucket = "No schedule" // Including paused and finished timers.
+)
+
+// bucket is the section that c goes in as of now.
+func (c CountDown) bucket(now time.Time) dueBucket {
+	if !c.repeats() {
+		return bucketNoSchedule
+	}
+	local := now.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	switch due := c.NextDue(); {
+	case due.Before(now):
+		return bucketOverdue
+	case due.Before(today.AddDate(0, 0, 1)):
+		return bucketToday
+	case due.Before(startOfWeek(today).AddDate(0, 0, 7)):
+		return bucketThisWeek
+	}
+	return bucketLater
+}
+
+// groupByDue puts each of the cards but the pinned ones, which have their own section, in its bucket as of now.
+func groupByDue(cards []timerCard, now time.Time) {
+	for i, card := range cards {
+		if !card.Pinned {
+			cards[i].Bucket = card.CountDown.bucket(now)
+		}
+	}
+}
+
+// urgencyBucket is the section that c goes in as of now in the default order, see sortByUrgency.
+func (c CountDown) urgencyBucket(now time.Time) dueBucket {
+	if c.repeats() && c.state(now) == stateDueSoon {
+		return bucketDueSoon
+	}
+	return c.bucket(now)
+}
+
+// groupByUrgency is groupByDue for the default order.
+func groupByUrgency(cards []timerCard, now time.Time) {
+	for i, card := range cards {
+		if !card.Pinned {
+			cards[i].Bucket = card.CountDown.urgencyBucket(now)
+		}
+	}
+}
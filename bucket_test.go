@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDueBucket tests which section timers go in around midnight in location and the start of the week
+func TestDueBucket(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	defer func(d time.Weekday) { weekStart = d }(weekStart)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	day := 24 * time.Hour
+	// A Wednesday, 8 PM in New York, already Thursday in UTC.
+	now := time.Date(2025, 3, 5, 20, 0, 0, 0, location)
+	dueAt := func(due time.Time) CountDown { return CountDown{LastTime: due.Add(-day), Frequency: day} }
+
+	for _, test := range []struct {
+		name      string
+		weekStart time.Weekday
+		c         CountDown
+		expected  dueBucket
+	}{
+		{"due a minute ago", time.Monday, dueAt(now.Add(-time.Minute)), bucketOverdue},
+		{"due now", time.Monday, dueAt(now), bucketToday},
+		{"due before midnight", time.Monday, dueAt(time.Date(2025, 3, 5, 23, 59, 0, 0, location)), bucketToday},
+		{"due at midnight", time.Monday, dueAt(time.Date(2025, 3, 6, 0, 0, 0, 0, location)), bucketThisWeek},
+		{"due at the end of the week", time.Monday, dueAt(time.Date(2025, 3, 9, 23, 59, 0, 0, location)), bucketThisWeek},
+		{"due next week", time.Monday, dueAt(time.Date(2025, 3, 10, 0, 0, 0, 0, location)), bucketLater},
+		{"due on Sunday when weeks start on Sunday", time.Sunday, dueAt(time.Date(2025, 3, 9, 0, 0, 0, 0, location)), bucketLater},
+		{"due on Saturday when weeks start on Sunday", time.Sunday, dueAt(time.Date(2025, 3, 8, 23, 59, 0, 0, location)), bucketThisWeek},
+		{"due tomorrow when weeks start on Thursday", time.Thursday, dueAt(time.Date(2025, 3, 6, 9, 0, 0, 0, location)), bucketLater},
+		{"no schedule", time.Monday, CountDown{LastTime: now.Add(-30 * day)}, bucketNoSchedule},
+		{"paused", time.Monday, CountDown{LastTime: now.Add(-2 * day), Frequency: day, PausedAt: now.Add(-day)}, bucketNoSchedule},
+	} {
+		weekStart = test.weekStart
+		if got := test.c.bucket(now); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+}
+
+// TestGroupedHomepage tests that the homepage by when timers are due is in sections, leaving out the empty ones, and
+// that the other orders aren't
+func TestGroupedHomepage(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	defer func(d time.Weekday) { weekStart = d }(weekStart)
+	location, weekStart = time.UTC, time.Monday
+	// A Wednesday.
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	s := &Server{db: setupTestDB(t)}
+	day := 24 * time.Hour
+	for _, c := range []CountDown{
+		{Name: "Passport"},
+		{Name: "Gutters", LastTime: now.Add(-20 * day), Frequency: 30 * day},
+		{Name: "Floss", LastTime: now.Add(-20 * time.Hour), Frequency: day},
+		{Name: "Water plants", LastTime: now.Add(-3 * day), Frequency: day},
+		{Name: "Bins", LastTime: now.Add(-2 * day), Frequency: day, Pinned: true},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(target string) string {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	body := get("/?sort=nextdue")
+	last := -1
+	for _, text := range []string{"Pinned", "Bins", "Overdue", "Water plants", "Today", "Floss", "Later", "Gutters", "No schedule", "Passport"} {
+		i := strings.Index(body, text)
+		if i < last {
+			t.Errorf("Expected %q after the text before it, got %s", text, body)
+		}
+		last = i
+	}
+	if strings.Contains(body, "This week") || strings.Contains(body, "Everything else") {
+		t.Errorf("Expected no empty sections, got %s", body)
+	}
+	if body := get("/?sort=name"); strings.Contains(body, ">Overdue</h2>") {
+		t.Errorf("Expected no sections by name, got %s", body)
+	}
+}
+
+// TestUrgencySections tests that the homepage's default order is in sections too, with the timers that are due soon
+// in one of their own between the overdue ones and the rest
+func TestUrgencySections(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	defer func(d time.Weekday) { weekStart = d }(weekStart)
+	location, weekStart = time.UTC, time.Monday
+	// A Wednesday.
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	s := &Server{db: setupTestDB(t)}
+	day := 24 * time.Hour
+	for _, c := range []CountDown{
+		{Name: "Passport"},
+		{Name: "Gutters", LastTime: now.Add(-20 * day), Frequency: 30 * day},
+		{Name: "Floss", LastTime: now.Add(-20 * time.Hour), Frequency: day},
+		// Due tomorrow, but within its grace so it's before Floss.
+		{Name: "Descale kettle", LastTime: now.Add(-6 * day), Frequency: 7 * day, Grace: 2 * day},
+		{Name: "Water plants", LastTime: now.Add(-3 * day), Frequency: day},
+		{Name: "Bins", LastTime: now.Add(-2 * day), Frequency: day, Pinned: true},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	body := w.Body.String()
+	last := -1
+	for _, text := range []string{"Pinned</h2>", "Bins", ">Overdue</h2>", "Water plants", ">Due soon</h2>", "Descale kettle", ">Today</h2>", "Floss", ">Later</h2>", "Gutters", ">No schedule</h2>", "Passport"} {
+		i := strings.Index(body, text)
+		if i < 0 || i < last {
+			t.Errorf("Expected %q after the text before it, got %s", text, body)
+		}
+		last = i
+	}
+	if strings.Contains(body, ">This week</h2>") {
+		t.Errorf("Expected no empty sections, got %s", body)
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// caldavSyncer keeps one event per timer, at its next due time, in a CalDAV calendar.
+// Timers are synced in the background as they change and all at once by reconcile. What was last pushed for each
+// timer is kept in the caldav_sync table so that unchanged timers aren't pushed again.
+// A nil *caldavSyncer is valid and ignores every change.
+type caldavSyncer struct {
+	calendar       string // The URL of the calendar collection, ending in a slash.
+	user, password string
+	client         *http.Client
+	s              *Server // For its timers.
+
+	queue chan int64 // Ids of timers that changed.
+	done  sync.WaitGroup
+	mu    sync.Mutex // Held while syncing so that a reconcile and the worker don't race on the same event.
+
+	delivered delivery // How the worker's last sync went, for the instance stats.
+}
+
+// The number of changed timers that can wait to be synced before new ones are dropped, until the next reconcile.
+const caldavQueueSize = 100
+
+// How long an event lasts in the calendar, it only marks when the timer is due.
+const caldavEventLength = 15 * time.Minute
+
+func newCaldavSyncer(s *Server, calendar, user, password string) *caldavSyncer {
+	c := &caldavSyncer{
+		calendar: strings.TrimSuffix(calendar, "/") + "/",
+		user:     user,
+		password: password,
+		client:   s.outboundClient(30 * time.Second),
+		s:        s,
+		queue:    make(chan int64, caldavQueueSize),
+	}
+
+	c.done.Add(1)
+	go func() {
+		defer c.done.Done()
+		for id := range c.queue {
+			err := c.syncID(context.Background(), id)
+			if err != nil {
+				log.Printf("Error syncing timer %d to CalDAV: %v\n", id, err)
+			}
+			c.delivered.record(err)
+		}
+	}()
+	return c
+}
+
+// notify queues a timer that changed for syncing without ever blocking the caller.
+func (c *caldavSyncer) notify(id int64) {
+	if c == nil {
+		return
+	}
+	select {
+	case c.queue <- id:
+	default:
+		log.Printf("CalDAV queue is full, timer %d will be synced by the next reconcile\n", id)
+	}
+}
+
+// Close waits for the queued timers to be synced and stops the worker.
+func (c *caldavSyncer) Close() {
+	if c == nil {
+		return
+	}
+	close(c.queue)
+	c.done.Wait()
+}
+
+// caldavReconcileResult counts what a reconcile did.
+type caldavReconcileResult struct {
+	Pushed    int `json:"pushed"`
+	Unchanged int `json:"unchanged"`
+	Deleted   int `json:"deleted"`
+}
+
+// reconcile syncs every timer and deletes the events of timers that have been deleted.
+func (c *caldavSyncer) reconcile(ctx context.Context) (caldavReconcileResult, error) {
+	var result caldavReconcileResult
+
+	timers, err := c.s.listTimers(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, t := range timers {
+		pushed, err := c.sync(ctx, t)
+		if err != nil {
+			return result, fmt.Errorf("Error syncing timer %d: %w", t.Id, err)
+		}
+		if pushed {
+			result.Pushed++
+		} else {
+			result.Unchanged++
+		}
+	}
+
+	rows, err := c.s.db.QueryContext(ctx, `SELECT timer_id FROM caldav_sync WHERE timer_id NOT IN (SELECT id FROM timer WHERE deleted_at IS NULL)`)
+	if err != nil {
+		return result, err
+	}
+	var gone []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return result, err
+		}
+		gone = append(gone, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	for _, id := range gone {
+		if err := c.remove(ctx, id); err != nil {
+			return result, fmt.Errorf("Error deleting the event of timer %d: %w", id, err)
+		}
+		result.Deleted++
+	}
+	return result, nil
+}
+
+// syncID syncs the timer with id, deleting its event if the timer is gone.
+func (c *caldavSyncer) syncID(ctx context.Context, id int64) error {
+	t, err := c.s.getTimer(ctx, id)
+	if httpErr, ok := err.(HTTPError); ok && httpErr.HTTPStatusCode() == http.StatusNotFound {
+		return c.remove(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = c.sync(ctx, t)
+	return err
+}
+
+// sync pushes the event for t unless it is unchanged since the last push, reporting whether it pushed.
+// Timers that don't repeat are never due, so they have no event.
+func (c *caldavSyncer) sync(ctx context.Context, t CountDown) (bool, error) {
+	if !t.repeats() {
+		return false, c.remove(ctx, t.Id)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	etag, hash, err := c.state(ctx, t.Id)
+	if err != nil {
+		return false, err
+	}
+	event := caldavEvent(t)
+	sum := sha256.Sum256([]byte(event))
+	if hex.EncodeToString(sum[:]) == hash {
+		return false, nil
+	}
+
+	resp, err := c.put(ctx, t.Id, event, etag)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		// Someone else changed or removed the event since our last push. Ours wins, against whatever is there now.
+		if etag, err = c.currentETag(ctx, t.Id); err != nil {
+			return false, err
+		}
+		if resp, err = c.put(ctx, t.Id, event, etag); err != nil {
+			return false, err
+		}
+	}
+	if resp.StatusCode/100 != 2 {
+		return false, fmt.Errorf("PUT %s: %s", c.eventURL(t.Id), resp.Status)
+	}
+
+	// Some servers leave the ETag out when they change what was PUT, it's fetched again on a conflict.
+	_, err = c.s.db.ExecContext(ctx, `
+		INSERT INTO caldav_sync (timer_id, etag, hash) VALUES (?, ?, ?)
+		ON CONFLICT (timer_id) DO UPDATE SET etag = excluded.etag, hash = excluded.hash`,
+		t.Id, resp.Header.Get("ETag"), hex.EncodeToString(sum[:]))
+	return true, err
+}
+
+// remove deletes the event of a timer, if it was ever pushed.
+func (c *caldavSyncer) remove(ctx context.Context, id int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	etag, hash, err := c.state(ctx, id)
+	if err != nil || hash == "" {
+		return err
+	}
+
+	req, err := c.request(ctx, "DELETE", id, nil)
+	if err != nil {
+		return err
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		// Changed by someone else, it still goes along with the timer.
+		req.Header.Del("If-Match")
+		if resp, err = c.do(req); err != nil {
+			return err
+		}
+	}
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("DELETE %s: %s", c.eventURL(id), resp.Status)
+	}
+
+	_, err = c.s.db.ExecContext(ctx, `DELETE FROM caldav_sync WHERE timer_id = ?`, id)
+	return err
+}
+
+// state is what was last pushed for a timer, empty if nothing was.
+func (c *caldavSyncer) state(ctx context.Context, id int64) (etag, hash string, err error) {
+	err = c.s.db.QueryRowContext(ctx, `SELECT etag, hash FROM caldav_sync WHERE timer_id = ?`, id).Scan(&etag, &hash)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return etag, hash, err
+}
+
+// put writes the event, only over the version with etag, or only if there's none when etag is empty.
+func (c *caldavSyncer) put(ctx context.Context, id int64, event, etag string) (*http.Response, error) {
+	req, err := c.request(ctx, "PUT", id, strings.NewReader(event))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	} else {
+		req.Header.Set("If-None-Match", "*")
+	}
+	return c.do(req)
+}
+
+// currentETag fetches the ETag of the event as the server has it now, empty if it doesn't exist.
+func (c *caldavSyncer) currentETag(ctx context.Context, id int64) (string, error) {
+	req, err := c.request(ctx, "GET", id, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", nil
+	case resp.StatusCode/100 != 2:
+		return "", fmt.Errorf("GET %s: %s", c.eventURL(id), resp.Status)
+	case resp.Header.Get("ETag") == "":
+		return "", errors.New("The CalDAV server didn't send an ETag")
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+func (c *caldavSyncer) eventURL(id int64) string {
+	return fmt.Sprintf("%scountup-timer-%d.ics", c.calendar, id)
+}
+
+func (c *caldavSyncer) request(ctx context.Context, method string, id int64, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.eventURL(id), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+	return req, nil
+}
+
+// do sends req, the body of the response is never needed.
+func (c *caldavSyncer) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp, nil
+}
+
+// caldavEvent is the iCalendar object for a timer's next due time.
+// The UID is derived from the timer id so that every push updates the same event.
+func caldavEvent(t CountDown) string {
+	const format = "20060102T150405Z"
+	due := t.NextDue().UTC()
+	// DTSTAMP is when the timer was last done rather than now, so that the event only changes with the timer.
+	stamp := t.LastTime
+	if stamp.IsZero() {
+		stamp = due
+	}
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//countup//EN",
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:countup-timer-%d", t.Id),
+		"DTSTAMP:" + stamp.UTC().Format(format),
+		"DTSTART:" + due.Format(format),
+		"DTEND:" + due.Add(caldavEventLength).Format(format),
+		"SUMMARY:" + icalText(t.Name),
+	}
+	if t.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+icalText(t.Description))
+	}
+	if t.ReferenceURL != "" {
+		lines = append(lines, "URL:"+t.ReferenceURL)
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+	for i, line := range lines {
+		lines[i] = icalFold(line)
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// icalText escapes a TEXT value, RFC 5545 section 3.3.11. A lone \r is a line break too, as old Macs wrote them.
+var icalText = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace
+
+// The most octets on a line of iCalendar, not counting its CRLF.
+const icalLineOctets = 75
+
+// icalFold folds a content line longer than icalLineOctets onto continuation lines that start with a space, RFC 5545
+// section 3.1, without splitting a UTF-8 character across them.
+func icalFold(line string) string {
+	var b strings.Builder
+	limit := icalLineOctets
+	for len(line) > limit {
+		n := limit
+		for n > 0 && !utf8.RuneStart(line[n]) {
+			n--
+		}
+		b.WriteString(line[:n])
+		b.WriteString("\r\n ")
+		line = line[n:]
+		limit = icalLineOctets - 1 // The space takes one of the continuation's octets.
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+func (s *Server) caldavSyncHandler(w http.ResponseWriter, r *http.Request) error {
+	if s.caldav == nil {
+		return httpError{http.StatusNotFound, errors.New("CalDAV sync isn't configured, see -caldav-url")}
+	}
+	result, err := s.caldav.reconcile(r.Context())
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, result)
+}
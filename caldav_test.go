@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// fakeCalDAV is just enough of a CalDAV server to store events by path with ETags and honor preconditions.
+type fakeCalDAV struct {
+	mu       sync.Mutex
+	events   map[string]string // Path to body.
+	etags    map[string]string // Path to ETag.
+	version  int
+	requests []string // "METHOD path precondition" of every request.
+}
+
+func newFakeCalDAV(t *testing.T) (*fakeCalDAV, *httptest.Server) {
+	f := &fakeCalDAV{events: map[string]string{}, etags: map[string]string{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeCalDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	precondition := strings.TrimSpace(r.Header.Get("If-Match") + " " + r.Header.Get("If-None-Match"))
+	f.requests = append(f.requests, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+precondition))
+
+	etag, exists := f.etags[r.URL.Path]
+	if m := r.Header.Get("If-Match"); m != "" && m != etag {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if r.Header.Get("If-None-Match") == "*" && exists {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		io.WriteString(w, f.events[r.URL.Path])
+	case "PUT":
+		b, _ := io.ReadAll(r.Body)
+		f.events[r.URL.Path] = string(b)
+		w.Header().Set("ETag", f.newETag(r.URL.Path))
+		if exists {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	case "DELETE":
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.events, r.URL.Path)
+		delete(f.etags, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeCalDAV) newETag(path string) string {
+	f.version++
+	f.etags[path] = fmt.Sprintf(`"%d"`, f.version)
+	return f.etags[path]
+}
+
+// takeRequests returns the requests since the last call.
+func (f *fakeCalDAV) takeRequests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := f.requests
+	f.requests = nil
+	return r
+}
+
+func (f *fakeCalDAV) event(path string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.events[path]
+}
+
+// TestCaldavReconcile tests that timers are pushed once, pushed again only when they change, and that the events of
+// deleted timers are removed
+func TestCaldavReconcile(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	clock = fixedClock(time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC))
+
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	fake, srv := newFakeCalDAV(t)
+	s := &Server{db: db}
+	c := newCaldavSyncer(s, srv.URL+"/cal", "user", "secret")
+	defer c.Close()
+
+	path := fmt.Sprintf("/cal/countup-timer-%d.ics", testTimers[0].Id)
+	result, err := c.reconcile(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (caldavReconcileResult{Pushed: len(testTimers)}) {
+		t.Errorf("Unexpected result of the first reconcile: %+v", result)
+	}
+	if r := fake.takeRequests(); len(r) != len(testTimers) || r[0] != "PUT "+path+" *" {
+		t.Errorf("Expected a PUT with If-None-Match of each timer, got %q", r)
+	}
+	event := fake.event(path)
+	for _, want := range []string{
+		fmt.Sprintf("UID:countup-timer-%d\r\n", testTimers[0].Id),
+		"SUMMARY:Test Timer 1\r\n",
+		"DESCRIPTION:First test timer\r\n",
+		"DTSTART:" + testTimers[0].NextDue().UTC().Format("20060102T150405Z") + "\r\n",
+	} {
+		if !strings.Contains(event, want) {
+			t.Errorf("Expected the event to contain %q:\n%s", want, event)
+		}
+	}
+
+	// Nothing changed, so nothing is sent.
+	if result, err = c.reconcile(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if result != (caldavReconcileResult{Unchanged: len(testTimers)}) {
+		t.Errorf("Unexpected result of the second reconcile: %+v", result)
+	}
+	if r := fake.takeRequests(); len(r) != 0 {
+		t.Errorf("Expected no requests for unchanged timers, got %q", r)
+	}
+
+	// Resetting a timer moves its event, over the version that was pushed.
+	done := testTimers[0].LastTime.Add(time.Hour).Truncate(time.Second)
+	if err := s.resetTimer(t.Context(), testTimers[0].Id, done, CompletionDone, ""); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = c.reconcile(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if result != (caldavReconcileResult{Pushed: 1, Unchanged: len(testTimers) - 1}) {
+		t.Errorf("Unexpected result after a reset: %+v", result)
+	}
+	if r := fake.takeRequests(); len(r) != 1 || !strings.HasPrefix(r[0], "PUT "+path+` "`) {
+		t.Errorf("Expected a PUT with If-Match, got %q", r)
+	}
+	due := done.Add(testTimers[0].Frequency).UTC().Format("20060102T150405Z")
+	if event := fake.event(path); !strings.Contains(event, "DTSTART:"+due) {
+		t.Errorf("Expected the event to start at %s:\n%s", due, event)
+	}
+
+	// Deleting a timer deletes its event.
+	if err := s.deleteTimer(t.Context(), testTimers[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = c.reconcile(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if result.Deleted != 1 {
+		t.Errorf("Expected 1 deleted event, got %+v", result)
+	}
+	if event := fake.event(path); event != "" {
+		t.Errorf("Expected the event to be deleted, got:\n%s", event)
+	}
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM caldav_sync WHERE timer_id = ?`, testTimers[0].Id).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 0 {
+		t.Errorf("Expected the sync state of the deleted timer to be gone, got %d rows", rows)
+	}
+}
+
+// TestCaldavConflict tests that an event changed on the server is overwritten with the timer
+func TestCaldavConflict(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	fake, srv := newFakeCalDAV(t)
+	s := &Server{db: db}
+	c := newCaldavSyncer(s, srv.URL+"/cal/", "user", "secret")
+	defer c.Close()
+
+	if _, err := c.reconcile(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/cal/countup-timer-%d.ics", testTimers[0].Id)
+
+	// Someone edits the event in their calendar app.
+	fake.mu.Lock()
+	fake.events[path] = "edited elsewhere"
+	fake.newETag(path)
+	fake.mu.Unlock()
+	fake.takeRequests()
+
+	if err := s.resetTimer(t.Context(), testTimers[0].Id, clock.Now(), CompletionDone, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.reconcile(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	r := fake.takeRequests()
+	if len(r) != 3 || !strings.HasPrefix(r[0], "PUT ") || !strings.HasPrefix(r[1], "GET ") || !strings.HasPrefix(r[2], "PUT ") {
+		t.Errorf("Expected a failed PUT, a GET of the ETag and a PUT, got %q", r)
+	}
+	if event := fake.event(path); !strings.Contains(event, "SUMMARY:Test Timer 1") {
+		t.Errorf("Expected the timer to win, got:\n%s", event)
+	}
+}
+
+// TestCaldavNotify tests that changes made through the server are synced in the background
+func TestCaldavNotify(t *testing.T) {
+	db := setupTestDB(t)
+	fake, srv := newFakeCalDAV(t)
+	s := &Server{db: db}
+	s.caldav = newCaldavSyncer(s, srv.URL+"/cal/", "user", "secret")
+
+	c := CountDown{Name: "Water plants", Frequency: 48 * time.Hour, LastTime: clock.Now()}
+	if err := s.createTimer(t.Context(), &c); err != nil {
+		t.Fatal(err)
+	}
+	oneOff := CountDown{Name: "Renew passport", LastTime: clock.Now()}
+	if err := s.createTimer(t.Context(), &oneOff); err != nil {
+		t.Fatal(err)
+	}
+	s.caldav.Close() // Waits for the queue to drain.
+
+	if event := fake.event(fmt.Sprintf("/cal/countup-timer-%d.ics", c.Id)); !strings.Contains(event, "SUMMARY:Water plants") {
+		t.Errorf("Expected an event for the new timer, got:\n%s", event)
+	}
+	if event := fake.event(fmt.Sprintf("/cal/countup-timer-%d.ics", oneOff.Id)); event != "" {
+		t.Errorf("Expected no event for a timer without a frequency, got:\n%s", event)
+	}
+}
+
+// TestCaldavSyncHandler tests that the admin endpoint is only there when CalDAV is configured
+func TestCaldavSyncHandler(t *testing.T) {
+	db := setupTestDB(t)
+	insertTestData(t, db)
+
+	w := httptest.NewRecorder()
+	(&Server{db: db}).mux().ServeHTTP(w, httptest.NewRequest("POST", "/admin/caldav-sync", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status Not Found without -caldav-url, got %v", w.Code)
+	}
+
+	_, srv := newFakeCalDAV(t)
+	s := &Server{db: db}
+	s.caldav = newCaldavSyncer(s, srv.URL+"/cal/", "user", "secret")
+	defer s.caldav.Close()
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("POST", "/admin/caldav-sync", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	var result caldavReconcileResult
+	decodeResponse(t, w, &result)
+	if result.Pushed != 2 {
+		t.Errorf("Expected 2 pushed events, got %+v", result)
+	}
+}
+
+// TestIcalText tests escaping of iCalendar text values
+func TestIcalText(t *testing.T) {
+	if got, want := icalText("a, b; c\\d\ne"), `a\, b\; c\\d\ne`; got != want {
+		t.Errorf("icalText() = %q, expected %q", got, want)
+	}
+	if got, want := icalText("a\rb\r\nc"), `a\nb\nc`; got != want {
+		t.Errorf("icalText() = %q, expected %q", got, want)
+	}
+}
+
+// TestCaldavEventFolding tests that no line of an event is longer than 75 octets, even with multibyte characters where
+// it's folded, and that unfolding gives back the original lines
+func TestCaldavEventFolding(t *testing.T) {
+	c := CountDown{
+		Id: 1, Name: strings.Repeat("Water the ferns ", 5), Description: strings.Repeat("Ünd die Kräuter 🌿, ", 8),
+		LastTime: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), Frequency: 24 * time.Hour,
+	}
+	event := caldavEvent(c)
+	if !strings.HasSuffix(event, "END:VCALENDAR\r\n") {
+		t.Errorf("Expected the event to end with a CRLF, got %q", event)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(event, "\r\n"), "\r\n") {
+		if len(line) > 75 || !utf8.ValidString(line) {
+			t.Errorf("Expected at most 75 octets of whole characters, got %d: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(event, "\r\n ", "")
+	for _, expected := range []string{"SUMMARY:" + icalText(c.Name) + "\r\n", "DESCRIPTION:" + icalText(c.Description) + "\r\n"} {
+		if !strings.Contains(unfolded, expected) {
+			t.Errorf("Expected %q once unfolded, got %q", expected, unfolded)
+		}
+	}
+}
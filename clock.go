@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	_ "time/tzdata" // The alpine image has no zoneinfo for -timezone.
+)
+
+// Clock is where the current time comes from, so that tests can pin it.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the real time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// fixedClock always reports the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// offsetClock is another clock shifted by an offset that can change while the server runs, for -time-offset and demos.
+type offsetClock struct {
+	base Clock
+
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+func (c *offsetClock) Now() time.Time { return c.base.Now().Add(c.Offset()) }
+
+func (c *offsetClock) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+func (c *offsetClock) SetOffset(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = d
+}
+
+// clock is read for every "now", including from templates through CountDown's methods.
+var clock Clock = systemClock{}
+
+// location is the time zone that timers' times of day and days are in, and that pages show times in, see -timezone.
+var location = time.Local
+
+// local is t in location, for the templates to show times in -timezone rather than whatever offset they were stored
+// with.
+func local(t time.Time) time.Time {
+	return t.In(location)
+}
+
+// timeOffset is the response of POST /admin/time-offset.
+type timeOffset struct {
+	Offset string    `json:"offset"` // As a Go duration.
+	Now    time.Time `json:"now"`    // What the server now takes the time to be.
+}
+
+// timeOffsetHandler shifts the server's clock by the offset form value, a Go duration from the real time like 72h or
+// -30m. It's only there with -demo so that production servers always run on the real time, give or take -time-offset.
+func (s *Server) timeOffsetHandler(w http.ResponseWriter, r *http.Request) error {
+	if s.timeOffset == nil {
+		return httpError{http.StatusNotFound, errors.New("Changing the time is only allowed with -demo")}
+	}
+	d, err := time.ParseDuration(r.FormValue("offset"))
+	if err != nil {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing offset: %w", err)}
+	}
+	s.timeOffset.SetOffset(d)
+	return encodeJSON(w, timeOffset{d.String(), s.timeOffset.Now()})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTimeOffset tests that shifting the clock moves timers to overdue, and only with -demo
+func TestTimeOffset(t *testing.T) {
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	shifted := &offsetClock{base: fixedClock(now)}
+	defer func(c Clock) { clock = c }(clock)
+	clock = shifted
+
+	s := &Server{db: setupTestDB(t), timeOffset: shifted}
+	c := CountDown{Name: "Water plants", LastTime: now.Add(-12 * time.Hour), Frequency: 24 * time.Hour}
+	if err := s.createTimer(t.Context(), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	page := func() string {
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, httptest.NewRequest("GET", "/timer/1", nil))
+		return w.Body.String()
+	}
+	setOffset := func(s *Server, offset string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/admin/time-offset", strings.NewReader(url.Values{"offset": {offset}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	if body := page(); !strings.Contains(body, "Do it again in 12 hours") {
+		t.Errorf("Expected the timer to be upcoming, got %s", body)
+	}
+
+	w := setOffset(s, "60h")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	var got timeOffset
+	decodeResponse(t, w, &got)
+	if got.Offset != "60h0m0s" || !got.Now.Equal(now.Add(60*time.Hour)) {
+		t.Errorf("Unexpected response: %+v", got)
+	}
+	if body := page(); !strings.Contains(body, "Overdue by 2 days") || !strings.Contains(body, "bg-danger-subtle") {
+		t.Errorf("Expected the timer to be overdue, got %s", body)
+	}
+
+	if w := setOffset(s, "tomorrow"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status Bad Request, got %v", w.Code)
+	}
+	if w := setOffset(&Server{db: s.db}, "1h"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status Not Found without -demo, got %v", w.Code)
+	}
+	if shifted.Offset() != 60*time.Hour {
+		t.Errorf("Expected the failed requests to leave the offset alone, got %v", shifted.Offset())
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// notModified sets Last-Modified to when the homepage last changed and reports whether the client's copy, going by
+// If-Modified-Since, is still current. When it is, the 304 has already been written and the page needn't be rendered.
+// The homepage changes when a timer does, and also as timers come due without changing, see dueChanged.
+func (s *Server) notModified(w http.ResponseWriter, r *http.Request) (bool, error) {
+	// Check every time rather than let the browser guess how long the page stays fresh from Last-Modified.
+	w.Header().Set("Cache-Control", "no-cache")
+
+	modified, err := s.lastModified(r.Context())
+	if err != nil {
+		return false, err
+	}
+	// A new binary or -template-dir can change the page without any timer changing.
+	if s.startedAt.After(modified) {
+		modified = s.startedAt
+	}
+	// Only the schedules matter here, reading the rest of every timer on every request would defeat the point.
+	timers, err := queryTimers(r.Context(), s.db, `SELECT `+scheduleColumns+` FROM timer WHERE deleted_at IS NULL`)
+	if err != nil {
+		return false, err
+	}
+	if due := dueChanged(timers, clock.Now()); due.After(modified) {
+		modified = due
+	}
+	// Last-Modified only has whole seconds, so another change within the same second would go unnoticed.
+	if modified.IsZero() || clock.Now().Sub(modified) < time.Second {
+		return false, nil
+	}
+
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.Truncate(time.Second).After(since) {
+		return false, nil
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true, nil
+}
+
+// dueChanged is the last time up to now that the homepage changed by itself: when a timer came due soon or overdue,
+// which moves it up the default order and recolors it, or when the day started, which moves timers between the
+// summary's and the sections' days.
+func dueChanged(timers []CountDown, now time.Time) time.Time {
+	local := now.In(location)
+	changed := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	for _, c := range timers {
+		if !c.repeats() {
+			continue
+		}
+		due := c.NextDue()
+		for _, t := range []time.Time{due.Add(-c.grace(due)), due} {
+			if t.After(changed) && !t.After(now) {
+				changed = t
+			}
+		}
+	}
+	return changed
+}
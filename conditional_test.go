@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHomePageNotModified tests that GET / answers 304 until a timer changes
+func TestHomePageNotModified(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	s := &Server{db: setupTestDB(t)}
+	var testTimers []CountDown
+	for _, name := range []string{"Water plants", "Oil change"} {
+		c := CountDown{Name: name, LastTime: now, Frequency: 24 * time.Hour}
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+		testTimers = append(testTimers, c)
+	}
+	get := func(ifModifiedSince string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		if ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", ifModifiedSince)
+		}
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	// Changes less than a second old can't be told apart from ones later in the same second.
+	if w := get(""); w.Header().Get("Last-Modified") != "" {
+		t.Errorf("Expected no Last-Modified right after a change, got %q", w.Header().Get("Last-Modified"))
+	}
+
+	clock = fixedClock(now.Add(time.Minute))
+	w := get("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+	lastModified := w.Header().Get("Last-Modified")
+	if lastModified != "Wed, 05 Mar 2025 10:00:00 GMT" {
+		t.Errorf("Unexpected Last-Modified: %q", lastModified)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Expected Cache-Control no-cache, got %q", cc)
+	}
+
+	w = get(lastModified)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("Expected status Not Modified, got %v", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected no body, got %q", w.Body.String())
+	}
+
+	// Any change to a timer means the page has to be sent again.
+	for _, mutate := range []struct {
+		method, target string
+	}{
+		{"POST", fmt.Sprintf("/timer/%d/reset", testTimers[0].Id)},
+		{"DELETE", fmt.Sprintf("/timer/%d", testTimers[1].Id)},
+		{"POST", fmt.Sprintf("/timer/%d/restore", testTimers[1].Id)},
+	} {
+		clock = fixedClock(clock.Now().Add(time.Minute))
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, httptest.NewRequest(mutate.method, mutate.target, nil))
+		if w.Code/100 != 2 {
+			t.Fatalf("%s %s failed: %v", mutate.method, mutate.target, w.Code)
+		}
+
+		clock = fixedClock(clock.Now().Add(time.Minute))
+		w = get(lastModified)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status OK after %s %s, got %v", mutate.method, mutate.target, w.Code)
+		}
+		if w.Header().Get("Last-Modified") == lastModified {
+			t.Errorf("Expected Last-Modified to move after %s %s", mutate.method, mutate.target)
+		}
+		lastModified = w.Header().Get("Last-Modified")
+	}
+}
+
+// TestHomePageNotModifiedAsDue tests that GET / answers 304 until a timer comes due, even though no timer changed
+func TestHomePageNotModifiedAsDue(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	s := &Server{db: setupTestDB(t)}
+	if err := s.createTimer(t.Context(), &CountDown{Name: "Check the oven", LastTime: now, Frequency: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	get := func(ifModifiedSince string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("If-Modified-Since", ifModifiedSince)
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	clock = fixedClock(now.Add(time.Minute))
+	lastModified := get("").Header().Get("Last-Modified")
+	clock = fixedClock(now.Add(30 * time.Minute))
+	if w := get(lastModified); w.Code != http.StatusNotModified {
+		t.Errorf("Expected status Not Modified before it's due, got %v", w.Code)
+	}
+
+	clock = fixedClock(now.Add(61 * time.Minute))
+	w := get(lastModified)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK once it's overdue, got %v", w.Code)
+	}
+	if w.Header().Get("Last-Modified") != "Wed, 05 Mar 2025 11:00:00 GMT" {
+		t.Errorf("Expected Last-Modified to be when it came due, got %q", w.Header().Get("Last-Modified"))
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed 5 field cron expression, "minute hour day-of-month month day-of-week", for timers that
+// repeat on days that a single frequency can't express, like "0 9 * * mon,thu". Times are in -timezone.
+type cronSchedule struct {
+	// Bit i is set when i matches the field.
+	minute, hour, dom, month, dow uint64
+	// As in cron, a day matches either day field when both are restricted, and both when one starts with *.
+	domAll, dowAll bool
+}
+
+// cronField is the range of values that one field takes.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names for the values from min, like jan or sun.
+}
+
+var cronFields = [5]cronField{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}, // 7 is Sunday too.
+}
+
+// How far ahead next looks before deciding that an expression never matches, long enough for February 29th.
+const cronHorizon = 8
+
+// parseCron parses a cron expression, failing with a 400 that says which field is wrong and why. Fields are
+// numbers, names, *, ranges like 1-5, steps like */15 or 1-5/2, and lists of those. Expressions that never match,
+// like February 30th, are rejected too.
+func parseCron(expr string) (cronSchedule, error) {
+	fail := func(format string, args ...any) (cronSchedule, error) {
+		return cronSchedule{}, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing cron schedule %q: "+format, append([]any{expr}, args...)...)}
+	}
+
+	fields := strings.Fields(strings.ToLower(expr))
+	if len(fields) != len(cronFields) {
+		return fail("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+	var sets [len(cronFields)]uint64
+	for i, f := range cronFields {
+		var err error
+		if sets[i], err = f.parse(fields[i]); err != nil {
+			return fail("%s field %q: %v", f.name, fields[i], err)
+		}
+	}
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1 // Sunday
+	}
+
+	s := cronSchedule{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domAll: strings.HasPrefix(fields[2], "*"), dowAll: strings.HasPrefix(fields[4], "*"),
+	}
+	if s.next(time.Date(2000, 1, 1, 0, 0, 0, 0, location)).IsZero() {
+		return fail("it never matches")
+	}
+	return s, nil
+}
+
+// looksLikeCron tells a cron expression typed as the create form's schedule from the schedules that parseSchedule
+// reads: it has five fields and the minute and hour are numbers or *, unlike in "3 weeks on the 1st".
+func looksLikeCron(text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) != len(cronFields) {
+		return false
+	}
+	for _, f := range fields[:2] {
+		if !strings.ContainsRune("0123456789*", rune(f[0])) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateCron fails with a 400 for a cron schedule that doesn't parse, or that comes with a frequency or time of day,
+// which it has its own of.
+func validateCron(c CountDown) error {
+	if c.Cron == "" {
+		return nil
+	}
+	if c.Frequency != 0 || c.FrequencyValue != 0 || c.FrequencyUnit != "" {
+		return httpError{http.StatusBadRequest, errors.New("A timer repeats on either a frequency or a cron schedule, not both")}
+	}
+	if c.DueTimeOfDay != nil {
+		return httpError{http.StatusBadRequest, errors.New("A cron schedule has its own times of day, it can't have another")}
+	}
+	_, err := parseCron(c.Cron)
+	return err
+}
+
+// parse sets the bit of each value that field matches.
+func (f cronField) parse(field string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		values, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, fmt.Errorf("the step %q isn't a positive number", stepText)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		if values != "*" {
+			first, last, isRange := strings.Cut(values, "-")
+			var err error
+			if lo, err = f.value(first); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(last); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max // 5/15 is every 15 from 5.
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("the range %q is backwards", values)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value parses one number or name in f's range.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if s == name {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q isn't a number", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%d isn't between %d and %d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (s cronSchedule) matchesDay(t time.Time) bool {
+	dom, dow := s.dom&(1<<t.Day()) != 0, s.dow&(1<<t.Weekday()) != 0
+	if s.domAll || s.dowAll {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next is the first minute after t that s matches, in location, or zero when there isn't one within cronHorizon
+// years. Minutes skipped by a DST change never match.
+func (s cronSchedule) next(t time.Time) time.Time {
+	t = t.In(location)
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.AddDate(cronHorizon, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+		case s.hour&(1<<t.Hour()) == 0:
+			// Added rather than built with time.Date, which would land back before a DST gap.
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// describe spells out s, e.g. "every Monday and Thursday at 9:00 AM". Expressions that it doesn't have words for are
+// described as expr.
+func (s cronSchedule) describe(expr string) string {
+	var at string
+	hours, minutes := setValues(s.hour), setValues(s.minute)
+	switch {
+	case len(minutes) == 1 && len(hours) <= 4:
+		var times []string
+		for _, h := range hours {
+			times = append(times, time.Date(0, 1, 1, h, minutes[0], 0, 0, time.UTC).Format("3:04 PM"))
+		}
+		at = "at " + joinAnd(times)
+	case len(minutes) == 1 && len(hours) == 24:
+		at = fmt.Sprintf("every hour at :%02d", minutes[0])
+	default:
+		return "on the cron schedule " + strings.Join(strings.Fields(expr), " ")
+	}
+
+	var days string
+	var weekdays []string
+	for _, d := range setValues(s.dow) {
+		if d < 7 {
+			weekdays = append(weekdays, time.Weekday(d).String())
+		}
+	}
+	var monthDays []string
+	for _, d := range setValues(s.dom) {
+		monthDays = append(monthDays, ordinal(d))
+	}
+	switch {
+	case s.domAll && s.dowAll:
+		days = "every day"
+	case s.domAll:
+		days = "every " + joinAnd(weekdays)
+	case s.dowAll:
+		days = "on the " + joinAnd(monthDays)
+	default:
+		days = "on the " + joinAnd(monthDays) + " or every " + joinAnd(weekdays)
+	}
+
+	if months := setValues(s.month); len(months) < 12 {
+		var names []string
+		for _, m := range months {
+			names = append(names, time.Month(m).String())
+		}
+		days += " in " + joinAnd(names)
+	}
+	switch {
+	case len(hours) < 24:
+		return days + " " + at
+	case days == "every day":
+		return at
+	}
+	return days + ", " + at
+}
+
+// setValues lists the values whose bits are set.
+func setValues(set uint64) []int {
+	var values []int
+	for set != 0 {
+		v := bits.TrailingZeros64(set)
+		values = append(values, v)
+		set &^= 1 << v
+	}
+	return values
+}
+
+// joinAnd joins words as a sentence would, "a, b and c".
+func joinAnd(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+// ordinal is n as 1st, 2nd, 3rd and so on.
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
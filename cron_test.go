@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCronNext tests finding the next minute that a cron expression matches
+func TestCronNext(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	// A Wednesday.
+	from := time.Date(2025, 3, 5, 10, 0, 30, 0, location)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"0 9 * * mon,thu", time.Date(2025, 3, 6, 9, 0, 0, 0, location)},
+		{"*/15 * * * *", time.Date(2025, 3, 5, 10, 15, 0, 0, location)},
+		{"* * * * *", time.Date(2025, 3, 5, 10, 1, 0, 0, location)},
+		{"30 8 1 * *", time.Date(2025, 4, 1, 8, 30, 0, 0, location)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, location)},
+		{"0 12 * * 7", time.Date(2025, 3, 9, 12, 0, 0, 0, location)},
+		// Either day field matches when both are restricted.
+		{"0 9 15 * fri", time.Date(2025, 3, 7, 9, 0, 0, 0, location)},
+		{"0 9 * jun-aug sat", time.Date(2025, 6, 7, 9, 0, 0, 0, location)},
+		// 2:30 doesn't exist on March 9th, the day that clocks go forward.
+		{"30 2 9 3 *", time.Date(2026, 3, 9, 2, 30, 0, 0, location)},
+		{"0 3 9 3 *", time.Date(2025, 3, 9, 3, 0, 0, 0, location)},
+	}
+	for _, tt := range tests {
+		s, err := parseCron(tt.expr)
+		if err != nil {
+			t.Errorf("parseCron(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := s.next(from); !got.Equal(tt.expected) {
+			t.Errorf("%q: expected %v, got %v", tt.expr, tt.expected, got)
+		}
+	}
+}
+
+// TestParseCronErrors tests that expressions that can't be used fail with a 400 saying why
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		expr, expected string
+	}{
+		{"0 9 * *", "expected 5 fields"},
+		{"60 9 * * *", `minute field "60": 60 isn't between 0 and 59`},
+		{"0 9 * * someday", `day of week field "someday": "someday" isn't a number`},
+		{"0 17-9 * * *", `hour field "17-9": the range "17-9" is backwards`},
+		{"*/0 9 * * *", `the step "0" isn't a positive number`},
+		{"0 0 30 2 *", "it never matches"},
+	}
+	for _, tt := range tests {
+		_, err := parseCron(tt.expr)
+		if h, ok := err.(httpError); !ok || h.code != http.StatusBadRequest || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("parseCron(%q) = %v, expected a 400 containing %q", tt.expr, err, tt.expected)
+		}
+	}
+}
+
+// TestCronDescribe tests the readable form of cron expressions
+func TestCronDescribe(t *testing.T) {
+	tests := []struct {
+		expr, expected string
+	}{
+		{"0 9 * * mon,thu", "every Monday and Thursday at 9:00 AM"},
+		{"30 18 * * *", "every day at 6:30 PM"},
+		{"0 9,17 * * 1-5", "every Monday, Tuesday, Wednesday, Thursday and Friday at 9:00 AM and 5:00 PM"},
+		{"0 8 1,15 * *", "on the 1st and 15th at 8:00 AM"},
+		{"0 8 1 jan,jul *", "on the 1st in January and July at 8:00 AM"},
+		{"15 * * * *", "every hour at :15"},
+		{"15 * * * sun", "every Sunday, every hour at :15"},
+		{"*/5  9-17 * * *", "on the cron schedule */5 9-17 * * *"},
+	}
+	for _, tt := range tests {
+		s, err := parseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q) failed: %v", tt.expr, err)
+		}
+		if got := s.describe(tt.expr); got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.expr, tt.expected, got)
+		}
+	}
+}
+
+// TestCronTimer tests creating a timer with a cron schedule and when it's due
+func TestCronTimer(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	clock = fixedClock(time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)) // A Wednesday.
+
+	s := &Server{db: setupTestDB(t)}
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	// Typed as the schedule, the last time was Monday.
+	w := post(url.Values{"name": {"Bins"}, "lasttime": {"2025-03-03T09:30"}, "scheduleMode": {"text"}, "schedule": {"0 9 * * mon,thu"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Repeats every Monday and Thursday at 9:00 AM") {
+		t.Errorf("Expected the schedule in the fragment, got %s", w.Body.String())
+	}
+	timers, err := s.listTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timers) != 1 || timers[0].Cron != "0 9 * * mon,thu" {
+		t.Fatalf("Expected the cron schedule to be stored, got %+v", timers)
+	}
+	if due := timers[0].NextDue(); !due.Equal(time.Date(2025, 3, 6, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected it due on Thursday, got %v", due)
+	}
+
+	// Never done, so due the first time it matches after being created.
+	neverDone := CountDown{Cron: "0 9 * * mon", CreatedAt: clock.Now()}
+	if due := neverDone.NextDue(); !due.Equal(time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a timer that was never done due next Monday, got %v", due)
+	}
+
+	for name, form := range map[string]url.Values{
+		"invalid":       {"name": {"Bad"}, "lasttime": {"2025-03-03T09:30"}, "cron": {"0 25 * * *"}},
+		"with due time": {"name": {"Bad"}, "lasttime": {"2025-03-03T09:30"}, "cron": {"0 9 * * *"}, "dueTime": {"21:00"}},
+	} {
+		if w := post(form); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status Bad Request, got %v: %s", name, w.Code, w.Body.String())
+		}
+	}
+	// The form only reads one of them, the API can send both.
+	if err := validateTimer(CountDown{Name: "Bad", Cron: "0 9 * * *", Frequency: time.Hour}); err == nil {
+		t.Errorf("Expected an error for a cron schedule with a frequency")
+	}
+}
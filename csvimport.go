@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The timer fields that a CSV column can be imported into, by default from a column with the same name.
+var csvFields = []string{"name", "description", "lasttime", "frequency"}
+
+// The layouts accepted for the lasttime column, the first is what spreadsheets tend to export.
+var csvTimeLayouts = []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04", time.RFC3339}
+
+// csvRowError is a row of a CSV import that couldn't be imported, Line is 1-based and counts the header.
+type csvRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// csvImportResult is what POST /import.csv did.
+type csvImportResult struct {
+	Created int           `json:"created"`
+	Errors  []csvRowError `json:"errors"` // The rows that were left out.
+}
+
+// parseCSVMapping parses a mapping like "name:Chore,frequency:Every" into a header for each field.
+// Fields that aren't mapped are read from the column named after them.
+func parseCSVMapping(raw string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, f := range csvFields {
+		mapping[f] = f
+	}
+	if raw == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		field, header, ok := strings.Cut(pair, ":")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || strings.TrimSpace(header) == "" {
+			return nil, fmt.Errorf("%q isn't field:header", pair)
+		}
+		if _, known := mapping[field]; !known {
+			return nil, fmt.Errorf("%q isn't one of %s", field, strings.Join(csvFields, ", "))
+		}
+		mapping[field] = strings.TrimSpace(header)
+	}
+	return mapping, nil
+}
+
+// parseCSVTime parses a lasttime cell, empty for a timer that has never been done.
+func parseCSVTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Error parsing lasttime %q: expected a date like 2025-03-05", s)
+}
+
+// parseCSVFrequency sets c's frequency from a cell, which is anything parseFrequency takes or a number of days.
+func parseCSVFrequency(c *CountDown, s string) error {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		s += " days"
+	}
+	return parseFrequency(c, s)
+}
+
+// readCSVTimers reads the timers in a CSV with a header row. Rows that can't be parsed are returned as errors
+// alongside the ones that could, a CSV that can't be read at all is an error.
+func readCSVTimers(r io.Reader, mapping map[string]string) ([]CountDown, []csvRowError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // Short rows just have empty cells.
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, httpError{http.StatusBadRequest, errors.New("The CSV is empty")}
+	}
+	if err != nil {
+		return nil, nil, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing CSV: %w", err)}
+	}
+
+	columns := map[string]int{}
+	for field, name := range mapping {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, nil, httpError{http.StatusBadRequest, fmt.Errorf("The CSV has no %q column, see the mapping parameter", mapping["name"])}
+	}
+
+	var timers []CountDown
+	var rowErrors []csvRowError
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing CSV: %w", err)}
+		}
+		line, _ := cr.FieldPos(0)
+		cell := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		c := CountDown{Name: cell("name"), Description: cell("description")}
+		if c.Name == "" {
+			rowErrors = append(rowErrors, csvRowError{line, "A timer needs a name"})
+			continue
+		}
+		if c.LastTime, err = parseCSVTime(cell("lasttime")); err != nil {
+			rowErrors = append(rowErrors, csvRowError{line, err.Error()})
+			continue
+		}
+		if err := parseCSVFrequency(&c, cell("frequency")); err != nil {
+			rowErrors = append(rowErrors, csvRowError{line, err.Error()})
+			continue
+		}
+		timers = append(timers, c)
+	}
+	return timers, rowErrors, nil
+}
+
+// insertTimers adds all of the timers or none of them.
+func (s *Server) insertTimers(ctx context.Context, timers []CountDown) error {
+	lock, err := acquireOperationLock(ctx, s.db, OperationImport)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range timers {
+		timers[i].Position = i // In the order of the file, at the top.
+		if err := insertTimer(ctx, tx, &timers[i]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, c := range timers {
+		s.emit(EventCreated, c)
+	}
+	return nil
+}
+
+func (s *Server) importCSVHandler(w http.ResponseWriter, r *http.Request) error {
+	mapping, err := parseCSVMapping(r.URL.Query().Get("mapping"))
+	if err != nil {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'mapping': %w", err)}
+	}
+	strict := r.URL.Query().Get("strict") == "1"
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	if err := r.ParseMultipartForm(maxImportBytes); err != nil {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing form : %w", err)}
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error reading uploaded file: %w", err)}
+	}
+	defer file.Close()
+
+	timers, rowErrors, err := readCSVTimers(file, mapping)
+	if err != nil {
+		return err
+	}
+	if strict && len(rowErrors) > 0 {
+		lines := make([]string, len(rowErrors))
+		for i, e := range rowErrors {
+			lines[i] = fmt.Sprintf("Line %d: %s", e.Line, e.Error)
+		}
+		return httpError{http.StatusBadRequest, errors.New("Nothing was imported:\n" + strings.Join(lines, "\n"))}
+	}
+
+	if err := s.insertTimers(r.Context(), timers); err != nil {
+		return err
+	}
+	if rowErrors == nil {
+		rowErrors = []csvRowError{}
+	}
+	return encodeJSON(w, csvImportResult{Created: len(timers), Errors: rowErrors})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postCSV uploads csv to target the way a browser would.
+func postCSV(t *testing.T, s *Server, target, csv string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "chores.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(csv))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", target, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	return w
+}
+
+// TestImportCSV tests that good rows are imported and bad ones are reported by line
+func TestImportCSV(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	csv := "Name,Description,LastTime,Frequency,Room\n" +
+		"Water plants,By the window,2025-03-01,3 days,Kitchen\n" +
+		"Oil change,,2025-01-15 09:30,3 months,Garage\n" +
+		"Descale kettle,,not a date,1 month,Kitchen\n" +
+		"\"Clean\nfilters\",,,2 fortnights,Everywhere\n" +
+		"Renew passport\n"
+
+	w := postCSV(t, s, "/import.csv", csv)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	var result csvImportResult
+	decodeResponse(t, w, &result)
+	if result.Created != 3 {
+		t.Errorf("Expected 3 timers created, got %+v", result)
+	}
+	// The quoted name spans lines 5 and 6, the error is reported where the row starts.
+	if len(result.Errors) != 2 || result.Errors[0].Line != 4 || result.Errors[1].Line != 5 {
+		t.Errorf("Expected errors on lines 4 and 5, got %+v", result.Errors)
+	}
+
+	timers, err := s.listTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []CountDown{
+		{Name: "Water plants", Description: "By the window", LastTime: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Frequency: 3 * 24 * time.Hour},
+		{Name: "Oil change", LastTime: time.Date(2025, 1, 15, 9, 30, 0, 0, time.UTC), Frequency: 90 * 24 * time.Hour},
+		{Name: "Renew passport"},
+	}
+	if len(timers) != len(expected) {
+		t.Fatalf("Expected %d timers, got %+v", len(expected), timers)
+	}
+	for i, c := range timers {
+		e := expected[i]
+		if c.Name != e.Name || c.Description != e.Description || !c.LastTime.Equal(e.LastTime) || c.Frequency != e.Frequency {
+			t.Errorf("Expected %+v, got %+v", e, c)
+		}
+	}
+}
+
+// TestImportCSVMapping tests importing from columns with other names
+func TestImportCSVMapping(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	csv := "Chore,Every,Done\nWater plants,48h,2025-03-01\n"
+	w := postCSV(t, s, "/import.csv?mapping=name:chore,frequency:Every,lasttime:done", csv)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	timers, err := s.listTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timers) != 1 || timers[0].Name != "Water plants" || timers[0].Frequency != 48*time.Hour {
+		t.Errorf("Unexpected timers: %+v", timers)
+	}
+}
+
+// TestImportCSVStrict tests that with strict=1 one bad row means nothing is imported
+func TestImportCSVStrict(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	csv := "name,frequency\nWater plants,3 days\nOil change,sometimes\n"
+
+	w := postCSV(t, s, "/import.csv?strict=1", csv)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status Bad Request, got %v", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Line 3: ") {
+		t.Errorf("Expected the error to name line 3, got %q", w.Body.String())
+	}
+	timers, err := s.listTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timers) != 0 {
+		t.Errorf("Expected nothing imported, got %+v", timers)
+	}
+}
+
+// TestImportCSVErrors tests the uploads that are rejected outright
+func TestImportCSVErrors(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	tests := []struct {
+		name, target, csv string
+	}{
+		{"empty", "/import.csv", ""},
+		{"no name column", "/import.csv", "chore,frequency\nWater plants,3 days\n"},
+		{"unknown mapping field", "/import.csv?mapping=colour:Colour", "name\nWater plants\n"},
+		{"malformed mapping", "/import.csv?mapping=name", "name\nWater plants\n"},
+		{"bad quoting", "/import.csv", "name\n\"Water \"plants\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postCSV(t, s, tt.target, tt.csv); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status Bad Request, got %v: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestParseCSVFrequency tests the frequency formats that spreadsheets are likely to have
+func TestParseCSVFrequency(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in       string
+		expected time.Duration
+		unit     string
+		valid    bool
+	}{
+		{"", 0, "", true},
+		{"3", 3 * day, UnitDay, true},
+		{"1 day", day, UnitDay, true},
+		{"30 days", 30 * day, UnitDay, true},
+		{"2 Weeks", 14 * day, UnitWeek, true},
+		{"1 month", 30 * day, UnitMonth, true},
+		{"1 year", 365 * day, UnitYear, true},
+		{"36h", 36 * time.Hour, "", true},
+		{"0 days", 0, "", false},
+		{"-1 days", 0, "", false},
+		{"weekly", 0, "", false},
+		{"3 fortnights", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		var c CountDown
+		err := parseCSVFrequency(&c, tt.in)
+		if (err == nil) != tt.valid || c.Frequency != tt.expected || c.FrequencyUnit != tt.unit {
+			t.Errorf("parseCSVFrequency(%q) = %v %q, %v, expected %v %q, valid: %v", tt.in, c.Frequency, c.FrequencyUnit, err, tt.expected, tt.unit, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Dashboard is a public, read-only view of a chosen set of timers, or of the ones with a tag, e.g. the aquarium ones
+// for a forum signature. Anyone with its URL can see those timers and nothing else, until it is deleted. They're
+// managed from GET /dashboards.
+type Dashboard struct {
+	Id       int64   `json:"id"`
+	Name     string  `json:"name"`
+	Token    string  `json:"token"`         // The unguessable part of the URL.
+	TimerIds []int64 `json:"timerIds"`      // Empty for a dashboard of a tag, and once all of its timers are deleted.
+	Tag      string  `json:"tag,omitempty"` // Whichever timers have it when the dashboard is viewed.
+}
+
+// URL is where the dashboard is served, see dashboardHandler.
+func (d Dashboard) URL() string { return "/d/" + d.Token + "/" }
+
+// MarshalJSON adds the URL to the stored fields.
+func (d Dashboard) MarshalJSON() ([]byte, error) {
+	type dashboard Dashboard // Drops the methods so that json.Marshal doesn't recurse.
+	return json.Marshal(struct {
+		dashboard
+		URL string `json:"url"`
+	}{dashboard(d), d.URL()})
+}
+
+// newDashboardToken returns 128 random bits, URL safe.
+func newDashboardToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// createDashboard stores d with a new token, filling in its Id and Token.
+// Every timer has to exist, and a tag has to be in use, so that a typo doesn't silently leave a timer out.
+func (s *Server) createDashboard(ctx context.Context, d *Dashboard) error {
+	d.Tag = normalizeTag(d.Tag)
+	switch {
+	case strings.TrimSpace(d.Name) == "":
+		return httpError{http.StatusBadRequest, errors.New("A dashboard needs a name")}
+	case d.Tag != "" && len(d.TimerIds) > 0:
+		return httpError{http.StatusBadRequest, errors.New("A dashboard is of either timers or a tag, not both")}
+	case d.Tag == "" && len(d.TimerIds) == 0:
+		return httpError{http.StatusBadRequest, errors.New("A dashboard needs at least one timer, or a tag")}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if d.Tag != "" {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tag WHERE name = ?)`, d.Tag).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return httpError{http.StatusBadRequest, fmt.Errorf("No timer is tagged %s", d.Tag)}
+		}
+	}
+
+	d.Token = newDashboardToken()
+	result, err := tx.ExecContext(ctx, `INSERT INTO dashboard (name, token, tag) VALUES (?, ?, ?)`, d.Name, d.Token, d.Tag)
+	if err != nil {
+		return err
+	}
+	if d.Id, err = result.LastInsertId(); err != nil {
+		return err
+	}
+
+	for _, id := range d.TimerIds {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM timer WHERE id = ? AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return httpError{http.StatusBadRequest, fmt.Errorf("No timer with id: %d", id)}
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO dashboard_timer (dashboard_id, timer_id) VALUES (?, ?)`, d.Id, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// listDashboards lists every dashboard with the ids of its timers that haven't been deleted. A dashboard whose timers
+// are all deleted is still listed, since its URL still works until it's revoked.
+func (s *Server) listDashboards(ctx context.Context) ([]Dashboard, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.id, d.name, d.token, d.tag, t.id
+		FROM dashboard d
+		LEFT JOIN dashboard_timer dt ON dt.dashboard_id = d.id
+		LEFT JOIN timer t ON t.id = dt.timer_id AND t.deleted_at IS NULL
+		ORDER BY d.id, dt.timer_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dashboards := []Dashboard{}
+	for rows.Next() {
+		var d Dashboard
+		var timerID sql.NullInt64
+		if err := rows.Scan(&d.Id, &d.Name, &d.Token, &d.Tag, &timerID); err != nil {
+			return nil, err
+		}
+		if n := len(dashboards); n == 0 || dashboards[n-1].Id != d.Id {
+			d.TimerIds = []int64{}
+			dashboards = append(dashboards, d)
+		}
+		if timerID.Valid {
+			last := &dashboards[len(dashboards)-1]
+			last.TimerIds = append(last.TimerIds, timerID.Int64)
+		}
+	}
+	return dashboards, rows.Err()
+}
+
+// deleteDashboard revokes a dashboard, its URL stops working once caches of it expire.
+func (s *Server) deleteDashboard(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM dashboard WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return httpError{http.StatusNotFound, fmt.Errorf("No dashboard with id: %d", id)}
+	}
+	return nil
+}
+
+// dashboardTimers loads only the timers on the dashboard with token, so that nothing else can end up in its pages.
+func (s *Server) dashboardTimers(ctx context.Context, token string) ([]CountDown, error) {
+	var id int64
+	var tag string
+	err := s.db.QueryRowContext(ctx, `SELECT id, tag FROM dashboard WHERE token = ?`, token).Scan(&id, &tag)
+	if err == sql.ErrNoRows {
+		return nil, httpError{http.StatusNotFound, errors.New("No such dashboard")}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tag != "" {
+		return s.listTaggedTimers(ctx, tag, "", timerSort{})
+	}
+	return queryTimers(ctx, s.db, `
+		SELECT `+timerColumns+` FROM timer
+		WHERE id IN (SELECT timer_id FROM dashboard_timer WHERE dashboard_id = ?) AND deleted_at IS NULL`, id)
+}
+
+// dashboardHandler serves a dashboard as the pages of a snapshot of its timers, so the cards are the same read-only
+// ones and the snapshot's relative links work under /d/{token}/.
+func (s *Server) dashboardHandler(w http.ResponseWriter, r *http.Request) error {
+	timers, err := s.dashboardTimers(r.Context(), r.PathValue("token"))
+	if err != nil {
+		return err
+	}
+	files, err := s.renderSnapshot(r.Context(), timers)
+	if err != nil {
+		return err
+	}
+
+	page := r.PathValue("page")
+	if page == "" {
+		page = "index.html"
+	}
+	b, ok := files[page]
+	if !ok {
+		return httpError{http.StatusNotFound, fmt.Errorf("No page %q on this dashboard", page)}
+	}
+
+	// Short enough that deleting a dashboard takes effect soon for anyone who has it cached.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = w.Write(b)
+	return err
+}
+
+// dashboardsPage is the page for managing dashboards, with the timers and tags that a new one can show.
+type dashboardsPage struct {
+	Timers []CountDown
+	Tags   []string
+}
+
+// dashboardsPageHandler serves the page for managing dashboards. The dashboards themselves, with their tokens, are
+// only in dashboardListHandler's list, which the page loads with the API token when the server has one.
+func (s *Server) dashboardsPageHandler(w http.ResponseWriter, r *http.Request) error {
+	timers, err := s.listTimers(r.Context())
+	if err != nil {
+		return err
+	}
+	tags, err := s.listTags(r.Context())
+	if err != nil {
+		return err
+	}
+	return s.render(r.Context(), w, "dashboards", dashboardsPage{timers, tags})
+}
+
+// dashboardListHandler lists the dashboards for their page.
+func (s *Server) dashboardListHandler(w http.ResponseWriter, r *http.Request) error {
+	dashboards, err := s.listDashboards(r.Context())
+	if err != nil {
+		return err
+	}
+	return s.render(r.Context(), w, "dashboardlist", dashboards)
+}
+
+// createDashboardFormHandler creates a dashboard from the form on their page, of its checked timers or of its tag,
+// and responds with the list of dashboards with the new one in it.
+func (s *Server) createDashboardFormHandler(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing form : %w", err)}
+	}
+	d := Dashboard{Name: r.Form.Get("name"), Tag: r.Form.Get("tag")}
+	for _, text := range r.Form["timerId"] {
+		id, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing timer id %q: %w", text, err)}
+		}
+		d.TimerIds = append(d.TimerIds, id)
+	}
+	if err := s.createDashboard(r.Context(), &d); err != nil {
+		return err
+	}
+	dashboards, err := s.listDashboards(r.Context())
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", d.URL())
+	w.WriteHeader(http.StatusCreated)
+	return s.render(r.Context(), w, "dashboardlist", dashboards)
+}
+
+// The dashboards with their tokens, for the page that manages them. Like the API's list of them it needs the API
+// token, see requireToken.
+const dashboardListPath = "/dashboards/list"
+
+// registerDashboards adds the public dashboard pages and the API to manage them to m.
+func (s *Server) registerDashboards(m *routes) {
+	// The pages link to each other relatively, so the dashboard itself has to end in a slash.
+	m.HandleFunc("GET /d/{token}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+	})
+	m.HandleFunc("GET /d/{token}/{page}", ErrorHTTPHandler(s.dashboardHandler))
+	m.HandleFunc("GET /d/{token}/{$}", ErrorHTTPHandler(s.dashboardHandler))
+
+	m.HandleFunc("GET /dashboards", ErrorHTTPHandler(s.dashboardsPageHandler))
+	m.HandleFunc("GET "+dashboardListPath, ErrorHTTPHandler(s.dashboardListHandler))
+	m.HandleFunc("POST /dashboards", ErrorHTTPHandler(s.createDashboardFormHandler))
+
+	m.HandleFunc("GET /api/v1/dashboards", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		d, err := s.listDashboards(r.Context())
+		return http.StatusOK, d, err
+	}))
+
+	m.HandleFunc("POST /api/v1/dashboards", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		var d Dashboard
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			return 0, nil, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing dashboard JSON: %w", err)}
+		}
+		if err := s.createDashboard(r.Context(), &d); err != nil {
+			return 0, nil, err
+		}
+
+		w.Header().Set("Location", d.URL())
+		return http.StatusCreated, d, nil
+	}))
+
+	m.HandleFunc("DELETE /api/v1/dashboards/{id}", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return 0, nil, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing id : %w", err)}
+		}
+		return http.StatusNoContent, nil, s.deleteDashboard(r.Context(), id)
+	}))
+}
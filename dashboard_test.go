@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPublicDashboard tests that a dashboard shows its own timers read-only and never any other timer
+func TestPublicDashboard(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	s := &Server{db: db}
+
+	secret := CountDown{Name: "Secret timer", Description: "Not for the forum", Frequency: time.Hour}
+	if err := s.createTimer(t.Context(), &secret); err != nil {
+		t.Fatalf("Failed to create timer: %v", err)
+	}
+
+	w := serveAPI(t, s, "POST", "/api/v1/dashboards", fmt.Sprintf(`{"name":"Aquarium","timerIds":[%d,%d]}`, testTimers[0].Id, testTimers[1].Id))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var d struct {
+		Dashboard
+		URL string `json:"url"`
+	}
+	decodeResponse(t, w, &d)
+	if len(d.Token) < 20 || d.URL != "/d/"+d.Token+"/" {
+		t.Errorf("Unexpected dashboard: %+v", d)
+	}
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	w = get(d.URL)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if cc := w.Header().Get("Cache-Control"); !strings.Contains(cc, "public") {
+		t.Errorf("Expected a cacheable page, got Cache-Control %q", cc)
+	}
+	page := w.Body.String()
+	for _, timer := range testTimers {
+		if !strings.Contains(page, timer.Name) {
+			t.Errorf("Expected timer %q on the dashboard", timer.Name)
+		}
+	}
+	for _, forbidden := range []string{secret.Name, secret.Description, "hx-", "<form"} {
+		if strings.Contains(page, forbidden) {
+			t.Errorf("Dashboard contains %q", forbidden)
+		}
+	}
+
+	// The timer pages are there for the listed timers only.
+	if w := get(fmt.Sprintf("%stimer-%d.html", d.URL, testTimers[0].Id)); w.Code != http.StatusOK {
+		t.Errorf("Expected status OK for a listed timer's page, got %v", w.Code)
+	}
+	if w := get(fmt.Sprintf("%stimer-%d.html", d.URL, secret.Id)); w.Code != http.StatusNotFound {
+		t.Errorf("Expected NotFound for an unlisted timer's page, got %v", w.Code)
+	}
+
+	if w := get("/d/" + d.Token); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != d.URL {
+		t.Errorf("Expected a redirect to %s, got %v %q", d.URL, w.Code, w.Header().Get("Location"))
+	}
+	if w := get("/d/not-a-token/"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected NotFound for an unknown token, got %v", w.Code)
+	}
+
+	// Revoking
+	if w := serveAPI(t, s, "DELETE", fmt.Sprintf("/api/v1/dashboards/%d", d.Id), ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status NoContent, got %v", w.Code)
+	}
+	if w := get(d.URL); w.Code != http.StatusNotFound {
+		t.Errorf("Expected NotFound after revoking, got %v", w.Code)
+	}
+}
+
+// TestCreateDashboardErrors tests the validation of new dashboards
+func TestCreateDashboardErrors(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	s := &Server{db: db}
+
+	for _, body := range []string{
+		`{"name":"","timerIds":[1]}`,
+		`{"name":"Empty","timerIds":[]}`,
+		fmt.Sprintf(`{"name":"Typo","timerIds":[%d,999]}`, testTimers[0].Id),
+		`{"name":`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/dashboards", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected BadRequest for %s, got %v", body, w.Code)
+		}
+	}
+
+	var dashboards []Dashboard
+	decodeResponse(t, serveAPI(t, s, "GET", "/api/v1/dashboards", ""), &dashboards)
+	if len(dashboards) != 0 {
+		t.Errorf("Expected failed creates to leave nothing behind, got %+v", dashboards)
+	}
+}
+
+// TestTagDashboard tests that a dashboard of a tag shows whichever timers have the tag when it's viewed, and no others
+func TestTagDashboard(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	for _, c := range []CountDown{
+		{Name: "Change the filter", Tags: []string{"aquarium"}},
+		{Name: "Feed the fish", Tags: []string{"aquarium", "daily"}},
+		{Name: "Secret timer"},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, body := range []string{
+		`{"name":"Typo","tag":"aquarim"}`,
+		`{"name":"Both","tag":"aquarium","timerIds":[1]}`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/dashboards", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected BadRequest for %s, got %v: %s", body, w.Code, w.Body.String())
+		}
+	}
+	w := serveAPI(t, s, "POST", "/api/v1/dashboards", `{"name":"Aquarium","tag":"Aquarium"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var d Dashboard
+	decodeResponse(t, w, &d)
+	if d.Tag != "aquarium" {
+		t.Errorf("Expected the tag normalized, got %+v", d)
+	}
+
+	if w := serveAPI(t, s, "PUT", "/api/v1/timers/3", `{"name":"Secret timer","tags":["aquarium"],"version":1}`); w.Code != http.StatusOK {
+		t.Fatalf("Failed to tag the timer: %v %s", w.Code, w.Body.String())
+	}
+	page := serveAPI(t, s, "GET", d.URL(), "").Body.String()
+	for _, name := range []string{"Change the filter", "Feed the fish", "Secret timer"} {
+		if !strings.Contains(page, name) {
+			t.Errorf("Expected %q, tagged when the dashboard was viewed, on it: %s", name, page)
+		}
+	}
+	if w := serveAPI(t, s, "PUT", "/api/v1/timers/3", `{"name":"Secret timer","version":2}`); w.Code != http.StatusOK {
+		t.Fatalf("Failed to untag the timer: %v %s", w.Code, w.Body.String())
+	}
+	if page := serveAPI(t, s, "GET", d.URL(), "").Body.String(); strings.Contains(page, "Secret timer") {
+		t.Errorf("Expected the untagged timer gone from the dashboard: %s", page)
+	}
+}
+
+// TestDashboardOfDeletedTimers tests that a dashboard is still listed, so that it can be revoked, once all of its
+// timers are deleted
+func TestDashboardOfDeletedTimers(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	c := CountDown{Name: "Feed the fish"}
+	if err := s.createTimer(t.Context(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if w := serveAPI(t, s, "POST", "/api/v1/dashboards", `{"name":"Aquarium","timerIds":[1]}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	if err := s.deleteTimer(t.Context(), c.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serveAPI(t, s, "GET", "/api/v1/dashboards", "")
+	if body := strings.TrimSpace(w.Body.String()); !strings.Contains(body, `"timerIds":[]`) {
+		t.Errorf("Expected the dashboard listed without timers, got %s", body)
+	}
+	if body := serveAPI(t, s, "GET", "/dashboards/list", "").Body.String(); !strings.Contains(body, "Aquarium") || !strings.Contains(body, `hx-delete="/api/v1/dashboards/1"`) {
+		t.Errorf("Expected the dashboard on its page to revoke, got %s", body)
+	}
+}
+
+// TestCreateDashboardForm tests creating a dashboard from the form on the page that manages them
+func TestCreateDashboardForm(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	for _, c := range []CountDown{{Name: "Feed the fish", Tags: []string{"aquarium"}}, {Name: "Change the filter"}} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page := serveAPI(t, s, "GET", "/dashboards", "").Body.String()
+	for _, expected := range []string{`<option value="aquarium">aquarium</option>`, `name="timerId" value="2"`, `hx-get="/dashboards/list"`} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected %q on the page, got %s", expected, page)
+		}
+	}
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/dashboards", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	w := post(url.Values{"name": {"Fish"}, "tag": {""}, "timerId": {"1", "2"}})
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "Fish") || !strings.Contains(w.Body.String(), "2 timers") {
+		t.Errorf("Expected the list with the new dashboard, got %v: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); !strings.HasPrefix(loc, "/d/") {
+		t.Errorf("Expected the dashboard's URL, got %q", loc)
+	}
+	if w := post(url.Values{"name": {"Aquarium"}, "tag": {"aquarium"}}); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "Timers tagged aquarium") {
+		t.Errorf("Expected the list with the tag's dashboard, got %v: %s", w.Code, w.Body.String())
+	}
+	if w := post(url.Values{"name": {"Nothing"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected BadRequest without timers or a tag, got %v: %s", w.Code, w.Body.String())
+	}
+}
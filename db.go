@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// PRAGMAs that every connection needs.
+// database/sql pools connections, so running these once with db.Exec would only configure whichever connection
+// happened to run them. Passing them in the DSN has the driver run them on each new connection instead.
+var connectionPragmas = []string{
+	"busy_timeout(5000)", // Wait for locks held by other connections rather than failing with SQLITE_BUSY.
+	"foreign_keys(1)",
+}
+
+// openDB opens the sqlite database in file with connectionPragmas, and any extra pragmas, applied to every connection.
+// file can be a URI with parameters of its own, like file:name?mode=memory.
+func openDB(file string, pragmas ...string) (*sql.DB, error) {
+	sep := "?"
+	if strings.Contains(file, "?") {
+		sep = "&"
+	}
+	return sql.Open("sqlite", file+sep+url.Values{"_pragma": append(pragmas, connectionPragmas...)}.Encode())
+}
+
+// The pragma that has sqlite refuse every write on a connection, for -allow-newer-schema.
+const readOnlyPragma = "query_only(1)"
+
+// The schema, as the list of statements that build it up.
+// Each migration runs exactly once, its 1-based position in the list is recorded in schema_migrations.
+// Never edit a migration that has been released, append a new one instead.
+var migrations = []string{
+	// 1: The original table, which databases from before schema_migrations already have.
+	`CREATE TABLE IF NOT EXISTS timer (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		lasttime TEXT NOT NULL,
+		frequency INTEGER NOT NULL
+	);`,
+
+	// 2: Why the timer exists.
+	`ALTER TABLE timer ADD COLUMN reference_url TEXT NOT NULL DEFAULT '';`,
+
+	// 3: The timers created for each Idempotency-Key, see idempotency.go.
+	`CREATE TABLE idempotency_key (
+		key TEXT PRIMARY KEY,
+		timer_id INTEGER NOT NULL REFERENCES timer(id) ON DELETE CASCADE,
+		created_at TEXT NOT NULL
+	);`,
+
+	// 4: Public read-only dashboards of chosen timers, see dashboard.go.
+	`CREATE TABLE dashboard (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		token TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE dashboard_timer (
+		dashboard_id INTEGER NOT NULL REFERENCES dashboard(id) ON DELETE CASCADE,
+		timer_id INTEGER NOT NULL REFERENCES timer(id) ON DELETE CASCADE,
+		PRIMARY KEY (dashboard_id, timer_id)
+	);`,
+
+	// 5: What was last pushed to CalDAV for each timer, see caldav.go.
+	// Rows outlive their timer until the event is deleted from the calendar.
+	`CREATE TABLE caldav_sync (
+		timer_id INTEGER PRIMARY KEY,
+		etag TEXT NOT NULL,
+		hash TEXT NOT NULL
+	);`,
+
+	// 6: Deleting a timer only hides it for a while, so that it can be undone.
+	`ALTER TABLE timer ADD COLUMN deleted_at TEXT;`,
+
+	// 7: When each timer last changed, for Last-Modified. Empty for timers that haven't changed since.
+	`ALTER TABLE timer ADD COLUMN updated_at TEXT NOT NULL DEFAULT '';`,
+
+	// 8: Frequencies counted in calendar units, see frequency.go. frequency stays as the nominal length.
+	// The form only ever stored whole numbers of its units, which are converted here. Anything else keeps an empty
+	// unit and repeats after exactly frequency.
+	`ALTER TABLE timer ADD COLUMN frequency_value INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE timer ADD COLUMN frequency_unit TEXT NOT NULL DEFAULT '';
+	UPDATE timer SET frequency_unit = CASE
+		WHEN frequency <= 0 THEN ''
+		WHEN frequency % 31536000000000000 = 0 THEN 'year'
+		WHEN frequency % 2592000000000000 = 0 THEN 'month'
+		WHEN frequency % 604800000000000 = 0 THEN 'week'
+		WHEN frequency % 86400000000000 = 0 THEN 'day'
+		ELSE ''
+	END;
+	UPDATE timer SET frequency_value = CASE frequency_unit
+		WHEN 'year' THEN frequency / 31536000000000000
+		WHEN 'month' THEN frequency / 2592000000000000
+		WHEN 'week' THEN frequency / 604800000000000
+		WHEN 'day' THEN frequency / 86400000000000
+		ELSE 0
+	END;`,
+
+	// 9: When each timer was created, for telling the ones that were never done. Timers from before this count from
+	// the upgrade.
+	`ALTER TABLE timer ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+	UPDATE timer SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now');`,
+
+	// 10: The time of day that a timer is due at, in minutes after midnight. NULL for timers due a whole number of
+	// periods after they were last done.
+	`ALTER TABLE timer ADD COLUMN due_time_of_day INTEGER;`,
+
+	// 11: A cron expression for timers that repeat on particular days instead of every frequency, see cron.go.
+	`ALTER TABLE timer ADD COLUMN cron TEXT NOT NULL DEFAULT '';`,
+
+	// 12: Whether a timer that comes due on a weekend is due on the Monday after instead.
+	`ALTER TABLE timer ADD COLUMN skip_weekends INTEGER NOT NULL DEFAULT 0;`,
+
+	// 13: The start of an anchored schedule in RFC 3339, or empty for timers that are due a period after they're done.
+	`ALTER TABLE timer ADD COLUMN anchor TEXT NOT NULL DEFAULT '';`,
+
+	// 14-16: Limits after which a timer is finished, its last day in RFC 3339 and how many times it's done, and how
+	// many times it has been.
+	`ALTER TABLE timer ADD COLUMN ends_at TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE timer ADD COLUMN max_completions INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE timer ADD COLUMN completions INTEGER NOT NULL DEFAULT 0;`,
+
+	// 17: When a timer with skipped occurrences is next due in RFC 3339, empty when none are.
+	`ALTER TABLE timer ADD COLUMN skipped_until TEXT NOT NULL DEFAULT '';`,
+
+	// 18: When a snoozed timer is due in RFC 3339, empty when it isn't snoozed.
+	`ALTER TABLE timer ADD COLUMN snoozed_until TEXT NOT NULL DEFAULT '';`,
+
+	// 19: When a paused timer was paused in RFC 3339, empty when it isn't.
+	`ALTER TABLE timer ADD COLUMN paused_at TEXT NOT NULL DEFAULT '';`,
+
+	// 20-21: Vacations that paused every timer, see vacation.go, with at most one ongoing. Times are RFC 3339 but
+	// updated_at, which is as for timers.
+	`CREATE TABLE vacation (
+		id INTEGER PRIMARY KEY,
+		started_at TEXT NOT NULL,
+		ended_at TEXT NOT NULL DEFAULT '',
+		updated_at TEXT NOT NULL
+	);`,
+	`CREATE UNIQUE INDEX vacation_ongoing ON vacation (ended_at) WHERE ended_at = '';`,
+
+	// 22: The id of the vacation that paused a timer, 0 for a timer that isn't paused or was paused on its own.
+	`ALTER TABLE timer ADD COLUMN paused_by_vacation INTEGER NOT NULL DEFAULT 0;`,
+
+	// 23: How long before it's due that a timer is due soon, in nanoseconds, 0 for a tenth of its period.
+	`ALTER TABLE timer ADD COLUMN grace INTEGER NOT NULL DEFAULT 0;`,
+
+	// 24: -1 for low, 0 for normal and 1 for high, see priority.go.
+	`ALTER TABLE timer ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;`,
+
+	// 25: Tags that group timers, see tags.go, and the tag whose timers a dashboard shows instead of the ones in
+	// dashboard_timer, empty for chosen timers.
+	`CREATE TABLE tag (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE timer_tag (
+		timer_id INTEGER NOT NULL REFERENCES timer(id) ON DELETE CASCADE,
+		tag_id INTEGER NOT NULL REFERENCES tag(id) ON DELETE CASCADE,
+		PRIMARY KEY (timer_id, tag_id)
+	);
+	ALTER TABLE dashboard ADD COLUMN tag TEXT NOT NULL DEFAULT '';`,
+
+	// 26: A color to tell the timer apart by, as #rrggbb or empty for none.
+	`ALTER TABLE timer ADD COLUMN color TEXT NOT NULL DEFAULT '';`,
+
+	// 27: The name of a Bootstrap Icon for the timer, see icons.go.
+	`ALTER TABLE timer ADD COLUMN icon TEXT NOT NULL DEFAULT '';`,
+
+	// 28: Whether the timer is pinned to the top of the homepage, see pin.go.
+	`ALTER TABLE timer ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0;`,
+
+	// 29: Where the timer is in the homepage's order, from 0 at the top, see order.go. The timers start in the order
+	// they were listed in.
+	`ALTER TABLE timer ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+	UPDATE timer SET position = listed.n
+	FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY pinned DESC, priority DESC, id) - 1 AS n FROM timer) AS listed
+	WHERE listed.id = timer.id;`,
+
+	// 30: Timers that haven't changed since migration 7 count as changed by the upgrade, like created_at in 9.
+	`UPDATE timer SET updated_at = strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z' WHERE updated_at = '';`,
+
+	// 31: lasttime is NULL for timers that have never been done, rather than empty. SQLite can't drop NOT NULL from a
+	// column, so it's replaced by a copy without it.
+	`ALTER TABLE timer ADD COLUMN last_time TEXT;
+	UPDATE timer SET last_time = NULLIF(lasttime, '');
+	ALTER TABLE timer DROP COLUMN lasttime;
+	ALTER TABLE timer RENAME COLUMN last_time TO lasttime;`,
+
+	// 32: How many times the timer has been edited, counting from 1, see version.go.
+	`ALTER TABLE timer ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`,
+
+	// 33: Each time a timer was done, see history.go. The history starts with the last time of each timer that has
+	// one, in UTC like the rest of it.
+	`CREATE TABLE completion (
+		id INTEGER PRIMARY KEY,
+		timer_id INTEGER NOT NULL REFERENCES timer(id) ON DELETE CASCADE,
+		completed_at TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX completion_timer ON completion (timer_id, completed_at);
+	INSERT INTO completion (timer_id, completed_at) SELECT id, strftime('%Y-%m-%dT%H:%M:%SZ', lasttime) FROM timer WHERE lasttime IS NOT NULL;`,
+
+	// 34: When the timer was due as it was done, for streaks, see streak.go. It's NULL for the completions from
+	// before it was kept.
+	`ALTER TABLE completion ADD COLUMN due_at TEXT;`,
+
+	// 35: Whether the timer was done, skipped or only partly done, see history.go.
+	`ALTER TABLE completion ADD COLUMN kind TEXT NOT NULL DEFAULT 'done';`,
+
+	// 36-37: How many times a day, week, month or year to do the timer, see target.go. 0 and empty for none.
+	`ALTER TABLE timer ADD COLUMN target_count INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE timer ADD COLUMN target_period TEXT NOT NULL DEFAULT '';`,
+
+	// 38: The timer's current streak as of its last completion, see streak.go. It's NULL until storeMissingStreaks works
+	// it out from the history.
+	`ALTER TABLE timer ADD COLUMN streak INTEGER;`,
+
+	// 39: How long the timer's schedule was put back by resuming it, in nanoseconds, see unpauseTimer. Timers resumed
+	// before it was kept had their lasttime moved on instead.
+	`ALTER TABLE timer ADD COLUMN paused_for INTEGER NOT NULL DEFAULT 0;`,
+}
+
+// migrateOrReopen migrates db, which was opened from file. When the schema is newer than this binary and allowNewer is
+// set it is reopened read-only instead, reporting so, otherwise that is an error.
+func migrateOrReopen(db *sql.DB, file string, allowNewer bool) (*sql.DB, bool, error) {
+	err := migrate(db)
+	if _, newer := err.(newerSchemaError); !newer || !allowNewer {
+		return db, false, err
+	}
+	log.Printf("%v Starting read-only.\n", err)
+
+	// Reopened so that sqlite refuses writes too, rather than relying on every code path to check.
+	db.Close()
+	db, err = openDB(file, readOnlyPragma)
+	return db, true, err
+}
+
+// schemaVersion reports the latest migration applied to db, 0 for a new database.
+func schemaVersion(db *sql.DB) (int, error) {
+	if _, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	);`); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// newerSchemaError is returned by migrate for a database migrated by a newer binary. Its tables may have columns that
+// this binary doesn't know to fill in, or would drop when rewriting a row, so it mustn't write to it.
+type newerSchemaError struct {
+	version int
+}
+
+func (e newerSchemaError) Error() string {
+	return fmt.Sprintf("The database is at schema version %d but this binary only knows up to %d. "+
+		"Run a newer binary, or pass -allow-newer-schema to start read-only.", e.version, len(migrations))
+}
+
+// migrate applies the migrations that db hasn't seen yet, each in its own transaction.
+func migrate(db *sql.DB) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return newerSchemaError{version}
+	}
+	if version == len(migrations) {
+		return nil
+	}
+	lock, err := acquireOperationLock(context.Background(), db, OperationMigrate)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	// Another binary may have migrated since the version was read.
+	if version, err = schemaVersion(db); err != nil {
+		return err
+	} else if version > len(migrations) {
+		return newerSchemaError{version}
+	}
+
+	for ; version < len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Error applying migration %d: %w", version+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version+1, clock.Now().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropTables drops every table in db, including schema_migrations so that migrate starts over. The operation lock's
+// table is kept, the drop holds it.
+func dropTables(db *sql.DB) error {
+	ctx := context.Background()
+	lock, err := acquireOperationLock(ctx, db, OperationRecreate)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	// foreign_keys is per connection, so everything has to happen on the same one.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'operation_lock'`)
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Foreign keys would make the order matter.
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+	for _, name := range tables {
+		if _, err := conn.ExecContext(ctx, `DROP TABLE "`+name+`"`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
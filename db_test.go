@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConnectionPragmas tests that every pooled connection gets the PRAGMAs, not just the first one
+func TestConnectionPragmas(t *testing.T) {
+	db := setupTestDB(t)
+
+	const conns = 4
+	db.SetMaxOpenConns(conns)
+
+	// Hold every connection at once so that the pool has to open new ones.
+	ctx := context.Background()
+	var held []*sql.Conn
+	for range conns {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get a connection: %v", err)
+		}
+		defer conn.Close()
+		held = append(held, conn)
+	}
+	if open := db.Stats().OpenConnections; open != conns {
+		t.Fatalf("Expected %d open connections, got %d", conns, open)
+	}
+
+	for i, conn := range held {
+		var foreignKeys, busyTimeout int
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+			t.Fatalf("Failed to read foreign_keys: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+			t.Fatalf("Failed to read busy_timeout: %v", err)
+		}
+
+		if foreignKeys != 1 {
+			t.Errorf("Connection %d: expected foreign_keys 1, got %d", i, foreignKeys)
+		}
+		if busyTimeout != 5000 {
+			t.Errorf("Connection %d: expected busy_timeout 5000, got %d", i, busyTimeout)
+		}
+	}
+}
+
+// TestMigrateExistingDatabase tests upgrading a database created before schema_migrations existed
+func TestMigrateExistingDatabase(t *testing.T) {
+	db, err := openDB(t.TempDir() + "/old.db")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// The schema and data as the original binary wrote them
+	if _, err := db.Exec(migrations[0]); err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO timer (name, description, lasttime, frequency) VALUES ('Old timer', '', '', 0), ('Done timer', '', '2025-01-02T09:00:00Z', 0)`); err != nil {
+		t.Fatalf("Failed to insert old data: %v", err)
+	}
+
+	// Migrating twice is the same as migrating once
+	for range 2 {
+		if err := migrate(db); err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+	}
+
+	version, err := schemaVersion(db)
+	if err != nil {
+		t.Fatalf("Failed to get schema version: %v", err)
+	}
+	if version != len(migrations) {
+		t.Errorf("Expected schema version %d, got %d", len(migrations), version)
+	}
+
+	timers, err := (&Server{db: db}).listTimers(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list timers: %v", err)
+	}
+	if len(timers) != 2 || timers[0].Name != "Old timer" {
+		t.Fatalf("Expected the old timers to survive, got %+v", timers)
+	}
+	if timers[0].CreatedAt.IsZero() {
+		t.Errorf("Expected the old timer to count as created by the upgrade")
+	}
+	if !timers[0].LastTime.IsZero() || !timers[1].LastTime.Equal(time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the last times to survive, got %v and %v", timers[0].LastTime, timers[1].LastTime)
+	}
+	var never int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM timer WHERE lasttime IS NULL`).Scan(&never); err != nil || never != 1 {
+		t.Errorf("Expected the timer that was never done to have a NULL lasttime, got %d, %v", never, err)
+	}
+	if history, err := (&Server{db: db}).listCompletions(context.Background(), timers[1].Id); err != nil || len(history) != 1 || !history[0].CompletedAt.Equal(timers[1].LastTime) {
+		t.Errorf("Expected the done timer's history to start with its last time, got %+v, %v", history, err)
+	}
+}
+
+// TestMigrateFrequencyUnits tests that the form's nanosecond frequencies become calendar units
+func TestMigrateFrequencyUnits(t *testing.T) {
+	db, err := openDB(t.TempDir() + "/old.db")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(migrations[0]); err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	if _, err := db.Exec(`
+	INSERT INTO timer (name, description, lasttime, frequency) VALUES
+		('Never', '', '', 0),
+		('Daily', '', '', 86400000000000),
+		('Fortnightly', '', '', 1209600000000000),
+		('Monthly', '', '', 2592000000000000),
+		('Quarterly', '', '', 7776000000000000),
+		('Yearly', '', '', 31536000000000000),
+		('Odd', '', '', 129600000000000)`); err != nil {
+		t.Fatalf("Failed to insert old data: %v", err)
+	}
+	if err := migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	timers, err := (&Server{db: db}).listTimers(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list timers: %v", err)
+	}
+	var got []string
+	for _, c := range timers {
+		got = append(got, c.Name+": "+c.Schedule())
+	}
+	expected := "Never: ,Daily: every day,Fortnightly: every 2 weeks,Monthly: every month,Quarterly: every 3 months," +
+		"Yearly: every year,Odd: every 2 days"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, ","))
+	}
+	if timers[6].FrequencyUnit != "" || timers[6].Frequency != 36*time.Hour {
+		t.Errorf("Expected 36 hours to stay an exact duration, got %+v", timers[6])
+	}
+}
+
+// TestNewerSchema tests that a database migrated by a newer binary is refused, or opened read-only when allowed
+func TestNewerSchema(t *testing.T) {
+	file := t.TempDir() + "/newer.db"
+	newerDB := func() *sql.DB {
+		db, err := openDB(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+
+	db := newerDB()
+	if err := migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, '')`, len(migrations)+1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO timer (name, description, lasttime, frequency) VALUES ('From the future', '', NULL, 0)`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, readOnly, err := migrateOrReopen(newerDB(), file, false)
+	db.Close()
+	if _, ok := err.(newerSchemaError); !ok || readOnly {
+		t.Fatalf("Expected a newerSchemaError without -allow-newer-schema, got %v, read-only: %v", err, readOnly)
+	}
+	if !strings.Contains(err.Error(), "-allow-newer-schema") {
+		t.Errorf("Expected the error to say how to start anyway, got %q", err)
+	}
+
+	db, readOnly, err = migrateOrReopen(newerDB(), file, true)
+	if err != nil || !readOnly {
+		t.Fatalf("Expected to open read-only, got %v, read-only: %v", err, readOnly)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`DELETE FROM timer`); err == nil {
+		t.Errorf("Expected sqlite to refuse writes")
+	}
+
+	s := &Server{db: db, readOnly: true}
+	if s.templates, s.staticTemplates, _, err = overrideTemplates("", template.FuncMap{"readOnly": func() bool { return true }}); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); !strings.Contains(body, "From the future") || !strings.Contains(body, "changes are turned off") {
+		t.Errorf("Expected the timers and a read-only banner, got %q", body)
+	}
+
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("POST", "/timer/1/reset", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "read-only") {
+		t.Errorf("Expected status Service Unavailable explaining why, got %v: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// Each dev server gets its own in-memory database, tests start more than one.
+var devDatabases atomic.Int64
+
+// newDevServer is the app that `countup dev-server` serves, for working on the UI without making up timers by hand.
+// It has an in-memory database of Fixtures, POST /admin/time-offset moves shifted, and the templates in
+// templateDir, if any, are read again for every request so that edits show up on reload.
+func newDevServer(templateDir string, shifted *offsetClock) (http.Handler, error) {
+	db, err := openDB(fmt.Sprintf("file:countup-dev-%d?mode=memory&cache=shared", devDatabases.Add(1)))
+	if err != nil {
+		return nil, err
+	}
+	// The database only lasts as long as a connection to it, so one is held for good.
+	if _, err := db.Conn(context.Background()); err != nil {
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+	for i, c := range Fixtures(clock.Now()) {
+		c.Position = i
+		if err := insertTimer(context.Background(), db, &c); err != nil {
+			return nil, fmt.Errorf("Error inserting fixture %q: %w", c.Name, err)
+		}
+	}
+
+	s := &Server{db: db, startedAt: clock.Now(), timeOffset: shifted}
+	if templateDir == "" {
+		return s.mux(), nil
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dev := *s
+		// Templates can change between any two requests, so pages are never Not Modified.
+		dev.startedAt = clock.Now()
+		var err error
+		if dev.templates, dev.staticTemplates, _, err = overrideTemplates(templateDir, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		dev.mux().ServeHTTP(w, r)
+	}), nil
+}
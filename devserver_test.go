@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDevServer tests that the dev server serves the fixtures, lets the time be changed and reloads templates
+func TestDevServer(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	shifted := &offsetClock{base: fixedClock(goldenNow)}
+	clock = shifted
+
+	dir := t.TempDir()
+	writeCard := func(card string) {
+		if err := os.WriteFile(filepath.Join(dir, "timer.html"), []byte(card), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCard(`<div class="timer" id="timer-{{.Id}}">{{.Name}}: {{.DueStatus}}</div>`)
+
+	h, err := newDevServer(dir, shifted)
+	if err != nil {
+		t.Fatalf("Failed to start the dev server: %v", err)
+	}
+	get := func() string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+
+	body := get()
+	for _, c := range Fixtures(goldenNow) {
+		if !strings.Contains(body, fmt.Sprintf(`id="timer-%d"`, c.Id)) {
+			t.Errorf("Expected fixture %d, %q, on the homepage", c.Id, c.Name)
+		}
+	}
+	if !strings.Contains(body, "Water plants: Overdue by 3 days") {
+		t.Errorf("Expected the overridden card, got %s", body)
+	}
+
+	// Edits to templates show up without a restart.
+	writeCard(`<div class="timer" id="timer-{{.Id}}">{{.Name}} was edited</div>`)
+	if body := get(); !strings.Contains(body, "Water plants was edited") {
+		t.Errorf("Expected the edited card, got %s", body)
+	}
+
+	req := httptest.NewRequest("POST", "/admin/time-offset", strings.NewReader(url.Values{"offset": {"-96h"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the time offset to be allowed, got %v: %s", w.Code, w.Body.String())
+	}
+	writeCard(`<div class="timer" id="timer-{{.Id}}">{{.Name}}: {{.DueStatus}}</div>`)
+	if body := get(); !strings.Contains(body, "Water plants: Do it again in 1 day") {
+		t.Errorf("Expected the card four days earlier, got %s", body)
+	}
+}
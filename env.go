@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The flags and environment variables that hold secrets, the report only says whether they are set.
+var (
+	secretFlags = map[string]bool{"api-token": true}
+	secretEnv   = []string{"COUNTUP_CALDAV_PASSWORD"}
+)
+
+// envReport is the effective configuration of a running server, for working out why one instance behaves differently
+// from another. It is logged at startup and served at GET /admin/env.
+type envReport struct {
+	Flags         map[string]string `json:"flags"` // Every registered flag, so that a new one can't be left out.
+	Env           map[string]string `json:"env"`   // The secretEnv variables, redacted.
+	SchemaVersion int               `json:"schemaVersion"`
+	SQLiteVersion string            `json:"sqliteVersion"`
+	Pragmas       map[string]string `json:"pragmas"` // The connectionPragmas as a connection reports them.
+	Templates     string            `json:"templates"`
+	Features      []string          `json:"features"` // The optional parts of the server that are turned on.
+}
+
+// redact hides a secret while still showing whether it is set.
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "<redacted>"
+}
+
+// flagValue is how a flag shows up in the report, without secrets or passwords in URLs.
+func flagValue(f *flag.Flag) string {
+	v := f.Value.String()
+	if secretFlags[f.Name] {
+		return redact(v)
+	}
+	if u, err := url.Parse(v); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return v
+}
+
+// envReport builds the report from the flags in fs and the state of s.
+func (s *Server) envReport(ctx context.Context, fs *flag.FlagSet) (envReport, error) {
+	r := envReport{Flags: map[string]string{}, Env: map[string]string{}, Pragmas: map[string]string{}, Templates: "embedded"}
+
+	fs.VisitAll(func(f *flag.Flag) { r.Flags[f.Name] = flagValue(f) })
+	for _, name := range secretEnv {
+		r.Env[name] = redact(os.Getenv(name))
+	}
+
+	var err error
+	if r.SchemaVersion, err = schemaVersion(s.db); err != nil {
+		return r, err
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT sqlite_version()`).Scan(&r.SQLiteVersion); err != nil {
+		return r, err
+	}
+	for _, p := range connectionPragmas {
+		name, _, _ := strings.Cut(p, "(")
+		var v string
+		if err := s.db.QueryRowContext(ctx, `PRAGMA `+name).Scan(&v); err != nil {
+			return r, fmt.Errorf("Error reading PRAGMA %s: %w", name, err)
+		}
+		r.Pragmas[name] = v
+	}
+
+	if s.templateSource != "" {
+		r.Templates = s.templateSource
+	}
+	r.Features = []string{}
+	for _, f := range []struct {
+		name string
+		on   bool
+	}{
+		{"api-token", s.apiToken != ""},
+		{"hooks", s.hooks != nil},
+		{"caldav", s.caldav != nil},
+		{"read-only", s.readOnly},
+		{"demo", s.timeOffset != nil},
+	} {
+		if f.on {
+			r.Features = append(r.Features, f.name)
+		}
+	}
+	return r, nil
+}
+
+// lines is the report as sorted key=value lines for the log.
+func (r envReport) lines() []string {
+	var lines []string
+	for name, v := range r.Flags {
+		lines = append(lines, fmt.Sprintf("flag.%s=%q", name, v))
+	}
+	for name, v := range r.Env {
+		lines = append(lines, fmt.Sprintf("env.%s=%q", name, v))
+	}
+	for name, v := range r.Pragmas {
+		lines = append(lines, fmt.Sprintf("pragma.%s=%q", name, v))
+	}
+	sort.Strings(lines)
+	return append(lines,
+		fmt.Sprintf("schema.version=%d", r.SchemaVersion),
+		fmt.Sprintf("sqlite.version=%q", r.SQLiteVersion),
+		fmt.Sprintf("templates=%q", r.Templates),
+		fmt.Sprintf("features=%q", strings.Join(r.Features, ",")),
+	)
+}
+
+func (s *Server) envHandler(w http.ResponseWriter, r *http.Request) error {
+	report, err := s.envReport(r.Context(), flag.CommandLine)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, report)
+}
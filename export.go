@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// The version of the export format written by GET /export. Imports only accept this version.
+const exportVersion = 1
+
+// The largest export that POST /import reads.
+const maxImportBytes = 10 << 20
+
+// exportFile is the JSON that GET /export downloads and POST /import reads back.
+type exportFile struct {
+	Version    int         `json:"version"`
+	ExportedAt time.Time   `json:"exportedAt"`
+	Timers     []CountDown `json:"timers"`
+}
+
+// What POST /import does with a timer that has the same name as an existing one.
+const (
+	ConflictSkip      = "skip"      // Keep the existing timer.
+	ConflictReplace   = "replace"   // Overwrite the existing timer, keeping its id.
+	ConflictDuplicate = "duplicate" // Create the imported timer alongside it.
+)
+
+// importResult counts what an import did with each timer.
+type importResult struct {
+	Created  int `json:"created"`
+	Skipped  int `json:"skipped"`
+	Replaced int `json:"replaced"`
+}
+
+func (s *Server) exportHandler(w http.ResponseWriter, r *http.Request) error {
+	timers, err := s.listTimers(r.Context())
+	if err != nil {
+		return err
+	}
+	if timers == nil {
+		timers = []CountDown{}
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="countup-export.json"`)
+	return encodeJSON(w, exportFile{Version: exportVersion, ExportedAt: clock.Now(), Timers: timers})
+}
+
+// readImport reads an export from the "file" field of a multipart upload or else the raw request body.
+// Everything is checked before the database is touched.
+func readImport(w http.ResponseWriter, r *http.Request) (exportFile, error) {
+	var f exportFile
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	var body io.Reader = r.Body
+	if err := r.ParseMultipartForm(maxImportBytes); err == nil {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return f, httpError{http.StatusBadRequest, fmt.Errorf("Error reading uploaded file: %w", err)}
+		}
+		defer file.Close()
+		body = file
+	} else if !errors.Is(err, http.ErrNotMultipart) {
+		return f, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing form : %w", err)}
+	}
+
+	if err := json.NewDecoder(body).Decode(&f); err != nil {
+		return f, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing export JSON: %w", err)}
+	}
+	if f.Version != exportVersion {
+		return f, httpError{http.StatusBadRequest, fmt.Errorf("Unsupported export version %d, expected %d", f.Version, exportVersion)}
+	}
+	for _, c := range f.Timers {
+		if err := validateTimer(c); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// importTimers adds the timers in one transaction, resolving name conflicts with the given strategy.
+func (s *Server) importTimers(ctx context.Context, timers []CountDown, conflict string) (importResult, error) {
+	var result importResult
+	lock, err := acquireOperationLock(ctx, s.db, OperationImport)
+	if err != nil {
+		return result, err
+	}
+	defer lock.release()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	var created, replaced []CountDown
+	for _, c := range timers {
+		var existing int64
+		err := tx.QueryRowContext(ctx, `SELECT id FROM timer WHERE name = ? AND deleted_at IS NULL ORDER BY id LIMIT 1`, c.Name).Scan(&existing)
+		if err != nil && err != sql.ErrNoRows {
+			return result, err
+		}
+		found := err == nil
+
+		switch {
+		case found && conflict == ConflictSkip:
+			result.Skipped++
+		case found && conflict == ConflictReplace:
+			c.Id = existing
+			c.normalizeFrequency()
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE timer SET description = ?, lasttime = ?, frequency = ?, frequency_value = ?, frequency_unit = ?, reference_url = ?, due_time_of_day = ?, cron = ?, skip_weekends = ?, anchor = ?, ends_at = ?, max_completions = ?, completions = ?, skipped_until = ?, snoozed_until = ?, paused_at = ?, paused_for = ?, grace = ?, priority = ?, color = ?, icon = ?, pinned = ?, target_count = ?, target_period = ?, version = version + 1, updated_at = ? WHERE id = ?`,
+				c.Description, nullTime(c.LastTime), c.Frequency, c.FrequencyValue, c.FrequencyUnit, c.ReferenceURL, c.DueTimeOfDay, c.Cron, c.SkipWeekends, formatLastTime(c.Anchor),
+				formatLastTime(c.EndsAt), c.MaxCompletions, c.Completions, formatLastTime(c.SkippedUntil), formatLastTime(c.SnoozedUntil), formatLastTime(c.PausedAt), c.PausedFor, c.Grace, c.Priority, c.Color, c.Icon, c.Pinned, c.TargetCount, c.TargetPeriod, updatedAt(), c.Id); err != nil {
+				return result, err
+			}
+			c.Tags = normalizeTags(c.Tags)
+			if err := setTimerTags(ctx, tx, c.Id, c.Tags); err != nil {
+				return result, err
+			}
+			if err := storeStreak(ctx, tx, c); err != nil {
+				return result, err
+			}
+			replaced = append(replaced, c)
+			result.Replaced++
+		default:
+			// An export lists the timers in order, they keep it at the top.
+			c.Position = result.Created
+			if err := insertTimer(ctx, tx, &c); err != nil {
+				return result, err
+			}
+			created = append(created, c)
+			result.Created++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return result, err
+	}
+
+	for _, c := range created {
+		s.emit(EventCreated, c)
+	}
+	for _, c := range replaced {
+		s.emit(EventUpdated, c)
+	}
+	return result, nil
+}
+
+func (s *Server) importHandler(w http.ResponseWriter, r *http.Request) error {
+	conflict := r.URL.Query().Get("conflict")
+	switch conflict {
+	case "":
+		conflict = ConflictSkip
+	case ConflictSkip, ConflictReplace, ConflictDuplicate:
+	default:
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'conflict': must be %s, %s or %s", ConflictSkip, ConflictReplace, ConflictDuplicate)}
+	}
+
+	f, err := readImport(w, r)
+	if err != nil {
+		return err
+	}
+	result, err := s.importTimers(r.Context(), f.Timers, conflict)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, result)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestExportImportRoundTrip tests that an export imports into an empty database as the same timers
+func TestExportImportRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+
+	w := httptest.NewRecorder()
+	(&Server{db: db}).mux().ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+	var export exportFile
+	decodeResponse(t, w, &export)
+	if export.Version != exportVersion || len(export.Timers) != len(testTimers) {
+		t.Fatalf("Unexpected export: %+v", export)
+	}
+
+	// Upload it as a file, the way a browser would.
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "countup-export.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(w.Body.Bytes())
+	mw.Close()
+
+	target := setupTestDB(t)
+	req := httptest.NewRequest("POST", "/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	(&Server{db: target}).mux().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	var result importResult
+	decodeResponse(t, w, &result)
+	if result != (importResult{Created: len(testTimers)}) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	imported, err := (&Server{db: target}).listTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range imported {
+		if c.Name != testTimers[i].Name || c.Frequency != testTimers[i].Frequency || !c.LastTime.Equal(export.Timers[i].LastTime) {
+			t.Errorf("Expected %+v, got %+v", export.Timers[i], c)
+		}
+	}
+}
+
+// TestImportConflicts tests each strategy for timers named like existing ones
+func TestImportConflicts(t *testing.T) {
+	body := `{"version":1,"timers":[
+		{"name":"Test Timer 1","description":"Imported","frequency":3600000000000},
+		{"name":"Brand new","frequency":60000000000}
+	]}`
+
+	tests := []struct {
+		conflict        string
+		expected        importResult
+		expectedTimers  int
+		expectedDescOf1 string
+	}{
+		{"", importResult{Created: 1, Skipped: 1}, 3, "First test timer"},
+		{ConflictSkip, importResult{Created: 1, Skipped: 1}, 3, "First test timer"},
+		{ConflictReplace, importResult{Created: 1, Replaced: 1}, 3, "Imported"},
+		{ConflictDuplicate, importResult{Created: 2}, 4, "First test timer"},
+	}
+
+	for _, tt := range tests {
+		t.Run("conflict="+tt.conflict, func(t *testing.T) {
+			db := setupTestDB(t)
+			testTimers := insertTestData(t, db)
+			s := &Server{db: db}
+
+			w := serveAPI(t, s, "POST", "/import?conflict="+tt.conflict, body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+			}
+			var result importResult
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+
+			timers, err := s.listTimers(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(timers) != tt.expectedTimers {
+				t.Errorf("Expected %d timers, got %d", tt.expectedTimers, len(timers))
+			}
+			c, err := s.getTimer(t.Context(), testTimers[0].Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Description != tt.expectedDescOf1 {
+				t.Errorf("Expected the existing timer's description to be %q, got %q", tt.expectedDescOf1, c.Description)
+			}
+		})
+	}
+}
+
+// TestImportErrors tests that bad imports are a 400 and leave the database alone
+func TestImportErrors(t *testing.T) {
+	db := setupTestDB(t)
+	insertTestData(t, db)
+	s := &Server{db: db}
+
+	tests := []struct{ name, target, body string }{
+		{"bad json", "/import", `{"version":1,"timers":[`},
+		{"unknown version", "/import", `{"version":2,"timers":[{"name":"From the future"}]}`},
+		{"missing version", "/import", `{"timers":[{"name":"No version"}]}`},
+		{"invalid timer", "/import", `{"version":1,"timers":[{"name":"Fine"},{"name":"Bad","referenceUrl":"javascript:alert(1)"}]}`},
+		{"unknown conflict strategy", "/import?conflict=merge", `{"version":1,"timers":[{"name":"Fine"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAPI(t, s, "POST", tt.target, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected BadRequest, got %v: %s", w.Code, w.Body.String())
+			}
+
+			var count int
+			if err := db.QueryRow("SELECT COUNT(*) FROM timer").Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != 2 {
+				t.Errorf("Expected the database to be untouched, got %d timers", count)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "time"
+
+// Fixtures is a set of timers covering what the dashboard has to show, as of now. The golden file tests render it
+// and `countup dev-server` serves it, so that what's tinkered with in a browser is what the tests check.
+// Each timer has its Id set, in the order that inserting them into an empty database gives them.
+func Fixtures(now time.Time) []CountDown {
+	day := 24 * time.Hour
+	nine := 9 * 60
+	return []CountDown{
+		{Id: 1, Name: "Water plants", Description: "The ones by the window", LastTime: now.Add(-5 * day), Frequency: 2 * day, Tags: []string{"garden", "house"}, Icon: "droplet", Pinned: true},
+		{Id: 2, Name: "Oil change", LastTime: now.Add(-30 * day), Frequency: 90 * day, ReferenceURL: "https://example.com/manual?page=12&section=4", Tags: []string{"car"}, Icon: "car-front"},
+		// Done once and never again.
+		{Id: 3, Name: "Renew passport", LastTime: now.Add(-400 * day)},
+		// Markup in user input has to come out as text, in both content and attributes.
+		{Id: 4, Name: `<b>"Quotes" & 'apostrophes'</b>`, Description: "</p><script>alert(1)</script>", LastTime: now.Add(-time.Hour), Frequency: day},
+		// Never done since long before now.
+		{Id: 5, Name: "Learn the banjo", CreatedAt: now.Add(-60 * day), Frequency: 7 * day},
+		// Never done, so due since it was created.
+		{Id: 6, Name: "Descale kettle", CreatedAt: now.Add(-2 * day), Frequency: 30 * day},
+		{Id: 7, Name: "Put the bins out", LastTime: now.Add(-2 * day), Cron: "0 20 * * mon,thu"},
+		// Due within the hour.
+		{Id: 8, Name: "Feed the sourdough starter", LastTime: now.Add(-day + 45*time.Minute), Frequency: day},
+		{
+			Id: 9, Name: "🪴 Repot the monstera 🌿",
+			Description: "Go one pot size up, no more. Use the chunky aroid mix from the shed rather than plain compost, " +
+				"water it in well, and keep it out of direct sun for a week or so while the roots settle. If the roots " +
+				"are circling the bottom of the old pot, tease them apart gently before moving it.",
+			LastTime: now.Add(-200 * day), FrequencyValue: 1, FrequencyUnit: UnitYear, Frequency: 365 * day,
+		},
+		{
+			Id: 10, Name: "Check the office mailbox", LastTime: now.Add(-3 * day),
+			FrequencyValue: 2, FrequencyUnit: UnitBusinessDay, Frequency: 2 * businessDaySize, DueTimeOfDay: &nine, SkipWeekends: true,
+		},
+		// A course that's over, it's never due again.
+		{Id: 11, Name: "Antibiotics", LastTime: now.Add(-10 * time.Hour), FrequencyValue: 1, FrequencyUnit: UnitDay, Frequency: day, MaxCompletions: 7, Completions: 7},
+		// Dormant for the winter, it would be overdue otherwise.
+		{Id: 12, Name: "Water the fig tree", LastTime: now.Add(-40 * day), Frequency: 7 * day, PausedAt: now.Add(-30 * day)},
+		{Id: 13, Name: "Change the smoke detector batteries", LastTime: now.Add(-100 * day), FrequencyValue: 1, FrequencyUnit: UnitYear, Frequency: 365 * day, Priority: PriorityHigh, Color: "#dc3545"},
+		{Id: 14, Name: "Dust the shelves", LastTime: now.Add(-10 * day), FrequencyValue: 1, FrequencyUnit: UnitMonth, Frequency: 30 * day, Priority: PriorityLow},
+		// A number of times a week rather than a due date.
+		{Id: 15, Name: "Go to the gym", LastTime: now.Add(-day), TargetCount: 3, TargetPeriod: UnitWeek, Icon: "bicycle"},
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// fleetInstance is a server that `countup fleet` polls, from its config file.
+type fleetInstance struct {
+	Name     string
+	URL      string // Of the server's root, like https://countup.example.com
+	Token    string // Its -api-token.
+	TokenEnv string // Or the environment variable to read the token from, to keep it out of the file.
+}
+
+// parseFleetConfig reads the instances from a config like:
+//
+//	instances:
+//	  - name: home
+//	    url: https://countup.example.com
+//	    tokenEnv: COUNTUP_HOME_TOKEN
+//
+// It's only as much YAML as that takes: comments, and strings that can be quoted.
+func parseFleetConfig(r io.Reader) ([]fleetInstance, error) {
+	var instances []fleetInstance
+	inList := false
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			if trimmed != "instances:" {
+				return nil, fmt.Errorf("line %d: expected instances:, got %q", n, trimmed)
+			}
+			inList = true
+			continue
+		}
+		if !inList {
+			return nil, fmt.Errorf("line %d: expected instances: before the list", n)
+		}
+		if item, ok := strings.CutPrefix(trimmed, "-"); ok {
+			instances = append(instances, fleetInstance{})
+			trimmed = strings.TrimSpace(item)
+			if trimmed == "" {
+				continue
+			}
+		} else if len(instances) == 0 {
+			return nil, fmt.Errorf("line %d: expected an instance starting with -", n)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value, got %q", n, trimmed)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", n, err)
+				}
+				value = unquoted
+			} else {
+				value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+			}
+		}
+		c := &instances[len(instances)-1]
+		switch strings.TrimSpace(key) {
+		case "name":
+			c.Name = value
+		case "url":
+			c.URL = value
+		case "token":
+			c.Token = value
+		case "tokenEnv":
+			c.TokenEnv = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q, expected name, url, token or tokenEnv", n, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(instances) == 0 {
+		return nil, errors.New("No instances to poll")
+	}
+	for i, c := range instances {
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("Instance %d needs an http:// or https:// url, got %q", i+1, c.URL)
+		}
+		if c.Name == "" {
+			instances[i].Name = u.Host
+		}
+	}
+	return instances, nil
+}
+
+// fleetResult is what polling one instance got, either its stats or why it couldn't.
+type fleetResult struct {
+	Name  string         `json:"name"`
+	URL   string         `json:"url"`
+	Stats *instanceStats `json:"stats,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// pollFleet gets the stats of every instance at once. An instance that can't be reached or fails only has its Error
+// set, the others are still polled. The results are in the order of instances.
+func pollFleet(ctx context.Context, client *http.Client, instances []fleetInstance) []fleetResult {
+	results := make([]fleetResult, len(instances))
+	var wg sync.WaitGroup
+	for i, c := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = fleetResult{Name: c.Name, URL: c.URL}
+			stats, err := pollInstance(ctx, client, c)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Stats = &stats
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func pollInstance(ctx context.Context, client *http.Client, c fleetInstance) (instanceStats, error) {
+	var stats instanceStats
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.URL, "/")+instanceStatsPath, nil)
+	if err != nil {
+		return stats, err
+	}
+	token := c.Token
+	if c.TokenEnv != "" {
+		token = os.Getenv(c.TokenEnv)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return stats, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return stats, fmt.Errorf("%s: %s", resp.Status, apiErr.Message)
+		}
+		return stats, errors.New(resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return stats, fmt.Errorf("Error decoding the stats: %w", err)
+	}
+	return stats, nil
+}
+
+// writeFleetTable writes the results as a table with a row per instance.
+func writeFleetTable(w io.Writer, results []fleetResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INSTANCE\tVERSION\tUPTIME\tTIMERS\tOVERDUE\tDB SIZE\tNOTIFICATIONS\tERROR")
+	for _, r := range results {
+		if r.Stats == nil {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t-\t-\t%s\n", r.Name, r.Error)
+			continue
+		}
+		s := r.Stats
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\t%s\t\n", r.Name, s.Version,
+			humanizeDuration(time.Duration(s.UptimeSeconds)*time.Second), s.Timers, s.Overdue, formatBytes(s.DBSizeBytes), notificationsText(s.Notifications))
+	}
+	return tw.Flush()
+}
+
+// notificationsText sums up the last deliveries like "caldav ok, hooks failed".
+func notificationsText(notifications map[string]*deliveryStatus) string {
+	if len(notifications) == 0 {
+		return "-"
+	}
+	var parts []string
+	for name, d := range notifications {
+		switch {
+		case d == nil:
+			parts = append(parts, name+" idle")
+		case d.OK:
+			parts = append(parts, name+" ok")
+		default:
+			parts = append(parts, name+" failed")
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// formatBytes is a size like 1.5 MB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	size, prefix := float64(n)/unit, 0
+	for ; size >= unit && prefix < 3; prefix++ {
+		size /= unit
+	}
+	return fmt.Sprintf("%.1f %cB", size, "KMGT"[prefix])
+}
+
+// runFleet is `countup fleet`, which polls the instances in a config file and compares them. It fails when any of them
+// couldn't be polled, after showing the others.
+func runFleet(args []string, stdout io.Writer, client *http.Client) error {
+	fleetFlags := flag.NewFlagSet("fleet", flag.ContinueOnError)
+	config := fleetFlags.String("config", "fleet.yaml", "The file listing the instances to poll, see parseFleetConfig.")
+	asJSON := fleetFlags.Bool("json", false, "Print the results as JSON rather than a table.")
+	timeout := fleetFlags.Duration("timeout", 10*time.Second, "How long to wait for all of the instances.")
+	if err := fleetFlags.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := os.Open(*config)
+	if err != nil {
+		return err
+	}
+	instances, err := parseFleetConfig(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("Error reading %s: %w", *config, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	results := pollFleet(ctx, client, instances)
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+	} else {
+		err = writeFleetTable(stdout, results)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d instances couldn't be polled", failed, len(results))
+	}
+	return nil
+}
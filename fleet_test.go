@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseFleetConfig tests reading the instances to poll, and the mistakes that are reported by line
+func TestParseFleetConfig(t *testing.T) {
+	instances, err := parseFleetConfig(strings.NewReader(`
+# Everyone's servers.
+instances:
+  - name: home
+    url: https://countup.example.com  # The one in the closet.
+    tokenEnv: COUNTUP_HOME_TOKEN
+  - url: "http://cabin.example.com:8080"
+    token: 'it''s secret'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []fleetInstance{
+		{Name: "home", URL: "https://countup.example.com", TokenEnv: "COUNTUP_HOME_TOKEN"},
+		{Name: "cabin.example.com:8080", URL: "http://cabin.example.com:8080", Token: "it's secret"},
+	}
+	if len(instances) != len(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, instances)
+	}
+	for i := range expected {
+		if instances[i] != expected[i] {
+			t.Errorf("Expected instance %d to be %+v, got %+v", i, expected[i], instances[i])
+		}
+	}
+
+	for _, tt := range []struct{ name, config, expectedErr string }{
+		{"empty", "instances:\n", "No instances"},
+		{"other top level key", "servers:\n  - url: https://example.com\n", "line 1"},
+		{"unknown key", "instances:\n  - url: https://example.com\n    password: hunter2\n", `line 3: unknown key "password"`},
+		{"no url", "instances:\n  - name: home\n", "needs an http:// or https:// url"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseFleetConfig(strings.NewReader(tt.config)); err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestFleet tests polling instances that are up alongside ones that fail, as a table and as JSON
+func TestFleet(t *testing.T) {
+	home := &Server{db: setupTestDB(t), apiToken: "s3cret", startedAt: time.Now().Add(-3 * 24 * time.Hour), serveInstanceStats: true}
+	if err := home.createTimer(t.Context(), &CountDown{Name: "Water plants", LastTime: time.Now().Add(-72 * time.Hour), Frequency: 24 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	up := httptest.NewServer(home.mux())
+	defer up.Close()
+	off := httptest.NewServer((&Server{db: setupTestDB(t)}).mux())
+	defer off.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	t.Setenv("COUNTUP_HOME_TOKEN", "s3cret")
+	config := filepath.Join(t.TempDir(), "fleet.yaml")
+	if err := os.WriteFile(config, []byte("instances:\n"+
+		"  - name: home\n    url: "+up.URL+"\n    tokenEnv: COUNTUP_HOME_TOKEN\n"+
+		"  - name: parents\n    url: "+off.URL+"\n"+
+		"  - name: cabin\n    url: "+broken.URL+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := runFleet([]string{"-config", config}, &out, http.DefaultClient)
+	if err == nil || !strings.Contains(err.Error(), "2 of 3") {
+		t.Errorf("Expected 2 of the 3 instances to fail, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and a row per instance, got:\n%s", out.String())
+	}
+	for i, expected := range []string{
+		`^home +\S+ +3 days +1 +1 +\d+\.\d KB +- *$`,
+		`^parents( +-){6} +404 Not Found: This server doesn't serve its stats`,
+		`^cabin( +-){6} +502 Bad Gateway$`,
+	} {
+		if !regexp.MustCompile(expected).MatchString(lines[i+1]) {
+			t.Errorf("Expected row %d to match %s, got %q", i+1, expected, lines[i+1])
+		}
+	}
+
+	out.Reset()
+	runFleet([]string{"-config", config, "-json"}, &out, http.DefaultClient)
+	var results []fleetResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode %s: %v", out.String(), err)
+	}
+	if len(results) != 3 || results[0].Stats == nil || results[0].Stats.Overdue != 1 || results[1].Stats != nil || results[2].Error == "" {
+		t.Errorf("Unexpected results: %s", out.String())
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A projected due date of a timer.
+type Occurrence struct {
+	TimerId int64     `json:"timerId"`
+	Name    string    `json:"name"`
+	Due     time.Time `json:"due"`
+	Overdue bool      `json:"overdue,omitempty"` // Already due before the forecast started.
+}
+
+// The occurrences due within one week of a forecast.
+type ForecastWeek struct {
+	Start       time.Time    `json:"start"`
+	End         time.Time    `json:"end"`
+	Occurrences []Occurrence `json:"occurrences"`
+}
+
+// Bounds on a forecast so that a tiny frequency can't turn a request into a huge loop.
+const (
+	forecastMaxWeeks       = 52
+	forecastMaxOccurrences = 1000 // Per timer.
+)
+
+// forecast projects every timer's due dates over the given number of weeks starting from the day of now.
+// Each occurrence is assumed to be done exactly when it's due, except for overdue timers which are assumed to be done
+// now. Timers without a frequency never come due again so they aren't part of the forecast.
+func forecast(timers []CountDown, now time.Time, weeks int) []ForecastWeek {
+	local := now.In(location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	forecast := make([]ForecastWeek, weeks)
+	for i := range forecast {
+		forecast[i] = ForecastWeek{
+			Start:       start.AddDate(0, 0, 7*i),
+			End:         start.AddDate(0, 0, 7*(i+1)),
+			Occurrences: []Occurrence{},
+		}
+	}
+	end := forecast[weeks-1].End
+
+	for _, c := range timers {
+		if !c.repeats() {
+			continue
+		}
+
+		for n := 0; n < forecastMaxOccurrences; n++ {
+			due := c.NextDue()
+			if !due.Before(end) {
+				break
+			}
+
+			o := Occurrence{TimerId: c.Id, Name: c.Name, Due: due.In(location)}
+			if due.Before(now) {
+				o.Overdue = true
+				due = now
+			}
+			week := 0
+			for !due.Before(forecast[week].End) {
+				week++
+			}
+			forecast[week].Occurrences = append(forecast[week].Occurrences, o)
+
+			c.LastTime, c.PausedFor = due, 0
+		}
+	}
+
+	for _, w := range forecast {
+		sort.SliceStable(w.Occurrences, func(i, j int) bool { return w.Occurrences[i].Due.Before(w.Occurrences[j].Due) })
+	}
+	return forecast
+}
+
+func (s *Server) forecastHandler(w http.ResponseWriter, r *http.Request) error {
+	weeks := 4
+	if v := r.URL.Query().Get("weeks"); v != "" {
+		var err error
+		if weeks, err = strconv.Atoi(v); err != nil || weeks < 1 || weeks > forecastMaxWeeks {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'weeks': must be a number from 1 to %d", forecastMaxWeeks)}
+		}
+	}
+
+	ct, err := negotiate(w, r, "text/html", "application/json")
+	if err != nil {
+		return err
+	}
+
+	timers, err := s.listTimers(r.Context())
+	if err != nil {
+		return err
+	}
+
+	f := forecast(timers, clock.Now(), weeks)
+	if ct == "application/json" {
+		return encodeJSON(w, f)
+	}
+	return s.render(r.Context(), w, "forecast", f)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestForecast pins the projected due dates of a fixture set of timers
+func TestForecast(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC) // A Wednesday
+	timers := []CountDown{
+		{Id: 1, Name: "Daily", LastTime: now.Add(-12 * time.Hour), Frequency: 24 * time.Hour},
+		{Id: 2, Name: "Overdue weekly", LastTime: time.Date(2025, 2, 20, 10, 0, 0, 0, time.UTC), Frequency: 7 * 24 * time.Hour},
+		{Id: 3, Name: "Monthly", LastTime: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), Frequency: 30 * 24 * time.Hour},
+		{Id: 4, Name: "Yearly", LastTime: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Frequency: 365 * 24 * time.Hour},
+		{Id: 5, Name: "One off", LastTime: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	f := forecast(timers, now, 2)
+	if len(f) != 2 {
+		t.Fatalf("Expected 2 weeks, got %d", len(f))
+	}
+	if !f[0].Start.Equal(time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)) || !f[1].End.Equal(time.Date(2025, 3, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected forecast bounds: %v - %v", f[0].Start, f[1].End)
+	}
+
+	// Summarize each week as "name@due" in order
+	summarize := func(w ForecastWeek) []string {
+		var s []string
+		for _, o := range w.Occurrences {
+			entry := o.Name + "@" + o.Due.Format("Jan 2 15:04")
+			if o.Overdue {
+				entry += " (overdue)"
+			}
+			s = append(s, entry)
+		}
+		return s
+	}
+
+	expected := [][]string{
+		{
+			"Overdue weekly@Feb 27 10:00 (overdue)",
+			"Daily@Mar 5 22:00", "Daily@Mar 6 22:00", "Daily@Mar 7 22:00", "Daily@Mar 8 22:00",
+			"Daily@Mar 9 22:00", "Daily@Mar 10 22:00", "Daily@Mar 11 22:00",
+		},
+		{
+			"Monthly@Mar 12 00:00",
+			"Overdue weekly@Mar 12 10:00", // A week after being done now
+			"Daily@Mar 12 22:00", "Daily@Mar 13 22:00", "Daily@Mar 14 22:00", "Daily@Mar 15 22:00",
+			"Daily@Mar 16 22:00", "Daily@Mar 17 22:00", "Daily@Mar 18 22:00",
+		},
+	}
+	for i := range expected {
+		got := summarize(f[i])
+		if strings.Join(got, ", ") != strings.Join(expected[i], ", ") {
+			t.Errorf("Week %d:\nexpected %v\n     got %v", i, expected[i], got)
+		}
+	}
+}
+
+// TestForecastTimezone tests that the forecast's days are in location rather than the zone of now or of the timers'
+// times
+func TestForecastTimezone(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	// 10 PM on Tuesday in New York, already Wednesday in UTC.
+	now := time.Date(2025, 3, 5, 3, 0, 0, 0, time.UTC)
+	f := forecast([]CountDown{{Id: 1, Name: "Daily", LastTime: now.Add(-time.Hour), Frequency: 24 * time.Hour}}, now, 1)
+	if expected := time.Date(2025, 3, 4, 0, 0, 0, 0, location); !f[0].Start.Equal(expected) {
+		t.Errorf("Expected the forecast to start on %v, got %v", expected, f[0].Start)
+	}
+	if due := f[0].Occurrences[0].Due; due.Location() != location || due.Format("Mon Jan 2 3:04 PM") != "Wed Mar 5 9:00 PM" {
+		t.Errorf("Expected the first time due in New York, got %v", due)
+	}
+}
+
+// TestForecastBoundsOccurrences tests that a tiny frequency can't make the forecast loop forever
+func TestForecastBoundsOccurrences(t *testing.T) {
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	f := forecast([]CountDown{{Id: 1, LastTime: now, Frequency: time.Nanosecond}}, now, forecastMaxWeeks)
+
+	total := 0
+	for _, w := range f {
+		total += len(w.Occurrences)
+	}
+	if total != forecastMaxOccurrences {
+		t.Errorf("Expected %d occurrences, got %d", forecastMaxOccurrences, total)
+	}
+}
+
+// TestForecastHandler tests GET /forecast
+func TestForecastHandler(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+
+	serve := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		(&Server{db: db}).mux().ServeHTTP(w, req)
+		return w
+	}
+
+	// HTML lists the timers that come due
+	w := serve("/forecast", "text/html")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), testTimers[0].Name) {
+		t.Errorf("Expected the daily timer in the forecast")
+	}
+
+	// JSON has one entry per week
+	w = serve("/forecast?weeks=3", "application/json")
+	var f []ForecastWeek
+	if err := json.Unmarshal(w.Body.Bytes(), &f); err != nil {
+		t.Fatalf("Failed to decode forecast: %v", err)
+	}
+	if len(f) != 3 {
+		t.Errorf("Expected 3 weeks, got %d", len(f))
+	}
+
+	for _, weeks := range []string{"0", "53", "soon"} {
+		if w := serve("/forecast?weeks="+weeks, "text/html"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected BadRequest for weeks=%s, got %v", weeks, w.Code)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// POST /timer is the create form. When htmx submits it with a mistake in it, the response is the form again with
+// what was typed and each error beside its field, retargeted at the form with HX-Retarget and HX-Reswap, so the modal
+// stays open to fix it. Other clients get the errors as plain text like any other 400.
+
+// createFormPrefix is the prefix of the homepage's create form's ids, and of the modal that it's in.
+const createFormPrefix = "createTimer"
+
+// fieldError is what's wrong with one of the create form's fields, by its name.
+type fieldError struct {
+	Field   string
+	Message string
+}
+
+// fieldErrors are the create form's mistakes, in the order of its fields, as a single 400.
+type fieldErrors []fieldError
+
+func (e fieldErrors) HTTPStatusCode() int { return http.StatusBadRequest }
+
+func (e fieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, f := range e {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "\n")
+}
+
+// timerFormView is the create form with what was typed into it and what was wrong with it, empty the first time.
+type timerFormView struct {
+	Prefix string
+	Values url.Values
+	Errors fieldErrors
+	Error  string // A mistake that isn't any one field's, like a schedule that the target doesn't go with.
+}
+
+// Value is what was typed into field.
+func (v timerFormView) Value(field string) string { return v.Values.Get(field) }
+
+// FieldError is what's wrong with field, empty when nothing is.
+func (v timerFormView) FieldError(field string) string {
+	for _, f := range v.Errors {
+		if f.Field == field {
+			return f.Message
+		}
+	}
+	return ""
+}
+
+// CreateForm is the homepage's empty create form.
+func (d homePageData) CreateForm() timerFormView { return timerFormView{Prefix: createFormPrefix} }
+
+// timerFromForm reads the create form into a timer, failing with the fieldErrors of every field that can't be read.
+// What's left for validateTimer is only checked when the timer is created.
+func timerFromForm(form url.Values) (CountDown, error) {
+	var errs fieldErrors
+	check := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, fieldError{field, err.Error()})
+		}
+	}
+	cd := CountDown{
+		Name:         strings.TrimSpace(form.Get("name")),
+		Description:  form.Get("description"),
+		ReferenceURL: form.Get("referenceUrl"),
+		SkipWeekends: form.Get("skipWeekends") == "1",
+		Tags:         parseTags(form.Get("tags")),
+		Color:        form.Get("color"),
+		Icon:         strings.TrimSpace(form.Get("icon")),
+	}
+	if cd.Name == "" {
+		errs = append(errs, fieldError{"name", "A timer needs a name"})
+	}
+	var err error
+	// A datetime-local input, in -timezone like the other times that forms send, see parseDoneTime.
+	if cd.LastTime, err = time.ParseInLocation("2006-01-02T15:04", form.Get("lasttime"), location); err != nil {
+		errs = append(errs, fieldError{"lasttime", "Error parsing query 'lasttime': " + err.Error()})
+	} else if cd.LastTime.After(clock.Now()) {
+		errs = append(errs, fieldError{"lasttime", fmt.Sprintf("It can't have been done in the future, at %s", cd.LastTime.Format("Mon Jan 2, 2006 3:04 PM"))})
+	}
+	cd.DueTimeOfDay, err = parseTimeOfDay(form.Get("dueTime"))
+	check("dueTime", err)
+	cd.Anchor, err = parseAnchor(form.Get("anchor"))
+	check("anchor", err)
+	check("endsAt", parseLimits(&cd, form.Get("endsAt"), ""))
+	check("maxCompletions", parseLimits(&cd, "", form.Get("maxCompletions")))
+	check("grace", parseGrace(&cd, form.Get("grace")))
+	check("priority", parsePriority(&cd, form.Get("priority")))
+	check("targetCount", parseTarget(&cd, form.Get("targetCount"), form.Get("targetPeriod")))
+
+	switch {
+	case form.Get("once") == "1":
+		// A one-time timer, with no frequency on purpose.
+	case form.Get("scheduleMode") == "text" && looksLikeCron(form.Get("schedule")):
+		cd.Cron = strings.TrimSpace(form.Get("schedule"))
+	case form.Get("scheduleMode") == "text":
+		sched, err := parseSchedule(form.Get("schedule"))
+		check("schedule", err)
+		cd.FrequencyValue, cd.FrequencyUnit = sched.Value, sched.Unit
+		// The day it falls on anchors it, see nextAnchored.
+		if sched.Day != "" && !cd.Anchor.IsZero() {
+			errs = append(errs, fieldError{"schedule", "The schedule already says which day, leave out the anchor"})
+		} else if sched.Day != "" {
+			cd.Anchor = sched.anchor(clock.Now())
+		}
+	case form.Has("cron"):
+		cd.Cron = strings.TrimSpace(form.Get("cron"))
+	case form.Has("frequency"):
+		// A single field like "3 days", for scripts.
+		check("frequency", parseFrequency(&cd, form.Get("frequency")))
+	default:
+		frequencyValue, err := strconv.ParseInt(form.Get("frequencyValue"), 10, 64)
+		if err != nil {
+			errs = append(errs, fieldError{"frequencyValue", "Error parsing frequency value: " + err.Error()})
+			break
+		}
+		frequencyUnit := form.Get("frequencyUnit")
+		if ns, err := strconv.ParseInt(frequencyUnit, 10, 64); err == nil {
+			// Forms from before units had names send the unit's length in nanoseconds.
+			cd.Frequency = time.Duration(frequencyValue * ns)
+		} else {
+			cd.FrequencyValue, cd.FrequencyUnit = frequencyValue, frequencyUnit
+		}
+	}
+	if len(errs) > 0 {
+		return cd, errs
+	}
+	return cd, nil
+}
+
+// formError responds to htmx's submission of the create form with the form again and err beside the field that it's
+// about, or above them all when it isn't any field's. Anything but a 400, and any other client, gets err as usual.
+func (s *Server) formError(w http.ResponseWriter, r *http.Request, err error) error {
+	if h, ok := err.(HTTPError); !ok || h.HTTPStatusCode() != http.StatusBadRequest || r.Header.Get("HX-Request") == "" {
+		return err
+	}
+	view := timerFormView{Prefix: createFormPrefix, Values: r.Form}
+	if errs, ok := err.(fieldErrors); ok {
+		view.Errors = errs
+	} else {
+		view.Error = err.Error()
+	}
+	w.Header().Set("HX-Retarget", "#"+createFormPrefix+"-form")
+	w.Header().Set("HX-Reswap", "outerHTML")
+	w.WriteHeader(http.StatusBadRequest)
+	return s.render(r.Context(), w, "timerform", view)
+}
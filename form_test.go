@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCreateFormErrors tests that htmx gets the create form back with what was typed and the errors beside their
+// fields, and that anyone else gets them as plain text
+func TestCreateFormErrors(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	post := func(form url.Values, htmx bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if htmx {
+			req.Header.Set("HX-Request", "true")
+		}
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	form := url.Values{
+		"name":           {"  "},
+		"description":    {"The ones by the window"},
+		"lasttime":       {"last tuesday"},
+		"frequencyValue": {"3"},
+		"frequencyUnit":  {"week"},
+		"priority":       {"high"},
+	}
+
+	w := post(form, true)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("HX-Retarget") != "#createTimer-form" || w.Header().Get("HX-Reswap") != "outerHTML" {
+		t.Errorf("Expected the response retargeted at the form, got %v", w.Header())
+	}
+	body := w.Body.String()
+	for _, expected := range []string{
+		`<form id="createTimer-form"`,
+		`class="form-control is-invalid" name="name"`,
+		`<div class="invalid-feedback d-block">A timer needs a name</div>`,
+		`name="lasttime" value="last tuesday" class="is-invalid">`,
+		`<div class="invalid-feedback d-block">Error parsing query &#39;lasttime&#39;`,
+		`>The ones by the window</textarea>`,
+		`<option value="week" selected>Weeks</option>`,
+		`<option value="high" selected>High</option>`,
+		`min="1" value="3"`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in the form, got %s", expected, body)
+		}
+	}
+
+	// Errors that aren't any one field's go above them all.
+	form.Set("name", "Water plants")
+	form.Set("lasttime", "2025-03-10T09:00")
+	form.Set("color", "red")
+	if w := post(form, true); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `<div class="alert alert-danger" role="alert">Error parsing color`) {
+		t.Errorf("Expected the form with the color's error above it, got %v: %s", w.Code, w.Body.String())
+	}
+
+	form.Set("name", "")
+	w = post(form, false)
+	if w.Code != http.StatusBadRequest || w.Header().Get("HX-Retarget") != "" || strings.TrimSpace(w.Body.String()) != "A timer needs a name" {
+		t.Errorf("Expected a plain text error without htmx, got %v %v: %s", w.Code, w.Header(), w.Body.String())
+	}
+
+	form.Set("name", "Water plants")
+	form.Del("color")
+	if w := post(form, true); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `id="timer-1"`) {
+		t.Errorf("Expected the new timer's card once the form is fixed, got %v: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestCreateFormLastTime tests that the create form's last time is read in -timezone, like a reset's, and can't be in
+// the future
+func TestCreateFormLastTime(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	clock = fixedClock(time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC))
+	defer func(l *time.Location) { location = l }(location)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{db: setupTestDB(t)}
+	post := func(lasttime string) *httptest.ResponseRecorder {
+		form := url.Values{"name": {"Take medication"}, "lasttime": {lasttime}, "frequencyValue": {"1"}, "frequencyUnit": {"day"}}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("2025-03-10T21:00"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected Created, got %v: %s", w.Code, w.Body.String())
+	}
+	c, err := s.getTimer(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2025, 3, 10, 21, 0, 0, 0, location); !c.LastTime.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, c.LastTime)
+	}
+
+	// 9:00 in New York is 13:00 UTC, an hour after now.
+	if w := post("2025-03-11T09:00"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "in the future") {
+		t.Errorf("Expected a future last time to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The units that a timer's frequency is counted in.
+// Days and weeks are fixed lengths of time, months and years follow the calendar so that a monthly timer stays on the
+// same day of the month. Business days only count Monday to Friday.
+const (
+	UnitDay         = "day"
+	UnitWeek        = "week"
+	UnitMonth       = "month"
+	UnitYear        = "year"
+	UnitBusinessDay = "business day"
+)
+
+// The nominal length of a business day, a week over the five of them.
+const businessDaySize = 7 * 24 * time.Hour / 5
+
+// frequencyUnits are the units from largest to smallest with their nominal lengths, which is what months and years
+// count for in CountDown.Frequency. They match the create form's old fixed lengths.
+var frequencyUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{UnitYear, 365 * 24 * time.Hour},
+	{UnitMonth, 30 * 24 * time.Hour},
+	{UnitWeek, 7 * 24 * time.Hour},
+	{UnitDay, 24 * time.Hour},
+}
+
+// unitSize is the nominal length of unit, false if it isn't one of the units.
+// Business days aren't in frequencyUnits so that normalizeFrequency never picks them for a bare duration.
+func unitSize(unit string) (time.Duration, bool) {
+	if unit == UnitBusinessDay {
+		return businessDaySize, true
+	}
+	for _, u := range frequencyUnits {
+		if u.name == unit {
+			return u.size, true
+		}
+	}
+	return 0, false
+}
+
+// setFrequency makes c repeat every value units.
+func (c *CountDown) setFrequency(value int64, unit string) {
+	size, _ := unitSize(unit)
+	c.FrequencyValue, c.FrequencyUnit, c.Frequency = value, unit, time.Duration(value)*size
+}
+
+// normalizeFrequency makes Frequency agree with FrequencyValue and FrequencyUnit before a timer is stored.
+// A timer with only Frequency set, e.g. from an older API client or export, gets the largest unit that divides it.
+// Frequencies that no unit divides, like 36 hours, stay exact durations with no unit.
+func (c *CountDown) normalizeFrequency() {
+	if c.FrequencyUnit != "" {
+		c.setFrequency(c.FrequencyValue, c.FrequencyUnit)
+		return
+	}
+	c.FrequencyValue = 0
+	for _, u := range frequencyUnits {
+		if c.Frequency > 0 && c.Frequency%u.size == 0 {
+			c.setFrequency(int64(c.Frequency/u.size), u.name)
+			return
+		}
+	}
+}
+
+// validateFrequency fails with a 400 for a unit that doesn't exist or a count of them that isn't positive.
+func validateFrequency(c CountDown) error {
+	if c.FrequencyUnit == "" {
+		if c.Frequency < 0 {
+			return httpError{http.StatusBadRequest, errors.New("A frequency can't be negative")}
+		}
+		return nil
+	}
+	if _, ok := unitSize(c.FrequencyUnit); !ok {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Unknown frequency unit %q, expected day, week, month, year or business day", c.FrequencyUnit)}
+	}
+	if c.FrequencyValue <= 0 {
+		return httpError{http.StatusBadRequest, fmt.Errorf("A frequency needs a positive number of %ss", c.FrequencyUnit)}
+	}
+	if size, _ := unitSize(c.FrequencyUnit); c.FrequencyValue > math.MaxInt64/int64(size) {
+		return httpError{http.StatusBadRequest, fmt.Errorf("%d %ss is too long a frequency", c.FrequencyValue, c.FrequencyUnit)}
+	}
+	return nil
+}
+
+// parseFrequency sets c's frequency from text like "3 days", "2 weeks", "1 month" or a Go duration like "90m".
+// Empty is a timer that doesn't repeat. It fails with a 400.
+func parseFrequency(c *CountDown, text string) error {
+	s := strings.ToLower(strings.TrimSpace(text))
+	if s == "" {
+		c.setFrequency(0, "")
+		return nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing frequency %q: it has to be positive", text)}
+		}
+		c.setFrequency(0, "")
+		c.Frequency = d
+		return nil
+	}
+
+	// The number can run into the unit, as in 3days.
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '-' && r != '+' })
+	if i <= 0 {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing frequency %q: expected something like 3 days", text)}
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing frequency %q: expected something like 3 days", text)}
+	}
+	unit := strings.Join(strings.Fields(strings.TrimSuffix(s[i:], "s")), " ")
+	if unit == "weekday" {
+		unit = UnitBusinessDay
+	}
+	if _, ok := unitSize(unit); !ok {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing frequency %q: the unit must be days, weeks, months, years or business days", text)}
+	}
+	if n <= 0 {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing frequency %q: it has to be positive", text)}
+	}
+	c.setFrequency(n, unit)
+	return validateFrequency(*c)
+}
+
+// addFrequency is one period of c after t, at c's time of day if it has one.
+func (c CountDown) addFrequency(t time.Time) time.Time {
+	var due time.Time
+	switch c.FrequencyUnit {
+	case UnitMonth:
+		due = addMonths(t, int(c.FrequencyValue))
+	case UnitYear:
+		due = addMonths(t, 12*int(c.FrequencyValue))
+	case UnitBusinessDay:
+		due = addBusinessDays(t, int(c.FrequencyValue))
+	default:
+		due = t.Add(c.Frequency)
+	}
+	return c.atTimeOfDay(due)
+}
+
+// atTimeOfDay moves t to c's time of day on the same date in location, so that a daily timer done at 7pm is still
+// due at 9pm the next day. Timers that repeat more often than daily have no time of day.
+func (c CountDown) atTimeOfDay(t time.Time) time.Time {
+	if c.DueTimeOfDay == nil || c.Frequency < 24*time.Hour {
+		return t
+	}
+	t = t.In(location)
+	due := time.Date(t.Year(), t.Month(), t.Day(), *c.DueTimeOfDay/60, *c.DueTimeOfDay%60, 0, 0, location)
+	// A time of day skipped by a DST change comes back from time.Date as the hour before it, e.g. 1:30 for 2:30.
+	// Moving it on by the difference makes it the time after the gap instead, 3:30.
+	if got := due.Hour()*60 + due.Minute(); got != *c.DueTimeOfDay {
+		due = due.Add(time.Duration(*c.DueTimeOfDay-got) * time.Minute)
+	}
+	return due
+}
+
+// parseTimeOfDay parses the create form's optional time input, like 21:00, into minutes since midnight.
+func parseTimeOfDay(s string) (*int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return nil, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing time of day %q: expected something like 21:00", s)}
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	return &minutes, nil
+}
+
+// validateTimeOfDay fails with a 400 for minutes that aren't in a day.
+func validateTimeOfDay(c CountDown) error {
+	if c.DueTimeOfDay != nil && (*c.DueTimeOfDay < 0 || *c.DueTimeOfDay >= 24*60) {
+		return httpError{http.StatusBadRequest, fmt.Errorf("A time of day is between 0 and %d minutes after midnight, not %d", 24*60-1, *c.DueTimeOfDay)}
+	}
+	return nil
+}
+
+// addBusinessDays is the weekday that is days weekdays after t in location, at the same time of day. From a weekend
+// the first one is Monday.
+func addBusinessDays(t time.Time, days int) time.Time {
+	t = t.In(location)
+	// Whole weeks first, so that a long frequency doesn't step through every day.
+	weeks := (days - 1) / 5
+	t = t.AddDate(0, 0, 7*weeks)
+	for days -= 5 * weeks; days > 0; {
+		t = t.AddDate(0, 0, 1)
+		if !isWeekend(t) {
+			days--
+		}
+	}
+	return t
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// nextAnchored is the first time after t that c's anchored schedule falls due: its Anchor plus a whole number of
+// periods, at its time of day. Being done late doesn't move the schedule, and a timer that's been left for several
+// periods is due from the first one after it was last done.
+func (c CountDown) nextAnchored(t time.Time) time.Time {
+	// Guessed from the nominal length of a period, then corrected for months not all being as long.
+	k := 0
+	if t.After(c.Anchor) {
+		k = int(t.Sub(c.Anchor) / c.Frequency)
+	}
+	for k > 0 && c.anchored(k-1).After(t) {
+		k--
+	}
+	for !c.anchored(k).After(t) {
+		k++
+	}
+	return c.atTimeOfDay(c.anchored(k))
+}
+
+// anchored is k periods after c's Anchor. Months and years from the 31st land on the last day of shorter months
+// without drifting off the 31st for the rest.
+func (c CountDown) anchored(k int) time.Time {
+	a := c.Anchor.In(location)
+	n := k * int(c.FrequencyValue)
+	switch c.FrequencyUnit {
+	case UnitDay:
+		return a.AddDate(0, 0, n)
+	case UnitWeek:
+		return a.AddDate(0, 0, 7*n)
+	case UnitMonth:
+		return addMonths(a, n)
+	case UnitYear:
+		return addMonths(a, 12*n)
+	}
+	return a.Add(time.Duration(k) * c.Frequency)
+}
+
+// parseAnchor parses the create form's optional anchor date, like 2025-03-01, as midnight in location.
+func parseAnchor(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, location)
+	if err != nil {
+		return time.Time{}, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing anchor %q: expected a date like 2025-03-01", s)}
+	}
+	return t, nil
+}
+
+// validateAnchor fails with a 400 for an anchor on a timer that doesn't repeat every so many days, weeks, months or
+// years, or a fixed length of time.
+func validateAnchor(c CountDown) error {
+	switch {
+	case c.Anchor.IsZero():
+		return nil
+	case c.Cron != "":
+		return httpError{http.StatusBadRequest, errors.New("A cron schedule can't be anchored, it's fixed already")}
+	case c.FrequencyUnit == UnitBusinessDay:
+		return httpError{http.StatusBadRequest, errors.New("A schedule of business days can't be anchored")}
+	case c.Frequency <= 0 && c.FrequencyValue <= 0:
+		return httpError{http.StatusBadRequest, errors.New("Only a timer that repeats can be anchored")}
+	}
+	return nil
+}
+
+// skipWeekend moves a due time that falls on a weekend in location on to the Monday after, for timers with
+// SkipWeekends set.
+func (c CountDown) skipWeekend(due time.Time) time.Time {
+	if !c.SkipWeekends {
+		return due
+	}
+	for local := due.In(location); isWeekend(local); local = due.In(location) {
+		due = local.AddDate(0, 0, 1)
+	}
+	return due
+}
+
+// addMonths is t.AddDate(0, months, 0) except that a day that the target month doesn't have becomes its last day,
+// e.g. a month after January 31st is February 28th rather than March 3rd.
+func addMonths(t time.Time, months int) time.Time {
+	d := t.AddDate(0, months, 0)
+	if d.Day() != t.Day() {
+		// Went past the end of the month, back up to the last day of the one before.
+		d = d.AddDate(0, 0, -d.Day())
+	}
+	return d
+}
+
+// Schedule describes how often c repeats, e.g. "every 2 weeks", "every day at 9:00 PM" or, for a cron schedule,
+// "every Monday and Thursday at 9:00 AM". It's empty for timers that don't repeat.
+func (c CountDown) Schedule() string {
+	return c.schedule(locale(language))
+}
+
+func (c CountDown) schedule(l locale) string {
+	var every string
+	switch {
+	case c.Cron != "":
+		s, err := parseCron(c.Cron)
+		if err != nil {
+			return c.Cron
+		}
+		return s.describe(c.Cron) + c.limitsText(l)
+	case c.Frequency <= 0:
+		return ""
+	case c.FrequencyUnit == "":
+		every = l.tr("every %s", l.humanizeDuration(c.Frequency))
+	default:
+		every = l.plural("every %d "+c.FrequencyUnit, int(c.FrequencyValue))
+	}
+	if !c.Anchor.IsZero() {
+		switch a := c.Anchor.In(location); c.FrequencyUnit {
+		case UnitMonth:
+			every = l.tr("%s on the %s", every, ordinal(a.Day()))
+		case UnitYear:
+			every = l.tr("%s on %s", every, a.Format("January 2"))
+		default:
+			every = l.tr("%s from %s", every, a.Format("Mon Jan 2, 2006"))
+		}
+	}
+	if c.DueTimeOfDay != nil && c.Frequency >= 24*time.Hour {
+		every = l.tr("%s at %s", every, time.Date(0, 1, 1, *c.DueTimeOfDay/60, *c.DueTimeOfDay%60, 0, 0, time.UTC).Format("3:04 PM"))
+	}
+	if c.SkipWeekends && c.FrequencyUnit != UnitBusinessDay {
+		every = l.tr("%s, moved to Monday from weekends", every)
+	}
+	return every + c.limitsText(l)
+}
+
+// schedule is a frequency typed as text, see parseSchedule.
+type schedule struct {
+	Value int64
+	Unit  string
+	Day   string // The day the text asked for, a weekday like Saturday for weeks or one like 1st for months. See anchor.
+}
+
+// Single words for a schedule, and for a number of units.
+var (
+	scheduleAdverbs = map[string]schedule{
+		"daily": {1, UnitDay, ""}, "weekly": {1, UnitWeek, ""}, "fortnightly": {2, UnitWeek, ""}, "biweekly": {2, UnitWeek, ""},
+		"monthly": {1, UnitMonth, ""}, "quarterly": {3, UnitMonth, ""}, "yearly": {1, UnitYear, ""}, "annually": {1, UnitYear, ""},
+	}
+	scheduleNumbers = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten", "eleven", "twelve"}
+)
+
+// parseSchedule parses a schedule typed in the create form, e.g. "every 3 weeks", "every other Saturday",
+// "fortnightly" or "monthly on the 1st". It fails with a 400, including for a day that the unit doesn't have.
+func parseSchedule(text string) (schedule, error) {
+	words := strings.Fields(strings.ToLower(text))
+	fail := func() (schedule, error) {
+		return schedule{}, httpError{http.StatusBadRequest, fmt.Errorf("Can't tell how often %q is, try something like every 3 weeks", text)}
+	}
+
+	// The day it should fall on, see scheduleDay.
+	var day string
+	if i := slices.Index(words, "on"); i >= 0 {
+		if i == len(words)-1 {
+			return fail()
+		}
+		day = strings.Join(words[i+1:], " ")
+		words = words[:i]
+	}
+
+	var s schedule
+	switch {
+	case len(words) == 1 && scheduleAdverbs[words[0]].Unit != "":
+		s = scheduleAdverbs[words[0]]
+	default:
+		if len(words) > 0 && (words[0] == "every" || words[0] == "each") {
+			words = words[1:]
+		}
+		if n := len(words); n >= 2 && words[n-2] == "business" {
+			words = append(words[:n-2], "business "+words[n-1])
+		}
+		s.Value = 1
+		switch len(words) {
+		case 1:
+		case 2:
+			n, err := strconv.ParseInt(words[0], 10, 64)
+			if i := slices.Index(scheduleNumbers, words[0]); i >= 0 {
+				n, err = int64(i), nil
+			} else if words[0] == "other" {
+				n, err = 2, nil
+			}
+			if err != nil || n <= 0 {
+				return fail()
+			}
+			s.Value = n
+		default:
+			return fail()
+		}
+
+		s.Unit = strings.TrimSuffix(words[len(words)-1], "s")
+		if s.Unit == "weekday" {
+			s.Unit = UnitBusinessDay
+		}
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if s.Unit == strings.ToLower(d.String()) {
+				if day != "" {
+					return fail()
+				}
+				s.Unit, day = UnitWeek, s.Unit
+			}
+		}
+		if _, ok := unitSize(s.Unit); !ok {
+			return fail()
+		}
+	}
+	var err error
+	if s.Day, err = scheduleDay(day, s.Unit); err != nil {
+		return schedule{}, err
+	}
+
+	return s, validateFrequency(CountDown{FrequencyValue: s.Value, FrequencyUnit: s.Unit})
+}
+
+// scheduleDay reads the lowercase day that a schedule falls on, a weekday for a weekly one or a day of the month like
+// the 1st for a monthly one, as schedule.Day. It fails with a 400 for any other day.
+func scheduleDay(text, unit string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if name := strings.ToLower(d.String()); text == name || text == name+"s" {
+			if unit != UnitWeek {
+				return "", httpError{http.StatusBadRequest, fmt.Errorf("Only weeks fall on a %s, try something like every other %s", d, d)}
+			}
+			return d.String(), nil
+		}
+	}
+	number := strings.TrimPrefix(text, "the ")
+	if n, err := strconv.Atoi(strings.TrimRight(number, "stndrh")); err == nil && n >= 1 && n <= 31 && (number == strconv.Itoa(n) || number == ordinal(n)) {
+		if unit != UnitMonth {
+			return "", httpError{http.StatusBadRequest, fmt.Errorf("Only months fall on the %s, try something like monthly on the %s", ordinal(n), ordinal(n))}
+		}
+		return ordinal(n), nil
+	}
+	return "", httpError{http.StatusBadRequest, fmt.Errorf("Can't tell which day %q is, try something like on the 1st or on Saturday", text)}
+}
+
+// anchor is the first day from now's on that s falls on, for the timer's Anchor, zero for a schedule without a Day.
+// The 31st is only in the months that have one, after that it's anchored's last day of the shorter ones.
+func (s schedule) anchor(now time.Time) time.Time {
+	if s.Day == "" {
+		return time.Time{}
+	}
+	local := now.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if s.Day == d.String() {
+			return today.AddDate(0, 0, (int(d)-int(today.Weekday())+7)%7)
+		}
+	}
+	n, _ := strconv.Atoi(strings.TrimRight(s.Day, "stndrh"))
+	for months := 0; ; months++ {
+		if a := time.Date(today.Year(), today.Month()+time.Month(months), n, 0, 0, 0, 0, location); a.Day() == n && !a.Before(today) {
+			return a
+		}
+	}
+}
+
+// interpretation spells out in l how a timer with s repeats, days and weeks as days since that's what they are.
+func (s schedule) interpretation(l locale) string {
+	var c CountDown
+	c.setFrequency(s.Value, s.Unit)
+	every := c.schedule(l)
+	switch {
+	case s.Unit == UnitMonth && s.Day != "":
+		every = l.tr("%s on the %s", every, s.Day)
+	case s.Unit == UnitWeek && s.Day != "":
+		every = l.tr("%s on %s", every, s.Day)
+	case s.Unit == UnitMonth:
+		every = l.tr("%s on the same day of the month", every)
+	case s.Unit == UnitYear:
+		every = l.tr("%s on the same date", every)
+	case s.Unit == UnitBusinessDay:
+		every = l.tr("%s, counting Monday to Friday", every)
+	default:
+		c.setFrequency(int64(c.Frequency/(24*time.Hour)), UnitDay)
+		every = c.schedule(l)
+	}
+	return every
+}
+
+// schedulePreview is what the create form shows under the schedule as it's typed.
+type schedulePreview struct {
+	Schedule schedule
+	Cron     string // How a cron expression reads, instead of Schedule.
+	Error    string
+}
+
+// schedulePreviewHandler renders how the create form's schedule text will be read, or why it can't be.
+// Errors are rendered with a 200 too, htmx only swaps in successful responses.
+func (s *Server) schedulePreviewHandler(w http.ResponseWriter, r *http.Request) error {
+	var preview schedulePreview
+	text := r.URL.Query().Get("schedule")
+	switch {
+	case strings.TrimSpace(text) == "":
+	case looksLikeCron(text):
+		if cron, err := parseCron(text); err != nil {
+			preview.Error = err.Error()
+		} else {
+			preview.Cron = cron.describe(text)
+		}
+	default:
+		var err error
+		if preview.Schedule, err = parseSchedule(text); err != nil {
+			preview.Error = err.Error()
+		}
+	}
+	return s.render(r.Context(), w, "schedulepreview", preview)
+}
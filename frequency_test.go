@@ -0,0 +1,501 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNextDueCalendar tests that months and years land on the same day of the month, clamped to shorter months
+func TestNextDueCalendar(t *testing.T) {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		last     time.Time
+		value    int64
+		unit     string
+		expected time.Time
+	}{
+		{"monthly", at(2025, 3, 5), 1, UnitMonth, at(2025, 4, 5)},
+		{"past the end of February", at(2025, 1, 31), 1, UnitMonth, at(2025, 2, 28)},
+		{"past the end of a leap February", at(2024, 1, 31), 1, UnitMonth, at(2024, 2, 29)},
+		{"past the end of April", at(2025, 3, 31), 1, UnitMonth, at(2025, 4, 30)},
+		{"across a year", at(2025, 11, 30), 3, UnitMonth, at(2026, 2, 28)},
+		{"yearly over a leap day", at(2024, 1, 15), 1, UnitYear, at(2025, 1, 15)},
+		{"yearly from a leap day", at(2024, 2, 29), 1, UnitYear, at(2025, 2, 28)},
+		{"every 4 years from a leap day", at(2024, 2, 29), 4, UnitYear, at(2028, 2, 29)},
+		{"weekly", at(2025, 3, 5), 2, UnitWeek, at(2025, 3, 19)},
+		{"daily", at(2025, 2, 28), 1, UnitDay, at(2025, 3, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CountDown{LastTime: tt.last}
+			c.setFrequency(tt.value, tt.unit)
+			if got := c.NextDue(); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestWeekends tests business days and moving due times off weekends, from Fridays and across several weeks
+func TestWeekends(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	// March 7th 2025 is a Friday.
+	at := func(day int) time.Time {
+		return time.Date(2025, 3, day, 17, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name         string
+		last         time.Time
+		value        int64
+		unit         string
+		skipWeekends bool
+		expected     time.Time
+	}{
+		{"business day from Friday", at(7), 1, UnitBusinessDay, false, at(10)},
+		{"business days from Friday", at(7), 3, UnitBusinessDay, false, at(12)},
+		{"business days from Wednesday", at(5), 2, UnitBusinessDay, false, at(7)},
+		{"business days from Saturday", at(8), 1, UnitBusinessDay, false, at(10)},
+		{"a business week", at(7), 5, UnitBusinessDay, false, at(14)},
+		{"business days over 3 weeks", at(7), 11, UnitBusinessDay, false, at(24)},
+		{"days onto Monday", at(7), 3, UnitDay, true, at(10)},
+		{"days onto Saturday", at(5), 3, UnitDay, true, at(10)},
+		{"days onto Sunday", at(6), 3, UnitDay, true, at(10)},
+		{"days onto a weekday", at(7), 4, UnitDay, true, at(11)},
+		{"weekly from Saturday", at(1), 2, UnitWeek, true, at(17)},
+		{"days onto a weekend unskipped", at(5), 3, UnitDay, false, at(8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CountDown{LastTime: tt.last, SkipWeekends: tt.skipWeekends}
+			c.setFrequency(tt.value, tt.unit)
+			if got := c.NextDue(); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected.Format("Mon Jan 2"), got.Format("Mon Jan 2"))
+			}
+		})
+	}
+
+	// A timer that was never done isn't due before the Monday after a weekend it was created on.
+	c := CountDown{CreatedAt: at(8), Frequency: 24 * time.Hour, SkipWeekends: true}
+	if got := c.NextDue(); !got.Equal(at(10)) {
+		t.Errorf("Expected a timer created on a Saturday due on Monday, got %v", got)
+	}
+}
+
+// TestAnchored tests that anchored timers keep to their schedule however late they're done, from the 31st, and after
+// several periods have gone by
+func TestAnchored(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		anchor   time.Time
+		last     time.Time
+		value    int64
+		unit     string
+		expected time.Time
+	}{
+		{"done late", date(2025, 1, 1), date(2025, 3, 4), 1, UnitMonth, date(2025, 4, 1)},
+		{"done on the day", date(2025, 1, 1), date(2025, 3, 1).Add(10 * time.Hour), 1, UnitMonth, date(2025, 4, 1)},
+		{"done early", date(2025, 1, 1), date(2025, 2, 27), 1, UnitMonth, date(2025, 3, 1)},
+		{"from the 31st into February", date(2025, 1, 31), date(2025, 2, 3), 1, UnitMonth, date(2025, 2, 28)},
+		{"from the 31st after February", date(2025, 1, 31), date(2025, 3, 1), 1, UnitMonth, date(2025, 3, 31)},
+		{"from the 31st into April", date(2025, 1, 31), date(2025, 4, 2), 1, UnitMonth, date(2025, 4, 30)},
+		{"years of periods later", date(2020, 1, 31), date(2025, 6, 15), 1, UnitMonth, date(2025, 6, 30)},
+		{"every other month", date(2025, 1, 15), date(2025, 4, 1), 2, UnitMonth, date(2025, 5, 15)},
+		{"before the anchor", date(2025, 6, 1), date(2025, 3, 1), 1, UnitMonth, date(2025, 6, 1)},
+		{"fortnightly", date(2025, 3, 3), date(2025, 5, 1), 2, UnitWeek, date(2025, 5, 12)},
+		{"leap day yearly", date(2024, 2, 29), date(2025, 1, 1), 1, UnitYear, date(2025, 2, 28)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CountDown{LastTime: tt.last, Anchor: tt.anchor}
+			c.setFrequency(tt.value, tt.unit)
+			if got := c.NextDue(); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected.Format("Mon Jan 2 2006"), got.Format("Mon Jan 2 2006"))
+			}
+		})
+	}
+
+	// A timer that was never done is due on the first day of its schedule after it was created, at its time of day.
+	nine := 9 * 60
+	c := CountDown{CreatedAt: date(2025, 3, 5), Anchor: date(2025, 1, 1), DueTimeOfDay: &nine}
+	c.setFrequency(1, UnitMonth)
+	if got, expected := c.NextDue(), date(2025, 4, 1).Add(9*time.Hour); !got.Equal(expected) {
+		t.Errorf("Expected a timer that was never done due at %v, got %v", expected, got)
+	}
+	if got, expected := c.Schedule(), "every month on the 1st"; !strings.HasPrefix(got, expected) {
+		t.Errorf("Expected the schedule to start %q, got %q", expected, got)
+	}
+}
+
+// TestCreateAnchoredTimer tests creating an anchored timer, and that resetting it late doesn't move its schedule
+func TestCreateAnchoredTimer(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	clock = fixedClock(time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC))
+	db := setupTestDB(t)
+	s := &Server{db: db}
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	w := post(url.Values{"name": {"Rent"}, "lasttime": {"2025-01-30T12:00"}, "frequencyValue": {"1"}, "frequencyUnit": {UnitMonth}, "anchor": {"2025-01-31"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+
+	var id int64
+	if err := db.QueryRow("SELECT id FROM timer WHERE name = 'Rent'").Scan(&id); err != nil {
+		t.Fatalf("Failed to read the timer: %v", err)
+	}
+	req := httptest.NewRequest("POST", fmt.Sprintf("/timer/%d/reset", id), nil)
+	rw := httptest.NewRecorder()
+	s.mux().ServeHTTP(rw, req)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", rw.Code, rw.Body.String())
+	}
+	c, err := s.getTimer(t.Context(), id)
+	if err != nil {
+		t.Fatalf("Failed to read the timer: %v", err)
+	}
+	if expected := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC); !c.NextDue().Equal(expected) {
+		t.Errorf("Expected rent done on the 4th due on the 31st, got %v", c.NextDue())
+	}
+
+	for _, form := range []url.Values{
+		{"name": {"Once"}, "lasttime": {"2025-03-01T12:00"}, "once": {"1"}, "anchor": {"2025-01-31"}},
+		{"name": {"Cron"}, "lasttime": {"2025-03-01T12:00"}, "cron": {"0 9 * * mon"}, "anchor": {"2025-01-31"}},
+		{"name": {"Bad date"}, "lasttime": {"2025-03-01T12:00"}, "frequencyValue": {"1"}, "frequencyUnit": {UnitMonth}, "anchor": {"31/01/2025"}},
+	} {
+		if w := post(form); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %q to be rejected, got %v: %s", form.Get("name"), w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestParseFrequency tests the frequencies that can be written as text in the API and the create form
+func TestParseFrequency(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in       string
+		expected time.Duration
+		unit     string
+		valid    bool
+	}{
+		{"", 0, "", true},
+		{"3 days", 3 * day, UnitDay, true},
+		{"1 day", day, UnitDay, true},
+		{"1 days", day, UnitDay, true},
+		{"2 weeks", 14 * day, UnitWeek, true},
+		{"1 month", 30 * day, UnitMonth, true},
+		{"2 Years", 730 * day, UnitYear, true},
+		{"  3   days ", 3 * day, UnitDay, true},
+		{"3days", 3 * day, UnitDay, true},
+		{"3 business days", 3 * businessDaySize, UnitBusinessDay, true},
+		{"5 weekdays", 7 * day, UnitBusinessDay, true},
+		{"90m", 90 * time.Minute, "", true},
+		{"1h30m", 90 * time.Minute, "", true},
+		{"3", 0, "", false},
+		{"days", 0, "", false},
+		{"3 fortnights", 0, "", false},
+		{"3 dayz", 0, "", false},
+		{"0 days", 0, "", false},
+		{"-2 weeks", 0, "", false},
+		{"0s", 0, "", false},
+		{"-90m", 0, "", false},
+		{"99999999999999 years", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		var c CountDown
+		err := parseFrequency(&c, tt.in)
+		if (err == nil) != tt.valid || (tt.valid && (c.Frequency != tt.expected || c.FrequencyUnit != tt.unit)) {
+			t.Errorf("parseFrequency(%q) = %v %q, %v, expected %v %q, valid: %v", tt.in, c.Frequency, c.FrequencyUnit, err, tt.expected, tt.unit, tt.valid)
+		}
+		if h, ok := err.(httpError); err != nil && (!ok || h.code != http.StatusBadRequest) {
+			t.Errorf("parseFrequency(%q): expected a 400, got %v", tt.in, err)
+		}
+	}
+}
+
+// TestNormalizeFrequency tests working out the unit of a frequency that only has nanoseconds
+func TestNormalizeFrequency(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		frequency time.Duration
+		value     int64
+		unit      string
+		schedule  string
+	}{
+		{0, 0, "", ""},
+		{day, 1, UnitDay, "every day"},
+		{3 * day, 3, UnitDay, "every 3 days"},
+		{14 * day, 2, UnitWeek, "every 2 weeks"},
+		{60 * day, 2, UnitMonth, "every 2 months"},
+		{365 * day, 1, UnitYear, "every year"},
+		{12 * time.Hour, 0, "", "every 12 hours"},
+	}
+
+	for _, tt := range tests {
+		c := CountDown{Frequency: tt.frequency}
+		c.normalizeFrequency()
+		if c.FrequencyValue != tt.value || c.FrequencyUnit != tt.unit || c.Frequency != tt.frequency {
+			t.Errorf("%v: expected %d %q, got %d %q %v", tt.frequency, tt.value, tt.unit, c.FrequencyValue, c.FrequencyUnit, c.Frequency)
+		}
+		if got := c.Schedule(); got != tt.schedule {
+			t.Errorf("%v: expected schedule %q, got %q", tt.frequency, tt.schedule, got)
+		}
+	}
+
+	// A unit wins over a Frequency that disagrees with it.
+	c := CountDown{Frequency: day, FrequencyValue: 1, FrequencyUnit: UnitMonth}
+	c.normalizeFrequency()
+	if c.Frequency != 30*day {
+		t.Errorf("Expected the nominal length of a month, got %v", c.Frequency)
+	}
+}
+
+// TestParseSchedule tests the ways of typing a schedule into the create form
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected schedule
+		valid    bool
+	}{
+		{"every 3 weeks", schedule{3, UnitWeek, ""}, true},
+		{"Every Day", schedule{1, UnitDay, ""}, true},
+		{"each two months", schedule{2, UnitMonth, ""}, true},
+		{"10 days", schedule{10, UnitDay, ""}, true},
+		{"year", schedule{1, UnitYear, ""}, true},
+		{"fortnightly", schedule{2, UnitWeek, ""}, true},
+		{"quarterly", schedule{3, UnitMonth, ""}, true},
+		{"monthly on the 1st", schedule{1, UnitMonth, "1st"}, true},
+		{"every 2 months on 15", schedule{2, UnitMonth, "15th"}, true},
+		{"every week on Tuesdays", schedule{1, UnitWeek, "Tuesday"}, true},
+		{"every other Saturday", schedule{2, UnitWeek, "Saturday"}, true},
+		{"every monday", schedule{1, UnitWeek, "Monday"}, true},
+		{"every 3 business days", schedule{3, UnitBusinessDay, ""}, true},
+		{"every weekday", schedule{1, UnitBusinessDay, ""}, true},
+		{"", schedule{}, false},
+		{"every", schedule{}, false},
+		{"every 0 days", schedule{}, false},
+		{"every 3 fortnights", schedule{}, false},
+		{"every now and then", schedule{}, false},
+		{"monthly on", schedule{}, false},
+		{"monthly on Saturday", schedule{}, false},
+		{"every 3 weeks on the 1st", schedule{}, false},
+		{"every monday on the 1st", schedule{}, false},
+		{"monthly on the 32nd", schedule{}, false},
+		{"monthly on the 2st", schedule{}, false},
+		{"monthly on payday", schedule{}, false},
+		{"every 9999999999 years", schedule{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSchedule(tt.in)
+		if (err == nil) != tt.valid || (tt.valid && got != tt.expected) {
+			t.Errorf("parseSchedule(%q) = %+v, %v, expected %+v, valid: %v", tt.in, got, err, tt.expected, tt.valid)
+		}
+	}
+}
+
+// TestScheduleAnchor tests that the day a schedule falls on anchors it from the next time that day comes round
+func TestScheduleAnchor(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	// A Wednesday.
+	now := time.Date(2025, 4, 16, 10, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		s        schedule
+		expected time.Time
+	}{
+		{schedule{2, UnitWeek, "Saturday"}, time.Date(2025, 4, 19, 0, 0, 0, 0, time.UTC)},
+		{schedule{1, UnitWeek, "Wednesday"}, time.Date(2025, 4, 16, 0, 0, 0, 0, time.UTC)},
+		{schedule{1, UnitMonth, "1st"}, time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{schedule{1, UnitMonth, "16th"}, time.Date(2025, 4, 16, 0, 0, 0, 0, time.UTC)},
+		{schedule{1, UnitMonth, "31st"}, time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{schedule{1, UnitMonth, ""}, time.Time{}},
+	} {
+		if got := tt.s.anchor(now); !got.Equal(tt.expected) {
+			t.Errorf("%+v: expected %v, got %v", tt.s, tt.expected, got)
+		}
+	}
+}
+
+// TestSchedulePreview tests echoing how typed schedules are read, and why they can't be
+func TestSchedulePreview(t *testing.T) {
+	s := &Server{}
+	tests := []struct {
+		schedule, expected string
+	}{
+		{"every 3 weeks", "= every 21 days"},
+		{"every year", "= every year on the same date"},
+		{"every other Saturday", "= every 2 weeks on Saturday"},
+		{"monthly on the 1st", "= every month on the 1st"},
+		{"sometimes", "Can&#39;t tell how often &#34;sometimes&#34; is"},
+		{"every 2 business days", "= every 2 business days, counting Monday to Friday"},
+		{"0 9 * * mon,thu", "= every Monday and Thursday at 9:00 AM"},
+		{"0 9 * * someday", "day of week field"},
+		{"3 weeks on the 1st", "Only months fall on the 1st"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, httptest.NewRequest("GET", "/schedule/preview?schedule="+strings.ReplaceAll(tt.schedule, " ", "+"), nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.expected) {
+			t.Errorf("%q: expected %q, got %v: %s", tt.schedule, tt.expected, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("GET", "/schedule/preview?schedule=", nil))
+	if strings.TrimSpace(w.Body.String()) != "" {
+		t.Errorf("Expected nothing for no text, got %q", w.Body.String())
+	}
+}
+
+// TestFrequencyUnitKept tests that a frequency keeps the unit it was entered in, even one that a larger unit divides
+func TestFrequencyUnitKept(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	form := url.Values{"name": {"Change filter"}, "lasttime": {"2025-03-01T09:00"}, "frequencyValue": {"14"}, "frequencyUnit": {"day"}}
+	req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Repeats every 14 days") {
+		t.Errorf("Expected the schedule in the fragment, got %s", w.Body.String())
+	}
+
+	// Copies and exports keep it too.
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("POST", "/timer/1/duplicate", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+	target := &Server{db: setupTestDB(t)}
+	w = serveAPI(t, target, "POST", "/import", w.Body.String())
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+
+	timers, err := target.listTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timers) != 2 {
+		t.Fatalf("Expected the timer and its copy, got %+v", timers)
+	}
+	for _, c := range timers {
+		if c.FrequencyValue != 14 || c.FrequencyUnit != UnitDay {
+			t.Errorf("Expected 14 days, got %+v", c)
+		}
+	}
+}
+
+// TestTimeOfDay tests that timers with a time of day are due at it, including across DST changes
+func TestTimeOfDay(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2025, month, day, hour, min, 0, 0, location)
+	}
+	ninePM, twoThirty := 21*60, 2*60+30
+
+	tests := []struct {
+		name      string
+		last      time.Time
+		value     int64
+		unit      string
+		timeOfDay int
+		expected  time.Time
+	}{
+		{"reset earlier in the day", at(3, 4, 19, 0), 1, UnitDay, ninePM, at(3, 5, 21, 0)},
+		{"reset later in the day", at(3, 4, 23, 0), 1, UnitDay, ninePM, at(3, 5, 21, 0)},
+		{"weekly", at(3, 3, 19, 0), 1, UnitWeek, ninePM, at(3, 10, 21, 0)},
+		{"monthly", at(1, 31, 8, 0), 1, UnitMonth, ninePM, at(2, 28, 21, 0)},
+		{"into DST", at(3, 8, 21, 0), 1, UnitDay, ninePM, at(3, 9, 21, 0)},
+		{"out of DST", at(11, 1, 21, 0), 1, UnitDay, ninePM, at(11, 2, 21, 0)},
+		{"a time skipped by DST", at(3, 8, 2, 30), 1, UnitDay, twoThirty, at(3, 9, 3, 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CountDown{LastTime: tt.last, DueTimeOfDay: &tt.timeOfDay}
+			c.setFrequency(tt.value, tt.unit)
+			if got := c.NextDue(); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// Timers that repeat more often than daily ignore it.
+	c := CountDown{LastTime: at(3, 4, 19, 0), Frequency: 6 * time.Hour, DueTimeOfDay: &ninePM}
+	if got := c.NextDue(); !got.Equal(at(3, 5, 1, 0)) {
+		t.Errorf("Expected 6 hours later, got %v", got)
+	}
+	if got := c.Schedule(); got != "every 6 hours" {
+		t.Errorf("Expected no time in the schedule, got %q", got)
+	}
+	c.setFrequency(2, UnitDay)
+	if got := c.Schedule(); got != "every 2 days at 9:00 PM" {
+		t.Errorf("Expected the time in the schedule, got %q", got)
+	}
+}
+
+// TestCreateTimerTimeOfDay tests the create form's optional time of day
+func TestCreateTimerTimeOfDay(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	post := func(dueTime string) *httptest.ResponseRecorder {
+		form := url.Values{"name": {"Take medication"}, "lasttime": {"2025-03-04T19:00"}, "frequencyValue": {"1"}, "frequencyUnit": {"day"}, "dueTime": {dueTime}}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("21:00"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	c, err := s.getTimer(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DueTimeOfDay == nil || *c.DueTimeOfDay != 21*60 {
+		t.Errorf("Expected 9pm, got %v", c.DueTimeOfDay)
+	}
+
+	if w := post("25:00"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status Bad Request, got %v", w.Code)
+	}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Bad","frequency":"1 day","dueTimeOfDay":1440}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status Bad Request, got %v", w.Code)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata/golden with the current output.")
+
+// goldenNow is the pinned time and zone that every golden file is rendered at, the zone stands in for -timezone too.
+var goldenNow = time.Date(2025, 3, 5, 10, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+// goldenCase is one template rendered with fixture data, compared against testdata/golden/{name}.html.
+type goldenCase struct {
+	name     string
+	template string
+	data     any
+	static   bool
+}
+
+func goldenCases() []goldenCase {
+	timers := Fixtures(goldenNow)
+	overdue, upcoming, oneOff, awkward, stale, neverDone, cron := timers[0], timers[1], timers[2], timers[3], timers[4], timers[5], timers[6]
+	finished, paused, highPriority := timers[10], timers[11], timers[12]
+
+	onStreak := upcoming
+	onStreak.Streak, onStreak.TimesDone = 6, 27
+	// Done more often than the target, which the card caps.
+	target := timers[14]
+	target.DoneThisPeriod = 4
+
+	s := &Server{}
+	var cases []goldenCase
+	for _, static := range []bool{false, true} {
+		prefix, render := "", s.render
+		if static {
+			prefix, render = "static-", s.renderStatic
+		}
+		cases = append(cases,
+			goldenCase{prefix + "timer-overdue", "timer", newTimerView(overdue), static},
+			goldenCase{prefix + "timer-upcoming", "timer", newTimerView(upcoming), static},
+			goldenCase{prefix + "timer-one-off", "timer", newTimerView(oneOff), static},
+			goldenCase{prefix + "timer-awkward", "timer", newTimerView(awkward), static},
+			goldenCase{prefix + "timer-stale", "timer", newTimerView(stale), static},
+			goldenCase{prefix + "timer-never-done", "timer", newTimerView(neverDone), static},
+			goldenCase{prefix + "timer-cron", "timer", newTimerView(cron), static},
+			goldenCase{prefix + "timer-finished", "timer", newTimerView(finished), static},
+			goldenCase{prefix + "timer-paused", "timer", newTimerView(paused), static},
+			goldenCase{prefix + "timer-high-priority", "timer", newTimerView(highPriority), static},
+			goldenCase{prefix + "timer-streak", "timer", newTimerView(onStreak), static},
+			goldenCase{prefix + "timer-target", "timer", newTimerView(target), static},
+			goldenCase{prefix + "homepage", "homepage", homePageData{Cards: renderCards(context.Background(), render, newTimerViews(timers)), Summary: summarize(timers, goldenNow)}, static},
+			goldenCase{prefix + "homepage-vacation", "homepage", homePageData{Cards: renderCards(context.Background(), render, newTimerViews(timers[:2])), Vacation: goldenNow.Add(-3 * 24 * time.Hour)}, static},
+			goldenCase{prefix + "homepage-empty", "homepage", homePageData{Cards: renderCards(context.Background(), render, nil), Empty: true}, static},
+			goldenCase{prefix + "homepage-nothing-overdue", "homepage", homePageData{Cards: renderCards(context.Background(), render, nil), Summary: summarize(timers, goldenNow), State: stateOverdue}, static},
+			goldenCase{prefix + "timerpage", "timerpage", newTimerView(upcoming), static},
+		)
+	}
+	history := []completion{
+		{CompletedAt: goldenNow.Add(-time.Hour), Kind: CompletionSkipped},
+		{CompletedAt: goldenNow.Add(-2 * 24 * time.Hour), Kind: CompletionPartial, Note: "With the plant food", DueAt: goldenNow.Add(-4 * 24 * time.Hour)},
+		{CompletedAt: goldenNow.Add(-5 * 24 * time.Hour), Kind: CompletionDone, DueAt: goldenNow.Add(-5*24*time.Hour + 3*time.Hour)},
+	}
+	for i := range history {
+		history[i].Late = history[i].lateness()
+	}
+	byDue := slices.Clone(timers)
+	sortByNextDue(byDue, false)
+	bucketCards := renderCards(context.Background(), s.render, newTimerViews(byDue))
+	groupByDue(bucketCards, goldenNow)
+	return append(cases,
+		goldenCase{"timerlist", "timerlist", homePageData{Cards: renderCards(context.Background(), s.render, newTimerViews(timers[:2]))}, false},
+		goldenCase{"timerlist-tagged", "timerlist", homePageData{Cards: renderCards(context.Background(), s.render, newTimerViews(timers[1:2])), Tag: "car"}, false},
+		goldenCase{"timerlist-more", "timerlist", homePageData{Cards: renderCards(context.Background(), s.render, newTimerViews(timers[:2])), More: "/timers?cursor=" + cursorOf(timers[1]).String()}, false},
+		goldenCase{"timerchunk", "timerchunk", timerChunk{renderCards(context.Background(), s.render, newTimerViews(timers[2:4])), timers[1].Pinned, "", "/timers?cursor=" + cursorOf(timers[3]).String() + "&tag=car"}, false},
+		goldenCase{"timerlist-buckets", "timerlist", homePageData{Cards: bucketCards, Sort: "nextdue"}, false},
+		goldenCase{"timerlist-no-match", "timerlist", homePageData{Cards: renderCards(context.Background(), s.render, nil), Query: "furnace"}, false},
+		goldenCase{"tagoptions", "tagoptions", []string{"car", "garden", "house"}, false},
+		goldenCase{"iconoptions", "iconoptions", searchIcons("drop"), false},
+		goldenCase{"carderror", "carderror", awkward.Id, false},
+		goldenCase{"errortoast", "errortoast", errorToastView{"DELETE", "/timer/1", http.StatusInternalServerError, `database is locked <script>`}, false},
+		goldenCase{"timerform", "timerform", homePageData{}.CreateForm(), false},
+		goldenCase{"timerform-errors", "timerform", timerFormView{
+			Prefix: createFormPrefix,
+			Values: url.Values{"name": {`<b>"Quotes"</b>`}, "lasttime": {"yesterday"}, "color": {"#ff0000"}, "priority": {"high"}, "scheduleMode": {"text"}, "schedule": {"sometimes"}},
+			Errors: fieldErrors{{"lasttime", "Error parsing query 'lasttime'"}, {"schedule", `Can't tell how often "sometimes" is`}},
+		}, false},
+		goldenCase{"timerform-error", "timerform", timerFormView{Prefix: createFormPrefix, Values: url.Values{"name": {"Floss"}}, Error: "A target period needs a number of times too"}, false},
+		goldenCase{"undotoast", "undotoast", awkward, false},
+		goldenCase{"schedulepreview", "schedulepreview", schedulePreview{Schedule: schedule{1, UnitMonth, "1st"}}, false},
+		goldenCase{"schedulepreview-cron", "schedulepreview", schedulePreview{Cron: "every Monday and Thursday at 8:00 PM"}, false},
+		goldenCase{"schedulepreview-error", "schedulepreview", schedulePreview{Error: `Can't tell how often "<b>sometimes</b>" is`}, false},
+		goldenCase{"widget", "widget", widgetView{newTimerView(overdue), false}, false},
+		goldenCase{"widget-compact", "widget", widgetView{newTimerView(upcoming), true}, false},
+		goldenCase{"widget-never-done", "widget", widgetView{newTimerView(neverDone), false}, false},
+		goldenCase{"forecast", "forecast", forecast(timers, goldenNow, 2), false},
+		goldenCase{"dashboards", "dashboards", dashboardsPage{timers[:3], []string{"car", "house"}}, false},
+		goldenCase{"dashboardlist", "dashboardlist", []Dashboard{{Id: 1, Name: "Aquarium", Token: "abc", TimerIds: []int64{1, 2}}, {Id: 2, Name: `<b>"House"</b>`, Token: "def", Tag: "house"}, {Id: 3, Name: "Gone", Token: "ghi", TimerIds: []int64{}}}, false},
+		goldenCase{"dashboardlist-empty", "dashboardlist", []Dashboard{}, false},
+		goldenCase{"print", "print", newChoreSheet(timers, goldenNow, ""), false},
+		goldenCase{"print-empty", "print", newChoreSheet(nil, goldenNow, "car"), false},
+		goldenCase{"summary", "summary", summarize(timers, goldenNow), false},
+		goldenCase{"history", "history", historyData{1, history, computeTimerStats(timers[0], history, goldenNow)}, false},
+		goldenCase{"recentcompletions", "recentcompletions", recentCompletions{[]recentCompletion{{history[1], 2, `<b>"Oil"</b>`}, {history[2], 1, timers[0].Name}}, defaultRecentLimit, false}, false},
+		goldenCase{"recentcompletions-skipped", "recentcompletions", recentCompletions{[]recentCompletion{{history[0], 1, timers[0].Name}}, 5, true}, false},
+		goldenCase{"recentcompletions-empty", "recentcompletions", recentCompletions{[]recentCompletion{}, defaultRecentLimit, false}, false},
+	)
+}
+
+// TestGoldenTemplates renders every template with fixture data at a pinned time and compares it to the checked in
+// output. Run with -update to accept changes, then review the diff of testdata/golden.
+func TestGoldenTemplates(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	clock = fixedClock(goldenNow)
+	defer func(l *time.Location) { location = l }(location)
+	location = goldenNow.Location()
+
+	s := &Server{}
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			render := s.render
+			if tc.static {
+				render = s.renderStatic
+			}
+			if err := render(t.Context(), &buf, tc.template, tc.data); err != nil {
+				t.Fatalf("Failed to render: %v", err)
+			}
+
+			if err := checkHTML(buf.String()); err != nil {
+				t.Errorf("Rendered HTML is malformed: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "golden", tc.name+".html")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Failed to read golden file, run with -update to create it: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), expected) {
+				t.Errorf("Output differs from %s, run with -update and review the diff:\n%s", golden, buf.String())
+			}
+		})
+	}
+}
+
+// The elements that never have an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// checkHTML is stricter than browsers: every element has to be closed explicitly and in order, void elements can't
+// be closed, and ids have to be unique.
+func checkHTML(s string) error {
+	z := html.NewTokenizer(strings.NewReader(s))
+	var open []string
+	ids := map[string]bool{}
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if !errors.Is(z.Err(), io.EOF) {
+				return z.Err()
+			}
+			if len(open) > 0 {
+				return fmt.Errorf("unclosed elements: %v", open)
+			}
+			return nil
+
+		case html.StartTagToken:
+			tok := z.Token()
+			for _, a := range tok.Attr {
+				if a.Key == "id" {
+					if ids[a.Val] {
+						return fmt.Errorf("duplicate id %q", a.Val)
+					}
+					ids[a.Val] = true
+				}
+			}
+			if !voidElements[tok.Data] {
+				open = append(open, tok.Data)
+			}
+
+		case html.EndTagToken:
+			tok := z.Token()
+			if voidElements[tok.Data] {
+				return fmt.Errorf("</%s> closes a void element", tok.Data)
+			}
+			if len(open) == 0 || open[len(open)-1] != tok.Data {
+				return fmt.Errorf("</%s> doesn't close the innermost open element, open: %v", tok.Data, open)
+			}
+			open = open[:len(open)-1]
+		}
+	}
+}
+
+// TestCheckHTML tests that the well-formedness check catches what it's meant to
+func TestCheckHTML(t *testing.T) {
+	tests := []struct {
+		html  string
+		valid bool
+	}{
+		{`<div><p>Fine<br></p></div>`, true},
+		{`<div><p>Unclosed</div>`, false},
+		{`<div>Never closed`, false},
+		{`<input name="x"></input>`, false},
+		{`<a id="x"></a><a id="x"></a>`, false},
+	}
+
+	for _, tt := range tests {
+		if err := checkHTML(tt.html); (err == nil) != tt.valid {
+			t.Errorf("checkHTML(%q) = %v, expected valid: %v", tt.html, err, tt.valid)
+		}
+	}
+}
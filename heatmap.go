@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// heatmapDay is how many times a timer was done on one calendar day, for a contribution style heatmap.
+type heatmapDay struct {
+	Date  string `json:"date"` // Like 2025-03-09.
+	Count int    `json:"count"`
+}
+
+// The most days that a heatmap can go back, so that a request can't ask for a huge array of zeros.
+const heatmapMaxDays = 3660
+
+// heatmap counts completions by the day that they were done on in loc, for each of the days up to and including the
+// day of now, oldest first. Skips aren't counted. Days are calendar days, so the ones that daylight saving time
+// starts or ends on have 23 or 25 hours.
+func heatmap(completions []completion, now time.Time, days int, loc *time.Location) []heatmapDay {
+	counts := map[string]int{}
+	for _, done := range completions {
+		if done.Kind == CompletionSkipped {
+			continue
+		}
+		counts[done.CompletedAt.In(loc).Format(time.DateOnly)]++
+	}
+	now = now.In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, loc)
+	heatmap := make([]heatmapDay, days)
+	for i := range heatmap {
+		date := start.AddDate(0, 0, i).Format(time.DateOnly)
+		heatmap[i] = heatmapDay{date, counts[date]}
+	}
+	return heatmap
+}
+
+// heatmapHandler serves a timer's heatmap as JSON, over the last ?days in the ?tz time zone, a year in -timezone by
+// default.
+func (s *Server) heatmapHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	days := 365
+	if v := r.URL.Query().Get("days"); v != "" {
+		if days, err = strconv.Atoi(v); err != nil || days < 1 || days > heatmapMaxDays {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'days': must be a number from 1 to %d", heatmapMaxDays)}
+		}
+	}
+	loc := location
+	if v := r.URL.Query().Get("tz"); v != "" {
+		if loc, err = time.LoadLocation(v); err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'tz': %w", err)}
+		}
+	}
+	if _, err := negotiate(w, r, "application/json"); err != nil {
+		return err
+	}
+	// For the 404.
+	if _, err := s.getTimer(r.Context(), id); err != nil {
+		return err
+	}
+	completions, err := s.listCompletions(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, heatmap(completions, clock.Now(), days, loc))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestHeatmap tests that completions are counted on the day they were done on in the time zone asked for, across the
+// start of daylight saving time and the end of a month
+func TestHeatmap(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var completions []completion
+	for _, at := range []string{
+		"2025-02-20T12:00:00Z", // Before the heatmap.
+		"2025-03-01T04:30:00Z", // 11:30 PM on Feb 28 in New York.
+		"2025-03-01T15:00:00Z",
+		"2025-03-09T04:30:00Z", // 11:30 PM on Mar 8, the night that the clocks go forward.
+		"2025-03-09T07:30:00Z", // 3:30 AM on Mar 9, an hour after they did.
+		"2025-03-10T03:30:00Z", // 11:30 PM on Mar 9, which is 23 hours long.
+		"2025-03-10T16:00:00Z",
+	} {
+		done, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		completions = append(completions, completion{CompletedAt: done})
+	}
+	now := time.Date(2025, 3, 10, 20, 0, 0, 0, newYork)
+
+	for _, test := range []struct {
+		loc      *time.Location
+		expected []heatmapDay
+	}{
+		{newYork, []heatmapDay{{"2025-02-27", 0}, {"2025-02-28", 1}, {"2025-03-01", 1}, {"2025-03-02", 0}, {"2025-03-03", 0}, {"2025-03-04", 0},
+			{"2025-03-05", 0}, {"2025-03-06", 0}, {"2025-03-07", 0}, {"2025-03-08", 1}, {"2025-03-09", 2}, {"2025-03-10", 1}}},
+		// It's already Mar 11 in UTC.
+		{time.UTC, []heatmapDay{{"2025-02-28", 0}, {"2025-03-01", 2}, {"2025-03-02", 0}, {"2025-03-03", 0}, {"2025-03-04", 0}, {"2025-03-05", 0},
+			{"2025-03-06", 0}, {"2025-03-07", 0}, {"2025-03-08", 0}, {"2025-03-09", 2}, {"2025-03-10", 2}, {"2025-03-11", 0}}},
+	} {
+		if got := heatmap(completions, now, 12, test.loc); !slices.Equal(got, test.expected) {
+			t.Errorf("In %v, expected %v, got %v", test.loc, test.expected, got)
+		}
+	}
+}
+
+// TestHeatmapHandler tests the heatmap's query parameters and that it comes from the timer's history
+func TestHeatmapHandler(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Floss","frequency":"1 day"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	now := clock.Now()
+	for _, done := range []time.Time{now, now.Add(-time.Minute), now.Add(-48 * time.Hour)} {
+		if err := s.resetTimer(t.Context(), 1, done, CompletionDone, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var days []heatmapDay
+	decodeResponse(t, serveAPI(t, s, "GET", "/timer/1/heatmap?days=3&tz=UTC", ""), &days)
+	today := now.UTC()
+	expected := []heatmapDay{{today.AddDate(0, 0, -2).Format(time.DateOnly), 1}, {today.AddDate(0, 0, -1).Format(time.DateOnly), 0}, {today.Format(time.DateOnly), 2}}
+	// The minute before now might have been yesterday.
+	if now.Add(-time.Minute).UTC().Day() != today.Day() {
+		expected[1].Count, expected[2].Count = 1, 1
+	}
+	if !slices.Equal(days, expected) {
+		t.Errorf("Expected %v, got %v", expected, days)
+	}
+	decodeResponse(t, serveAPI(t, s, "GET", "/timer/1/heatmap", ""), &days)
+	if len(days) != 365 {
+		t.Errorf("Expected a year by default, got %d days", len(days))
+	}
+
+	for _, target := range []string{"/timer/1/heatmap?days=0", "/timer/1/heatmap?days=9999", "/timer/1/heatmap?days=week", "/timer/1/heatmap?tz=Mars/Olympus_Mons"} {
+		if w := serveAPI(t, s, "GET", target, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be a Bad Request, got %v: %s", target, w.Code, w.Body.String())
+		}
+	}
+	if w := serveAPI(t, s, "GET", "/timer/99/heatmap", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing timer to be Not Found, got %v: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Every reset is kept in the completion table as well as moving the timer's lasttime on, so that GET
+// /timer/{id}/history can show each time it was done. Skips are kept there too, though they leave lasttime be, so
+// that stats and streaks can tell a skipped occurrence from a missed one. A timer's completions go when it's purged,
+// like its tags.
+
+// The kinds of completion.
+const (
+	CompletionDone    = "done"
+	CompletionPartial = "partial" // Reset, but only partly done.
+	CompletionSkipped = "skipped" // Let go with POST /timer/{id}/skip rather than done.
+)
+
+// completion is one time that a timer was done.
+type completion struct {
+	id          int64     // For the order of completions at the same time.
+	CompletedAt time.Time `json:"completedAt"`
+	Kind        string    `json:"kind"`           // CompletionDone, CompletionPartial or CompletionSkipped.
+	Note        string    `json:"note,omitempty"` // Optional, as typed when it was done.
+	// When the timer was due at the time, snoozes, skips and all. Zero when it wasn't known, see dueWhenDone.
+	DueAt time.Time `json:"dueAt,omitzero"`
+	// How long after DueAt it was done, negative when it was early. Nanoseconds, and nil without a DueAt or for a
+	// skip. See lateness.go.
+	Late *time.Duration `json:"late,omitempty"`
+}
+
+// historyData is what the history template shows.
+type historyData struct {
+	Id          int64
+	Completions []completion
+	Stats       timerStats
+}
+
+// timesDoneColumn selects how many times a timer was done, fully or partly, for CountDown.TimesDone. It's counted
+// from the history rather than kept, so it can't drift from it.
+const timesDoneColumn = `(SELECT COUNT(*) FROM completion WHERE completion.timer_id = timer.id AND completion.kind != '` + CompletionSkipped + `')`
+
+// LastNote is the most recent of the completions that has a note, nil when none do.
+func (d historyData) LastNote() *completion {
+	for i, c := range d.Completions {
+		if c.Note != "" {
+			return &d.Completions[i]
+		}
+	}
+	return nil
+}
+
+// The most characters in a completion's note.
+const maxNoteLength = 500
+
+// validateNote fails with a 400 for a note that's too long.
+func validateNote(note string) error {
+	if utf8.RuneCountInString(note) > maxNoteLength {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Note is longer than %d characters", maxNoteLength)}
+	}
+	return nil
+}
+
+// parseCompletionKind reads the reset form's kind, done when it's empty. Skipping isn't a reset, so it's a 400.
+func parseCompletionKind(kind string) (string, error) {
+	switch kind {
+	case "":
+		return CompletionDone, nil
+	case CompletionDone, CompletionPartial:
+		return kind, nil
+	}
+	return "", httpError{http.StatusBadRequest, fmt.Errorf("Error parsing kind %q: expected %s or %s", kind, CompletionDone, CompletionPartial)}
+}
+
+// addCompletion records that the timer with id was done, or skipped, at t, when it was due at due.
+func addCompletion(ctx context.Context, db execer, id int64, kind string, t, due time.Time, note string) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO completion (timer_id, kind, completed_at, note, due_at) VALUES (?, ?, ?, ?, ?)`,
+		id, kind, formatLastTime(t.UTC()), note, nullTime(due.UTC()))
+	return err
+}
+
+// listCompletions is every time that the timer with id was done, newest first, in location.
+func (s *Server) listCompletions(ctx context.Context, id int64) ([]completion, error) {
+	return queryCompletions(ctx, s.db, id)
+}
+
+// queryCompletions is listCompletions from db, which can be a transaction.
+func queryCompletions(ctx context.Context, db querier, id int64) ([]completion, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, completed_at, kind, note, due_at FROM completion WHERE timer_id = ? ORDER BY completed_at DESC, id DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	completions := []completion{}
+	for rows.Next() {
+		c, err := scanCompletion(rows)
+		if err != nil {
+			return nil, err
+		}
+		completions = append(completions, c)
+	}
+	return completions, rows.Err()
+}
+
+// scanCompletion reads a row selected as id, completed_at, kind, note and due_at, in location, and then any more
+// columns into extra.
+func scanCompletion(row scanner, extra ...any) (completion, error) {
+	var c completion
+	var at string
+	var due sql.NullString
+	if err := row.Scan(append([]any{&c.id, &at, &c.Kind, &c.Note, &due}, extra...)...); err != nil {
+		return c, err
+	}
+	var err error
+	if c.CompletedAt, err = time.Parse(time.RFC3339, at); err != nil {
+		return c, err
+	}
+	c.CompletedAt = c.CompletedAt.In(location)
+	if due.Valid {
+		if c.DueAt, err = time.Parse(time.RFC3339, due.String); err != nil {
+			return c, err
+		}
+		c.DueAt = c.DueAt.In(location)
+	}
+	c.Late = c.lateness()
+	return c, nil
+}
+
+// historyHandler lists the times that a timer was done, as the timer page's history with its stats or as JSON.
+func (s *Server) historyHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	ct, err := negotiate(w, r, "text/html", "application/json")
+	if err != nil {
+		return err
+	}
+	c, err := s.getTimer(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	completions, err := s.listCompletions(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if ct == "application/json" {
+		return encodeJSON(w, completions)
+	}
+	return s.render(r.Context(), w, "history", historyData{id, completions, computeTimerStats(c, completions, clock.Now())})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHistory tests that every reset is kept in a timer's history, newest first, and that it goes when the timer is
+// purged
+func TestHistory(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	shifted := &offsetClock{base: systemClock{}}
+	clock = shifted
+
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	serve := func(method, target string, form url.Values, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("POST", "/timer", url.Values{"name": {"Water plants"}, "lasttime": {"2025-03-01T09:00"}, "frequency": {"2 days"}}, ""); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	for _, form := range []url.Values{{"at": {"2025-03-05T08:30"}, "note": {" With the plant food "}}, {"at": {"2025-03-03T19:00"}}} {
+		if w := serve("POST", "/timer/1/reset", form, ""); w.Code != http.StatusOK {
+			t.Fatalf("Failed to reset: %v %s", w.Code, w.Body.String())
+		}
+	}
+
+	w := serve("GET", "/timer/1/history", nil, "application/json")
+	var history []completion
+	decodeResponse(t, w, &history)
+	var got []string
+	for _, c := range history {
+		got = append(got, c.CompletedAt.In(location).Format("Jan 2 15:04")+" "+c.Note)
+	}
+	if expected := []string{"Mar 5 08:30 With the plant food", "Mar 3 19:00 ", "Mar 1 09:00 "}; strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	// The timer itself keeps the latest of its times, however they were reset.
+	if c, err := s.getTimer(t.Context(), 1); err != nil || c.LastTime.In(location).Format("Jan 2 15:04") != "Mar 5 08:30" {
+		t.Errorf("Expected the last time to be the latest reset, got %+v, %v", c, err)
+	}
+	if body := serve("GET", "/timer/1/history", nil, "").Body.String(); !strings.Contains(body, "Done 3 times") || !strings.Contains(body, "With the plant food") {
+		t.Errorf("Expected the history as HTML, got %s", body)
+	}
+
+	// A deleted timer's history is kept until it's purged, for restoring it.
+	if w := serve("DELETE", "/timer/1", nil, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status No Content, got %v: %s", w.Code, w.Body.String())
+	}
+	if w := serve("GET", "/timer/1/history", nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a deleted timer's history to be Not Found, got %v", w.Code)
+	}
+	shifted.SetOffset(deletedTimerRetention + time.Hour)
+	if _, err := s.purgeDeletedTimers(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	var left int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM completion`).Scan(&left); err != nil || left != 0 {
+		t.Errorf("Expected the purged timer's history to go with it, got %d, %v", left, err)
+	}
+}
+
+// TestResetNote tests that a note is kept with the reset without a time, that it's escaped, that the latest one is
+// shown at the top of the history, and that one that's too long is refused
+func TestResetNote(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Change the furnace filter","frequency":"3 months"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	reset := func(note string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/timer/1/reset", strings.NewReader(url.Values{"note": {note}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	for _, note := range []string{"Used the last one, <b>buy more</b>", ""} {
+		if w := reset(note); w.Code != http.StatusOK {
+			t.Fatalf("Failed to reset with %q: %v %s", note, w.Code, w.Body.String())
+		}
+	}
+	body := serveAPI(t, s, "GET", "/timer/1/history", "").Body.String()
+	if !strings.Contains(body, `<p class="last-note">`) || !strings.Contains(body, "Used the last one, &lt;b&gt;buy more&lt;/b&gt;") || strings.Contains(body, "<b>") {
+		t.Errorf("Expected the last note at the top, escaped, got %s", body)
+	}
+
+	if w := reset(strings.Repeat("é", maxNoteLength)); w.Code != http.StatusOK {
+		t.Errorf("Expected a note of %d characters to be OK, got %v: %s", maxNoteLength, w.Code, w.Body.String())
+	}
+	if w := reset(strings.Repeat("a", maxNoteLength+1)); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a note that's too long to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+	if c, err := s.getTimer(t.Context(), 1); err != nil || c.Completions != 3 {
+		t.Errorf("Expected the refused reset to leave the timer be, got %+v, %v", c, err)
+	}
+}
+
+// TestCompletionKinds tests that resets are done or partly done, that skips are kept in the history too, and that any
+// other kind is refused
+func TestCompletionKinds(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Floss","frequency":"1 day"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	post := func(target string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	for _, r := range []struct {
+		target string
+		form   url.Values
+	}{
+		{"/timer/1/reset", nil},
+		{"/timer/1/skip", nil},
+		{"/timer/1/reset", url.Values{"kind": {CompletionPartial}}},
+	} {
+		if w := post(r.target, r.form); w.Code != http.StatusOK {
+			t.Fatalf("Failed to POST %s %v: %v %s", r.target, r.form, w.Code, w.Body.String())
+		}
+	}
+	for _, kind := range []string{CompletionSkipped, "half"} {
+		if w := post("/timer/1/reset", url.Values{"kind": {kind}}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a reset of kind %q to be a Bad Request, got %v: %s", kind, w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/timer/1/history", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	var history []completion
+	decodeResponse(t, w, &history)
+	var kinds []string
+	for _, c := range history {
+		kinds = append(kinds, c.Kind)
+	}
+	if expected := []string{CompletionPartial, CompletionSkipped, CompletionDone}; strings.Join(kinds, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, kinds)
+	}
+	// Only the resets count towards the timer's limits.
+	if c, err := s.getTimer(t.Context(), 1); err != nil || c.Completions != 2 {
+		t.Errorf("Expected 2 completions, got %+v, %v", c, err)
+	}
+}
+
+// TestTimesDone tests that a timer's count of times done comes from its history, leaving out skips
+func TestTimesDone(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Floss","frequency":"1 day","lastTime":"2025-03-01T09:00:00Z"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	timesDone := func() int {
+		t.Helper()
+		var c CountDown
+		decodeResponse(t, serveAPI(t, s, "GET", "/api/v1/timers/1", ""), &c)
+		return c.TimesDone
+	}
+	if got := timesDone(); got != 1 {
+		t.Errorf("Expected the last time to count, got %d", got)
+	}
+
+	for _, target := range []string{"/api/v1/timers/1/reset", "/api/v1/timers/1/reset", "/timer/1/skip"} {
+		if w := serveAPI(t, s, "POST", target, ""); w.Code != http.StatusOK {
+			t.Fatalf("Failed to POST %s: %v %s", target, w.Code, w.Body.String())
+		}
+	}
+	if got := timesDone(); got != 3 {
+		t.Errorf("Expected 3 times done, got %d", got)
+	}
+	if body := serveAPI(t, s, "GET", "/timer/1", "").Body.String(); !strings.Contains(body, "Done 3 times") {
+		t.Errorf("Expected the card to show the count, got %s", body)
+	}
+
+	// Taking a completion out of the history takes it off the count.
+	if _, err := db.Exec(`DELETE FROM completion WHERE id = (SELECT MAX(id) FROM completion WHERE kind = ?)`, CompletionDone); err != nil {
+		t.Fatal(err)
+	}
+	if got := timesDone(); got != 2 {
+		t.Errorf("Expected 2 times done, got %d", got)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// GET /history.csv and GET /timer/{id}/history.csv download the raw history as a spreadsheet, skips and all, oldest
+// first, with the times in -timezone. ?from= and ?to= are dates that limit it to the days from one to the other, both
+// included.
+
+// historyCSVHeader is the first row of a history CSV.
+var historyCSVHeader = []string{"name", "completed_at", "kind", "note"}
+
+// parseHistoryRange reads ?from= and ?to= as the start of from and the end of to in location, zero when left out.
+func parseHistoryRange(r *http.Request) (from, to time.Time, err error) {
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		if *p.t, err = time.ParseInLocation(time.DateOnly, v, location); err != nil {
+			return from, to, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query '%s': expected a date like 2025-03-01", p.name)}
+		}
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, httpError{http.StatusBadRequest, fmt.Errorf("The query 'from' has to be no later than 'to'")}
+	}
+	return from, to, nil
+}
+
+// historyCSV writes the completions of the timer with id, or of every timer when it's 0, from the start of from up to
+// to as CSV. Deleted timers are left out of the whole history, like they are from the homepage.
+func (s *Server) historyCSV(w http.ResponseWriter, r *http.Request, id int64, filename string) error {
+	from, to, err := parseHistoryRange(r)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		// For the 404.
+		if _, err := s.getTimer(r.Context(), id); err != nil {
+			return err
+		}
+	}
+
+	query := `SELECT timer.name, completion.completed_at, completion.kind, completion.note FROM completion JOIN timer ON timer.id = completion.timer_id WHERE timer.deleted_at IS NULL`
+	var args []any
+	if id != 0 {
+		query += ` AND completion.timer_id = ?`
+		args = append(args, id)
+	}
+	// completed_at is RFC 3339 in UTC, which sorts as text.
+	if !from.IsZero() {
+		query += ` AND completion.completed_at >= ?`
+		args = append(args, formatLastTime(from.UTC()))
+	}
+	if !to.IsZero() {
+		query += ` AND completion.completed_at < ?`
+		args = append(args, formatLastTime(to.UTC()))
+	}
+	rows, err := s.db.QueryContext(r.Context(), query+` ORDER BY completion.completed_at, completion.id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	out := csv.NewWriter(w)
+	if err := out.Write(historyCSVHeader); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var name, at, kind, note string
+		if err := rows.Scan(&name, &at, &kind, &note); err != nil {
+			return err
+		}
+		done, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return err
+		}
+		if err := out.Write([]string{name, done.In(location).Format(time.RFC3339), kind, note}); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func (s *Server) historyCSVHandler(w http.ResponseWriter, r *http.Request) error {
+	return s.historyCSV(w, r, 0, "countup-history.csv")
+}
+
+func (s *Server) timerHistoryCSVHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	return s.historyCSV(w, r, id, fmt.Sprintf("countup-history-%d.csv", id))
+}
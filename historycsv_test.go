@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHistoryCSV tests downloading the history of one timer and of all of them, between dates and not
+func TestHistoryCSV(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{db: setupTestDB(t)}
+	for _, body := range []string{
+		`{"name":"Take the pills","frequency":"1 day"}`,
+		`{"name":"Oil change, \"synthetic\"","frequency":"3 months"}`,
+		`{"name":"Deleted","frequency":"1 day"}`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", body); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create %s: %v %s", body, w.Code, w.Body.String())
+		}
+	}
+	for _, reset := range []struct {
+		id   int64
+		at   string
+		kind string
+		note string
+	}{
+		{1, "2025-03-02T08:00:00-05:00", CompletionDone, "With breakfast"},
+		{2, "2025-03-01T12:00:00-05:00", CompletionPartial, "At the garage, 40k miles"},
+		{1, "2025-02-28T23:30:00-05:00", CompletionDone, ""}, // Mar 1 in UTC.
+		{1, "2025-03-03T08:00:00-05:00", CompletionDone, ""},
+		{3, "2025-03-02T08:00:00-05:00", CompletionDone, ""},
+	} {
+		at, err := time.Parse(time.RFC3339, reset.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.resetTimer(t.Context(), reset.id, at, reset.kind, reset.note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w := serveAPI(t, s, "DELETE", "/api/v1/timers/3", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to delete: %v %s", w.Code, w.Body.String())
+	}
+
+	get := func(target string) [][]string {
+		t.Helper()
+		w := serveAPI(t, s, "GET", target, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK for %s, got %v: %s", target, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+			t.Errorf("Expected a CSV, got %s", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") {
+			t.Errorf("Expected a download, got %q", cd)
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", target, err)
+		}
+		if len(records) == 0 || !slices.Equal(records[0], historyCSVHeader) {
+			t.Fatalf("Expected a header row, got %q", records)
+		}
+		return records[1:]
+	}
+	check := func(target string, expected ...[]string) {
+		t.Helper()
+		if got := get(target); !slices.EqualFunc(got, expected, slices.Equal) {
+			t.Errorf("%s: expected %q, got %q", target, expected, got)
+		}
+	}
+
+	pills := func(at, note string) []string { return []string{"Take the pills", at, CompletionDone, note} }
+	oil := []string{`Oil change, "synthetic"`, "2025-03-01T12:00:00-05:00", CompletionPartial, "At the garage, 40k miles"}
+	check("/timer/1/history.csv", pills("2025-02-28T23:30:00-05:00", ""), pills("2025-03-02T08:00:00-05:00", "With breakfast"), pills("2025-03-03T08:00:00-05:00", ""))
+	check("/history.csv", pills("2025-02-28T23:30:00-05:00", ""), oil, pills("2025-03-02T08:00:00-05:00", "With breakfast"), pills("2025-03-03T08:00:00-05:00", ""))
+	// The dates are days in -timezone, both included.
+	check("/history.csv?from=2025-03-01&to=2025-03-02", oil, pills("2025-03-02T08:00:00-05:00", "With breakfast"))
+	check("/timer/1/history.csv?to=2025-02-28", pills("2025-02-28T23:30:00-05:00", ""))
+	check("/timer/1/history.csv?from=2025-03-03", pills("2025-03-03T08:00:00-05:00", ""))
+	check("/timer/2/history.csv?from=2026-01-01")
+
+	for _, target := range []string{"/history.csv?from=March", "/timer/1/history.csv?to=2025-02-30", "/history.csv?from=2025-03-02&to=2025-03-01"} {
+		if w := serveAPI(t, s, "GET", target, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be a Bad Request, got %v: %s", target, w.Code, w.Body.String())
+		}
+	}
+	if w := serveAPI(t, s, "GET", "/timer/3/history.csv", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a deleted timer to be Not Found, got %v: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The types of Event, one per kind of change to a timer.
+const (
+	EventCreated  = "created"
+	EventUpdated  = "updated"
+	EventDeleted  = "deleted"
+	EventReset    = "reset"
+	EventRestored = "restored" // Undoing a delete.
+	EventSkipped  = "skipped"  // An occurrence let go without doing it.
+	EventSnoozed  = "snoozed"  // Put off for a while without doing it.
+	EventPaused   = "paused"
+	EventUnpaused = "unpaused"
+)
+
+// Event describes a change to a timer, it's what the hook command receives on stdin.
+type Event struct {
+	Type  string    `json:"type"`
+	Time  time.Time `json:"time"`
+	Timer CountDown `json:"timer"` // Only the Id is set for deleted timers.
+}
+
+// hookRunner runs a user supplied command for every event of the selected types.
+// Events are queued and the command is run for one event at a time, so that a slow command can't pile up processes.
+// A nil *hookRunner is valid and ignores every event.
+type hookRunner struct {
+	command string
+	types   map[string]bool
+	timeout time.Duration
+
+	queue chan Event
+	done  sync.WaitGroup
+
+	delivered delivery // How the command last went, for the instance stats.
+}
+
+// The number of events that can wait for the command before new ones are dropped.
+const hookQueueSize = 100
+
+// newHookRunner starts running command for the comma separated event types.
+func newHookRunner(command, types string, timeout time.Duration) *hookRunner {
+	h := &hookRunner{
+		command: command,
+		types:   map[string]bool{},
+		timeout: timeout,
+		queue:   make(chan Event, hookQueueSize),
+	}
+	for _, t := range strings.Split(types, ",") {
+		h.types[strings.TrimSpace(t)] = true
+	}
+
+	h.done.Add(1)
+	go func() {
+		defer h.done.Done()
+		for e := range h.queue {
+			h.run(e)
+		}
+	}()
+	return h
+}
+
+// wants reports whether events of eventType are sent to the command.
+func (h *hookRunner) wants(eventType string) bool {
+	return h != nil && h.types[eventType]
+}
+
+// send queues an event for the command without ever blocking the caller.
+func (h *hookRunner) send(e Event) {
+	if !h.wants(e.Type) {
+		return
+	}
+	select {
+	case h.queue <- e:
+	default:
+		log.Printf("Hook queue is full, dropping %s event for timer %d\n", e.Type, e.Timer.Id)
+	}
+}
+
+// Close waits for the queued events to be handled and stops the runner.
+func (h *hookRunner) Close() {
+	if h == nil {
+		return
+	}
+	close(h.queue)
+	h.done.Wait()
+}
+
+// run invokes the command through the shell with the event as JSON on stdin.
+// The command gets a minimal environment rather than the server's, which may hold secrets.
+func (h *hookRunner) run(e Event) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("Error encoding %s event for hook: %v\n", e.Type, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", h.command)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "COUNTUP_EVENT=" + e.Type}
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.WaitDelay = time.Second // Don't wait on output from children that outlive the killed shell.
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Hook command failed for %s event of timer %d: %v: %s\n", e.Type, e.Timer.Id, err, out)
+	}
+	h.delivered.record(err)
+}
+
+// emit sends an event about c to the hook command and queues it for CalDAV.
+func (s *Server) emit(eventType string, c CountDown) {
+	s.hooks.send(Event{Type: eventType, Time: clock.Now(), Timer: c})
+	s.caldav.notify(c.Id)
+}
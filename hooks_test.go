@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHookCommand tests that the hook command gets selected events on stdin with a scrubbed environment
+func TestHookCommand(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+
+	t.Setenv("COUNTUP_TEST_SECRET", "hunter2")
+	out := filepath.Join(t.TempDir(), "events")
+	s := &Server{db: db, hooks: newHookRunner("sh testdata/record-stdin.sh "+out, "reset", 5*time.Second)}
+
+	// Reset a timer, which is selected, and create one, which isn't
+	req := httptest.NewRequest("POST", fmt.Sprintf("/timer/%d/reset", testTimers[0].Id), nil)
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+	if w = serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Not hooked"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v", w.Code)
+	}
+
+	// Wait for the queue to drain
+	s.hooks.Close()
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Hook command was not run: %v", err)
+	}
+	event, env, _ := strings.Cut(string(b), "\n")
+
+	var e Event
+	if err := json.Unmarshal([]byte(event), &e); err != nil {
+		t.Fatalf("Hook command did not get JSON on stdin, got %q: %v", event, err)
+	}
+	if e.Type != EventReset || e.Timer.Id != testTimers[0].Id || e.Timer.Name != testTimers[0].Name {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+	if time.Since(e.Timer.LastTime) > time.Minute {
+		t.Errorf("Expected the event to carry the new last time, got %v", e.Timer.LastTime)
+	}
+
+	if strings.Contains(string(b), "Not hooked") {
+		t.Errorf("Expected created events to be filtered out, got %q", b)
+	}
+	if !strings.Contains(env, "COUNTUP_EVENT=reset") {
+		t.Errorf("Expected COUNTUP_EVENT in the environment, got %q", env)
+	}
+	if strings.Contains(env, "hunter2") {
+		t.Errorf("Expected the server's environment to be scrubbed, got %q", env)
+	}
+}
+
+// TestHookCommandFailure tests that a failing hook doesn't affect the request
+func TestHookCommandFailure(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+
+	s := &Server{db: db, hooks: newHookRunner("exit 1", "reset,deleted", 5*time.Second)}
+	defer s.hooks.Close()
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/timer/%d/reset", testTimers[0].Id), nil)
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status OK, got %v", w.Code)
+	}
+}
+
+// TestHookCommandTimeout tests that a hung hook is killed
+func TestHookCommandTimeout(t *testing.T) {
+	h := newHookRunner("sleep 10", EventCreated, 50*time.Millisecond)
+
+	start := time.Now()
+	h.send(Event{Type: EventCreated})
+	h.Close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the hook to be killed after its timeout, took %v", elapsed)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// humanizeDuration describes d in plain english the way the dashboard shows it, e.g. "3 days".
+// It rounds to the largest unit that fits, a month is 30 days and a year is 365 days as in the create form. The
+// homepage's script does the same to keep the times current, see the footer template.
+// Durations just short of a unit count as one of it so that a timer reset a moment ago isn't due in "24 hours".
+func humanizeDuration(d time.Duration) string {
+	return locale(language).humanizeDuration(d)
+}
+
+// humanizeDuration is humanizeDuration in l.
+func (l locale) humanizeDuration(d time.Duration) string {
+	d = d.Abs()
+	for _, u := range durationUnits {
+		if d < u.size-u.size/20 {
+			continue
+		}
+		return l.plural("%d "+u.name, int(math.Round(float64(d)/float64(u.size))))
+	}
+	return l.tr("less than a minute")
+}
+
+// durationUnits are the units that humanizeDuration counts in, largest first.
+var durationUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"year", 365 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"week", 7 * 24 * time.Hour},
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+}
+
+// durationForm is one of durationUnits for the footer's script, in milliseconds and in the page's language with %d
+// for the count.
+type durationForm struct {
+	Size  int64  `json:"size"`
+	One   string `json:"one"`
+	Other string `json:"other"`
+}
+
+// durationForms is durationUnits for the footer's script, which can't pluralize them itself.
+func (l locale) durationForms() []durationForm {
+	var forms []durationForm
+	for _, u := range durationUnits {
+		m := l.pluralForms("%d " + u.name)
+		forms = append(forms, durationForm{u.size.Milliseconds(), m.One, m.Other})
+	}
+	return forms
+}
+
+// humanizeRelative describes t from now in l, e.g. "in 3 days" or "3 days ago", and "just now" within a minute of it.
+func (l locale) humanizeRelative(t, now time.Time) string {
+	switch {
+	case t.Sub(now).Abs() < time.Minute:
+		return l.tr("just now")
+	case t.Before(now):
+		return l.tr("%s ago", l.humanizeDuration(now.Sub(t)))
+	}
+	return l.tr("in %s", l.humanizeDuration(t.Sub(now)))
+}
+
+// humanizeSince is the templates' humanizeRelative for a time that's passed, like when a timer was last done. One in
+// the future, say from another device's clock, is still described the right way around.
+func (l locale) humanizeSince(t time.Time) string {
+	return l.humanizeRelative(t, clock.Now())
+}
+
+// humanizeUntil is the templates' humanizeRelative for a time that's coming, like when a timer's due.
+func (l locale) humanizeUntil(t time.Time) string {
+	return l.humanizeRelative(t, clock.Now())
+}
+
+// Overdue reports whether a repeating timer is past its due time.
+func (c CountDown) Overdue() bool {
+	return c.repeats() && c.NextDue().Before(clock.Now())
+}
+
+// DueStatus is the text equivalent of how the dashboard colors the timer.
+// It's empty for timers without a frequency since they never come due, and says why for finished and paused ones.
+func (c CountDown) DueStatus() string {
+	return c.dueStatus(locale(language), clock.Now())
+}
+
+// How long a timer can go without ever being done before it's stale, in periods of its frequency or, for timers
+// without one, outright.
+const (
+	stalePeriods     = 3
+	staleNoFrequency = 90 * 24 * time.Hour
+)
+
+// Stale reports whether a timer has never been done long after it was created, probably because it was only ever
+// aspirational. Timers without a CreatedAt are never stale since their age isn't known.
+func (c CountDown) Stale() bool {
+	return c.stale(clock.Now())
+}
+
+func (c CountDown) stale(now time.Time) bool {
+	if !c.LastTime.IsZero() || c.CreatedAt.IsZero() || c.Finished() || c.Paused() {
+		return false
+	}
+	age := now.Sub(c.CreatedAt)
+	switch {
+	case c.Cron != "":
+		due := c.CreatedAt
+		for range stalePeriods {
+			due = c.after(due)
+		}
+		return now.After(due)
+	case c.Frequency <= 0:
+		return age > staleNoFrequency
+	}
+	return age > stalePeriods*c.Frequency
+}
+
+// staleStatus suggests removing a stale timer in l, it's empty for the rest.
+func (c CountDown) staleStatus(l locale) string {
+	if !c.Stale() {
+		return ""
+	}
+	return l.tr("Never done in the %s since it was added, consider removing it", l.humanizeDuration(clock.Now().Sub(c.CreatedAt)))
+}
+
+// addedStatus says how long ago the timer was created in l, like "Added 3 months ago". It's empty when that isn't
+// known.
+func (c CountDown) addedStatus(l locale) string {
+	if c.CreatedAt.IsZero() {
+		return ""
+	}
+	return l.tr("Added %s", l.humanizeRelative(c.CreatedAt, clock.Now()))
+}
+
+func (c CountDown) dueStatus(l locale, now time.Time) string {
+	if c.Finished() {
+		return c.finishedStatus(l)
+	}
+	if c.Paused() && c.scheduled() {
+		return c.pausedStatus(l)
+	}
+	if !c.repeats() {
+		return ""
+	}
+	due := c.NextDue()
+	if due.Before(now) {
+		return l.tr("Overdue by %s", l.humanizeDuration(now.Sub(due)))
+	}
+	return l.tr("Do it again in %s", l.humanizeDuration(due.Sub(now)))
+}
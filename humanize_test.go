@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestHumanizeDuration tests the plain english durations
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "less than a minute"},
+		{30 * time.Second, "less than a minute"},
+		{time.Minute, "1 minute"},
+		{-5 * time.Minute, "5 minutes"},
+		{90 * time.Minute, "2 hours"},
+		{22 * time.Hour, "22 hours"},
+		{24*time.Hour - time.Minute, "1 day"},
+		{3 * 24 * time.Hour, "3 days"},
+		{-36 * time.Hour, "2 days"},
+		{7 * 24 * time.Hour, "1 week"},
+		{20 * 24 * time.Hour, "3 weeks"},
+		{29 * 24 * time.Hour, "1 month"},
+		{45 * 24 * time.Hour, "2 months"},
+		{400 * 24 * time.Hour, "1 year"},
+	}
+
+	for _, tt := range tests {
+		if got := humanizeDuration(tt.d); got != tt.expected {
+			t.Errorf("humanizeDuration(%v) = %q, expected %q", tt.d, got, tt.expected)
+		}
+	}
+}
+
+// TestDueStatus tests the text equivalent of a timer's state
+func TestDueStatus(t *testing.T) {
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		c        CountDown
+		expected string
+	}{
+		{"overdue", CountDown{LastTime: now.Add(-10 * 24 * time.Hour), Frequency: 7 * 24 * time.Hour}, "Overdue by 3 days"},
+		{"upcoming", CountDown{LastTime: now.Add(-time.Hour), Frequency: 3 * time.Hour}, "Do it again in 2 hours"},
+		{"no frequency", CountDown{LastTime: now}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.dueStatus("en", now); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestStale tests telling the timers that were never done long after they were created
+func TestStale(t *testing.T) {
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		c        CountDown
+		expected bool
+	}{
+		{"never done, old", CountDown{CreatedAt: now.Add(-22 * day), Frequency: 7 * day}, true},
+		{"never done, recent", CountDown{CreatedAt: now.Add(-20 * day), Frequency: 7 * day}, false},
+		{"done once", CountDown{CreatedAt: now.Add(-100 * day), LastTime: now.Add(-99 * day), Frequency: 7 * day}, false},
+		{"no frequency, old", CountDown{CreatedAt: now.Add(-91 * day)}, true},
+		{"no frequency, recent", CountDown{CreatedAt: now.Add(-89 * day)}, false},
+		{"unknown age", CountDown{Frequency: time.Hour}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.stale(now); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestHumanizeRelative tests the templates' relative times on both sides of now, and within a minute of it
+func TestHumanizeRelative(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	for _, test := range []struct {
+		name     string
+		f        func(time.Time) string
+		t        time.Time
+		expected string
+	}{
+		{"since now", locale("en").humanizeSince, now, "just now"},
+		{"since 59 seconds ago", locale("en").humanizeSince, now.Add(-59 * time.Second), "just now"},
+		{"since 30 seconds from now", locale("en").humanizeSince, now.Add(30 * time.Second), "just now"},
+		{"since a minute ago", locale("en").humanizeSince, now.Add(-time.Minute), "1 minute ago"},
+		{"since 3 hours ago", locale("en").humanizeSince, now.Add(-3 * time.Hour), "3 hours ago"},
+		{"since 2 months ago", locale("en").humanizeSince, now.AddDate(0, -2, 0), "2 months ago"},
+		{"since 2 days from now", locale("en").humanizeSince, now.Add(48 * time.Hour), "in 2 days"},
+		{"until 3 days from now", locale("en").humanizeUntil, now.Add(3 * 24 * time.Hour), "in 3 days"},
+		{"until 2 weeks from now", locale("en").humanizeUntil, now.Add(13 * 24 * time.Hour), "in 2 weeks"},
+		{"until 10 seconds from now", locale("en").humanizeUntil, now.Add(10 * time.Second), "just now"},
+		{"until a day ago", locale("en").humanizeUntil, now.Add(-23 * time.Hour), "1 day ago"},
+	} {
+		if got := test.f(test.t); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The pages' text is in the first language of each request's Accept-Language that there's a catalog for, otherwise in
+// -lang. Each language's catalog in locales/ maps the English text of a message to its translation, and messages
+// with a number in them to a form for one of it and one for any other number. Text that a catalog doesn't have stays
+// English, so a catalog can be partial.
+//
+// The templates get their text through functions in the request's language, see localeFuncs, and the text that Go
+// works out for them comes from methods that take a locale. Text without a request to go by, like hooks' and feeds',
+// is in -lang. Dates and cron schedules are still written the English way, and errors stay in English since API
+// clients read them too.
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// message is the translation of a piece of text, Other unless it's counted and the count is one.
+type message struct {
+	One   string `json:"one"`
+	Other string `json:"other"`
+}
+
+// UnmarshalJSON reads either a string, the text for any count, or the object of the forms for one and the rest.
+func (m *message) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err == nil {
+		*m = message{text, text}
+		return nil
+	}
+	type forms message // Drops UnmarshalJSON.
+	return json.Unmarshal(b, (*forms)(m))
+}
+
+// catalogs are the messages of each language, by the name of its file in locales/ like de.
+var catalogs = func() map[string]map[string]message {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := map[string]map[string]message{}
+	for _, f := range files {
+		b, err := localeFiles.ReadFile("locales/" + f.Name())
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]message
+		if err := json.Unmarshal(b, &catalog); err != nil {
+			panic(fmt.Errorf("Error parsing locales/%s: %w", f.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(f.Name(), path.Ext(f.Name()))] = catalog
+	}
+	return catalogs
+}()
+
+// language is the language that the pages are in without an Accept-Language to go by, see -lang.
+var language = "en"
+
+// locale is the language of a catalog that text is written in, like de.
+type locale string
+
+// catalogLanguage is the language of the catalog for s, a language like de or de-AT, if there is one.
+func catalogLanguage(s string) (string, bool) {
+	lang := strings.ToLower(strings.TrimSpace(s))
+	base, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	return base, catalogs[base] != nil
+}
+
+// parseLanguage reads -lang as the language of a catalog. Anything without one is English, with a warning rather than
+// an error since the pages still work.
+func parseLanguage(s string) string {
+	if lang, ok := catalogLanguage(s); ok {
+		return lang
+	}
+	log.Printf("There's no translation into %q in locales/, using English\n", s)
+	return "en"
+}
+
+// requestLanguage is the language that r's Accept-Language prefers most out of the ones that there are catalogs for,
+// or language when it doesn't accept any of them.
+func requestLanguage(r *http.Request) locale {
+	type accepted struct {
+		lang string
+		q    float64
+	}
+	var langs []accepted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if lang, ok := catalogLanguage(tag); ok && q > 0 {
+			langs = append(langs, accepted{lang, q})
+		}
+	}
+	if len(langs) == 0 {
+		return locale(language)
+	}
+	slices.SortStableFunc(langs, func(a, b accepted) int { return cmp.Compare(b.q, a.q) })
+	return locale(langs[0].lang)
+}
+
+type languageKey struct{}
+
+// withLanguage puts the language of each request in its context, see requestLanguage.
+func withLanguage(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), languageKey{}, requestLanguage(r))))
+	})
+}
+
+// languageOf is the language of the request that ctx is for, or language outside of one.
+func languageOf(ctx context.Context) locale {
+	if l, ok := ctx.Value(languageKey{}).(locale); ok {
+		return l
+	}
+	return locale(language)
+}
+
+// tr is l.tr in language, for text without a request to go by.
+func tr(text string, args ...any) string {
+	return locale(language).tr(text, args...)
+}
+
+// tr is text in l, formatted with args like fmt.Sprintf when there are any.
+func (l locale) tr(text string, args ...any) string {
+	if m, ok := catalogs[string(l)][text]; ok {
+		text = m.Other
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// plural is l.plural in language, for text without a request to go by.
+func plural(text string, n int) string {
+	return locale(language).plural(text, n)
+}
+
+// plural is text, which has %d for n in it, in l in its form for n. The form for one can leave the number out, e.g.
+// "every day".
+func (l locale) plural(text string, n int) string {
+	m := l.pluralForms(text)
+	form := m.Other
+	if n == 1 {
+		form = m.One
+	}
+	return strings.Replace(form, "%d", strconv.Itoa(n), 1)
+}
+
+// pluralForms are the forms of text in l, or in English when its catalog doesn't have them since only English's own
+// catalog knows the English form for one.
+func (l locale) pluralForms(text string) message {
+	if m, ok := catalogs[string(l)][text]; ok {
+		return m
+	}
+	if m, ok := catalogs["en"][text]; ok {
+		return m
+	}
+	return message{text, text}
+}
+
+// localeFuncs are the template functions that write text, in l. The templates are cloned for each language with
+// these, see localized.
+func localeFuncs(l locale) template.FuncMap {
+	return template.FuncMap{
+		"t":              l.tr,
+		"plural":         l.plural,
+		"lang":           func() string { return string(l) },
+		"durationForms":  l.durationForms,
+		"humanize":       l.humanizeDuration,
+		"humanizeSince":  l.humanizeSince,
+		"humanizeUntil":  l.humanizeUntil,
+		"schedule":       func(c CountDown) string { return c.schedule(l) },
+		"interpretation": func(s schedule) string { return s.interpretation(l) },
+		"dueStatus":      func(c CountDown) string { return c.dueStatus(l, clock.Now()) },
+		"staleStatus":    func(c CountDown) string { return c.staleStatus(l) },
+		"addedStatus":    func(c CountDown) string { return c.addedStatus(l) },
+		"snoozeStatus":   func(c CountDown) string { return c.snoozeStatus(l) },
+		"targetStatus":   func(c CountDown) string { return c.targetStatus(l) },
+		"lateStatus":     func(c completion) string { return c.lateStatus(l) },
+	}
+}
+
+// localized is a set of templates in each language. They're cloned from base, which is never executed since
+// html/template can't clone templates that have been.
+type localized struct {
+	base   *template.Template
+	funcs  template.FuncMap // Replaces the base's functions in every language, e.g. static.
+	mu     sync.Mutex
+	byLang map[locale]*template.Template
+}
+
+func newLocalized(base *template.Template, funcs template.FuncMap) *localized {
+	return &localized{base: base, funcs: funcs, byLang: map[locale]*template.Template{}}
+}
+
+// in is the templates in l, cloned the first time that they're needed.
+func (ts *localized) in(l locale) *template.Template {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if t, ok := ts.byLang[l]; ok {
+		return t
+	}
+	t := template.Must(ts.base.Clone()).Funcs(ts.funcs).Funcs(localeFuncs(l))
+	ts.byLang[l] = t
+	return t
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseLanguage tests that a region is dropped and that a language without a catalog is English
+func TestParseLanguage(t *testing.T) {
+	for s, expected := range map[string]string{"en": "en", "de": "de", "de-AT": "de", "DE_de": "de", "xx": "en", "": "en"} {
+		if got := parseLanguage(s); got != expected {
+			t.Errorf("Expected %q to be %q, got %q", s, expected, got)
+		}
+	}
+}
+
+// TestPlural tests the forms for one and for any other number, in English and in German
+func TestPlural(t *testing.T) {
+	defer func(l string) { language = l }(language)
+	for _, test := range []struct {
+		language, text string
+		n              int
+		expected       string
+	}{
+		{"en", "%d day", 1, "1 day"},
+		{"en", "%d day", 2, "2 days"},
+		{"en", "%d day", 0, "0 days"},
+		{"en", "every %d week", 1, "every week"},
+		{"de", "%d day", 1, "1 Tag"},
+		{"de", "%d day", 2, "2 Tage"},
+		{"de", "every %d week", 1, "jede Woche"},
+		{"de", "every %d week", 3, "alle 3 Wochen"},
+		{"de", "%d things", 2, "2 things"},
+	} {
+		language = test.language
+		if got := plural(test.text, test.n); got != test.expected {
+			t.Errorf("%s: expected %q for %d, got %q", test.language, test.expected, test.n, got)
+		}
+	}
+
+	language = "de"
+	if got := humanizeDuration(49 * time.Hour); got != "2 Tage" {
+		t.Errorf("Expected 2 Tage, got %q", got)
+	}
+	if got := tr("Not in any catalog %s", "yet"); got != "Not in any catalog yet" {
+		t.Errorf("Expected a missing message to stay English, got %q", got)
+	}
+}
+
+// TestCatalogs tests that each translation has the same verbs as the text it translates, so that tr and plural never
+// print %!s(MISSING)
+func TestCatalogs(t *testing.T) {
+	verbs := func(s string) int { return strings.Count(s, "%s") + strings.Count(s, "%d") }
+	for lang, catalog := range catalogs {
+		for text, m := range catalog {
+			// The form for one can leave its number out.
+			if verbs(m.Other) != verbs(text) || verbs(m.One) > verbs(text) {
+				t.Errorf("%s: %q has different verbs than %q", lang, m.Other, text)
+			}
+		}
+	}
+}
+
+// TestGermanCard tests that the card is rendered through the catalog
+func TestGermanCard(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l string) { language = l }(language)
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+	language = "de"
+	c := CountDown{Id: 1, Name: "Floss", LastTime: now.Add(-50 * time.Hour), Frequency: 24 * time.Hour, FrequencyValue: 1, FrequencyUnit: UnitDay, TimesDone: 3}
+
+	var b bytes.Buffer
+	if err := (&Server{}).render(t.Context(), &b, "timer", newTimerView(c)); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"Zuletzt passiert", "2 Tage her", "3-mal erledigt", "Wiederholt sich jeden Tag", "1 Tag überfällig", `aria-label="Floss als erledigt markieren"`} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("Expected %q in the card, got %s", expected, b.String())
+		}
+	}
+}
+
+// TestRequestLanguage tests that the most preferred language with a catalog wins, and that -lang is the fallback
+func TestRequestLanguage(t *testing.T) {
+	defer func(l string) { language = l }(language)
+	language = "en"
+	for header, expected := range map[string]locale{
+		"":                          "en",
+		"de":                        "de",
+		"de-AT,de;q=0.9,en;q=0.8":   "de",
+		"fr-FR, de;q=0.5, en;q=0.7": "en",
+		"en;q=0.2, de_CH;q=0.9":     "de",
+		"fr, xx":                    "en",
+		"de;q=0, en":                "en",
+		"de;q=nope":                 "en",
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Language", header)
+		if got := requestLanguage(r); got != expected {
+			t.Errorf("Expected %q to be %q, got %q", header, expected, got)
+		}
+	}
+
+	language = "de"
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "fr")
+	if got := requestLanguage(r); got != "de" {
+		t.Errorf("Expected -lang without a catalog for the request, got %q", got)
+	}
+}
+
+// TestAcceptLanguage tests that pages are in each request's language, whatever -lang is
+func TestAcceptLanguage(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	s := &Server{db: setupTestDB(t)}
+	c := CountDown{Name: "Floss", LastTime: now.Add(-50 * time.Hour), Frequency: 24 * time.Hour, FrequencyValue: 1, FrequencyUnit: UnitDay}
+	if err := s.createTimer(t.Context(), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	for header, expected := range map[string][]string{
+		"de-DE,de;q=0.9": {`<html lang="de">`, "1 Tag überfällig", "Timer anlegen", "Wiederholt sich jeden Tag"},
+		"en-US":          {`<html lang="en">`, "Overdue by 1 day", "Create Timer", "Repeats every day"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", header)
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status OK, got %v: %s", header, w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Header().Get("Vary"), "Accept-Language") {
+			t.Errorf("%s: expected the response to vary by Accept-Language, got %q", header, w.Header().Values("Vary"))
+		}
+		for _, text := range expected {
+			if !strings.Contains(w.Body.String(), text) {
+				t.Errorf("%s: expected %q in the page, got %s", header, text, w.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// icons.txt is the allow-list of Bootstrap Icons that a timer can have, one name per line, for the version that
+// the pages load. Any other name is refused so that what's in the class attribute is always an icon. The checked in
+// list is a selection that suits chores, go generate replaces it with every icon.
+//
+//go:generate sh -c "curl -fsS https://cdn.jsdelivr.net/npm/bootstrap-icons@1.11.3/font/bootstrap-icons.json | grep -o '\"[a-z0-9-]*\":' | tr -d '\":' | LC_ALL=C sort > icons.txt"
+//go:embed icons.txt
+var iconList string
+
+// iconNames is icons.txt, sorted for validateIcon.
+var iconNames = func() []string {
+	names := strings.Fields(iconList)
+	slices.Sort(names)
+	return names
+}()
+
+// validateIcon fails with a 400 for an icon that isn't in icons.txt.
+func validateIcon(c CountDown) error {
+	if c.Icon == "" {
+		return nil
+	}
+	if _, found := slices.BinarySearch(iconNames, c.Icon); !found {
+		return httpError{http.StatusBadRequest, fmt.Errorf("%q isn't an icon, GET /icons lists them", c.Icon)}
+	}
+	return nil
+}
+
+// searchIcons is the icons with q in their name, all of them for an empty q.
+func searchIcons(q string) []string {
+	q = strings.ToLower(strings.TrimSpace(q))
+	icons := []string{}
+	for _, name := range iconNames {
+		if strings.Contains(name, q) {
+			icons = append(icons, name)
+		}
+	}
+	return icons
+}
+
+// iconsHandler lists the icons matching ?q=, as the options of the create form's datalist or as JSON.
+func (s *Server) iconsHandler(w http.ResponseWriter, r *http.Request) error {
+	ct, err := negotiate(w, r, "text/html", "application/json")
+	if err != nil {
+		return err
+	}
+	icons := searchIcons(r.URL.Query().Get("q"))
+	if ct == "application/json" {
+		return encodeJSON(w, icons)
+	}
+	return s.render(r.Context(), w, "iconoptions", icons)
+}
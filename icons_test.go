@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestIcons tests that icons.txt is a list of names that are safe in a class attribute, and that a timer only takes
+// one of them
+func TestIcons(t *testing.T) {
+	name := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	for _, icon := range iconNames {
+		if !name.MatchString(icon) {
+			t.Errorf("Expected icon names like car-front, got %q", icon)
+		}
+	}
+	if len(slices.Compact(slices.Clone(iconNames))) != len(iconNames) {
+		t.Error("Expected no icon to be listed twice")
+	}
+
+	s := &Server{db: setupTestDB(t)}
+	w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Water plants","frequency":"2 days","icon":"droplet"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	for _, icon := range []string{"not-an-icon", "droplet bi-spin", `droplet" onclick="alert(1)`} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Water plants","frequency":"2 days","icon":`+strconv.Quote(icon)+`}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %q to be a Bad Request, got %v: %s", icon, w.Code, w.Body.String())
+		}
+	}
+
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("GET", "/timer/1", nil))
+	if !strings.Contains(w.Body.String(), `<i class="bi bi-droplet" aria-hidden="true"></i>`) {
+		t.Errorf("Expected the icon on the card, got %s", w.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/icons?q=Drop", nil)
+	req.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	var icons []string
+	decodeResponse(t, w, &icons)
+	if expected := []string{"droplet", "droplet-half"}; !slices.Equal(icons, expected) {
+		t.Errorf("Expected %v, got %v", expected, icons)
+	}
+	if got := searchIcons(""); len(got) != len(iconNames) {
+		t.Errorf("Expected every icon without a search, got %d of %d", len(got), len(iconNames))
+	}
+}
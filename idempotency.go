@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// How long an Idempotency-Key is remembered for. Older keys are purged the next time a key is used.
+const idempotencyKeyTTL = 24 * time.Hour
+
+// The longest Idempotency-Key accepted, a UUID is 36 characters.
+const idempotencyKeyMaxLen = 255
+
+// idempotencyKey returns the key that a client sent to make a create request safe to retry.
+// Scripts send the Idempotency-Key header, the create form sends a hidden field that is generated each time it opens.
+func idempotencyKey(r *http.Request) (string, error) {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		key = r.Form.Get("idempotencyKey")
+	}
+	if len(key) > idempotencyKeyMaxLen {
+		return "", httpError{http.StatusBadRequest, fmt.Errorf("Idempotency key is longer than %d characters", idempotencyKeyMaxLen)}
+	}
+	return key, nil
+}
+
+// createTimerOnce is createTimer for a request that may be a retry.
+// The first request with a key creates the timer, later ones with the same key get that timer back in c instead.
+// It reports whether c was created by this call. An empty key always creates.
+func (s *Server) createTimerOnce(ctx context.Context, key string, c *CountDown) (bool, error) {
+	if key == "" {
+		return true, s.createTimer(ctx, c)
+	}
+	if err := validateTimer(*c); err != nil {
+		return false, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_key WHERE created_at < ?`, clock.Now().Add(-idempotencyKeyTTL).Format(time.RFC3339)); err != nil {
+		log.Printf("Error purging idempotency keys: %v\n", err)
+	}
+
+	if found, err := s.idempotentTimer(ctx, key, c); found || err != nil {
+		return false, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := insertTimer(ctx, tx, c); err != nil {
+		return false, err
+	}
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO idempotency_key (key, timer_id, created_at) VALUES (?, ?, ?) ON CONFLICT (key) DO NOTHING`,
+		key, c.Id, clock.Now().Format(time.RFC3339))
+	if err != nil {
+		return false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		// A concurrent request with the same key got there first, return its timer instead.
+		tx.Rollback()
+		if err != nil {
+			return false, err
+		}
+		_, err := s.idempotentTimer(ctx, key, c)
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	s.emit(EventCreated, *c)
+	return true, nil
+}
+
+// idempotentTimer loads the timer that was created for key into c, reporting whether there was one.
+func (s *Server) idempotentTimer(ctx context.Context, key string, c *CountDown) (bool, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `SELECT timer_id FROM idempotency_key WHERE key = ?`, key).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	*c, err = s.getTimer(ctx, id)
+	return true, err
+}
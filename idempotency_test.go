@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCreateTimerIdempotencyKey tests that replaying the create form with the same key creates the timer once
+func TestCreateTimerIdempotencyKey(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+
+	countTimers := func() int {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM timer").Scan(&count); err != nil {
+			t.Fatalf("Failed to count timers: %v", err)
+		}
+		return count
+	}
+
+	submit := func(key string) *httptest.ResponseRecorder {
+		formData := url.Values{
+			"name":           {"Flaky connection"},
+			"lasttime":       {time.Now().Format("2006-01-02T15:04")},
+			"frequencyValue": {"1"},
+			"frequencyUnit":  {"86400000000000"},
+			"idempotencyKey": {key},
+		}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(formData.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	first := submit("form-1")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", first.Code, first.Body.String())
+	}
+
+	// The replay gets the same timer back without creating another one.
+	replay := submit("form-1")
+	if replay.Code != http.StatusOK {
+		t.Errorf("Expected status OK for a replay, got %v", replay.Code)
+	}
+	if replay.Header().Get("Location") != first.Header().Get("Location") {
+		t.Errorf("Expected the replay to point at %q, got %q", first.Header().Get("Location"), replay.Header().Get("Location"))
+	}
+	if replay.Body.String() != first.Body.String() {
+		t.Errorf("Expected the replay to render the same timer")
+	}
+	if n := countTimers(); n != 1 {
+		t.Errorf("Expected 1 timer, got %d", n)
+	}
+
+	// A new key, or none at all, is a new timer.
+	if w := submit("form-2"); w.Code != http.StatusCreated {
+		t.Errorf("Expected status Created for a new key, got %v", w.Code)
+	}
+	submit("")
+	submit("")
+	if n := countTimers(); n != 4 {
+		t.Errorf("Expected 4 timers, got %d", n)
+	}
+
+	if w := submit(strings.Repeat("k", idempotencyKeyMaxLen+1)); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected BadRequest for a long key, got %v", w.Code)
+	}
+}
+
+// TestAPIIdempotencyKey tests the Idempotency-Key header on the JSON API and that old keys are forgotten
+func TestAPIIdempotencyKey(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+
+	create := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/timers", strings.NewReader(`{"name":"Script"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "script-run-1")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	var first, replay CountDown
+	decodeResponse(t, create(), &first)
+	w := create()
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status OK for a replay, got %v", w.Code)
+	}
+	decodeResponse(t, w, &replay)
+	if replay.Id != first.Id {
+		t.Errorf("Expected timer %d back, got %d", first.Id, replay.Id)
+	}
+
+	// Once the key has expired the same key creates again.
+	expired := time.Now().Add(-idempotencyKeyTTL - time.Minute).Format(time.RFC3339)
+	if _, err := db.Exec(`UPDATE idempotency_key SET created_at = ?`, expired); err != nil {
+		t.Fatalf("Failed to age the key: %v", err)
+	}
+	if w := create(); w.Code != http.StatusCreated {
+		t.Errorf("Expected status Created after the key expired, got %v", w.Code)
+	}
+	var keys int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM idempotency_key`).Scan(&keys); err != nil {
+		t.Fatalf("Failed to count keys: %v", err)
+	}
+	if keys != 1 {
+		t.Errorf("Expected the expired key to be purged, got %d keys", keys)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import "time"
+
+// Each completion keeps when the timer was due as it was done, so the history can say how late or early it was, and
+// the stats how late a timer usually is. Timers without a schedule are never due, so theirs have no lateness, and
+// nor do skips, which aren't done at all, nor the completions from before due times were kept.
+
+// Within this of being due, a completion was on time rather than a little late or early.
+const onTimeWithin = time.Minute
+
+// lateness is how long after it was due c was done, nil when that isn't known.
+func (c completion) lateness() *time.Duration {
+	if c.DueAt.IsZero() || c.Kind == CompletionSkipped {
+		return nil
+	}
+	late := c.CompletedAt.Sub(c.DueAt)
+	return &late
+}
+
+// WasLate reports whether c was done later than onTimeWithin after it was due.
+func (c completion) WasLate() bool {
+	return c.Late != nil && *c.Late >= onTimeWithin
+}
+
+// LateStatus says how late or early c was done, like "2 days late". It's empty when that isn't known.
+func (c completion) LateStatus() string {
+	return c.lateStatus(locale(language))
+}
+
+func (c completion) lateStatus(l locale) string {
+	switch {
+	case c.Late == nil:
+		return ""
+	case c.Late.Abs() < onTimeWithin:
+		return l.tr("on time")
+	case *c.Late > 0:
+		return l.tr("%s late", l.humanizeDuration(*c.Late))
+	}
+	return l.tr("%s early", l.humanizeDuration(*c.Late))
+}
+
+// averageLateness is the mean of the known latenesses of completions, early ones taking away from it, and how many
+// of them are known.
+func averageLateness(completions []completion) (average time.Duration, n int) {
+	var total time.Duration
+	for _, c := range completions {
+		if late := c.lateness(); late != nil {
+			total += *late
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return total / time.Duration(n), n
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLateStatus tests how late or early a completion is said to be, and when it isn't known
+func TestLateStatus(t *testing.T) {
+	due := time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		name     string
+		c        completion
+		expected string
+		late     bool
+	}{
+		{"late", completion{CompletedAt: due.Add(49 * time.Hour), DueAt: due}, "2 days late", true},
+		{"early", completion{CompletedAt: due.Add(-3 * time.Hour), DueAt: due}, "3 hours early", false},
+		{"within a minute", completion{CompletedAt: due.Add(30 * time.Second), DueAt: due}, "on time", false},
+		{"no due time", completion{CompletedAt: due}, "", false},
+		{"skipped", completion{CompletedAt: due.Add(time.Hour), DueAt: due, Kind: CompletionSkipped}, "", false},
+	} {
+		test.c.Late = test.c.lateness()
+		if got := test.c.LateStatus(); got != test.expected || test.c.WasLate() != test.late {
+			t.Errorf("%s: expected %q and late %v, got %q and %v", test.name, test.expected, test.late, got, test.c.WasLate())
+		}
+	}
+
+	completions := []completion{
+		{CompletedAt: due.Add(10 * time.Hour), DueAt: due},
+		{CompletedAt: due.Add(-4 * time.Hour), DueAt: due},
+		{CompletedAt: due},
+	}
+	if average, n := averageLateness(completions); average != 3*time.Hour || n != 2 {
+		t.Errorf("Expected 3 hours late on average of 2, got %v of %d", average, n)
+	}
+}
+
+// TestLatenessHistory tests that the history shows the lateness of each reset, and that a timer without a schedule has
+// none
+func TestLatenessHistory(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	day := 24 * time.Hour
+	lastTime := clock.Now().Add(-5 * day).UTC().Format(time.RFC3339)
+	for _, body := range []string{
+		`{"name":"Floss","frequency":"1 day","lastTime":"` + lastTime + `"}`,
+		`{"name":"Haircut","lastTime":"` + lastTime + `"}`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", body); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+		}
+	}
+	// Due 4 days ago.
+	for _, id := range []int64{1, 2} {
+		if err := s.resetTimer(t.Context(), id, clock.Now().Add(-2*day), CompletionDone, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	completions, err := s.listCompletions(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if late := completions[0].Late; late == nil || *late != 2*day {
+		t.Errorf("Expected the reset to be 2 days late, got %v", late)
+	}
+	w := serveAPI(t, s, "GET", "/timer/1/history", "")
+	if body := w.Body.String(); !strings.Contains(body, `<span class="lateness text-danger">2 days late</span>`) {
+		t.Errorf("Expected the history to show 2 days late, got %s", body)
+	}
+
+	if completions, err = s.listCompletions(t.Context(), 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, done := range completions {
+		if done.Late != nil {
+			t.Errorf("Expected no lateness without a schedule, got %v", *done.Late)
+		}
+	}
+}
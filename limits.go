@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Finished reports whether a repeating timer has reached its EndsAt or MaxCompletions, like a course of medication
+// that's over. A finished timer is never due again and can't be reset until its limit is raised.
+func (c CountDown) Finished() bool {
+	if !c.scheduled() {
+		return false
+	}
+	if c.MaxCompletions > 0 && c.Completions >= c.MaxCompletions {
+		return true
+	}
+	if c.EndsAt.IsZero() {
+		return false
+	}
+	end := c.endOfLastDay()
+	return !clock.Now().Before(end) || !c.nextDue().Before(end)
+}
+
+// endOfLastDay is midnight at the end of EndsAt's day in location, it's due for the last time before then.
+func (c CountDown) endOfLastDay() time.Time {
+	y, m, d := c.EndsAt.In(location).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, location)
+}
+
+// finishedStatus says why a finished timer is, in place of when it's due, in l.
+func (c CountDown) finishedStatus(l locale) string {
+	if c.MaxCompletions > 0 && c.Completions >= c.MaxCompletions {
+		return l.plural("Finished, done all %d times", int(c.MaxCompletions))
+	}
+	return l.tr("Finished, its last day was %s", c.EndsAt.In(location).Format("Mon Jan 2, 2006"))
+}
+
+// limitsText is how Schedule describes the limits in l, empty for a timer without any.
+func (c CountDown) limitsText(l locale) string {
+	var s string
+	if c.MaxCompletions > 0 {
+		s += l.plural(", %d times", int(c.MaxCompletions))
+	}
+	if !c.EndsAt.IsZero() {
+		s += l.tr(" until %s", c.EndsAt.In(location).Format("Mon Jan 2, 2006"))
+	}
+	return s
+}
+
+// parseLimits reads the create form's optional last day, like 2025-03-10, and number of times to do the timer.
+func parseLimits(c *CountDown, endsAt, maxCompletions string) error {
+	if endsAt != "" {
+		t, err := time.ParseInLocation("2006-01-02", endsAt, location)
+		if err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing last day %q: expected a date like 2025-03-10", endsAt)}
+		}
+		c.EndsAt = t
+	}
+	if maxCompletions != "" {
+		n, err := strconv.Atoi(maxCompletions)
+		if err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing the number of times to do it: %w", err)}
+		}
+		c.MaxCompletions = n
+	}
+	return nil
+}
+
+// validateLimits fails with a 400 for limits on a timer that doesn't repeat, or a negative number of times.
+func validateLimits(c CountDown) error {
+	switch {
+	case c.MaxCompletions < 0:
+		return httpError{http.StatusBadRequest, fmt.Errorf("A timer is done a positive number of times, not %d", c.MaxCompletions)}
+	case c.EndsAt.IsZero() && c.MaxCompletions == 0:
+		return nil
+	case c.Frequency <= 0 && c.FrequencyValue <= 0 && c.Cron == "":
+		return httpError{http.StatusBadRequest, errors.New("Only a timer that repeats can have a last day or a number of times")}
+	}
+	return nil
+}
+
+// finishedError is the 409 for resetting a finished timer.
+func finishedError(c CountDown) error {
+	return httpError{http.StatusConflict, fmt.Errorf("%q is finished, raise its limit to do it again. %s", c.Name, c.finishedStatus("en"))}
+}
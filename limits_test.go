@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFinished tests when timers with a last day or a number of times are finished, and that finished timers are
+// never due
+func TestFinished(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		c        CountDown
+		finished bool
+		status   string
+	}{
+		{"no limits", CountDown{LastTime: now.Add(-2 * day), Frequency: day}, false, "Overdue by 1 day"},
+		{"times left", CountDown{LastTime: now.Add(-time.Hour), Frequency: day, MaxCompletions: 7, Completions: 6}, false, "Do it again in 1 day"},
+		{"done every time", CountDown{LastTime: now.Add(-time.Hour), Frequency: day, MaxCompletions: 7, Completions: 7}, true, "Finished, done all 7 times"},
+		{"due on the last day", CountDown{LastTime: now.Add(-2 * day), Frequency: day, EndsAt: now.Truncate(day)}, false, "Overdue by 1 day"},
+		{"next due after the last day", CountDown{LastTime: now.Add(-time.Hour), Frequency: day, EndsAt: now.Truncate(day)}, true, "Finished, its last day was Mon Mar 10, 2025"},
+		{"last day gone by", CountDown{LastTime: now.Add(-10 * day), Frequency: day, EndsAt: now.Add(-3 * day)}, true, "Finished, its last day was Fri Mar 7, 2025"},
+		{"one-time timers never finish", CountDown{LastTime: now.Add(-10 * day), MaxCompletions: 1, Completions: 1}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Finished(); got != tt.finished {
+				t.Errorf("Expected finished %v, got %v", tt.finished, got)
+			}
+			if got := tt.c.DueStatus(); got != tt.status {
+				t.Errorf("Expected status %q, got %q", tt.status, got)
+			}
+			if tt.finished && (!tt.c.NextDue().IsZero() || tt.c.Overdue()) {
+				t.Errorf("Expected a finished timer to never be due, got %v", tt.c.NextDue())
+			}
+		})
+	}
+}
+
+// TestResetFinished tests that resets are counted, that a finished timer can't be reset, and that it can be again
+// once its limit is raised
+func TestResetFinished(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	serve := func(method, target, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	form := url.Values{
+		"name": {"Antibiotics"}, "lasttime": {time.Now().Add(-time.Hour).Format("2006-01-02T15:04")},
+		"frequencyValue": {"1"}, "frequencyUnit": {UnitDay}, "maxCompletions": {"2"},
+	}
+	if w := serve("POST", "/timer", "application/x-www-form-urlencoded", form.Encode()); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	var id int64
+	if err := db.QueryRow("SELECT id FROM timer WHERE name = 'Antibiotics'").Scan(&id); err != nil {
+		t.Fatalf("Failed to read the timer: %v", err)
+	}
+	reset := fmt.Sprintf("/timer/%d/reset", id)
+
+	for range 2 {
+		if w := serve("POST", reset, "", ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+	}
+	w := serve("POST", reset, "", "")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected a finished timer's reset to be a Conflict, got %v: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "done all 2 times") {
+		t.Errorf("Expected the reason in the error, got %q", w.Body.String())
+	}
+
+	c, err := s.getTimer(t.Context(), id)
+	if err != nil {
+		t.Fatalf("Failed to read the timer: %v", err)
+	}
+	if c.Completions != 2 || !c.Finished() {
+		t.Fatalf("Expected a finished timer done 2 times, got %d", c.Completions)
+	}
+
+	// Editing doesn't touch the count, whatever the body says.
+	c.MaxCompletions, c.Completions = 3, 0
+	body, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve("PUT", fmt.Sprintf("/api/v1/timers/%d", id), "application/json", string(body)); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if w := serve("POST", reset, "", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected a reset once the limit was raised, got %v: %s", w.Code, w.Body.String())
+	}
+	if w := serve("POST", reset, "", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected the raised limit to be reached, got %v: %s", w.Code, w.Body.String())
+	}
+}
@@ -2,17 +2,22 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
+	"maps"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"database/sql"
-	_ "modernc.org/sqlite"
 )
 
 // When an HTTPError is returned by an ErrorHTTPHandler then a status code comes with it.
@@ -30,20 +35,28 @@ func (h httpError) Error() string       { return h.err.Error() }
 
 // A http.ResponseWriter that buffers everythign written to it until CopyBuffer is called.
 // ErrorHTTPHandler uses this to ensure that users don't see partially written results followed by an error.
+// The status code is held back too so that a handler can pick one before it knows whether it will fail.
 type bufferedRW struct {
-	w   http.ResponseWriter
-	buf bytes.Buffer
+	w    http.ResponseWriter
+	buf  bytes.Buffer
+	code int
 }
 
 func (w *bufferedRW) Header() http.Header         { return w.w.Header() }
 func (w *bufferedRW) Write(b []byte) (int, error) { return w.buf.Write(b) }
-func (w *bufferedRW) WriteHeader(s int)           { w.w.WriteHeader(s) }
-func (w *bufferedRW) CopyBuffer() (int64, error)  { return io.Copy(w.w, &w.buf) }
+func (w *bufferedRW) WriteHeader(s int)           { w.code = s }
+func (w *bufferedRW) CopyBuffer() (int64, error) {
+	if w.code != 0 {
+		w.w.WriteHeader(w.code)
+	}
+	return io.Copy(w.w, &w.buf)
+}
 
 // ErrorHTTPHandler has some behavior that makes it easier to do the right thing in http handlers.
 // 1. Buffer all output to the client until the entire handler has executed and the returned error is known.
 // 2. By default all errors get a 500 HTTP status code.
 // 3. Handlers can return an error of type: HTTPError to provide a different http status code.
+// 4. htmx requests get the error as a toast, retargeted at the page's toasts, since htmx doesn't swap errors in.
 func ErrorHTTPHandler(h func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -56,65 +69,406 @@ func ErrorHTTPHandler(h func(http.ResponseWriter, *http.Request) error) http.Han
 			return
 		}
 
-		sc := http.StatusInternalServerError
-		if httpErr, ok := err.(HTTPError); ok {
-			sc = httpErr.HTTPStatusCode()
-		}
-		if sc >= 500 {
-			log.Printf("%d Response for Request: %s %s, %s\n", sc, r.Method, r.URL, err.Error())
+		code := errorStatusCode(r, err)
+		if r.Header.Get("HX-Request") != "" {
+			errorToastResponse(w, r, code, err)
+			return
 		}
-		http.Error(w, err.Error(), sc)
+		http.Error(w, err.Error(), code)
+	}
+}
+
+// errorToastView is an error as the toast that errorToastResponse renders.
+type errorToastView struct {
+	Method, Path string
+	Code         int
+	Message      string
+}
+
+// Status is the text of the toast's status code, like Not Found.
+func (v errorToastView) Status() string { return http.StatusText(v.Code) }
+
+// errorToastResponse responds to an htmx request that failed with the error as a toast, with the status code that it
+// would have had otherwise. It's the built in template since there's no Server here to have overridden it.
+func errorToastResponse(w http.ResponseWriter, r *http.Request, code int, err error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("HX-Retarget", "#toasts")
+	w.Header().Set("HX-Reswap", "beforeend")
+	w.WriteHeader(code)
+	if err := livePages.in(languageOf(r.Context())).ExecuteTemplate(w, "errortoast", errorToastView{r.Method, r.URL.Path, code, err.Error()}); err != nil {
+		log.Printf("Error rendering the toast for %s %s: %v\n", r.Method, r.URL, err)
+	}
+}
+
+// errorStatusCode maps an error returned by a handler to the HTTP status code to respond with, logging server errors.
+func errorStatusCode(r *http.Request, err error) int {
+	sc := http.StatusInternalServerError
+	if httpErr, ok := err.(HTTPError); ok {
+		sc = httpErr.HTTPStatusCode()
+	}
+	if sc >= 500 {
+		log.Printf("%d Response for Request %s: %s %s, %s\n", sc, requestID(r.Context()), r.Method, r.URL, err.Error())
 	}
+	return sc
 }
 
 type CountDown struct {
-	Id                int64 // Primary Key for the different CountDowns
-	Name, Description string
-	LastTime          time.Time
-	Frequency         time.Duration
+	Id          int64         `json:"id"` // Primary Key for the different CountDowns
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	LastTime    time.Time     `json:"lastTime,omitzero"`
+	Frequency   time.Duration `json:"frequency"` // Nanoseconds, as with time.Duration
+
+	// The frequency as a number of calendar units, see frequency.go. Frequency is their nominal length.
+	// When a unit is given it wins over Frequency, without one the unit is worked out from Frequency.
+	FrequencyValue int64  `json:"frequencyValue,omitempty"`
+	FrequencyUnit  string `json:"frequencyUnit,omitempty"`
+
+	// Optional http(s) link explaining why the timer exists, e.g. the manual that says to do it yearly.
+	ReferenceURL string `json:"referenceUrl,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt,omitzero"` // Set when the timer is inserted, unless it already is.
+	UpdatedAt time.Time `json:"updatedAt,omitzero"` // Set whenever the timer changes, it's ignored in requests.
+
+	// Optional minutes after midnight in -timezone that the timer is due at, rather than a whole number of periods
+	// after it was last done. Only for timers that repeat daily or less often.
+	DueTimeOfDay *int `json:"dueTimeOfDay,omitempty"`
+
+	// Optional cron expression for timers that repeat on particular days, like "0 9 * * mon,thu", see cron.go.
+	// It's instead of a frequency rather than as well as one.
+	Cron string `json:"cron,omitempty"`
+
+	// Moves a due time that falls on a Saturday or Sunday in -timezone to the Monday after.
+	SkipWeekends bool `json:"skipWeekends,omitempty"`
+
+	// Optional start of a fixed schedule: the timer is due on it and every period after it, like rent on the 1st,
+	// however late it was last done. See nextAnchored.
+	Anchor time.Time `json:"anchor,omitzero"`
+
+	// Optional limits after which the timer is Finished: the last day that it's due on in -timezone, and how many
+	// times it's done.
+	EndsAt         time.Time `json:"endsAt,omitzero"`
+	MaxCompletions int       `json:"maxCompletions,omitempty"`
+
+	// How many times the timer has been reset, for MaxCompletions. Only resets change it.
+	Completions int `json:"completions,omitempty"`
+
+	// Set by skipping occurrences to the time the timer is next due instead, until it's reset.
+	SkippedUntil time.Time `json:"skippedUntil,omitzero"`
+
+	// Set by snoozing to the time the timer is due instead, unless it's due later anyway, until it's reset.
+	SnoozedUntil time.Time `json:"snoozedUntil,omitzero"`
+
+	// When the timer was paused, zero unless it is. A paused timer is never due, see Paused.
+	PausedAt time.Time `json:"pausedAt,omitzero"`
+
+	// How long the timer was paused for since LastTime, when resuming it put its schedule back by that long rather than
+	// making it due straight away. LastTime changing clears it. See unpauseTimer.
+	PausedFor time.Duration `json:"pausedFor,omitempty"` // Nanoseconds, as with Frequency
+
+	// Optional time before it's due that the timer is due soon, a tenth of its period when it's zero. See State.
+	Grace time.Duration `json:"grace,omitempty"` // Nanoseconds, as with Frequency
+
+	Priority int `json:"priority,omitempty"` // PriorityLow, PriorityNormal or PriorityHigh.
+
+	// Lowercase names of the groups that the timer is in, like house or car, sorted. See tags.go.
+	Tags []string `json:"tags,omitempty"`
+
+	// Optional color that the dashboard marks the timer with, as #rrggbb.
+	Color string `json:"color,omitempty"`
+
+	// Optional Bootstrap Icon shown with the name, like droplet, one of icons.txt.
+	Icon string `json:"icon,omitempty"`
+
+	// Whether the timer is listed before the others on the homepage, see pin.go.
+	Pinned bool `json:"pinned,omitempty"`
+
+	// Where the timer is in the homepage's own order, 0 at the top. Lists of timers are in this order, so it's
+	// left out of JSON.
+	Position int `json:"-"`
+
+	// How many times the timer has been edited, from 1. An edit sends the version it was made from, see version.go.
+	Version int `json:"version,omitempty"`
+
+	// Optional number of times to do the timer every TargetPeriod, a day, week, month or year. See target.go.
+	TargetCount  int    `json:"targetCount,omitempty"`
+	TargetPeriod string `json:"targetPeriod,omitempty"`
+
+	// How many times the timer was done in the current TargetPeriod, from its history. It's ignored in requests.
+	DoneThisPeriod int `json:"doneThisPeriod,omitempty"`
+
+	// How many times the timer was done, partly or fully, counted from its history. It's ignored in requests.
+	TimesDone int `json:"timesDone,omitempty"`
+
+	// How many times in a row, up to now, the timer was done before it was overdue. It's worked out from the history
+	// whenever the timer is read, so it's ignored in requests. See streak.go.
+	Streak int `json:"streak,omitempty"`
 }
 
+// UnmarshalJSON also takes the frequency as text, like "3 days", see parseFrequency.
+func (c *CountDown) UnmarshalJSON(b []byte) error {
+	type countDown CountDown // Drops the methods so that json.Unmarshal doesn't recurse.
+	v := struct {
+		*countDown
+		Frequency json.RawMessage `json:"frequency"` // Shadows the embedded one.
+	}{countDown: (*countDown)(c)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch {
+	case len(v.Frequency) > 0 && v.Frequency[0] == '"':
+		var text string
+		if err := json.Unmarshal(v.Frequency, &text); err != nil {
+			return err
+		}
+		return parseFrequency(c, text)
+	case len(v.Frequency) > 0:
+		return json.Unmarshal(v.Frequency, &c.Frequency)
+	}
+	return nil
+}
+
+// MarshalJSON adds the computed NextDue to the stored fields.
+func (c CountDown) MarshalJSON() ([]byte, error) {
+	type countDown CountDown // Drops the methods so that json.Marshal doesn't recurse.
+	return json.Marshal(struct {
+		countDown
+		NextDue time.Time `json:"nextDue,omitzero"`
+	}{countDown(c), c.NextDue()})
+}
+
+// NextDue is when the timer should be done again. It's zero for one-time timers, which have no frequency and are
+// never due again, and for finished and paused ones. A timer that has never been done is due from when it was created, or from
+// now when that isn't known. For a cron or anchored schedule that's the first time it falls due after then.
 func (c CountDown) NextDue() time.Time {
-	if c.LastTime.IsZero() {
-		return time.Now().Add(c.Frequency)
+	if !c.repeats() {
+		return time.Time{}
+	}
+	return c.nextDue()
+}
+
+// nextDue is NextDue for a timer that repeats, ignoring whether it's finished.
+func (c CountDown) nextDue() time.Time {
+	due := c.unsnoozedDue()
+	if due.Before(c.SnoozedUntil) {
+		return c.SnoozedUntil
 	}
-	return c.LastTime.Add(c.Frequency)
+	return due
+}
+
+// unsnoozedDue is nextDue without any snooze.
+func (c CountDown) unsnoozedDue() time.Time {
+	due := c.scheduledDue()
+	if due.Before(c.SkippedUntil) {
+		return c.SkippedUntil
+	}
+	return due
+}
+
+// scheduledDue is unsnoozedDue without any skipped occurrences.
+func (c CountDown) scheduledDue() time.Time {
+	if !c.LastTime.IsZero() {
+		return c.skipWeekend(c.after(c.LastTime.Add(c.PausedFor)))
+	}
+	from := c.CreatedAt
+	if from.IsZero() {
+		from = clock.Now()
+	}
+	if c.Cron != "" || !c.Anchor.IsZero() {
+		from = c.after(from)
+	}
+	return c.skipWeekend(from)
+}
+
+// repeats reports whether c has a frequency or a cron schedule and hasn't finished, nor is paused. One-time timers
+// have neither.
+func (c CountDown) repeats() bool {
+	return c.scheduled() && !c.Finished() && !c.Paused()
+}
+
+// scheduled reports whether c has a frequency or a cron schedule, finished or not.
+func (c CountDown) scheduled() bool {
+	return c.Frequency > 0 || c.Cron != ""
+}
+
+// after is when c is next due if it's done at t.
+func (c CountDown) after(t time.Time) time.Time {
+	if !c.Anchor.IsZero() {
+		return c.nextAnchored(t)
+	}
+	if c.Cron == "" {
+		return c.addFrequency(t)
+	}
+	s, err := parseCron(c.Cron)
+	if err != nil {
+		return time.Time{} // Can't happen, the schedule is checked by validateTimer.
+	}
+	return s.next(t)
 }
 
 var (
-	timer = template.Must(template.New("timer").Parse(`
-<div id="timer-{{.Id}}" hx-get="timer/{{.Id}}" hx-swap="outerHTML" hx-trigger="timerUpdate/{{.Id}}" class="timer d-flex text-muted">
+	// Templates check static to leave out htmx and anything that mutates timers, see snapshot.go.
+	// readOnly is for the banner shown with -allow-newer-schema.
+	// apiToken is the -api-token, for htmx to send with its requests.
+	// The functions that write text are English until the templates are cloned for a language, see localized.
+	templateFuncs = func() template.FuncMap {
+		funcs := template.FuncMap{"static": func() bool { return false }, "readOnly": func() bool { return false }, "apiToken": func() string { return "" }, "maxNoteLength": func() int { return maxNoteLength }, "maxTargetCount": func() int { return maxTargetCount }, "local": local}
+		maps.Copy(funcs, localeFuncs("en"))
+		return funcs
+	}()
+
+	timer = template.Must(template.New("timer").Funcs(templateFuncs).Parse(`
+<div id="timer-{{.Id}}" {{if not static}}hx-target="this" hx-swap="outerHTML"{{end}} class="timer timer-{{.State}} d-flex text-muted{{with .UrgencyClass}} {{.}}{{end}}{{if .Stale}} timer-stale opacity-50{{end}}{{if .Finished}} timer-finished{{end}}{{if .Paused}} timer-paused opacity-50{{end}}">
+{{- if and (not static) (not .Finished)}}
 <div class="p-1">
-  <button type="button" class="btn btn-sm btn-success" hx-post="timer/{{.Id}}/reset" hx-swap="none"><i class="bi bi-check-circle"></i></button>
+  <button type="button" class="btn btn-sm btn-success" hx-post="/timer/{{.Id}}/reset" aria-label="{{t "Mark %s as done" .Name}}"><i class="bi bi-check-circle" aria-hidden="true"></i></button>
+  <details class="done-at">
+    <summary class="btn btn-sm btn-outline-success" aria-label="{{t "Mark %s as done earlier" .Name}}" title="{{t "Done earlier"}}"><i class="bi bi-clock-history" aria-hidden="true"></i></summary>
+    <form class="d-flex gap-1 mt-1" hx-post="/timer/{{.Id}}/reset">
+      <input type="datetime-local" name="at" class="form-control form-control-sm" required aria-label="{{t "When %s was done" .Name}}">
+      <input type="text" name="note" class="form-control form-control-sm" maxlength="{{maxNoteLength}}" placeholder="{{t "Note"}}" aria-label="{{t "Note about doing %s (optional)" .Name}}">
+      <button type="submit" class="btn btn-sm btn-success">{{t "Done then"}}</button>
+    </form>
+  </details>
+  <details class="done-note">
+    <summary class="btn btn-sm btn-outline-success" aria-label="{{t "Mark %s as done with a note or as partly done" .Name}}" title="{{t "Done, with a note or partly"}}"><i class="bi bi-chat-left-text" aria-hidden="true"></i></summary>
+    <form class="d-flex gap-1 mt-1" hx-post="/timer/{{.Id}}/reset">
+      <input type="text" name="note" class="form-control form-control-sm" maxlength="{{maxNoteLength}}" placeholder="{{t "Note"}}" aria-label="{{t "Note about doing %s (optional)" .Name}}">
+      <div class="form-check text-nowrap align-self-center">
+        <input type="checkbox" id="timer-{{.Id}}-partial" name="kind" value="partial" class="form-check-input">
+        <label for="timer-{{.Id}}-partial" class="form-check-label">{{t "Partly"}}</label>
+      </div>
+      <button type="submit" class="btn btn-sm btn-success">{{t "Done"}}</button>
+    </form>
+  </details>
 </div>
+{{- end}}
 <div class="border-bottom p-1 flex-grow-1">
-  <strong class="text-dark">{{.Name}}</strong>
+  {{- with .Color}}
+  <i class="timer-color bi bi-circle-fill" style="color: {{.}}" aria-hidden="true"></i>
+  {{- end}}
+  {{- with .Icon}}
+  <i class="bi bi-{{.}}" aria-hidden="true"></i>
+  {{- end}}
+  <strong><a href="{{if static}}timer-{{.Id}}.html{{else}}/timer/{{.Id}}{{end}}" class="text-dark">{{.Name}}</a></strong>
+  {{- if eq .Priority 1}}
+  <span class="priority badge text-bg-danger">{{t "High priority"}}</span>
+  {{- else if eq .Priority -1}}
+  <span class="priority badge text-bg-light">{{t "Low priority"}}</span>
+  {{- end}}
+  {{- if ge .Streak 2}}
+  <span class="streak" title="{{plural "Done on time %d times in a row" .Streak}}">🔥 {{.Streak}}</span>
+  {{- end}}
+  {{- range .Tags}}
+  {{- if static}}
+  <span class="tag badge rounded-pill text-bg-info">{{.}}</span>
+  {{- else}}
+  <a href="/?tag={{.}}" hx-boost="true" class="tag badge rounded-pill text-bg-info text-decoration-none" title="{{t "Show only the timers tagged %s" .}}">{{.}}</a>
+  {{- end}}
+  {{- end}}
+  {{- with .ReferenceURL}}
+  <a href="{{.}}" target="_blank" rel="noopener noreferrer" title="{{t "Why?"}}" aria-label="{{t "Why? (opens in a new tab)"}}"><i class="bi bi-link-45deg" aria-hidden="true"></i></a>
+  {{- end}}
   <p class="my-0">
+      {{- with staleStatus .CountDown}}
+      <em>{{.}}</em><br>
+      {{- end}}
       {{.Description}}
       {{ if .Description }}<br>{{end}}
       {{ if not .LastTime.IsZero -}}
-	Last happened <span data-locale-date-string="{{.LastTime}}"></span>
-	(<span class="last-time" data-format-distance-to-now="{{/* RFC3339 */}}{{.LastTime.Format "2006-01-02T15:04:05Z07:00"}}"></span> ago)
+      {{ if static -}}
+	{{t "Last happened"}} {{(local .LastTime).Format "Mon Jan 2, 2006 3:04 PM"}}
+      {{- else -}}
+	{{/* Written server side, the script only keeps them current and in the browser's language. */}}
+	{{t "Last happened"}} <span data-locale-date-string="{{/* RFC3339 */}}{{.LastTime.Format "2006-01-02T15:04:05Z07:00"}}">{{(local .LastTime).Format "Mon Jan 2, 2006"}}</span>
+	(<span class="last-time" data-format-distance-to-now="{{/* RFC3339 */}}{{.LastTime.Format "2006-01-02T15:04:05Z07:00"}}">{{humanizeSince .LastTime}}</span>)
+      {{- end}}
 	<br>
+      {{- if gt .TimesDone 1}}
+	<span class="times-done">{{plural "Done %d times" .TimesDone}}</span><br>
+      {{- end}}
+      {{- else if not .Stale -}}
+	{{t "Not done yet"}}<br>
+      {{- end}}
+      {{ if .Finished -}}
+	<span class="schedule">{{t "Repeated %s" (schedule .CountDown)}}</span><br>
+	<span class="finished badge text-bg-secondary">{{dueStatus .CountDown}}</span>
+      {{ else if .Paused -}}
+	<span class="schedule">{{t "Repeats %s" (schedule .CountDown)}}</span><br>
+	<span class="paused badge text-bg-secondary">{{dueStatus .CountDown}}</span>
+      {{ else if .TargetCount -}}
+	<span class="target">{{targetStatus .CountDown}}</span>
+	<div class="progress mt-1" role="progressbar" aria-label="{{t "Done %s" (targetStatus .CountDown)}}" aria-valuenow="{{.TargetProgress}}" aria-valuemin="0" aria-valuemax="{{.TargetCount}}">
+	  <div class="progress-bar bg-success" style="width: {{.TargetPercent}}%"></div>
+	</div>
+      {{ else if .Schedule -}}
+	<span class="schedule">{{t "Repeats %s" (schedule .CountDown)}}</span><br>
+      {{ if static -}}
+	{{t "Do it again by"}} {{(local .NextDue).Format "Mon Jan 2, 2006 3:04 PM"}}
+	{{- if .Overdue}} <span class="visually-hidden">({{dueStatus .CountDown}})</span>{{end}}
+      {{- else -}}
+	{{/* Filled in server side so that the state is text even before the script keeps it current. */}}
+	<span data-next-due="{{/* RFC3339 */}}{{.NextDue.Format "2006-01-02T15:04:05Z07:00"}}" title="{{t "Due %s, %s" (humanizeUntil .NextDue) ((local .NextDue).Format "Mon Jan 2, 2006 3:04 PM")}}">{{dueStatus .CountDown}}</span>
+      {{- end}}
+      {{- with snoozeStatus .CountDown}}
+	<br><span class="snoozed">{{.}}</span>
       {{- end}}
-      {{ if .Frequency -}}
-	<span data-next-due="{{/* RFC3339 */}}{{.NextDue.Format "2006-01-02T15:04:05Z07:00"}}"></span>
+      {{- end}}
+      {{- with addedStatus .CountDown}}
+	<br><small class="added text-body-secondary">{{.}}</small>
       {{- end}}
   </p>
 </div>
+{{- if not static}}
 <div class="border-bottom p-1">
-  <button type="button" class="btn btn-sm btn-outline-danger" hx-delete="timer/{{.Id}}" hx-swap="delete" hx-target="#timer-{{.Id}}"><i class="bi bi-trash"></i></button>
+  {{- if .Pinned}}
+  <button type="button" class="btn btn-sm btn-outline-secondary" hx-post="/timer/{{.Id}}/pin" hx-swap="none" aria-label="{{t "Unpin %s" .Name}}" title="{{t "Unpin, list it with the others"}}"><i class="bi bi-pin-angle-fill" aria-hidden="true"></i></button>
+  {{- else}}
+  <button type="button" class="btn btn-sm btn-outline-secondary" hx-post="/timer/{{.Id}}/pin" hx-swap="none" aria-label="{{t "Pin %s" .Name}}" title="{{t "Pin, list it at the top"}}"><i class="bi bi-pin-angle" aria-hidden="true"></i></button>
+  {{- end}}
+  {{- if .Paused}}
+  <button type="button" class="btn btn-sm btn-outline-secondary" hx-post="/timer/{{.Id}}/unpause?shift=1" aria-label="{{t "Resume %s" .Name}}" title="{{t "Resume, due as long after it was last done as it would have been"}}"><i class="bi bi-play-circle" aria-hidden="true"></i></button>
+  {{- else if and .Schedule (not .Finished)}}
+  <button type="button" class="btn btn-sm btn-outline-secondary" hx-post="/timer/{{.Id}}/pause" aria-label="{{t "Pause %s" .Name}}" title="{{t "Pause, it's never due until resumed"}}"><i class="bi bi-pause-circle" aria-hidden="true"></i></button>
+  <button type="button" class="btn btn-sm btn-outline-secondary" hx-post="/timer/{{.Id}}/skip" aria-label="{{t "Skip this time of %s without doing it" .Name}}" title="{{t "Skip this time"}}"><i class="bi bi-skip-forward" aria-hidden="true"></i></button>
+  {{- end}}
+  {{- if .Overdue}}
+  <form class="d-inline-flex gap-1" hx-post="/timer/{{.Id}}/snooze">
+    <select class="form-select form-select-sm w-auto" name="for" aria-label="{{t "How long to snooze %s for" .Name}}">
+      <option value="3h">{{plural "%d hour" 3}}</option>
+      <option value="1d" selected>{{plural "%d day" 1}}</option>
+      <option value="3d">{{plural "%d day" 3}}</option>
+      <option value="1w">{{plural "%d week" 1}}</option>
+    </select>
+    <button type="submit" class="btn btn-sm btn-outline-secondary" aria-label="{{t "Snooze %s" .Name}}" title="{{t "Put it off without doing it"}}"><i class="bi bi-alarm" aria-hidden="true"></i></button>
+  </form>
+  {{- end}}
+  <button type="button" class="btn btn-sm btn-outline-secondary" hx-post="/timer/{{.Id}}/duplicate" hx-target="closest .timer" hx-swap="beforebegin" aria-label="{{t "Duplicate %s" .Name}}"><i class="bi bi-copy" aria-hidden="true"></i></button>
+  <button type="button" class="btn btn-sm btn-outline-danger" hx-delete="/timer/{{.Id}}" hx-swap="delete" hx-target="#timer-{{.Id}}" aria-label="{{t "Delete %s" .Name}}"><i class="bi bi-trash" aria-hidden="true"></i></button>
 </div>
+{{- end}}
 </div>
 `))
 
-	homePage = template.Must(timer.New("homepage").Parse(`
+	// The page layout shared by every full page, split around the page's content.
+	// The header takes the page title.
+	layout = template.Must(timer.New("layout").Parse(`
+{{define "header" -}}
 <!DOCTYPE html>
-<html>
+<html lang="{{lang}}">
   <head>
-    <title>Countdown</title>
+    <title>{{.}}</title>
     <meta name="viewport" content="width=device-width, initial-scale=1">
+    {{- if not static}}
+    {{/* htmx's default response handling plus swapping on 204, so that hx-swap="delete" works with DELETE's No Content. */}}
+    <meta name="htmx-config" content='{"responseHandling": [{"code": "204", "swap": true}, {"code": "[23]..", "swap": true}, {"code": "[45]..", "swap": false, "error": true}, {"code": "...", "swap": false}]}'>
     <script src="https://unpkg.com/htmx.org@2.0.4" integrity="sha384-HGfztofotfshcF7+8n44JQL2oJmowVChPTg48S+jvZoztPfvwD79OC/LTtG6dMp+" crossorigin="anonymous"></script>
+    {{- with apiToken}}
+    <meta name="api-token" content="{{.}}">
+    {{- end}}
+    {{- end}}
     <link href="https://cdn.jsdelivr.net/npm/bootstrap@5.3.2/dist/css/bootstrap.min.css" rel="stylesheet" integrity="sha384-T3c6CoIi6uLrA9TneNEoa7RxnatzjcDSCmG1MXxSR1GAsXEV/Dwwykc2MPK8M2HN" crossorigin="anonymous">
     <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/bootstrap-icons@1.11.3/font/bootstrap-icons.min.css">
     <style>
@@ -133,177 +487,935 @@ var (
       }
     </style>
   </head>
-  <body class="bg-light">
+  {{- /* hx-headers reads the token from its meta tag so that it's only written once and needs no JSON escaping. */}}
+  <body class="bg-light"{{if and apiToken (not static)}} hx-headers='js:{"Authorization": "Bearer " + document.querySelector("meta[name=api-token]").content}'{{end}}>
     <header class="d-flex flex-wrap justify-content-center border-bottom">
-      <h1 href="/" class="display-1 d-flex align-items-center mb-3 mb-md-0 me-md-auto text-dark text-decoration-none">
-        Count up Timer
+      <h1 class="display-1 d-flex align-items-center mb-3 mb-md-0 me-md-auto">
+        <a href="{{if static}}index.html{{else}}/{{end}}" class="text-dark text-decoration-none">Count up Timer</a>
       </h1>
+      {{- if not static}}
+      <nav class="d-flex align-items-center me-3">
+	<a href="/forecast" class="nav-link">{{t "Forecast"}}</a>
+	<a href="/print" class="nav-link">{{t "Print"}}</a>
+	<a href="/dashboards" class="nav-link">{{t "Dashboards"}}</a>
+      </nav>
+      {{- end}}
     </header>
-    <main class="container">
-      <div id="timerList" class="bg-body rounded shadow-sm">
-	{{range .}}
-	  {{template "timer" .}}
-	{{end}}
-      </div>
-    </main>
-
-    <!-- <button type="button" class="btn btn-primary" data-bs-toggle="modal" data-bs-target="#createTimer">New Timer</button> -->
-
-    <!-- Floating action button -->
-    <button type="button" class="btn btn-primary floating-button" data-bs-toggle="modal" data-bs-target="#createTimer">
-      <i class="bi bi-plus fs-4"></i>
-    </button>
-
-    {{/* Form for creating timers */}}
-    <div class="modal fade" id="createTimer" tabindex="-1" aria-labelledby="exampleModalLabel" aria-hidden="true">
-      <form hx-post="/timer" hx-target="#timerList" hx-swap="afterbegin">
-	<div class="modal-dialog">
-	  <div class="modal-content">
-	    <div class="modal-header">
-	      <h5 class="modal-title" id="exampleModalLabel">Create Timer</h5>
-	      <button type="button" class="btn-close" data-bs-dismiss="modal" aria-label="Close"></button>
-	    </div>
-	    <div class="modal-body">
-		  <div class="mb-3">
-		    <label for="timerName" class="form-label">Name</label>
-		    <input type="text" class="form-control" name="name" id="timerName">
-		  </div>
-		  <div class="mb-3">
-		    <label for="timerDescription" class="form-label">Description</label>
-		    <textarea class="form-control" id="timerDescription" name="description"></textarea>
-		  </div>
-		  <div class="mb-3">
-		    <label for="timerLastTime" class="form-label">Last time I did it</label>
-		    <input type="datetime-local" id="timerLastTime" name="lasttime"></input>
-		  </div>
-		  <div class="mb-3">
-                    <label for="timerFrequency" class="form-label">Do it every:</label>
-                    <div class="input-group">
-                      <input type="number" id="timerFrequencyValue" name="frequencyValue" class="form-control" min="1" value="1">
-                      <select id="timerFrequencyUnit" name="frequencyUnit" class="form-select">
-                        <option value="86400000000000">Days</option>
-                        <option value="604800000000000">Weeks</option>
-                        <option value="2592000000000000">Months</option>
-                        <option value="31536000000000000">Years</option>
-                      </select>
-                    </div>
-                  </div>
-	    </div>
-	    <div class="modal-footer">
-	      <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Close</button>
-	      <button type="submit" class="btn btn-primary" data-bs-dismiss="modal">Create</button>
-	    </div>
-	  </div>
-	</div>
-      </form>
-    </div>
+    {{- if and readOnly (not static)}}
+    <div class="alert alert-warning rounded-0" role="alert">{{t "This database was updated by a newer version of Count up Timer. This version can only show it, changes are turned off."}}</div>
+    {{- end}}
+{{end}}
 
+{{define "footer"}}
+    {{- if not static}}
+    {{/* Where the undo of a delete and the errors of htmx's requests go, see ErrorHTTPHandler. */}}
+    <div id="toasts" class="toast-container position-fixed bottom-0 start-0 p-3"></div>
     {{/* Bring in some more javascript now that we've got the styles and DOM loaded. */}}
-    <script src="https://cdn.jsdelivr.net/npm/date-fns@3.6.0/cdn.min.js"></script>
     <script src="https://cdn.jsdelivr.net/npm/bootstrap@5.3.2/dist/js/bootstrap.bundle.min.js" integrity="sha384-C6RzsynM9kWDrMNeT87bh95OGNyZPhcTNXj1NW7RuBCsyN/o0jlpcV8Qyq46cDfL" crossorigin="anonymous"></script>
     <script>
+      {{/* humanizeDuration in humanize.go, for keeping the times that the server wrote current. */}}
+      function humanizeDuration(ms) {
+	ms = Math.abs(ms);
+	for (const {size, one, other} of {{durationForms}}) {
+	  if (ms < size - size / 20) continue;
+	  const n = Math.round(ms / size);
+	  return (n === 1 ? one : other).replace('%d', n);
+	}
+	return {{t "less than a minute"}};
+      }
+
       {{/* Format the times to local locale with a plain english description of how long ago. */}}
       function renderTimer() {
-	document.querySelectorAll('[data-format-distance-to-now]').forEach(e => e.innerText = dateFns.formatDistanceToNow(e.dataset.formatDistanceToNow));
+	document.querySelectorAll('[data-format-distance-to-now]').forEach(e => {
+	    const ago = Date.now() - new Date(e.dataset.formatDistanceToNow);
+	    e.innerText = Math.abs(ago) < 6e4 ? {{t "just now"}} : (ago > 0 ? {{t "%s ago"}} : {{t "in %s"}}).replace('%s', humanizeDuration(ago));
+	});
 	document.querySelectorAll('[data-locale-date-string]').forEach(e => e.innerText = new Date(e.dataset.localeDateString).toLocaleDateString());
 	document.querySelectorAll('[data-next-due]').forEach(e => {
 	    const nextDue = new Date(e.dataset.nextDue);
-	    const isPast = dateFns.isPast(nextDue);
-	    const timeDistance = dateFns.formatDistanceToNow(nextDue);
+	    const isPast = nextDue < Date.now();
+	    const timeDistance = humanizeDuration(nextDue - Date.now());
 
 	    if (isPast) {
-	      e.innerText = ` + "`Overdue by ${timeDistance}!`" + `;
-	      e.closest(".timer").classList.add("bg-danger-subtle");
+	      e.innerText = {{t "Overdue by %s"}}.replace('%s', timeDistance);
+	      const timer = e.closest(".timer");
+	      timer.classList.remove("timer-ok", "timer-due-soon", "border-warning", "border-success");
+	      timer.classList.add("timer-overdue", "bg-danger-subtle", "border-start", "border-4", "border-danger");
 	    } else {
-	      e.innerText = ` + "`Do it again in ${timeDistance}`" + `;
+	      e.innerText = {{t "Do it again in %s"}}.replace('%s', timeDistance);
 	    }
 	});
       }
       renderTimer()
+
+      {{/* An error that says where it goes, like the create form with its errors or a toast, is swapped in rather than dropped. */}}
+      document.addEventListener('htmx:beforeSwap', e => {
+	if (e.detail.xhr.status >= 400 && e.detail.xhr.getResponseHeader('HX-Retarget')) e.detail.shouldSwap = true;
+      });
       document.addEventListener('htmx:afterSwap', renderTimer);
-      document.querySelector("input[type='datetime-local']").value = dateFns.format(new Date(), "yyyy-MM-dd'T'HH:mm");
+      {{/* Now in the browser's time zone, as yyyy-MM-ddTHH:mm. */}}
+      const local = new Date(Date.now() - new Date().getTimezoneOffset() * 6e4).toISOString().slice(0, 16);
+      document.querySelectorAll("input[type='datetime-local']").forEach(e => e.value = local);
+      document.querySelectorAll('.modal').forEach(m => m.addEventListener('show.bs.modal', () => {
+	m.querySelectorAll("input[name='idempotencyKey']").forEach(e => e.value = crypto.randomUUID ? crypto.randomUUID() : Date.now() + '-' + Math.random().toString(36).slice(2)); {{/* randomUUID needs https */}}
+      }));
     </script>
+    {{- end}}
+  </body>
+</html>
+{{end}}
+`))
+
+	// The fields of the create form, see form.go. Its prefix for the ids keeps the labels unique if the form is ever
+	// rendered more than once on a page. It's rendered again with what was typed and the errors beside their fields
+	// when htmx submits it with a mistake, and the modal only closes once the timer is created.
+	timerForm = template.Must(timer.New("timerform").Parse(`
+<form id="{{.Prefix}}-form" hx-post="/timer" hx-target="#timerList" hx-swap="afterbegin"
+  hx-on::after-request="if (event.detail.elt === this && event.detail.successful) bootstrap.Modal.getOrCreateInstance(this.closest('.modal')).hide()">
+  {{/* Set each time the modal opens, so that resubmitting the same form doesn't create the timer twice. */}}
+  <input type="hidden" name="idempotencyKey" value="{{.Value "idempotencyKey"}}">
+  <div class="modal-dialog">
+    <div class="modal-content">
+      <div class="modal-header">
+	<h5 class="modal-title" id="{{.Prefix}}-title">{{t "Create Timer"}}</h5>
+	<button type="button" class="btn-close" data-bs-dismiss="modal" aria-label="{{t "Close"}}"></button>
+      </div>
+      <div class="modal-body">
+	{{- with .Error}}
+	<div class="alert alert-danger" role="alert">{{.}}</div>
+	{{- end}}
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-name" class="form-label">{{t "Name"}}</label>
+	  <input type="text" class="form-control{{if .FieldError "name"}} is-invalid{{end}}" name="name" id="{{.Prefix}}-name" value="{{.Value "name"}}" required>
+	  {{- with .FieldError "name"}}
+	  <div class="invalid-feedback d-block">{{.}}</div>
+	  {{- end}}
+	</div>
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-description" class="form-label">{{t "Description"}}</label>
+	  <textarea class="form-control" id="{{.Prefix}}-description" name="description">{{.Value "description"}}</textarea>
+	</div>
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-tags" class="form-label">{{t "Tags (optional)"}}</label>
+	  <input type="text" class="form-control" id="{{.Prefix}}-tags" name="tags" value="{{.Value "tags"}}" list="{{.Prefix}}-tagList" placeholder="{{t "house, car"}}" aria-describedby="{{.Prefix}}-tagsHelp">
+	  {{/* The tags already in use, to autocomplete. */}}
+	  <datalist id="{{.Prefix}}-tagList" hx-get="/tags" hx-trigger="load, focus from:#{{.Prefix}}-tags"></datalist>
+	  <div id="{{.Prefix}}-tagsHelp" class="form-text">{{t "Separated by commas, to show one group of timers at a time."}}</div>
+	</div>
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-icon" class="form-label">{{t "Icon (optional)"}}</label>
+	  <input type="search" class="form-control" id="{{.Prefix}}-icon" name="icon" value="{{.Value "icon"}}" list="{{.Prefix}}-iconList" placeholder="droplet" autocomplete="off"
+	    hx-get="/icons" hx-trigger="load, input changed delay:300ms" hx-vals="js:{q: event.target.value || ''}" hx-target="#{{.Prefix}}-iconList">
+	  <datalist id="{{.Prefix}}-iconList"></datalist>
+	</div>
+	<div class="mb-3 d-flex align-items-center gap-2">
+	  {{/* A color input always has a value, so it's only sent while enabled. */}}
+	  <div class="form-check form-switch">
+	    <input class="form-check-input" type="checkbox" role="switch" id="{{.Prefix}}-colored"{{if .Value "color"}} checked{{end}}
+	      hx-on:change="this.closest('form').querySelector('[name=color]').disabled = !this.checked">
+	    <label class="form-check-label" for="{{.Prefix}}-colored">{{t "Color"}}</label>
+	  </div>
+	  <input type="color" class="form-control form-control-color" id="{{.Prefix}}-color" name="color" value="{{or (.Value "color") "#1e90ff"}}"{{if not (.Value "color")}} disabled{{end}} aria-label="{{t "Color"}}">
+	</div>
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-referenceUrl" class="form-label">{{t "Why? (link)"}}</label>
+	  <input type="url" class="form-control" id="{{.Prefix}}-referenceUrl" name="referenceUrl" value="{{.Value "referenceUrl"}}" placeholder="https://">
+	</div>
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-priority" class="form-label">{{t "Priority"}}</label>
+	  <select id="{{.Prefix}}-priority" name="priority" class="form-select{{if .FieldError "priority"}} is-invalid{{end}}">
+	    {{- $priority := or (.Value "priority") "normal"}}
+	    <option value="low"{{if eq $priority "low"}} selected{{end}}>{{t "Low"}}</option>
+	    <option value="normal"{{if eq $priority "normal"}} selected{{end}}>{{t "Normal"}}</option>
+	    <option value="high"{{if eq $priority "high"}} selected{{end}}>{{t "High"}}</option>
+	  </select>
+	  {{- with .FieldError "priority"}}
+	  <div class="invalid-feedback d-block">{{.}}</div>
+	  {{- end}}
+	</div>
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-targetCount" class="form-label">{{t "Target (optional)"}}</label>
+	  <div class="input-group">
+	    <input type="number" id="{{.Prefix}}-targetCount" name="targetCount" value="{{.Value "targetCount"}}" class="form-control{{if .FieldError "targetCount"}} is-invalid{{end}}" min="1" max="{{maxTargetCount}}" placeholder="3" aria-describedby="{{.Prefix}}-targetHelp">
+	    <span class="input-group-text">{{t "times a"}}</span>
+	    <select name="targetPeriod" class="form-select" aria-label="{{t "Target period"}}">
+	      {{- $period := or (.Value "targetPeriod") "week"}}
+	      <option value="day"{{if eq $period "day"}} selected{{end}}>{{t "day"}}</option>
+	      <option value="week"{{if eq $period "week"}} selected{{end}}>{{t "week"}}</option>
+	      <option value="month"{{if eq $period "month"}} selected{{end}}>{{t "month"}}</option>
+	      <option value="year"{{if eq $period "year"}} selected{{end}}>{{t "year"}}</option>
+	    </select>
+	  </div>
+	  {{- with .FieldError "targetCount"}}
+	  <div class="invalid-feedback d-block">{{.}}</div>
+	  {{- end}}
+	  <div id="{{.Prefix}}-targetHelp" class="form-text">{{t "Shows how many times it's done so far this week, say, instead of when it's due."}}</div>
+	</div>
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-lasttime" class="form-label">{{t "Last time I did it"}}</label>
+	  <input type="datetime-local" id="{{.Prefix}}-lasttime" name="lasttime" value="{{.Value "lasttime"}}"{{if .FieldError "lasttime"}} class="is-invalid"{{end}}>
+	  {{- with .FieldError "lasttime"}}
+	  <div class="invalid-feedback d-block">{{.}}</div>
+	  {{- end}}
+	</div>
+	<div class="mb-3">
+	  <label for="{{.Prefix}}-dueTime" class="form-label">{{t "Due at (optional)"}}</label>
+	  <input type="time" id="{{.Prefix}}-dueTime" name="dueTime" value="{{.Value "dueTime"}}"{{if .FieldError "dueTime"}} class="is-invalid"{{end}}>
+	  {{- with .FieldError "dueTime"}}
+	  <div class="invalid-feedback d-block">{{.}}</div>
+	  {{- end}}
+	</div>
+	<div class="form-check form-switch mb-3">
+	  <input class="form-check-input" type="checkbox" role="switch" id="{{.Prefix}}-once" name="once" value="1"{{if .Value "once"}} checked{{end}}
+	    hx-on:change="this.closest('form').querySelector('.timer-schedule').hidden = this.checked">
+	  <label class="form-check-label" for="{{.Prefix}}-once">{{t "Just once, it doesn't repeat"}}</label>
+	</div>
+	<fieldset class="mb-3 timer-schedule"{{if .Value "once"}} hidden{{end}}>
+	  <legend class="form-label fs-6">{{t "Do it every:"}}</legend>
+	  {{/* The switch shows one of the two ways to enter the schedule, POST /timer reads the one it names. */}}
+	  <div class="form-check form-switch">
+	    <input class="form-check-input" type="checkbox" role="switch" id="{{.Prefix}}-scheduleMode" name="scheduleMode" value="text"{{if .Value "scheduleMode"}} checked{{end}}
+	      hx-on:change="this.closest('fieldset').querySelectorAll('.schedule-mode').forEach(e => e.hidden = !e.hidden)">
+	    <label class="form-check-label" for="{{.Prefix}}-scheduleMode">{{t "Type it instead"}}</label>
+	  </div>
+	  <div class="input-group schedule-mode"{{if .Value "scheduleMode"}} hidden{{end}}>
+	    <input type="number" id="{{.Prefix}}-frequencyValue" name="frequencyValue" class="form-control{{if .FieldError "frequencyValue"}} is-invalid{{end}}" min="1" value="{{or (.Value "frequencyValue") "1"}}" aria-label="{{t "Number of units"}}">
+	    <select id="{{.Prefix}}-frequencyUnit" name="frequencyUnit" class="form-select" aria-label="{{t "Unit"}}">
+	      {{- $unit := .Value "frequencyUnit"}}
+	      <option value="day"{{if eq $unit "day"}} selected{{end}}>{{t "Days"}}</option>
+	      <option value="week"{{if eq $unit "week"}} selected{{end}}>{{t "Weeks"}}</option>
+	      <option value="month"{{if eq $unit "month"}} selected{{end}}>{{t "Months"}}</option>
+	      <option value="year"{{if eq $unit "year"}} selected{{end}}>{{t "Years"}}</option>
+	      <option value="business day"{{if eq $unit "business day"}} selected{{end}}>{{t "Business days"}}</option>
+	    </select>
+	  </div>
+	  {{- with .FieldError "frequencyValue"}}
+	  <div class="invalid-feedback d-block">{{.}}</div>
+	  {{- end}}
+	  <div class="schedule-mode"{{if not (.Value "scheduleMode")}} hidden{{end}}>
+	    <input type="text" id="{{.Prefix}}-schedule" name="schedule" value="{{.Value "schedule"}}" class="form-control{{if .FieldError "schedule"}} is-invalid{{end}}" placeholder="every 3 weeks, or cron like 0 9 * * mon,thu" aria-label="{{t "Schedule"}}" aria-describedby="{{.Prefix}}-schedulePreview"
+	      hx-get="/schedule/preview" hx-trigger="input changed delay:300ms" hx-target="#{{.Prefix}}-schedulePreview">
+	    <div id="{{.Prefix}}-schedulePreview" aria-live="polite">
+	      {{- with .FieldError "schedule"}}
+	      <div class="invalid-feedback d-block">{{.}}</div>
+	      {{- end -}}
+	    </div>
+	  </div>
+	  <div class="form-check mt-2">
+	    <input class="form-check-input" type="checkbox" id="{{.Prefix}}-skipWeekends" name="skipWeekends" value="1"{{if .Value "skipWeekends"}} checked{{end}}>
+	    <label class="form-check-label" for="{{.Prefix}}-skipWeekends">{{t "Never due on a weekend, move it to Monday"}}</label>
+	  </div>
+	  <div class="mt-2">
+	    <label for="{{.Prefix}}-anchor" class="form-label">{{t "Keep to a fixed schedule from (optional)"}}</label>
+	    <input type="date" id="{{.Prefix}}-anchor" name="anchor" value="{{.Value "anchor"}}"{{if .FieldError "anchor"}} class="is-invalid"{{end}} aria-describedby="{{.Prefix}}-anchorHelp">
+	    {{- with .FieldError "anchor"}}
+	    <div class="invalid-feedback d-block">{{.}}</div>
+	    {{- end}}
+	    <div id="{{.Prefix}}-anchorHelp" class="form-text">{{t "Due on this date and every period after it, however late it was done, like rent on the 1st."}}</div>
+	  </div>
+	  <div class="row g-2 mt-2">
+	    <div class="col">
+	      <label for="{{.Prefix}}-endsAt" class="form-label">{{t "Last day (optional)"}}</label>
+	      <input type="date" id="{{.Prefix}}-endsAt" name="endsAt" value="{{.Value "endsAt"}}" class="form-control{{if .FieldError "endsAt"}} is-invalid{{end}}">
+	      {{- with .FieldError "endsAt"}}
+	      <div class="invalid-feedback d-block">{{.}}</div>
+	      {{- end}}
+	    </div>
+	    <div class="col">
+	      <label for="{{.Prefix}}-maxCompletions" class="form-label">{{t "Times to do it (optional)"}}</label>
+	      <input type="number" id="{{.Prefix}}-maxCompletions" name="maxCompletions" value="{{.Value "maxCompletions"}}" class="form-control{{if .FieldError "maxCompletions"}} is-invalid{{end}}" min="1">
+	      {{- with .FieldError "maxCompletions"}}
+	      <div class="invalid-feedback d-block">{{.}}</div>
+	      {{- end}}
+	    </div>
+	  </div>
+	  <div class="mt-2">
+	    <label for="{{.Prefix}}-grace" class="form-label">{{t "Due soon from (optional)"}}</label>
+	    <input type="text" id="{{.Prefix}}-grace" name="grace" value="{{.Value "grace"}}" class="form-control{{if .FieldError "grace"}} is-invalid{{end}}" placeholder="2 days" aria-describedby="{{.Prefix}}-graceHelp">
+	    {{- with .FieldError "grace"}}
+	    <div class="invalid-feedback d-block">{{.}}</div>
+	    {{- end}}
+	    <div id="{{.Prefix}}-graceHelp" class="form-text">{{t "How long before it's due to show it as due soon, a tenth of how often it repeats when left empty."}}</div>
+	  </div>
+	</fieldset>
+      </div>
+      <div class="modal-footer">
+	<button type="button" class="btn btn-secondary" data-bs-dismiss="modal">{{t "Close"}}</button>
+	<button type="submit" class="btn btn-primary">{{t "Create"}}</button>
+      </div>
+    </div>
+  </div>
+</form>
+`))
+
+	// How the create form's schedule text will be read, see schedulePreviewHandler.
+	schedulePreviewTemplate = template.Must(timer.New("schedulepreview").Parse(`
+{{- with .Error}}
+<div class="invalid-feedback d-block">{{.}}</div>
+{{- else with .Cron}}
+<div class="form-text">= {{.}}</div>
+{{- else with .Schedule.Unit}}
+<div class="form-text">= {{interpretation $.Schedule}}</div>
+{{- end}}
+`))
+
+	// The options of the create form's datalist of tags, see tagsHandler.
+	tagOptions = template.Must(timer.New("tagoptions").Parse(`
+{{- range .}}
+<option value="{{.}}"></option>
+{{- end}}
+`))
+
+	// The options of the create form's datalist of icons, see iconsHandler.
+	iconOptions = template.Must(timer.New("iconoptions").Parse(`
+{{- range .}}
+<option value="{{.}}"></option>
+{{- end}}
+`))
+
+	// The homepage header's counts of timers by when they're due, see summaryHandler. It polls for itself, from when
+	// the page loads too since a cached homepage can be out of date. The red badge is only there when something's
+	// overdue.
+	summaryBadges = template.Must(timer.New("summary").Parse(`
+<div id="summary" class="d-flex gap-1"{{if not static}} hx-get="/summary" hx-trigger="load, every 60s" hx-swap="outerHTML"{{end}}>
+  {{- with .Overdue}}
+  <a href="/?state=overdue" class="badge text-bg-danger text-decoration-none">{{t "%d overdue" .}}</a>
+  {{- end}}
+  <span class="badge {{if .DueToday}}text-bg-warning{{else}}text-bg-light border{{end}}">{{t "%d due today" .DueToday}}</span>
+  <span class="badge text-bg-success">{{t "%d ok" .OK}}</span>
+</div>
+`))
+
+	// Each time a timer was done, on its page, see historyHandler.
+	historyList = template.Must(timer.New("history").Parse(`
+<h2 class="fs-5">{{plural "Done %d times" .Stats.Count}}{{with .Stats.Partial}}{{t ", %d partly" .}}{{end}}{{with .Stats.Skipped}}{{t ", skipped %d" .}}{{end}} <a href="/timer/{{.Id}}/history.csv" class="fs-6 fw-normal" download>{{t "Download as CSV"}}</a></h2>
+{{- with .Stats}}{{if gt .Count 1}}
+<p class="stats">{{t "Every %s on average, %s at the most" (humanize .AverageInterval) (humanize .LongestGap)}}{{if .Checked}}{{t ", on time %d%% of the time" .OnTimePercent}}{{end}}.</p>
+{{- end}}{{if .BestStreak}}
+<p class="streaks">{{plural "On time %d times in a row now" .Streak}}, {{t "%d at best" .BestStreak}}.</p>
+{{- end}}{{end}}
+{{- with .LastNote}}
+<p class="last-note"><i class="bi bi-chat-left-text" aria-hidden="true"></i> {{.Note}} <span class="text-body-secondary">&mdash; {{.CompletedAt.Format "Mon Jan 2"}}</span></p>
+{{- end}}
+<ol id="history-{{.Id}}" class="history list-group list-group-flush bg-body rounded shadow-sm">
+{{- range $done := .Completions}}
+  <li class="list-group-item">{{.CompletedAt.Format "Mon Jan 2, 2006 3:04 PM"}}
+  {{- if eq .Kind "skipped"}} <span class="badge text-bg-secondary">{{t "Skipped"}}</span>{{else if eq .Kind "partial"}} <span class="badge text-bg-warning">{{t "Partly done"}}</span>{{end}}
+  {{- with lateStatus .}} <span class="lateness {{if $done.WasLate}}text-danger{{else}}text-success{{end}}">{{.}}</span>{{end}}{{with .Note}} <span class="text-body-secondary">&mdash; {{.}}</span>{{end}}</li>
+{{- end}}
+</ol>
+<details class="backfill mt-2">
+  <summary>{{t "Add earlier times"}}</summary>
+  {{/* Blank ones are left out, see parseBackfill. */}}
+  <form class="d-flex flex-wrap gap-1 mt-1" hx-post="/timer/{{.Id}}/history" hx-target="closest section">
+    {{- range 3}}
+    <input type="datetime-local" name="completed_at" class="form-control form-control-sm w-auto" aria-label="{{t "When it was done"}}">
+    {{- end}}
+    <button type="submit" class="btn btn-sm btn-outline-success">{{t "Add"}}</button>
+  </form>
+</details>
+`))
+
+	// The homepage's strip of recent completions, see recent.go.
+	recentCompletionsTemplate = template.Must(timer.New("recentcompletions").Parse(`
+<div id="recentCompletions"{{if not static}} hx-get="{{.URL}}" hx-trigger="timersChanged from:body" hx-swap="outerHTML"{{end}}>
+  {{- if not static}}
+  <div class="form-check form-switch my-1">
+    <input class="form-check-input" type="checkbox" role="switch" id="recentSkipped" name="skipped" value="1"{{if .Skipped}} checked{{end}} hx-get="/timers/recent-completions?limit={{.Limit}}" hx-include="this" hx-target="#recentCompletions" hx-swap="outerHTML">
+    <label class="form-check-label" for="recentSkipped">{{t "Show skipped ones too"}}</label>
+  </div>
+  {{- end}}
+  {{- if .Completions}}
+  <ol class="list-group list-group-flush bg-body rounded shadow-sm">
+  {{- range .Completions}}
+    <li class="list-group-item d-flex justify-content-between"><span><a href="/timer/{{.TimerId}}">{{.TimerName}}</a>
+    {{- if eq .Kind "skipped"}} <span class="badge text-bg-secondary">{{t "Skipped"}}</span>{{else if eq .Kind "partial"}} <span class="badge text-bg-warning">{{t "Partly done"}}</span>{{end}}</span>
+    <span class="text-body-secondary" title="{{.CompletedAt.Format "Mon Jan 2, 2006 3:04 PM"}}">{{humanizeSince .CompletedAt}}</span></li>
+  {{- end}}
+  </ol>
+  {{- else}}
+  <p class="text-body-secondary">{{t "Nothing has been done yet."}}</p>
+  {{- end}}
+</div>
+`))
+
+	// Added to the homepage's toasts when a timer is deleted, out of band since the timer itself is swapped away.
+	undoToast = template.Must(timer.New("undotoast").Parse(`
+<div hx-swap-oob="beforeend:#toasts">
+  <div class="toast show" role="status" aria-live="polite" aria-atomic="true">
+    <div class="d-flex align-items-center">
+      <div class="toast-body">{{t "Deleted %s" .Name}}</div>
+      <button type="button" class="btn btn-sm btn-link" hx-post="/timer/{{.Id}}/restore" hx-target="#timerList" hx-swap="afterbegin" hx-on::after-request="if (event.detail.successful) this.closest('.toast').remove()">{{t "Undo"}}</button>
+      <button type="button" class="btn-close me-2" data-bs-dismiss="toast" aria-label="{{t "Close"}}"></button>
+    </div>
+  </div>
+</div>
+`))
+
+	// Added to the page's toasts in place of the response to an htmx request that failed, see ErrorHTTPHandler.
+	errorToast = template.Must(timer.New("errortoast").Parse(`
+<div class="toast show text-bg-danger" role="alert" aria-live="assertive" aria-atomic="true">
+  <div class="d-flex align-items-center">
+    <div class="toast-body">
+      <strong>{{.Code}} {{.Status}}</strong> <code class="text-reset">{{.Method}} {{.Path}}</code><br>
+      {{.Message}}
+    </div>
+    <button type="button" class="btn-close btn-close-white me-2 ms-auto" data-bs-dismiss="toast" aria-label="{{t "Close"}}"></button>
+  </div>
+</div>
+`))
+
+	// The list of timers on the homepage, on its own for htmx requests.
+	timerList = template.Must(timer.New("timerlist").Parse(`
+<div id="timerList" class="bg-body rounded shadow-sm" data-sort="{{.Sort}}">
+{{- with .Tag}}
+<div class="d-flex justify-content-between border-bottom p-1">
+  <span>{{t "Tagged"}} <span class="tag badge rounded-pill text-bg-info">{{.}}</span></span>
+  <a href="/" hx-boost="true">{{t "Show all"}}</a>
+</div>
+{{- end}}
+{{- if .Empty}}
+<div class="timer-empty card text-center border-0 my-4">
+  <div class="card-body">
+    <i class="bi bi-hourglass-split display-4 text-body-secondary" aria-hidden="true"></i>
+    <h2 class="h5 mt-3">{{t "No timers yet"}}</h2>
+    <p class="text-body-secondary">{{t "Create your first one to keep track of how long it's been since you last did something."}}</p>
+    {{- if not static}}
+    <button type="button" class="btn btn-primary" data-bs-toggle="modal" data-bs-target="#createTimer"><i class="bi bi-plus" aria-hidden="true"></i> {{t "Create your first timer"}}</button>
+    {{- end}}
+  </div>
+</div>
+{{- else if and .Query (not .Cards)}}
+<p class="text-body-secondary text-center p-3 mb-0">{{t "No timers match “%s”." .Query}}</p>
+{{- else if and .State (not .Cards)}}
+<div class="timer-empty card text-center border-0 my-4">
+  <div class="card-body">
+    {{- if eq .Filter "overdue"}}
+    <h2 class="h5">{{t "Nothing overdue 🎉"}}</h2>
+    {{- else if eq .Filter "due-soon"}}
+    <h2 class="h5">{{t "Nothing due soon 🎉"}}</h2>
+    {{- else}}
+    <h2 class="h5">{{t "No timers are ok right now"}}</h2>
+    {{- end}}
+    <a href="/?filter=all{{with .Tag}}&amp;tag={{.}}{{end}}" hx-boost="true">{{t "Show all"}}</a>
+  </div>
+</div>
+{{- end}}
+{{- template "timerchunk" .Chunk}}
+</div>
+`))
+
+	// Cards of the homepage's list, with the headings of their sections and what loads the ones after them as it
+	// scrolls into view, see scroll.go.
+	timerChunkTemplate = template.Must(timer.New("timerchunk").Parse(`
+{{- $pinned := .AfterPinned}}
+{{- $bucket := .AfterBucket}}
+{{- range .Cards}}
+{{- if and .Pinned (not $pinned)}}
+{{- $pinned = true}}
+<h2 class="timer-section fs-6 text-body-secondary border-bottom px-1 py-1 mb-0"><i class="bi bi-pin-angle-fill" aria-hidden="true"></i> {{t "Pinned"}}</h2>
+{{- else if and .Bucket (ne .Bucket $bucket)}}
+{{- $pinned = false}}
+{{- $bucket = .Bucket}}
+<h2 class="timer-section fs-6 text-body-secondary border-bottom px-1 py-1 mb-0">{{t (print .Bucket)}}</h2>
+{{- else if and $pinned (not .Pinned)}}
+{{- $pinned = false}}
+<h2 class="timer-section fs-6 text-body-secondary border-bottom px-1 py-1 mb-0">{{t "Everything else"}}</h2>
+{{- end}}
+{{.Card}}
+{{- end}}
+{{- with .More}}
+<div class="timer-more text-center p-2" hx-get="{{.}}" hx-trigger="revealed" hx-swap="outerHTML">
+  <span class="spinner-border spinner-border-sm text-secondary" role="status"><span class="visually-hidden">{{t "Loading more timers"}}</span></span>
+</div>
+{{- end -}}
+`))
+
+	// Shown in place of a card that couldn't be rendered, see renderCards. It only has the timer's id since the rest
+	// of it may be what broke the card.
+	cardError = template.Must(timer.New("carderror").Parse(`
+<div id="timer-{{.}}" class="timer d-flex border-bottom p-1">
+  <div class="alert alert-danger flex-grow-1 my-0 py-1" role="alert">{{t "Timer %d couldn't be shown, the server's log says why." .}}</div>
+</div>
+`))
+
+	homePage = template.Must(timer.New("homepage").Parse(`
+{{- template "header" .Summary.Title}}
+    <main class="container">
+      {{- if not .Vacation.IsZero}}
+      <div class="alert alert-info d-flex align-items-center justify-content-between my-2" role="status">
+	<span>{{t "On vacation since %s, every timer is paused." (.Vacation.Format "Mon Jan 2, 2006")}}</span>
+	{{- if not static}}
+	<button type="button" class="btn btn-sm btn-primary" hx-post="/resume-all" hx-swap="none">{{t "I'm back, resume them"}}</button>
+	{{- end}}
+      </div>
+      {{- end}}
+      {{- if not static}}
+      <div class="d-flex justify-content-between align-items-center my-2">
+	{{- template "summary" .Summary}}
+	<div class="d-flex gap-2">
+	  {{/* Swap in the list searched for, filtered and in the chosen order, see search.go, parseFilter and sort.go. */}}
+	  <input type="search" name="q" value="{{.Query}}" class="form-control form-control-sm w-auto" placeholder="{{t "Search"}}" aria-label="{{t "Search the timers"}}" hx-get="/{{with .Tag}}?tag={{.}}{{end}}" hx-trigger="keyup changed delay:300ms, search" hx-include="[name='sort'], [name='filter']" hx-target="#timerList" hx-swap="outerHTML" hx-push-url="true">
+	  <div class="btn-group btn-group-sm text-nowrap" role="group" aria-label="{{t "Filter the timers"}}">
+	    {{- range .FilterOptions}}
+	    <input type="radio" class="btn-check" name="filter" id="filter-{{.Value}}" value="{{.Value}}" autocomplete="off"{{if eq .Value $.Filter}} checked{{end}} hx-get="/{{with $.Tag}}?tag={{.}}{{end}}" hx-include="[name='q'], [name='sort']" hx-target="#timerList" hx-swap="outerHTML" hx-push-url="true">
+	    <label class="btn btn-outline-secondary" for="filter-{{.Value}}">{{t .Label}}</label>
+	    {{- end}}
+	  </div>
+	  <select name="sort" class="form-select form-select-sm w-auto" aria-label="{{t "Sort the timers"}}" hx-get="/{{with .Tag}}?tag={{.}}{{end}}" hx-include="[name='q'], [name='filter']" hx-target="#timerList" hx-swap="outerHTML" hx-push-url="true">
+	    {{- range .SortOptions}}
+	    <option value="{{.Value}}"{{if eq .Value $.Sort}} selected{{end}}>{{t .Label}}</option>
+	    {{- end}}
+	  </select>
+	  {{- if .Vacation.IsZero}}
+	  <button type="button" class="btn btn-sm btn-outline-secondary text-nowrap" hx-post="/pause-all" hx-swap="none">{{t "Going away? Pause everything"}}</button>
+	  {{- end}}
+	</div>
+      </div>
+      {{- end}}
+      {{- template "timerlist" .}}
+      {{- if not static}}
+      <details class="recent-completions mt-3">
+        <summary>{{t "Done recently"}}</summary>
+        <div id="recentCompletions" hx-get="/timers/recent-completions" hx-trigger="load" hx-swap="outerHTML"></div>
+      </details>
+      {{- end}}
+    </main>
+
+    {{- if not static}}
+    <!-- <button type="button" class="btn btn-primary" data-bs-toggle="modal" data-bs-target="#createTimer">New Timer</button> -->
+
+    <!-- Floating action button -->
+    <button type="button" class="btn btn-primary floating-button" data-bs-toggle="modal" data-bs-target="#createTimer" aria-label="{{t "New timer"}}">
+      <i class="bi bi-plus fs-4" aria-hidden="true"></i>
+    </button>
+
+    {{/* Form for creating timers */}}
+    <div class="modal fade" id="createTimer" tabindex="-1" aria-labelledby="createTimer-title" aria-hidden="true">
+      {{template "timerform" .CreateForm}}
+    </div>
+
+    <script src="https://cdn.jsdelivr.net/npm/sortablejs@1.15.6/Sortable.min.js"></script>
+    <script>
+      {{/* Dragging a timer sends the list's new order, see reorderHandler. htmx.onLoad also sees the list when it's swapped. */}}
+      htmx.onLoad(content => {
+	const list = content.id === 'timerList' ? content : content.querySelector('#timerList');
+	{{/* Any other order isn't the one that dragging saves. */}}
+	if (!list || list.dataset.sort !== 'position') return;
+	Sortable.create(list, {
+	  draggable: '.timer',
+	  filter: 'a, button, input, select',
+	  preventOnFilter: false,
+	  onEnd: e => {
+	    if (e.oldIndex === e.newIndex) return;
+	    const ids = [...list.querySelectorAll('.timer')].map(t => t.id.slice('timer-'.length));
+	    htmx.ajax('POST', '/timers/order', {values: {id: ids}, swap: 'none'});
+	  },
+	});
+      });
+      {{/* Show the number of overdue timers in the tab's title, as of the summary's last poll, see summaryHandler. */}}
+      document.body.addEventListener('pageTitle', e => document.title = e.detail.value);
+    </script>
+    {{- end}}
+
+{{template "footer"}}
+`))
+
+	// A full page for a single timer, the timer fragment is used for htmx requests.
+	timerPage = template.Must(timer.New("timerpage").Parse(`
+{{- template "header" (print .Name " - Countdown")}}
+    {{/* The timer is gone after deleting it here, so go back to the homepage. */}}
+    <main class="container" {{if not static}}hx-on::after-request="if (event.detail.successful && event.detail.requestConfig.verb === 'delete') window.location.href = '/'"{{end}}>
+      {{- /* Loaded again after backfilling its history, see backfillHandler. */}}
+      <div class="bg-body rounded shadow-sm mt-3"{{if not static}} hx-get="/timer/{{.Id}}" hx-trigger="timerBackfilled/{{.Id}} from:body" hx-target="#timer-{{.Id}}" hx-swap="outerHTML"{{end}}>
+	{{template "timer" .}}
+      </div>
+      {{- with .ReferenceURL}}
+      <p class="mt-3 text-break">{{t "Why:"}} <a href="{{.}}" target="_blank" rel="noopener noreferrer">{{.}}</a></p>
+      {{- end}}
+      {{- if not static}}
+      {{/* Bars of the gaps between the last times it was done, red when longer than it's meant to be, see sparklineHandler. */}}
+      <div id="sparkline" class="d-flex align-items-end gap-1 mt-3" style="height: 3rem" role="img" aria-label="{{t "The gaps between the last times it was done"}}"></div>
+      {{/* Loaded again whenever the timer is, so that a reset shows up in it. */}}
+      <section class="mt-3" hx-get="/timer/{{.Id}}/history" hx-trigger="load, timerUpdate/{{.Id}} from:body" aria-live="polite"></section>
+      <script>
+	async function renderSparkline() {
+	  const res = await fetch('/timer/{{.Id}}/sparkline', {headers: {Accept: 'application/json'}});
+	  if (!res.ok) return;
+	  const {intervals, targetHours} = await res.json();
+	  const tallest = Math.max(targetHours || 0, ...intervals);
+	  document.getElementById('sparkline').replaceChildren(...intervals.map(h => {
+	    const bar = document.createElement('div');
+	    bar.className = 'flex-fill rounded-top ' + (targetHours && h > targetHours ? 'bg-danger' : 'bg-success');
+	    bar.style.height = Math.max(2, 100 * h / tallest) + '%';
+	    bar.title = humanizeDuration(h * 36e5);
+	    return bar;
+	  }));
+	}
+	document.addEventListener('DOMContentLoaded', renderSparkline);
+	document.body.addEventListener('timerUpdate/{{.Id}}', renderSparkline);
+	document.body.addEventListener('timerBackfilled/{{.Id}}', renderSparkline);
+      </script>
+      {{- end}}
+    </main>
+{{template "footer"}}
+`))
+
+	// A timer on its own to frame in another page, see widget.go. Without the header and footer it has no htmx or
+	// script, so it's kept current by reloading.
+	widgetPage = template.Must(timer.New("widget").Parse(`<!DOCTYPE html>
+<html lang="{{lang}}">
+  <head>
+    <title>{{.Name}} - Countdown</title>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <meta http-equiv="refresh" content="{{.RefreshSeconds}}">
+    <link href="https://cdn.jsdelivr.net/npm/bootstrap@5.3.2/dist/css/bootstrap.min.css" rel="stylesheet" integrity="sha384-T3c6CoIi6uLrA9TneNEoa7RxnatzjcDSCmG1MXxSR1GAsXEV/Dwwykc2MPK8M2HN" crossorigin="anonymous">
+  </head>
+  <body class="bg-transparent">
+    <div id="timer-{{.Id}}" class="timer timer-{{.State}} p-2{{with .UrgencyClass}} {{.}}{{end}}{{if .Compact}} timer-compact d-flex gap-2 text-nowrap small{{end}}">
+      <strong><a href="/timer/{{.Id}}" target="_blank" rel="noopener" class="text-dark">{{.Name}}</a></strong>
+      {{- if .Compact}}
+      {{- if not .LastTime.IsZero}}
+      <span class="last-time text-body-secondary">{{humanizeSince .LastTime}}</span>
+      {{- end}}
+      {{- with dueStatus .CountDown}}
+      <span class="due-status">{{.}}</span>
+      {{- end}}
+      {{- else}}
+      <p class="my-0">
+	{{- if .LastTime.IsZero}}
+	{{t "Not done yet"}}
+	{{- else}}
+	{{t "Last happened"}} <span class="last-time">{{humanizeSince .LastTime}}</span>
+	{{- end}}
+	{{- with dueStatus .CountDown}}
+	<br><span class="due-status">{{.}}</span>
+	{{- end}}
+      </p>
+      {{- end}}
+    </div>
   </body>
 </html>
 `))
+
+	// What's due in each of the coming weeks.
+	forecastPage = template.Must(timer.New("forecast").Parse(`
+{{- template "header" (print (t "Forecast") " - Countdown")}}
+    <main class="container">
+      {{range .}}
+      <section class="my-3">
+	<h5>{{.Start.Format "Mon Jan 2"}} &ndash; {{(.End.AddDate 0 0 -1).Format "Mon Jan 2"}}</h5>
+	<ul class="list-group">
+	  {{range .Occurrences}}
+	  <li class="list-group-item d-flex{{if .Overdue}} list-group-item-danger{{end}}">
+	    <span class="text-muted me-3">{{.Due.Format "Mon Jan 2"}}</span>
+	    <a href="/timer/{{.TimerId}}" class="text-dark flex-grow-1">{{.Name}}</a>
+	    {{if .Overdue}}<span class="badge text-bg-danger align-self-center">{{t "Overdue"}}</span>{{end}}
+	  </li>
+	  {{else}}
+	  <li class="list-group-item text-muted">{{t "Nothing due"}}</li>
+	  {{end}}
+	</ul>
+      </section>
+      {{end}}
+    </main>
+{{template "footer"}}
+`))
+
+	// The chore sheet, see print.go. Printing leaves out the page's header and the button.
+	printPage = template.Must(timer.New("print").Parse(`
+{{- template "header" (print (t "Chores") " - Countdown")}}
+    <style>
+      .chore-box { display: inline-block; width: 1.2em; height: 1.2em; border: 1px solid #000; vertical-align: middle; }
+      @media print {
+	body > header { display: none !important; }
+	body { background: none !important; }
+      }
+    </style>
+    <main class="container chore-sheet">
+      <div class="d-flex justify-content-between align-items-baseline my-3">
+	<h2 class="h4 mb-0">{{with .Tag}}{{t "Chores tagged %s" .}}{{else}}{{t "Chores"}}{{end}}</h2>
+	<small class="text-body-secondary">{{t "As of %s" ((local .Printed).Format "Mon Jan 2, 2006 3:04 PM")}}</small>
+      </div>
+      <button type="button" class="btn btn-primary d-print-none mb-3" onclick="window.print()"><i class="bi bi-printer" aria-hidden="true"></i> {{t "Print"}}</button>
+      {{- if .Sections}}
+      <table class="table table-sm table-bordered align-middle">
+	<thead>
+	  <tr>
+	    <th scope="col" class="text-center" style="width: 3em">{{t "Done"}}</th>
+	    <th scope="col">{{t "Name"}}</th>
+	    <th scope="col">{{t "Last done"}}</th>
+	    <th scope="col">{{t "Due"}}</th>
+	  </tr>
+	</thead>
+	{{- range .Sections}}
+	<tbody>
+	  <tr class="table-light"><th scope="rowgroup" colspan="4">{{t (print .Bucket)}}</th></tr>
+	  {{- range .Timers}}
+	  <tr>
+	    <td class="text-center"><span class="chore-box"></span></td>
+	    <td>{{.Name}}</td>
+	    <td>{{if .LastTime.IsZero}}{{t "Never"}}{{else}}{{(local .LastTime).Format "Mon Jan 2, 2006"}}{{end}}</td>
+	    <td>{{if .Schedule}}{{(local .NextDue).Format "Mon Jan 2, 2006"}}{{else}}&ndash;{{end}}</td>
+	  </tr>
+	  {{- end}}
+	</tbody>
+	{{- end}}
+      </table>
+      {{- else}}
+      <p class="text-body-secondary">{{t "Nothing to do."}}</p>
+      {{- end}}
+    </main>
+{{template "footer"}}
+`))
+
+	// The page for managing public dashboards, see dashboard.go. The list of them is loaded separately since it needs
+	// the API token.
+	dashboardsPageTemplate = template.Must(timer.New("dashboards").Parse(`
+{{- template "header" (print (t "Dashboards") " - Countdown")}}
+    <main class="container">
+      <h2 class="h4 mt-3">{{t "Public dashboards"}}</h2>
+      <p class="text-body-secondary">{{t "Anyone with a dashboard's link can see its timers, and only those, until it's revoked."}}</p>
+      <div id="dashboards" hx-get="/dashboards/list" hx-trigger="load" hx-swap="outerHTML"></div>
+      <form class="my-4" hx-post="/dashboards" hx-target="#dashboards" hx-swap="outerHTML" hx-on::after-request="if (event.detail.successful) this.reset()">
+	<h3 class="h5">{{t "New dashboard"}}</h3>
+	<div class="mb-3">
+	  <label for="dashboard-name" class="form-label">{{t "Name"}}</label>
+	  <input type="text" class="form-control" id="dashboard-name" name="name" required>
+	</div>
+	<div class="mb-3">
+	  <label for="dashboard-tag" class="form-label">{{t "The timers tagged"}}</label>
+	  <select id="dashboard-tag" name="tag" class="form-select">
+	    <option value="">{{t "None, the ones chosen below"}}</option>
+	    {{- range .Tags}}
+	    <option value="{{.}}">{{.}}</option>
+	    {{- end}}
+	  </select>
+	</div>
+	<fieldset class="mb-3">
+	  <legend class="form-label fs-6">{{t "Or these timers"}}</legend>
+	  {{- range .Timers}}
+	  <div class="form-check">
+	    <input class="form-check-input" type="checkbox" name="timerId" value="{{.Id}}" id="dashboard-timer-{{.Id}}">
+	    <label class="form-check-label" for="dashboard-timer-{{.Id}}">{{.Name}}</label>
+	  </div>
+	  {{- end}}
+	</fieldset>
+	<button type="submit" class="btn btn-primary">{{t "Create dashboard"}}</button>
+      </form>
+    </main>
+{{template "footer"}}
+`))
+
+	// The dashboards on their page, each with its link and a button to revoke it.
+	dashboardList = template.Must(timer.New("dashboardlist").Parse(`
+<ul id="dashboards" class="list-group">
+  {{- range .}}
+  <li class="list-group-item d-flex align-items-center gap-2">
+    <div class="flex-grow-1">
+      <a href="{{.URL}}" target="_blank" rel="noopener">{{.Name}}</a>
+      <small class="text-body-secondary">{{with .Tag}}{{t "Timers tagged %s" .}}{{else}}{{plural "%d timer" (len .TimerIds)}}{{end}}</small>
+    </div>
+    <button type="button" class="btn btn-sm btn-outline-danger" hx-delete="/api/v1/dashboards/{{.Id}}" hx-target="closest li" hx-swap="delete" hx-confirm="{{t "Revoke %s? Its link stops working." .Name}}">{{t "Revoke"}}</button>
+  </li>
+  {{- else}}
+  <li class="list-group-item text-body-secondary">{{t "No dashboards yet."}}</li>
+  {{- end}}
+</ul>
+`))
+
+	// baseTemplates is a copy that is never executed, so that each language and -template-dir overrides can clone it.
+	// It has to stay after every other template is parsed. See localized and overrides.go.
+	baseTemplates = template.Must(homePage.Clone())
+
+	// livePages are the pages above in each language, and staticPages them with static returning true.
+	livePages   = newLocalized(baseTemplates, nil)
+	staticPages = newLocalized(baseTemplates, template.FuncMap{"static": func() bool { return true }})
 )
 
 type Server struct {
-	db *sql.DB
+	db       *sql.DB
+	hooks    *hookRunner   // nil when no -hook-command is set.
+	caldav   *caldavSyncer // nil when no -caldav-url is set.
+	apiToken string        // Required to change timers when set, see auth.go.
+
+	// The templates with any -template-dir overrides, nil for the ones above.
+	templates, staticTemplates *localized
+	templateSource             string // Which templates are overridden from where, for the environment report.
+
+	startedAt time.Time // Pages can change with a new binary, see notModified.
+	readOnly  bool      // Set for a database with a newer schema, see -allow-newer-schema.
+
+	timeOffset *offsetClock // The clock that POST /admin/time-offset shifts, nil unless -demo is set.
+
+	outboundProxy *url.URL // -outbound-proxy, nil to use the environment's, see outboundClient.
+
+	serveInstanceStats bool // -instance-stats, see stats.go.
 }
 
-func (s *Server) mux() *http.ServeMux {
-	m := http.NewServeMux()
-	m.HandleFunc("GET /", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
-		var timers []CountDown
+// render executes the named template, as overridden for this server, in the language of the request that ctx is for.
+func (s *Server) render(ctx context.Context, w io.Writer, name string, data any) error {
+	t := s.templates
+	if t == nil {
+		t = livePages
+	}
+	return t.in(languageOf(ctx)).ExecuteTemplate(w, name, data)
+}
+
+// renderStatic is render for the static pages of a snapshot.
+func (s *Server) renderStatic(ctx context.Context, w io.Writer, name string, data any) error {
+	t := s.staticTemplates
+	if t == nil {
+		t = staticPages
+	}
+	return t.in(languageOf(ctx)).ExecuteTemplate(w, name, data)
+}
+
+// routes is a ServeMux that remembers the patterns registered on it, see openapi.go.
+type routes struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (m *routes) HandleFunc(pattern string, h http.HandlerFunc) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.HandleFunc(pattern, h)
+}
+
+func (s *Server) mux() http.Handler {
+	return withRequestID(withLanguage(s.requireToken(s.refuseWrites(s.routes().ServeMux))))
+}
 
-		rows, err := s.db.QueryContext(r.Context(), `SELECT id, name, description, lastTime, frequency FROM timer`)
+func (s *Server) routes() *routes {
+	m := &routes{ServeMux: http.NewServeMux()}
+	// Every route names its method and "/" only matches itself, so that ServeMux answers a known path with the
+	// wrong method with a 405 and an Allow header, and anything else with a 404.
+	m.HandleFunc("GET /{$}", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		ct, err := negotiate(w, r, "text/html", "application/json")
 		if err != nil {
 			return err
 		}
-		defer rows.Close()
-		for rows.Next() {
-			var c CountDown
-			var lt string
-			if err := rows.Scan(&c.Id, &c.Name, &c.Description, &lt, &c.Frequency); err != nil {
+		state, err := parseState(r.URL.Query().Get("state"))
+		if err != nil {
+			return err
+		}
+		// The segmented control's ?filter= takes the place of ?state= when it's given.
+		if r.URL.Query().Has("filter") {
+			if state, err = parseFilter(r.URL.Query().Get("filter")); err != nil {
 				return err
 			}
+		}
+		// The times in the page are kept current by its script, so it only needs rendering again when a timer changes
+		// or comes due.
+		if notModified, err := s.notModified(w, r); notModified || err != nil {
+			return err
+		}
 
-			if lt != "" {
-				if c.LastTime, err = time.Parse(time.RFC3339, lt); err != nil {
-					return err
-				}
+		sort, err := parseSort(r.URL.Query().Get("sort"))
+		if err != nil {
+			return err
+		}
+		tag := normalizeTag(r.URL.Query().Get("tag"))
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		var timers []CountDown
+		var more string
+		// The page only lists the start of the default order or the user's own, the rest are loaded as it's scrolled
+		// through, see scroll.go. Every other order has to have them all to sort them anyway, and lists that are
+		// filtered by state are short.
+		switch {
+		case ct == "text/html" && sort == ownOrder && state == "":
+			var next *listCursor
+			if timers, next, err = s.listTimerChunk(r.Context(), tag, q, nil); err == nil && next != nil {
+				more = chunkURL(tag, q, sort, next.String())
 			}
-
-			timers = append(timers, c)
+		case ct == "text/html" && sort == timerSort{} && state == "":
+			var next *urgencyCursor
+			if _, timers, next, err = s.listUrgentChunk(r.Context(), tag, q, nil); err == nil && next != nil {
+				more = chunkURL(tag, q, sort, next.String())
+			}
+		default:
+			timers, err = s.listTaggedTimers(r.Context(), tag, q, sort)
 		}
-
-		return homePage.Execute(w, timers)
+		if err != nil {
+			return err
+		}
+		vacation, err := s.vacation(r.Context())
+		if err != nil {
+			return err
+		}
+		// The summary is of every timer, not just the tagged, searched for or first ones.
+		summary := summarize(timers, clock.Now())
+		if tag != "" || q != "" || more != "" {
+			if summary, err = s.summary(r.Context()); err != nil {
+				return err
+			}
+		}
+		cards := renderCards(r.Context(), s.render, inState(newTimerViews(timers), state))
+		switch sort {
+		case timerSort{}:
+			groupByUrgency(cards, clock.Now())
+		case timerSort{Key: sortNextDue}:
+			groupByDue(cards, clock.Now())
+		}
+		// Unfiltered but for the state, timers is every timer.
+		empty := len(timers) == 0 && tag == "" && q == ""
+		return s.respond(w, r, ct, homePageData{cards, vacation, tag, q, summary, sort.String(), more, empty, state}, "homepage", "timerlist")
 	}))
 
 	m.HandleFunc("GET /timer/{id}", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
-		id, err := strconv.Atoi(r.PathValue("id"))
+		id, err := timerID(r)
 		if err != nil {
-			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing id : %w", err)}
+			return err
 		}
 
-		var c CountDown
-		var lt string
-
-		row := s.db.QueryRowContext(r.Context(), `SELECT id, name, description, lastTime, frequency FROM timer WHERE id = ?`, id)
-		if err := row.Scan(&c.Id, &c.Name, &c.Description, &lt, &c.Frequency); err != nil {
-			if err == sql.ErrNoRows {
-				return httpError{http.StatusNotFound, fmt.Errorf("No timer with id: %d", id)}
-			}
+		ct, err := negotiate(w, r, "text/html", "application/json")
+		if err != nil {
 			return err
 		}
-		if lt != "" {
-			if c.LastTime, err = time.Parse(time.RFC3339, lt); err != nil {
-				return err
-			}
+
+		c, err := s.getTimer(r.Context(), id)
+		if err != nil {
+			return err
 		}
 
-		return timer.Execute(w, c)
+		// htmx refreshes the timer in place, everyone else gets a page they can bookmark.
+		return s.respond(w, r, ct, newTimerView(c), "timerpage", "timer")
 	}))
 
 	m.HandleFunc("DELETE /timer/{id}", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
-		id, err := strconv.Atoi(r.PathValue("id"))
+		id, err := timerID(r)
 		if err != nil {
-			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing id : %w", err)}
+			return err
+		}
+		if r.Header.Get("HX-Request") == "" {
+			if err := s.deleteTimer(r.Context(), id); err != nil {
+				return err
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return nil
 		}
 
-		result, err := s.db.ExecContext(r.Context(), `DELETE FROM timer WHERE id = ?`, id)
+		// htmx removes the timer itself, it gets a way to bring it back.
+		c, err := s.getTimer(r.Context(), id)
 		if err != nil {
 			return err
 		}
-		if rows, err := result.RowsAffected(); err != nil {
+		if err := s.deleteTimer(r.Context(), id); err != nil {
 			return err
-		} else if rows == 0 {
-			return httpError{http.StatusNotFound, fmt.Errorf("No timer with id: %d", id)}
-		} else if rows != 1 {
-			return fmt.Errorf("Exepected only 1 row to be deleted but instead %d where.", rows)
 		}
-		return nil
+		return s.render(r.Context(), w, "undotoast", c)
+	}))
+
+	m.HandleFunc("POST /timer/{id}/restore", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := timerID(r)
+		if err != nil {
+			return err
+		}
+		c, err := s.restoreTimer(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return s.render(r.Context(), w, "timer", newTimerView(c))
 	}))
 
 	m.HandleFunc("POST /timer", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
@@ -311,104 +1423,271 @@ func (s *Server) mux() *http.ServeMux {
 			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing form : %w", err)}
 		}
 
-		lastTime, err := time.Parse("2006-01-02T15:04", r.Form.Get("lasttime"))
+		cd, err := timerFromForm(r.Form)
 		if err != nil {
-			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'lasttime': %w", err)}
+			return s.formError(w, r, err)
 		}
+		key, err := idempotencyKey(r)
+		if err != nil {
+			return err
+		}
+		created, err := s.createTimerOnce(r.Context(), key, &cd)
+		if err != nil {
+			return s.formError(w, r, err)
+		}
+
+		// The fragment is still the body so that htmx can add it to the list. The first timer replaces the list's
+		// call to create one instead.
+		w.Header().Set("Location", "/timer/"+strconv.FormatInt(cd.Id, 10))
+		n, err := s.countTimers(r.Context())
+		if err != nil {
+			return err
+		}
+		if n == 1 {
+			w.Header().Set("HX-Reswap", "innerHTML")
+		}
+		if created {
+			w.WriteHeader(http.StatusCreated)
+		}
+		return s.render(r.Context(), w, "timer", newTimerView(cd))
+	}))
+
+	m.HandleFunc("GET /timer/{id}/history", ErrorHTTPHandler(s.historyHandler))
+	m.HandleFunc("POST /timer/{id}/history", ErrorHTTPHandler(s.backfillHandler))
+	m.HandleFunc("GET /timer/{id}/stats", ErrorHTTPHandler(s.timerStatsHandler))
+	m.HandleFunc("GET /timer/{id}/heatmap", ErrorHTTPHandler(s.heatmapHandler))
+	m.HandleFunc("GET /timer/{id}/sparkline", ErrorHTTPHandler(s.sparklineHandler))
+	m.HandleFunc("GET /timer/{id}/widget", ErrorHTTPHandler(s.widgetHandler))
+	m.HandleFunc("GET /timer/{id}/badge.svg", ErrorHTTPHandler(s.badgeHandler))
+	m.HandleFunc("GET /timer/{id}/history.csv", ErrorHTTPHandler(s.timerHistoryCSVHandler))
 
-		// Parse frequency parameters
-		frequencyValue, err := strconv.ParseInt(r.Form.Get("frequencyValue"), 10, 64)
+	m.HandleFunc("POST /timer/{id}/reset", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := timerID(r)
 		if err != nil {
-			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing frequency value: %w", err)}
+			return err
 		}
 
-		frequencyUnit, err := strconv.ParseInt(r.Form.Get("frequencyUnit"), 10, 64)
+		at, err := doneAt(r)
 		if err != nil {
-			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing frequency unit: %w", err)}
+			return err
 		}
+		kind, err := parseCompletionKind(r.FormValue("kind"))
+		if err != nil {
+			return err
+		}
+		if err := s.resetTimer(r.Context(), id, at, kind, strings.TrimSpace(r.FormValue("note"))); err != nil {
+			return err
+		}
+
+		return s.timerUpdated(w, r, id)
+	}))
 
-		// Calculate total frequency in nanoseconds, to match with Duration.
-		frequency := time.Duration(frequencyValue * frequencyUnit)
+	m.HandleFunc("POST /timer/{id}/skip", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := timerID(r)
+		if err != nil {
+			return err
+		}
 
-		cd := CountDown{
-			Name:        r.Form.Get("name"),
-			Description: r.Form.Get("description"),
-			LastTime:    lastTime,
-			Frequency:   frequency,
+		if err := s.skipTimer(r.Context(), id); err != nil {
+			return err
 		}
 
-		result, err := s.db.ExecContext(r.Context(),
-			`INSERT INTO timer (name, description, lasttime, frequency) VALUES (?,?,?,?);`,
-			cd.Name, cd.Description, lastTime.Format(time.RFC3339), cd.Frequency)
+		return s.timerUpdated(w, r, id)
+	}))
+
+	m.HandleFunc("POST /timer/{id}/pin", ErrorHTTPHandler(s.pinHandler))
+
+	m.HandleFunc("POST /timer/{id}/snooze", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := timerID(r)
 		if err != nil {
 			return err
 		}
 
-		if cd.Id, err = result.LastInsertId(); err != nil {
+		if err := s.snoozeTimer(r.Context(), id, r.FormValue("for")); err != nil {
 			return err
 		}
 
-		return timer.Execute(w, cd)
+		return s.timerUpdated(w, r, id)
 	}))
 
-	m.HandleFunc("POST /timer/{id}/reset", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
-		id, err := strconv.Atoi(r.PathValue("id"))
+	m.HandleFunc("POST /timer/{id}/pause", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := timerID(r)
 		if err != nil {
-			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing id : %w", err)}
+			return err
 		}
 
-		result, err := s.db.ExecContext(r.Context(), `UPDATE timer SET lasttime = ? WHERE id = ?`, time.Now().Format(time.RFC3339), id)
+		if err := s.pauseTimer(r.Context(), id); err != nil {
+			return err
+		}
+
+		return s.timerUpdated(w, r, id)
+	}))
+
+	m.HandleFunc("POST /timer/{id}/unpause", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := timerID(r)
 		if err != nil {
 			return err
 		}
-		rows, err := result.RowsAffected()
+		// Whether to put the schedule back by as long as the timer was paused for, so that it isn't due straight away.
+		var shift bool
+		if v := r.URL.Query().Get("shift"); v != "" {
+			if shift, err = strconv.ParseBool(v); err != nil {
+				return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'shift': %w", err)}
+			}
+		}
+
+		if err := s.unpauseTimer(r.Context(), id, shift); err != nil {
+			return err
+		}
+
+		return s.timerUpdated(w, r, id)
+	}))
+
+	m.HandleFunc("POST /timer/{id}/duplicate", ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := timerID(r)
 		if err != nil {
 			return err
 		}
-		if rows == 0 {
-			return httpError{http.StatusNotFound, fmt.Errorf("No timer with id: %d", id)}
+
+		c, err := s.getTimer(r.Context(), id)
+		if err != nil {
+			return err
 		}
-		if rows > 1 {
-			return fmt.Errorf("Expected only 1 row to be affect, but instead %d where", rows)
+
+		// The copy hasn't been done yet, nor skipped, snoozed or paused.
+		c.Name += " (copy)"
+		c.LastTime, c.CreatedAt, c.SkippedUntil, c.SnoozedUntil, c.PausedAt, c.PausedFor, c.Completions = time.Time{}, time.Time{}, time.Time{}, time.Time{}, time.Time{}, 0, 0
+		if err := s.createTimer(r.Context(), &c); err != nil {
+			return err
 		}
 
-		w.Header().Set("HX-Trigger", "timerUpdate/"+r.PathValue("id"))
-		return nil
+		w.Header().Set("Location", "/timer/"+strconv.FormatInt(c.Id, 10))
+		w.WriteHeader(http.StatusCreated)
+		return s.render(r.Context(), w, "timer", newTimerView(c))
+	}))
+
+	m.HandleFunc("POST /timers/order", ErrorHTTPHandler(s.reorderHandler))
+	m.HandleFunc("GET /timers", ErrorHTTPHandler(s.timerChunkHandler))
+	m.HandleFunc("GET /timers/recent-completions", ErrorHTTPHandler(s.recentCompletionsHandler))
+	m.HandleFunc("POST /pause-all", ErrorHTTPHandler(s.pauseAllHandler))
+	m.HandleFunc("POST /resume-all", ErrorHTTPHandler(s.resumeAllHandler))
+
+	m.HandleFunc("GET /tags", ErrorHTTPHandler(s.tagsHandler))
+	m.HandleFunc("GET /icons", ErrorHTTPHandler(s.iconsHandler))
+	m.HandleFunc("GET /forecast", ErrorHTTPHandler(s.forecastHandler))
+	m.HandleFunc("GET /print", ErrorHTTPHandler(s.printHandler))
+	m.HandleFunc("GET /summary", ErrorHTTPHandler(s.summaryHandler))
+	m.HandleFunc("GET /schedule/preview", ErrorHTTPHandler(s.schedulePreviewHandler))
+
+	m.HandleFunc("GET /admin/env", ErrorHTTPHandler(s.envHandler))
+	m.HandleFunc("POST /admin/caldav-sync", ErrorHTTPHandler(s.caldavSyncHandler))
+	m.HandleFunc("POST /admin/time-offset", ErrorHTTPHandler(s.timeOffsetHandler))
+
+	m.HandleFunc("GET /history.csv", ErrorHTTPHandler(s.historyCSVHandler))
+	m.HandleFunc("GET /export", ErrorHTTPHandler(s.exportHandler))
+	m.HandleFunc("POST /import", ErrorHTTPHandler(s.importHandler))
+	m.HandleFunc("POST /import.csv", ErrorHTTPHandler(s.importCSVHandler))
+	m.HandleFunc("GET /export/snapshot.zip", ErrorHTTPHandler(s.snapshotHandler))
+
+	s.registerAPI(m)
+	s.registerDashboards(m)
+	m.HandleFunc("GET /api/openapi.json", JSONHTTPHandler(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		doc, err := openAPI()
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, doc, nil
 	}))
 	return m
 }
 
-func main() {
+// The flags are registered up front so that the environment report can list all of them, see env.go.
+var (
+	dbFile             = flag.String("db-file", "timers.db", "The sqlite file to read and write state from.")
+	dbRecreate         = flag.Bool("db-recreate", false, "Drops data in the file and creates the necessary schemas.")
+	allowNewerSchema   = flag.Bool("allow-newer-schema", false, "Start read-only rather than refuse to, when the database was migrated by a newer binary.")
+	dbPopulateTestData = flag.Bool("db-populate-test-data", false, "Inserts rows of test data into the table.")
+
+	httpPort = flag.Int("port", 8080, "The http port to expose the server on.")
+
+	templateDir = flag.String("template-dir", "", "A directory of templates to use instead of the built in ones, e.g. timer.html for just the timer card.")
+
+	apiToken = flag.String("api-token", "", "A bearer token that POST, PUT and DELETE requests, and anything under /admin/, must send. Other reads stay open.")
+
+	instanceStatsFlag = flag.Bool("instance-stats", false, "Serve GET /api/instance-stats, which needs the -api-token, for `countup fleet` to compare this instance with others.")
+
+	hookCommand = flag.String("hook-command", "", "A shell command to run for timer events, it gets the event as JSON on stdin.")
+	hookEvents  = flag.String("hook-events", "created,updated,deleted,reset,restored,skipped,snoozed,paused,unpaused", "Comma separated event types that -hook-command runs for.")
+	hookTimeout = flag.Duration("hook-timeout", 10*time.Second, "How long -hook-command may run for a single event before it is killed.")
 
-	var dbFile = flag.String("db-file", "timers.db", "The sqlite file to read and write state from.")
-	var dbRecreate = flag.Bool("db-recreate", false, "Drops data in the file and creates the necessary schemas.")
-	var dbPopulateTestData = flag.Bool("db-populate-test-data", false, "Inserts rows of test data into the table.")
+	caldavURL  = flag.String("caldav-url", "", "A CalDAV calendar to keep an event per timer in, at its next due time.")
+	caldavUser = flag.String("caldav-user", "", "The user for -caldav-url, the password is read from $COUNTUP_CALDAV_PASSWORD.")
 
-	var httpPort = flag.Int("port", 8080, "The http port to expose the server on.")
+	outboundProxy = flag.String("outbound-proxy", "", "An http(s):// or socks5:// proxy for CalDAV and any other requests the server makes, instead of $HTTP_PROXY, $HTTPS_PROXY and $NO_PROXY.")
 
+	timeOffsetFlag = flag.Duration("time-offset", 0, "Run as if it were this much later, or earlier if negative, than it is. For screenshots and tests.")
+	timezone       = flag.String("timezone", "Local", "The time zone for timers' times of day, the days that they're due on and the times that pages show, like America/New_York.")
+	weekStartFlag  = flag.String("week-start", "monday", "The day that weeks start on for timers' targets and the homepage's this week, like sunday.")
+	langFlag       = flag.String("lang", "en", "The language of the pages when the browser's Accept-Language has none with a translation in locales/, like de. Ones without a translation are English.")
+	demo           = flag.Bool("demo", false, "Allow changing -time-offset while running with POST /admin/time-offset. Never set this in production.")
+)
+
+func main() {
 	flag.Parse()
 
+	var err error
+	if location, err = time.LoadLocation(*timezone); err != nil {
+		log.Fatalf("Error loading -timezone %q, expected an IANA time zone like America/New_York or UTC: %v", *timezone, err)
+	}
+	if weekStart, err = parseWeekday(*weekStartFlag); err != nil {
+		log.Fatalf("Error parsing -week-start: %v", err)
+	}
+	language = parseLanguage(*langFlag)
+
+	// Before anything reads the time, so that the whole server agrees on it.
+	var shifted *offsetClock
+	if *timeOffsetFlag != 0 || *demo || flag.Arg(0) == "dev-server" {
+		shifted = &offsetClock{base: clock, offset: *timeOffsetFlag}
+		clock = shifted
+		log.Printf("Running %v from the real time\n", *timeOffsetFlag)
+	}
+
+	// The dev server has a database of its own.
+	if flag.Arg(0) == "dev-server" {
+		h, err := newDevServer(*templateDir, shifted)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Serving fixtures on http://localhost:%d/\n", *httpPort)
+		log.Fatal(http.ListenAndServe(":"+strconv.Itoa(*httpPort), h))
+	}
+
+	// The fleet command is a client of other servers, it has no database.
+	if flag.Arg(0) == "fleet" {
+		proxy, err := parseOutboundProxy(*outboundProxy)
+		if err != nil {
+			log.Fatalf("Error parsing -outbound-proxy: %v", err)
+		}
+		if err := runFleet(flag.Args()[1:], os.Stdout, (&Server{outboundProxy: proxy}).outboundClient(0)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Initialiaze a DB connection.
-	db, err := sql.Open("sqlite", *dbFile)
+	db, err := openDB(*dbFile)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	defer func() { db.Close() }() // db is reopened for -allow-newer-schema.
 
 	if *dbRecreate {
-		if _, err = db.Exec(`DROP TABLE IF EXISTS timer;`); err != nil {
+		if err := dropTables(db); err != nil {
 			log.Fatal(err)
 		}
 	}
 
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS timer (
-		id INTEGER PRIMARY KEY,
-		name TEXT NOT NULL,
-		description TEXT NOT NULL,
-		lasttime TEXT NOT NULL,
-		frequency INTEGER NOT NULL
-	);`)
+	db, readOnly, err := migrateOrReopen(db, *dbFile, *allowNewerSchema)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -416,20 +1695,104 @@ func main() {
 	if *dbPopulateTestData {
 		_, err = db.Exec(`
 		INSERT INTO timer
-			(name, description, lastTime, frequency)
+			(name, description, lastTime, frequency, frequency_value, frequency_unit)
 		VALUES
-			('Sandro Test',      '', '2025-01-02T00:00:00-05:00', 0),
-			('Check Money',      '', '2025-01-02T00:00:00-05:00', 2592000000000000),
-			('Go to gym',        '', '2025-01-02T00:00:00-05:00', 259200000000000),
-			('Check on Mike',    '', '2025-01-02T00:00:00-05:00', 2 * 2592000000000000),
-			('Start new coffee', '', '2025-01-02T00:00:00-05:00', 86400000000000),
-			('Make Pizza',       '', '',                          2 * 2592000000000000)
+			('Sandro Test',      '', '2025-01-02T00:00:00-05:00', 0,                    0, ''),
+			('Check Money',      '', '2025-01-02T00:00:00-05:00', 2592000000000000,     1, 'month'),
+			('Go to gym',        '', '2025-01-02T00:00:00-05:00', 259200000000000,      3, 'day'),
+			('Check on Mike',    '', '2025-01-02T00:00:00-05:00', 2 * 2592000000000000, 2, 'month'),
+			('Start new coffee', '', '2025-01-02T00:00:00-05:00', 86400000000000,       1, 'day'),
+			('Make Pizza',       '', NULL,                        2 * 2592000000000000, 2, 'month')
 		`)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
 
+	s := &Server{db: db, apiToken: *apiToken, startedAt: clock.Now(), readOnly: readOnly, serveInstanceStats: *instanceStatsFlag}
+	if !readOnly {
+		if err := s.storeMissingStreaks(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if s.outboundProxy, err = parseOutboundProxy(*outboundProxy); err != nil {
+		log.Fatalf("Error parsing -outbound-proxy: %v", err)
+	}
+	if *demo {
+		s.timeOffset = shifted
+	}
+	if *templateDir != "" || readOnly || *apiToken != "" {
+		funcs := template.FuncMap{}
+		if readOnly {
+			funcs["readOnly"] = func() bool { return true }
+		}
+		if *apiToken != "" {
+			funcs["apiToken"] = func() string { return *apiToken }
+		}
+		var overridden []string
+		if s.templates, s.staticTemplates, overridden, err = overrideTemplates(*templateDir, funcs); err != nil {
+			log.Fatal(err)
+		}
+		if *templateDir != "" {
+			s.templateSource = fmt.Sprintf("%s from %s", strings.Join(overridden, ", "), *templateDir)
+			log.Printf("Using templates from %s for: %s\n", *templateDir, strings.Join(overridden, ", "))
+		}
+	}
+
+	switch flag.Arg(0) {
+	case "snapshot":
+		snapshotFlags := flag.NewFlagSet("snapshot", flag.ExitOnError)
+		out := snapshotFlags.String("out", "snapshot", "The directory to write the static HTML files to.")
+		snapshotFlags.Parse(flag.Args()[1:])
+
+		files, err := s.snapshot(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeSnapshotDir(*out, files); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Wrote %d pages to %s\n", len(files), *out)
+		return
+	case "":
+		// No command, serve the app.
+	default:
+		log.Fatalf("Unknown command: %q", flag.Arg(0))
+	}
+
+	// Deleted timers are kept for a while so that they can be restored.
+	go func() {
+		for ; !readOnly; <-time.Tick(24 * time.Hour) {
+			if n, err := s.purgeDeletedTimers(context.Background()); err != nil {
+				log.Printf("Error purging deleted timers: %v\n", err)
+			} else if n > 0 {
+				log.Printf("Purged %d timers deleted more than %v ago\n", n, deletedTimerRetention)
+			}
+		}
+	}()
+
+	if *hookCommand != "" {
+		s.hooks = newHookRunner(*hookCommand, *hookEvents, *hookTimeout)
+	}
+	if *caldavURL != "" && readOnly {
+		log.Println("Not syncing to CalDAV while read-only")
+	} else if *caldavURL != "" {
+		s.caldav = newCaldavSyncer(s, *caldavURL, *caldavUser, os.Getenv("COUNTUP_CALDAV_PASSWORD"))
+		go func() {
+			if _, err := s.caldav.reconcile(context.Background()); err != nil {
+				log.Printf("Error syncing to CalDAV: %v\n", err)
+			}
+		}()
+	}
+
+	report, err := s.envReport(context.Background(), flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, line := range report.lines() {
+		log.Println(line)
+	}
+
 	log.Printf("Serving on :%d\n", *httpPort)
-	log.Fatal(http.ListenAndServe(":"+strconv.Itoa(*httpPort), (&Server{db}).mux()))
+	log.Fatal(http.ListenAndServe(":"+strconv.Itoa(*httpPort), s.mux()))
 }
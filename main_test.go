@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -28,21 +29,13 @@ func setupTestDB(t *testing.T) *sql.DB {
 	tmpFile.Close()
 
 	// Open database connection
-	db, err := sql.Open("sqlite", tmpFile.Name())
+	db, err := openDB(tmpFile.Name())
 	if err != nil {
 		t.Fatalf("Failed to open database: %v", err)
 	}
 
 	// Create schema
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS timer (
-		id INTEGER PRIMARY KEY,
-		name TEXT NOT NULL,
-		description TEXT NOT NULL,
-		lasttime TEXT NOT NULL,
-		frequency INTEGER NOT NULL
-	);`)
-	if err != nil {
+	if err := migrate(db); err != nil {
 		t.Fatalf("Failed to create schema: %v", err)
 	}
 
@@ -83,9 +76,7 @@ func insertTestData(t *testing.T, db *sql.DB) []CountDown {
 	for i, timer := range testTimers {
 		result, err := db.Exec(
 			`INSERT INTO timer (name, description, lasttime, frequency) VALUES (?, ?, ?, ?)`,
-			timer.Name, timer.Description,
-			timer.LastTime.Format(time.RFC3339),
-			timer.Frequency,
+			timer.Name, timer.Description, nullTime(timer.LastTime), timer.Frequency,
 		)
 		if err != nil {
 			t.Fatalf("Failed to insert test data: %v", err)
@@ -157,6 +148,73 @@ func TestHTTPError(t *testing.T) {
 	}
 }
 
+// TestHTTPErrorAfterWriteHeader tests that a status code picked before an error doesn't leak to the client
+func TestHTTPErrorAfterWriteHeader(t *testing.T) {
+	handler := ErrorHTTPHandler(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "partial")
+		return io.EOF
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if w.Body.String() != "EOF\n" {
+		t.Errorf("Expected body %q, got %q", "EOF\n", w.Body.String())
+	}
+}
+
+// TestHTMXErrorToast tests that htmx requests get their errors as a toast for the page's toasts, with the status code
+// that anyone else gets
+func TestHTMXErrorToast(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	post := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, nil)
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	for _, test := range []struct {
+		name, target string
+		code         int
+		message      string
+	}{
+		{"bad request", "/timer/abc/reset", http.StatusBadRequest, "Error parsing id"},
+		{"not found", "/timer/99/reset", http.StatusNotFound, "99"},
+		{"server error", "/timer/1/reset", http.StatusInternalServerError, "database is closed"},
+	} {
+		if test.code == http.StatusInternalServerError {
+			db.Close()
+		}
+		w := post(test.target)
+		if w.Code != test.code {
+			t.Errorf("%s: expected status %d, got %d: %s", test.name, test.code, w.Code, w.Body.String())
+		}
+		if w.Header().Get("HX-Retarget") != "#toasts" || w.Header().Get("HX-Reswap") != "beforeend" {
+			t.Errorf("%s: expected the toast retargeted at #toasts, got %v", test.name, w.Header())
+		}
+		body := w.Body.String()
+		for _, expected := range []string{`class="toast show text-bg-danger"`, fmt.Sprintf("<strong>%d %s</strong>", test.code, http.StatusText(test.code)), "POST " + test.target, test.message} {
+			if !strings.Contains(body, expected) {
+				t.Errorf("%s: expected %q in the toast, got %s", test.name, expected, body)
+			}
+		}
+	}
+
+	// Without htmx it's still the plain error.
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("POST", "/timer/abc/reset", nil))
+	if w.Code != http.StatusBadRequest || w.Header().Get("HX-Retarget") != "" || strings.Contains(w.Body.String(), "toast") {
+		t.Errorf("Expected a plain text error without htmx, got %v %v: %s", w.Code, w.Header(), w.Body.String())
+	}
+}
+
 // TestCountDownNextDue tests the NextDue method of CountDown
 func TestCountDownNextDue(t *testing.T) {
 	now := time.Now()
@@ -179,17 +237,35 @@ func TestCountDownNextDue(t *testing.T) {
 			},
 		},
 		{
-			name: "with zero last time",
+			name: "never done",
 			countdown: CountDown{
 				LastTime:  time.Time{}, // Zero time
 				Frequency: 24 * time.Hour,
+				CreatedAt: now.Add(-time.Hour),
 			},
 			expectFn: func(result time.Time) bool {
-				expected := now.Add(24 * time.Hour)
-				diff := result.Sub(expected)
+				return result.Equal(now.Add(-time.Hour)) // Due since it was created.
+			},
+		},
+		{
+			name: "never done, created at an unknown time",
+			countdown: CountDown{
+				Frequency: 24 * time.Hour,
+			},
+			expectFn: func(result time.Time) bool {
+				diff := result.Sub(now)
 				return diff > -time.Second && diff < time.Second
 			},
 		},
+		{
+			name: "one-time timer",
+			countdown: CountDown{
+				LastTime: now.Add(-24 * time.Hour),
+			},
+			expectFn: func(result time.Time) bool {
+				return result.IsZero()
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -212,17 +288,20 @@ func TestHomePageHandler(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Execute the handler
-	(&Server{db}).mux().ServeHTTP(w, req)
+	(&Server{db: db}).mux().ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status OK, got %v", w.Code)
 	}
 
-	// Check that all test timer names appear in the response
+	// Check that all test timer names appear in the response, linking to their page
 	for _, timer := range testTimers {
 		if !strings.Contains(w.Body.String(), timer.Name) {
 			t.Errorf("Response does not contain timer name: %s", timer.Name)
 		}
+		if !strings.Contains(w.Body.String(), fmt.Sprintf(`href="/timer/%d"`, timer.Id)) {
+			t.Errorf("Response does not link to timer: %d", timer.Id)
+		}
 	}
 }
 
@@ -239,7 +318,7 @@ func TestGetTimerHandler(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		// Execute the handler
-		(&Server{db}).mux().ServeHTTP(w, req)
+		(&Server{db: db}).mux().ServeHTTP(w, req)
 
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status OK, got %v", w.Code)
@@ -251,6 +330,32 @@ func TestGetTimerHandler(t *testing.T) {
 		}
 	})
 
+	// Test that browsers get a full page and htmx gets just the fragment
+	t.Run("page and fragment", func(t *testing.T) {
+		target := fmt.Sprintf("/timer/%d", testTimers[0].Id)
+
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		(&Server{db: db}).mux().ServeHTTP(w, req)
+		if !strings.HasPrefix(w.Body.String(), "<!DOCTYPE html>") {
+			t.Errorf("Expected a full page, got %q", w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "<title>"+testTimers[0].Name+" - Countdown</title>") {
+			t.Errorf("Expected the timer name in the page title")
+		}
+
+		req = httptest.NewRequest("GET", target, nil)
+		req.Header.Set("HX-Request", "true")
+		w = httptest.NewRecorder()
+		(&Server{db: db}).mux().ServeHTTP(w, req)
+		if strings.Contains(w.Body.String(), "<html>") {
+			t.Errorf("Expected only the timer fragment for htmx, got %q", w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), fmt.Sprintf(`id="timer-%d"`, testTimers[0].Id)) {
+			t.Errorf("Expected the timer fragment, got %q", w.Body.String())
+		}
+	})
+
 	// Test getting a timer that doesn't exist
 	t.Run("non-existent timer", func(t *testing.T) {
 		// Set up a request
@@ -259,7 +364,7 @@ func TestGetTimerHandler(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		// Execute the handler
-		(&Server{db}).mux().ServeHTTP(w, req)
+		(&Server{db: db}).mux().ServeHTTP(w, req)
 
 		if w.Code != http.StatusNotFound {
 			t.Errorf("Expected NotFound error, got %v", w.Code)
@@ -300,11 +405,20 @@ func TestCreateTimerHandler(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		// Execute the handler
-		(&Server{db}).mux().ServeHTTP(w, req)
+		(&Server{db: db}).mux().ServeHTTP(w, req)
 
 		// Verify response
-		if w.Code != http.StatusOK {
-			t.Errorf("Expected status OK, got %v", w.Code)
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected status Created, got %v", w.Code)
+		}
+
+		// Check that the new timer's location is returned
+		var id int64
+		if err := db.QueryRow("SELECT MAX(id) FROM timer").Scan(&id); err != nil {
+			t.Fatalf("Failed to get new timer id: %v", err)
+		}
+		if loc := w.Header().Get("Location"); loc != fmt.Sprintf("/timer/%d", id) {
+			t.Errorf("Expected Location /timer/%d, got %q", id, loc)
 		}
 
 		// Check that timer was created
@@ -333,7 +447,7 @@ func TestCreateTimerHandler(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		// Execute the handler
-		(&Server{db}).mux().ServeHTTP(w, req)
+		(&Server{db: db}).mux().ServeHTTP(w, req)
 
 		if w.Result().StatusCode != http.StatusBadRequest {
 			t.Errorf("Expected BadRequest error, got %d", w.Result().StatusCode)
@@ -344,6 +458,136 @@ func TestCreateTimerHandler(t *testing.T) {
 			t.Errorf("Invalid timer creation affected database")
 		}
 	})
+
+	// Test the schedule typed as text, which wins over the structured inputs that are still submitted
+	t.Run("typed schedule", func(t *testing.T) {
+		formData := url.Values{
+			"name":           {"Typed Timer"},
+			"lasttime":       {time.Now().Format("2006-01-02T15:04")},
+			"frequencyValue": {"1"},
+			"frequencyUnit":  {"day"},
+			"scheduleMode":   {"text"},
+			"schedule":       {"every other week"},
+		}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(formData.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		(&Server{db: db}).mux().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+		}
+
+		var value int64
+		var unit string
+		if err := db.QueryRow("SELECT frequency_value, frequency_unit FROM timer WHERE name = 'Typed Timer'").Scan(&value, &unit); err != nil {
+			t.Fatalf("Failed to read the timer: %v", err)
+		}
+		if value != 2 || unit != UnitWeek {
+			t.Errorf("Expected every 2 weeks, got %d %s", value, unit)
+		}
+
+		// The day that it falls on anchors it.
+		formData.Set("name", "Pay rent")
+		formData.Set("schedule", "monthly on the 1st")
+		req = httptest.NewRequest("POST", "/timer", strings.NewReader(formData.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w = httptest.NewRecorder()
+		(&Server{db: db}).mux().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "every month on the 1st") {
+			t.Fatalf("Expected a timer due every month on the 1st, got %v: %s", w.Code, w.Body.String())
+		}
+
+		formData.Set("schedule", "now and then")
+		req = httptest.NewRequest("POST", "/timer", strings.NewReader(formData.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w = httptest.NewRecorder()
+		(&Server{db: db}).mux().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status Bad Request, got %v", w.Code)
+		}
+	})
+
+	// Test the switch for one-time timers, which ignores the schedule inputs
+	t.Run("one-time timer", func(t *testing.T) {
+		formData := url.Values{
+			"name":           {"One-time Timer"},
+			"lasttime":       {time.Now().Format("2006-01-02T15:04")},
+			"once":           {"1"},
+			"frequencyValue": {"1"},
+			"frequencyUnit":  {"week"},
+		}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(formData.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		(&Server{db: db}).mux().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+		}
+		if body := w.Body.String(); !strings.Contains(body, "Last happened") || strings.Contains(body, "data-next-due") || strings.Contains(body, "Repeats") {
+			t.Errorf("Expected only when it last happened, got %s", body)
+		}
+
+		var frequency int64
+		if err := db.QueryRow("SELECT frequency FROM timer WHERE name = 'One-time Timer'").Scan(&frequency); err != nil {
+			t.Fatalf("Failed to read the timer: %v", err)
+		}
+		if frequency != 0 {
+			t.Errorf("Expected no frequency, got %d", frequency)
+		}
+	})
+
+	// Test the single frequency field that scripts can send instead of the value and unit
+	t.Run("frequency field", func(t *testing.T) {
+		for frequency, code := range map[string]int{"3 days": http.StatusCreated, "-3 days": http.StatusBadRequest} {
+			formData := url.Values{"name": {"Scripted Timer"}, "lasttime": {time.Now().Format("2006-01-02T15:04")}, "frequency": {frequency}}
+			req := httptest.NewRequest("POST", "/timer", strings.NewReader(formData.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			(&Server{db: db}).mux().ServeHTTP(w, req)
+			if w.Code != code {
+				t.Errorf("%q: expected status %v, got %v: %s", frequency, code, w.Code, w.Body.String())
+			}
+		}
+
+		var value int64
+		var unit string
+		if err := db.QueryRow("SELECT frequency_value, frequency_unit FROM timer WHERE name = 'Scripted Timer'").Scan(&value, &unit); err != nil {
+			t.Fatalf("Failed to read the timer: %v", err)
+		}
+		if value != 3 || unit != UnitDay {
+			t.Errorf("Expected every 3 days, got %d %s", value, unit)
+		}
+	})
+
+	// Test business days and skipping weekends from the form
+	t.Run("weekends", func(t *testing.T) {
+		formData := url.Values{
+			"name":           {"Weekday Timer"},
+			"lasttime":       {time.Now().Format("2006-01-02T15:04")},
+			"frequencyValue": {"3"},
+			"frequencyUnit":  {UnitBusinessDay},
+			"skipWeekends":   {"1"},
+		}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(formData.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		(&Server{db: db}).mux().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "Repeats every 3 business days") {
+			t.Errorf("Expected the schedule in the fragment, got %s", w.Body.String())
+		}
+
+		var unit string
+		var skipWeekends bool
+		if err := db.QueryRow("SELECT frequency_unit, skip_weekends FROM timer WHERE name = 'Weekday Timer'").Scan(&unit, &skipWeekends); err != nil {
+			t.Fatalf("Failed to read the timer: %v", err)
+		}
+		if unit != UnitBusinessDay || !skipWeekends {
+			t.Errorf("Expected business days skipping weekends, got %q %v", unit, skipWeekends)
+		}
+	})
 }
 
 // TestResetTimerHandler tests the POST /timer/{id}/reset handler
@@ -376,7 +620,7 @@ func TestResetTimerHandler(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Execute the handler
-	(&Server{db}).mux().ServeHTTP(w, req)
+	(&Server{db: db}).mux().ServeHTTP(w, req)
 
 	// Verify response
 	resp := w.Result()
@@ -387,7 +631,7 @@ func TestResetTimerHandler(t *testing.T) {
 
 	// Verify HX-Trigger header
 	triggerHeader := resp.Header.Get("HX-Trigger")
-	expectedTrigger := fmt.Sprintf("timerUpdate/%d", testTimers[0].Id)
+	expectedTrigger := fmt.Sprintf("timerUpdate/%d, timersChanged", testTimers[0].Id)
 	if triggerHeader != expectedTrigger {
 		t.Errorf("Expected HX-Trigger %q, got %q", expectedTrigger, triggerHeader)
 	}
@@ -397,6 +641,126 @@ func TestResetTimerHandler(t *testing.T) {
 	if !updatedLastTime.After(initialLastTime) {
 		t.Errorf("Last time was not updated. Initial: %v, Updated: %v", initialLastTime, updatedLastTime)
 	}
+
+	// The body is the card to swap in, already showing the new last time.
+	body := w.Body.String()
+	if !strings.HasPrefix(body, fmt.Sprintf("\n<div id=\"timer-%d\"", testTimers[0].Id)) || !strings.Contains(body, `data-locale-date-string="`+updatedLastTime.Format(time.RFC3339)+`"`) {
+		t.Errorf("Expected the timer's card with its new last time %v, got %s", updatedLastTime, body)
+	}
+}
+
+// TestResetTimerAt tests recording that a timer was done earlier than now
+func TestResetTimerAt(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	location = time.FixedZone("EST", -5*60*60)
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	s := &Server{db: db}
+	reset := fmt.Sprintf("/timer/%d/reset", testTimers[0].Id)
+
+	lastNight := time.Now().In(location).Add(-12 * time.Hour).Truncate(time.Minute)
+	tests := []struct {
+		name, target, body string
+		expectedStatus     int
+		expectedLastTime   time.Time
+	}{
+		{"datetime-local form field", reset, url.Values{"at": {lastNight.Format("2006-01-02T15:04")}}.Encode(), http.StatusOK, lastNight},
+		{"RFC 3339 query", reset + "?at=" + url.QueryEscape(lastNight.Add(time.Hour).UTC().Format(time.RFC3339)), "", http.StatusOK, lastNight.Add(time.Hour)},
+		{"before the last time", reset, url.Values{"at": {lastNight.Format("2006-01-02T15:04")}}.Encode(), http.StatusOK, lastNight.Add(time.Hour)},
+		{"in the future", reset, url.Values{"at": {time.Now().In(location).Add(time.Hour).Format("2006-01-02T15:04")}}.Encode(), http.StatusBadRequest, time.Time{}},
+		{"not a time", reset, "at=last+night", http.StatusBadRequest, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.mux().ServeHTTP(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedLastTime.IsZero() {
+				return
+			}
+			c, err := s.getTimer(t.Context(), testTimers[0].Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !c.LastTime.Equal(tt.expectedLastTime) {
+				t.Errorf("Expected the last time to be %v, got %v", tt.expectedLastTime, c.LastTime)
+			}
+		})
+	}
+}
+
+// TestSkipTimerHandler tests that skipping puts the next due date off by a period without the timer being done, and
+// that doing it clears the skip
+func TestSkipTimerHandler(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	post := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, httptest.NewRequest("POST", target, nil))
+		return w
+	}
+
+	timer := CountDown{Name: "Water plants", LastTime: time.Now().Add(-time.Hour), Frequency: 24 * time.Hour}
+	if err := s.createTimer(t.Context(), &timer); err != nil {
+		t.Fatalf("Failed to create a timer: %v", err)
+	}
+	id := timer.Id
+	before, err := s.getTimer(t.Context(), id)
+	if err != nil {
+		t.Fatalf("Failed to read the timer: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		w := post(fmt.Sprintf("/timer/%d/skip", id))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if got, expected := w.Header().Get("HX-Trigger"), fmt.Sprintf("timerUpdate/%d, timersChanged", id); got != expected {
+			t.Errorf("Expected HX-Trigger %q, got %q", expected, got)
+		}
+		c, err := s.getTimer(t.Context(), id)
+		if err != nil {
+			t.Fatalf("Failed to read the timer: %v", err)
+		}
+		if expected := before.NextDue().Add(time.Duration(i) * 24 * time.Hour); !c.NextDue().Equal(expected) {
+			t.Errorf("Expected skip %d to make it due at %v, got %v", i, expected, c.NextDue())
+		}
+		if expected := `data-next-due="` + c.NextDue().Format(time.RFC3339) + `"`; !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Expected skip %d to respond with the card due at %v, got %s", i, c.NextDue(), w.Body.String())
+		}
+		if !c.LastTime.Equal(before.LastTime) {
+			t.Errorf("Expected skipping to leave the last time at %v, got %v", before.LastTime, c.LastTime)
+		}
+	}
+
+	if w := post(fmt.Sprintf("/timer/%d/reset", id)); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	c, err := s.getTimer(t.Context(), id)
+	if err != nil {
+		t.Fatalf("Failed to read the timer: %v", err)
+	}
+	if !c.SkippedUntil.IsZero() {
+		t.Errorf("Expected doing the timer to clear its skip, got %v", c.SkippedUntil)
+	}
+	if expected := c.LastTime.Add(24 * time.Hour); !c.NextDue().Equal(expected) {
+		t.Errorf("Expected it to be due a day after being done at %v, got %v", expected, c.NextDue())
+	}
+
+	once := CountDown{Name: "Renew passport", LastTime: time.Now()}
+	if err := s.createTimer(t.Context(), &once); err != nil {
+		t.Fatalf("Failed to create a timer: %v", err)
+	}
+	if w := post(fmt.Sprintf("/timer/%d/skip", once.Id)); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a one-time timer's skip to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+	if w := post("/timer/999/skip"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown timer's skip to be Not Found, got %v: %s", w.Code, w.Body.String())
+	}
 }
 
 // TestDeleteTimerHandler tests the DELETE /timer/{id} handler
@@ -404,10 +768,10 @@ func TestDeleteTimerHandler(t *testing.T) {
 	db := setupTestDB(t)
 	testTimers := insertTestData(t, db)
 
-	// Helper function to check if timer exists
+	// Helper function to check if timer exists, deleted timers are kept but hidden
 	timerExists := func(id int64) bool {
 		var count int
-		row := db.QueryRow("SELECT COUNT(*) FROM timer WHERE id = ?", id)
+		row := db.QueryRow("SELECT COUNT(*) FROM timer WHERE id = ? AND deleted_at IS NULL", id)
 		if err := row.Scan(&count); err != nil {
 			t.Fatalf("Failed to check timer existence: %v", err)
 		}
@@ -424,10 +788,13 @@ func TestDeleteTimerHandler(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Execute the handler
-	(&Server{db}).mux().ServeHTTP(w, req)
+	(&Server{db: db}).mux().ServeHTTP(w, req)
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status OK, got %v", w.Code)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status NoContent, got %v", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected no body, got %q", w.Body.String())
 	}
 
 	// Verify timer was deleted
@@ -436,6 +803,208 @@ func TestDeleteTimerHandler(t *testing.T) {
 	}
 }
 
+// TestDeleteAndRestoreTimer tests that htmx deletes come with an undo toast and that restoring brings the timer back
+func TestDeleteAndRestoreTimer(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	s := &Server{db: db}
+	id := testTimers[0].Id
+
+	req := httptest.NewRequest("DELETE", fmt.Sprintf("/timer/%d", id), nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+	restore := fmt.Sprintf(`hx-post="/timer/%d/restore"`, id)
+	if body := w.Body.String(); !strings.Contains(body, `hx-swap-oob="beforeend:#toasts"`) || !strings.Contains(body, restore) {
+		t.Errorf("Expected an out of band undo toast, got %q", body)
+	}
+
+	// Gone from everywhere.
+	timers, err := s.listTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timers) != len(testTimers)-1 {
+		t.Errorf("Expected %d timers listed after the delete, got %d", len(testTimers)-1, len(timers))
+	}
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/timer/%d", id), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status Not Found for a deleted timer, got %v", w.Code)
+	}
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("DELETE", fmt.Sprintf("/timer/%d", id), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status Not Found deleting twice, got %v", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("POST", fmt.Sprintf("/timer/%d/restore", id), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), fmt.Sprintf(`id="timer-%d"`, id)) {
+		t.Errorf("Expected the timer's fragment, got %q", w.Body.String())
+	}
+	c, err := s.getTimer(t.Context(), id)
+	if err != nil {
+		t.Fatalf("Expected the timer back after restoring: %v", err)
+	}
+	if c.Description != testTimers[0].Description || c.Frequency != testTimers[0].Frequency {
+		t.Errorf("Expected %+v, got %+v", testTimers[0], c)
+	}
+
+	// Only deleted timers can be restored.
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("POST", fmt.Sprintf("/timer/%d/restore", id), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status Not Found restoring a timer that isn't deleted, got %v", w.Code)
+	}
+}
+
+// TestPurgeDeletedTimers tests that only timers deleted more than 30 days ago are removed for good
+func TestPurgeDeletedTimers(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	s := &Server{db: db}
+
+	clock = fixedClock(now.Add(-31 * 24 * time.Hour))
+	if err := s.deleteTimer(t.Context(), testTimers[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	clock = fixedClock(now.Add(-29 * 24 * time.Hour))
+	if err := s.deleteTimer(t.Context(), testTimers[1].Id); err != nil {
+		t.Fatal(err)
+	}
+
+	clock = fixedClock(now)
+	n, err := s.purgeDeletedTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 timer purged, got %d", n)
+	}
+	if _, err := s.restoreTimer(t.Context(), testTimers[0].Id); err == nil {
+		t.Errorf("Expected the purged timer to be gone for good")
+	}
+	if _, err := s.restoreTimer(t.Context(), testTimers[1].Id); err != nil {
+		t.Errorf("Expected the recently deleted timer to still be restorable: %v", err)
+	}
+}
+
+// TestDuplicateTimerHandler tests the POST /timer/{id}/duplicate handler
+func TestDuplicateTimerHandler(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	s := &Server{db: db}
+
+	// Duplicate a timer that has been done before
+	req := httptest.NewRequest("POST", fmt.Sprintf("/timer/%d/duplicate", testTimers[0].Id), nil)
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), testTimers[0].Name+" (copy)") {
+		t.Errorf("Response does not contain the copy's name: %q", w.Body.String())
+	}
+
+	// Check the copied row
+	var id int64
+	if err := db.QueryRow("SELECT MAX(id) FROM timer").Scan(&id); err != nil {
+		t.Fatalf("Failed to get new timer id: %v", err)
+	}
+	c, err := s.getTimer(t.Context(), id)
+	if err != nil {
+		t.Fatalf("Failed to get the copy: %v", err)
+	}
+	if c.Name != testTimers[0].Name+" (copy)" || c.Description != testTimers[0].Description || c.Frequency != testTimers[0].Frequency {
+		t.Errorf("Copy does not match the original: %+v", c)
+	}
+	if !c.LastTime.IsZero() {
+		t.Errorf("Expected the copy to have no last time, got %v", c.LastTime)
+	}
+
+	// Duplicating a timer that doesn't exist
+	req = httptest.NewRequest("POST", "/timer/999/duplicate", nil)
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected NotFound error, got %v", w.Code)
+	}
+}
+
+// TestTimestamps tests that every handler that changes a timer moves its updated_at on and leaves created_at be
+func TestTimestamps(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	shifted := &offsetClock{base: systemClock{}}
+	clock = shifted
+
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Water plants","lastTime":"2025-03-01T09:00:00Z","frequency":"2 days"}`)
+	var timer CountDown
+	decodeResponse(t, w, &timer)
+	if w.Code != http.StatusCreated || timer.CreatedAt.IsZero() || timer.UpdatedAt.IsZero() {
+		t.Fatalf("Expected the new timer with both times, got %v: %s", w.Code, w.Body.String())
+	}
+	var created, updated string
+	if err := db.QueryRow(`SELECT created_at, updated_at FROM timer WHERE id = 1`).Scan(&created, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if created == "" || updated == "" {
+		t.Fatalf("Expected a new timer to have both, got created_at %q and updated_at %q", created, updated)
+	}
+
+	for i, tt := range []struct{ method, target, body string }{
+		{"POST", "/timer/1/snooze", "for=1d"},
+		{"POST", "/timer/1/skip", ""},
+		{"POST", "/timer/1/pause", ""},
+		{"POST", "/timer/1/unpause", ""},
+		{"POST", "/timer/1/pin", ""},
+		{"POST", "/timers/order", "id=1"},
+		{"POST", "/timer/1/reset", ""},
+		{"POST", "/api/v1/timers/1/reset", ""},
+		{"PUT", "/api/v1/timers/1", `{"name":"Water the plants","frequency":"3 days","version":1}`},
+		{"DELETE", "/timer/1", ""},
+		{"POST", "/timer/1/restore", ""},
+		{"DELETE", "/api/v1/timers/1", ""},
+	} {
+		shifted.SetOffset(time.Duration(i+1) * time.Minute)
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if strings.HasPrefix(tt.body, "{") {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		if w.Code >= 300 {
+			t.Fatalf("%s %s: expected success, got %v: %s", tt.method, tt.target, w.Code, w.Body.String())
+		}
+
+		var c, u string
+		if err := db.QueryRow(`SELECT created_at, updated_at FROM timer WHERE id = 1`).Scan(&c, &u); err != nil {
+			t.Fatal(err)
+		}
+		if c != created {
+			t.Errorf("%s %s: expected created_at to stay %q, got %q", tt.method, tt.target, created, c)
+		}
+		if u <= updated {
+			t.Errorf("%s %s: expected updated_at to move on from %q, got %q", tt.method, tt.target, updated, u)
+		}
+		updated = u
+	}
+}
+
 // TestHTTPErrorInterface tests the HTTPError interface implementation
 func TestHTTPErrorInterface(t *testing.T) {
 	err := httpError{
@@ -460,3 +1029,88 @@ func TestHTTPErrorInterface(t *testing.T) {
 		t.Errorf("Interface implementation failed")
 	}
 }
+
+// TestTimerAccessibility tests that every button in a rendered card has an accessible name and every state is also text
+func TestTimerAccessibility(t *testing.T) {
+	timers := []CountDown{
+		{Id: 1, Name: "Gym", LastTime: time.Now().Add(-5 * 24 * time.Hour), Frequency: 2 * 24 * time.Hour, ReferenceURL: "https://example.com"},
+		{Id: 2, Name: "Coffee", LastTime: time.Now(), Frequency: 24 * time.Hour},
+	}
+
+	var buf strings.Builder
+	if err := (&Server{}).render(t.Context(), &buf, "homepage", homePageData{Cards: renderCards(t.Context(), (&Server{}).render, newTimerViews(timers))}); err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	body := buf.String()
+
+	// Buttons and links that only hold an icon need an aria-label instead.
+	controls := regexp.MustCompile(`(?s)<(button|a)\b([^>]*)>(.*?)</(?:button|a)>`)
+	tags := regexp.MustCompile(`<[^>]*>`)
+	for _, m := range controls.FindAllStringSubmatch(body, -1) {
+		if strings.Contains(m[2], `aria-label="`) || strings.TrimSpace(tags.ReplaceAllString(m[3], "")) != "" {
+			continue
+		}
+		t.Errorf("Expected an accessible name for <%s%s>", m[1], m[2])
+	}
+
+	for _, expected := range []string{`aria-label="Mark Gym as done"`, `aria-label="Delete Coffee"`, "Overdue by 3 days", "Do it again in 1 day"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in the rendered page", expected)
+		}
+	}
+	// Only the overdue timer is colored, and that color comes with the text above.
+	if n := strings.Count(body, `bg-danger-subtle">`); n != 1 {
+		t.Errorf("Expected 1 overdue timer, got %d", n)
+	}
+
+	// Labels point at inputs that exist exactly once.
+	for _, m := range regexp.MustCompile(`<label for="([^"]+)"`).FindAllStringSubmatch(body, -1) {
+		if n := strings.Count(body, `id="`+m[1]+`"`); n != 1 {
+			t.Errorf("Expected one element with id %q, got %d", m[1], n)
+		}
+	}
+}
+
+// TestRouting tests the status codes and Allow headers over a matrix of methods and paths
+func TestRouting(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	timerPath := fmt.Sprintf("/timer/%d", testTimers[0].Id)
+
+	tests := []struct {
+		method, path   string
+		expectedStatus int
+		expectedAllow  string
+	}{
+		{"GET", "/", http.StatusOK, ""},
+		{"POST", "/", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{"GET", timerPath, http.StatusOK, ""},
+		{"PUT", timerPath, http.StatusMethodNotAllowed, "DELETE, GET, HEAD"},
+		{"PATCH", timerPath, http.StatusMethodNotAllowed, "DELETE, GET, HEAD"},
+		{"GET", "/timer", http.StatusMethodNotAllowed, "POST"},
+		{"GET", timerPath + "/reset", http.StatusMethodNotAllowed, "POST"},
+		{"DELETE", timerPath + "/duplicate", http.StatusMethodNotAllowed, "POST"},
+		{"POST", "/forecast", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{"PUT", "/api/v1/timers", http.StatusMethodNotAllowed, "GET, HEAD, POST"},
+		{"POST", "/api/v1/timers/1", http.StatusMethodNotAllowed, "DELETE, GET, HEAD, PUT"},
+		{"GET", "/api/v1/timers/1/reset", http.StatusMethodNotAllowed, "POST"},
+		{"GET", "/nope", http.StatusNotFound, ""},
+		{"PUT", "/nope", http.StatusNotFound, ""},
+		{"GET", timerPath + "/nope", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			(&Server{db: db}).mux().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if allow := w.Header().Get("Allow"); allow != tt.expectedAllow {
+				t.Errorf("Expected Allow %q, got %q", tt.expectedAllow, allow)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// negotiate picks which of the offered media types to respond with based on the request's Accept header.
+// Offers are in order of preference, the first one is used when the client accepts anything.
+// When none of the offers are acceptable a 406 HTTPError is returned.
+func negotiate(w http.ResponseWriter, r *http.Request, offers ...string) (string, error) {
+	w.Header().Add("Vary", "Accept")
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return offers[0], nil
+	}
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, q := parseMediaRange(part)
+		if q <= 0 {
+			continue
+		}
+		for _, offer := range offers {
+			specificity := matchMediaRange(mediaRange, offer)
+			if specificity < 0 {
+				continue
+			}
+			// Prefer the highest quality, then the most specific range, then the server's order.
+			if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+				best, bestQ, bestSpecificity = offer, q, specificity
+			}
+		}
+	}
+
+	if best == "" {
+		return "", httpError{http.StatusNotAcceptable, fmt.Errorf("Cannot produce any of %q, available types are: %s", accept, strings.Join(offers, ", "))}
+	}
+	return best, nil
+}
+
+// parseMediaRange splits one element of an Accept header into its media range and quality value.
+func parseMediaRange(s string) (string, float64) {
+	params := strings.Split(s, ";")
+	q := 1.0
+	for _, p := range params[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(params[0])), q
+}
+
+// matchMediaRange reports how specifically mediaRange matches offer: 2 for an exact match, 1 for type/*, 0 for */*,
+// and -1 when it doesn't match at all.
+func matchMediaRange(mediaRange, offer string) int {
+	switch {
+	case mediaRange == offer:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestNegotiate tests picking a media type from an Accept header
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected string // Empty when a 406 is expected
+	}{
+		{"no accept header", "", "text/html"},
+		{"anything", "*/*", "text/html"},
+		{"json", "application/json", "application/json"},
+		{"html", "text/html", "text/html"},
+		{"browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html"},
+		{"curl preferring json", "application/json, */*;q=0.1", "application/json"},
+		{"quality beats order", "text/html;q=0.5, application/json", "application/json"},
+		{"type wildcard", "application/*", "application/json"},
+		{"case insensitive", "Application/JSON", "application/json"},
+		{"explicitly refused", "text/html;q=0", ""},
+		{"unsupported", "application/xml", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			got, err := negotiate(w, req, "text/html", "application/json")
+			if tt.expected == "" {
+				if httpErr, ok := err.(HTTPError); !ok || httpErr.HTTPStatusCode() != http.StatusNotAcceptable {
+					t.Errorf("Expected a 406 error, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("Expected %q, got %q, %v", tt.expected, got, err)
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Errorf("Expected Vary: Accept, got %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+// TestHandlersNegotiateContentType tests that GET / and GET /timer/{id} honor the Accept header
+func TestHandlersNegotiateContentType(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+
+	for _, target := range []string{"/", fmt.Sprintf("/timer/%d", testTimers[0].Id)} {
+		serve := func(accept string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			req.Header.Set("Accept", accept)
+			w := httptest.NewRecorder()
+			(&Server{db: db}).mux().ServeHTTP(w, req)
+			return w
+		}
+
+		t.Run(target+" html", func(t *testing.T) {
+			w := serve("text/html")
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status OK, got %v", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), `id="timer-`) {
+				t.Errorf("Expected the rendered template, got %q", w.Body.String())
+			}
+		})
+
+		t.Run(target+" json", func(t *testing.T) {
+			w := serve("application/json")
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status OK, got %v", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected Content-Type application/json, got %q", ct)
+			}
+			if !json.Valid(w.Body.Bytes()) || !strings.Contains(w.Body.String(), testTimers[0].Name) {
+				t.Errorf("Expected JSON containing %q, got %q", testTimers[0].Name, w.Body.String())
+			}
+		})
+
+		t.Run(target+" not acceptable", func(t *testing.T) {
+			if w := serve("application/xml"); w.Code != http.StatusNotAcceptable {
+				t.Errorf("Expected status NotAcceptable, got %v", w.Code)
+			}
+		})
+	}
+}
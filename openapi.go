@@ -0,0 +1,908 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// The subset of an OpenAPI 3 document that describes this server, served at GET /api/openapi.json.
+type openAPIDoc struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       openAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]openAPIOp  `json:"paths"` // Path, then lowercase method.
+	Components map[string]map[string]jsonSchema `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOp struct {
+	Summary     string                     `json:"summary"`
+	Parameters  []openAPIParam             `json:"parameters,omitempty"`
+	RequestBody *openAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"` // Keyed by status code.
+}
+
+type openAPIParam struct {
+	Name        string     `json:"name"`
+	In          string     `json:"in"`
+	Description string     `json:"description,omitempty"`
+	Required    bool       `json:"required,omitempty"`
+	Schema      jsonSchema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Required bool                    `json:"required,omitempty"`
+	Content  map[string]openAPIMedia `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                   `json:"description"`
+	Headers     map[string]openAPIHeader `json:"headers,omitempty"`
+	Content     map[string]openAPIMedia  `json:"content,omitempty"`
+}
+
+type openAPIHeader struct {
+	Description string     `json:"description,omitempty"`
+	Schema      jsonSchema `json:"schema"`
+}
+
+type openAPIMedia struct {
+	Schema jsonSchema `json:"schema"`
+}
+
+// A JSON Schema as a plain map, they are small enough here that types would only get in the way.
+type jsonSchema map[string]any
+
+// schemaOf describes how encoding/json marshals values of type t, failing for a type that it doesn't know how to.
+// The JSON types already say what their fields are, so the document is generated from them rather than written out.
+func schemaOf(t reflect.Type) (jsonSchema, error) {
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return jsonSchema{"type": "string", "format": "date-time"}, nil
+	case reflect.TypeFor[time.Duration]():
+		return jsonSchema{"type": "integer", "description": "Nanoseconds"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return jsonSchema{"type": "integer"}, nil
+	case reflect.Float64:
+		return jsonSchema{"type": "number"}, nil
+	case reflect.String:
+		return jsonSchema{"type": "string"}, nil
+	case reflect.Slice:
+		items, err := schemaOf(t.Elem())
+		return jsonSchema{"type": "array", "items": items}, err
+	case reflect.Map:
+		values, err := schemaOf(t.Elem())
+		return jsonSchema{"type": "object", "additionalProperties": values}, err
+	case reflect.Pointer:
+		return schemaOf(t.Elem())
+	case reflect.Struct:
+		properties := jsonSchema{}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			schema, err := schemaOf(f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+			}
+			properties[name] = schema
+		}
+		return jsonSchema{"type": "object", "properties": properties}, nil
+	}
+	return nil, fmt.Errorf("no JSON schema for %s", t)
+}
+
+// schemaBuilder is schemaOf for the many types in the document, keeping the first error rather than checking each.
+type schemaBuilder struct {
+	err error
+}
+
+// of is the schema of t, an empty object once t has none.
+func (b *schemaBuilder) of(t reflect.Type) jsonSchema {
+	s, err := schemaOf(t)
+	if err != nil {
+		b.err = cmp.Or(b.err, err)
+		return jsonSchema{"type": "object", "properties": jsonSchema{}}
+	}
+	return s
+}
+
+// timerSchema is the JSON form of a CountDown, including what its MarshalJSON adds.
+func timerSchema(b *schemaBuilder) jsonSchema {
+	s := b.of(reflect.TypeFor[CountDown]())
+	s["properties"].(jsonSchema)["nextDue"] = jsonSchema{"type": "string", "format": "date-time", "description": "Left out for timers that don't repeat"}
+	s["properties"].(jsonSchema)["color"] = jsonSchema{"type": "string", "pattern": colorPattern.String(), "description": "Like #1e90ff"}
+	s["properties"].(jsonSchema)["priority"] = jsonSchema{"type": "integer", "enum": []int{PriorityLow, PriorityNormal, PriorityHigh}, "description": "-1 for low and 1 for high, left out for normal"}
+	return s
+}
+
+// timerViewSchema is the JSON form of a timerView, a Timer with what the dashboard works out about it.
+func timerViewSchema(b *schemaBuilder) jsonSchema {
+	s := timerSchema(b)
+	props := s["properties"].(jsonSchema)
+	props["overdue"] = jsonSchema{"type": "boolean"}
+	props["state"] = jsonSchema{"type": "string", "enum": []dueState{stateOK, stateDueSoon, stateOverdue}, "description": "How urgent it is, due-soon within its grace of being due"}
+	props["stale"] = jsonSchema{"type": "boolean", "description": "Never done long after it was created"}
+	props["finished"] = jsonSchema{"type": "boolean", "description": "Past its endsAt or maxCompletions, it's never due again"}
+	props["paused"] = jsonSchema{"type": "boolean", "description": "Paused, it's never due until it's unpaused"}
+	props["schedule"] = jsonSchema{"type": "string", "description": "How often it repeats, e.g. every 2 weeks"}
+	props["dueStatus"] = jsonSchema{"type": "string", "description": "When it's due as the dashboard says it, e.g. Due in 3 days"}
+	return s
+}
+
+// apiTimerSchema is the JSON form of an apiTimer, a Timer with humanized companions unless ?humanize=false.
+func apiTimerSchema(b *schemaBuilder) jsonSchema {
+	s := timerSchema(b)
+	props := s["properties"].(jsonSchema)
+	props["nextDueHuman"] = jsonSchema{"type": "string", "description": "nextDue from now, e.g. in 3 days or 2 hours ago"}
+	props["sinceLastHuman"] = jsonSchema{"type": "string", "description": "lastTime from now, e.g. 3 days ago"}
+	props["frequencyHuman"] = jsonSchema{"type": "string", "description": "How often it repeats, e.g. every 2 weeks"}
+	return s
+}
+
+// dashboardSchema is the JSON form of a Dashboard, including what its MarshalJSON adds.
+func dashboardSchema(b *schemaBuilder) jsonSchema {
+	s := b.of(reflect.TypeFor[Dashboard]())
+	s["properties"].(jsonSchema)["url"] = jsonSchema{"type": "string"}
+	return s
+}
+
+func ref(name string) jsonSchema { return jsonSchema{"$ref": "#/components/schemas/" + name} }
+
+func jsonContent(s jsonSchema) map[string]openAPIMedia {
+	return map[string]openAPIMedia{"application/json": {s}}
+}
+
+var (
+	htmlContent = map[string]openAPIMedia{"text/html": {jsonSchema{"type": "string"}}}
+
+	// The timer's card is the body of the response, see timerUpdated.
+	timerUpdatedHeaders = map[string]openAPIHeader{"HX-Trigger": {Description: "timerUpdate/{id}, for anything else on the page that shows the timer, and timersChanged, for what shows them all", Schema: jsonSchema{"type": "string"}}}
+
+	idParam = openAPIParam{Name: "id", In: "path", Required: true, Schema: jsonSchema{"type": "integer"}}
+
+	humanizeParam = openAPIParam{
+		Name: "humanize", In: "query", Description: "false to leave out the humanized companions of the machine fields, which are in English",
+		Schema: jsonSchema{"type": "boolean", "default": true},
+	}
+
+	historyRangeParams = []openAPIParam{
+		{Name: "from", In: "query", Schema: jsonSchema{"type": "string", "format": "date"}, Description: "The first day to include, in -timezone"},
+		{Name: "to", In: "query", Schema: jsonSchema{"type": "string", "format": "date"}, Description: "The last day to include, in -timezone"},
+	}
+	historyCSVResponse = openAPIResponse{Description: "A header row of name, completed_at, kind and note then a row per completion, with completed_at in RFC 3339", Content: map[string]openAPIMedia{"text/csv": {jsonSchema{"type": "string"}}}}
+
+	badgeResponse = openAPIResponse{Description: "The badge", Content: map[string]openAPIMedia{"image/svg+xml": {jsonSchema{"type": "string"}}}}
+
+	doneAtSchema    = jsonSchema{"type": "string", "description": "A past time like 2025-03-10T21:30 in -timezone, or in RFC 3339. Now when it's left out, and one from before the timer was last done only goes in its history"}
+	snoozeForSchema = jsonSchema{"type": "string", "description": "Like 2d, 1w, 3 days or a Go duration like 3h"}
+
+	refreshHeader = map[string]openAPIHeader{"HX-Refresh": {Description: "true, so htmx reloads the page", Schema: jsonSchema{"type": "string"}}}
+
+	tokenParam = openAPIParam{Name: "token", In: "path", Required: true, Schema: jsonSchema{"type": "string"}}
+
+	idempotencyKeyParam = openAPIParam{
+		Name: "Idempotency-Key", In: "header", Schema: jsonSchema{"type": "string", "maxLength": idempotencyKeyMaxLen},
+		Description: "Repeating a create with the same key returns the first timer instead of creating another.",
+	}
+
+	locationHeader = map[string]openAPIHeader{"Location": {Description: "The URL of the timer", Schema: jsonSchema{"type": "string"}}}
+
+	// ErrorHTTPHandler routes fail with the message as plain text, the API routes with an Error object.
+	textError = openAPIResponse{Description: "The error message", Content: map[string]openAPIMedia{"text/plain": {jsonSchema{"type": "string"}}}}
+	jsonError = openAPIResponse{Description: "The error", Content: jsonContent(ref("Error"))}
+
+	// The fields of the create form.
+	timerFormSchema = jsonSchema{
+		"type":     "object",
+		"required": []string{"name", "lasttime"},
+		"properties": jsonSchema{
+			"name":           jsonSchema{"type": "string"},
+			"description":    jsonSchema{"type": "string"},
+			"referenceUrl":   jsonSchema{"type": "string", "format": "uri"},
+			"lasttime":       jsonSchema{"type": "string", "description": "A past time in -timezone as 2006-01-02T15:04"},
+			"dueTime":        jsonSchema{"type": "string", "description": "Optional time of day that the timer is due at, as 21:00"},
+			"frequency":      jsonSchema{"type": "string", "description": "Instead of frequencyValue and frequencyUnit, like 3 days, 1 month or 90m"},
+			"once":           jsonSchema{"type": "string", "enum": []string{"1"}, "description": "Set for a timer that doesn't repeat"},
+			"frequencyValue": jsonSchema{"type": "integer"},
+			"scheduleMode":   jsonSchema{"type": "string", "enum": []string{"text"}, "description": "Set to read schedule instead of frequencyValue and frequencyUnit"},
+			"schedule":       jsonSchema{"type": "string", "description": "Like every 3 weeks, fortnightly or every other Saturday, or a cron expression"},
+			"skipWeekends":   jsonSchema{"type": "string", "enum": []string{"1"}, "description": "Set for a timer that's due on the Monday after rather than on a weekend"},
+			"cron":           jsonSchema{"type": "string", "description": "Instead of a frequency, a 5 field cron expression like 0 9 * * mon,thu"},
+			"anchor":         jsonSchema{"type": "string", "format": "date", "description": "Optional date that the timer is due on and every period after, however late it's done"},
+			"endsAt":         jsonSchema{"type": "string", "format": "date", "description": "Optional last day that the timer is due on"},
+			"maxCompletions": jsonSchema{"type": "integer", "minimum": 1, "description": "Optional number of times to do the timer"},
+			"grace":          jsonSchema{"type": "string", "description": "Optional time before it's due that the timer is due soon, like 2 days or 12h"},
+			"priority":       jsonSchema{"type": "string", "enum": []string{"low", "normal", "high"}, "default": "normal"},
+			"tags":           jsonSchema{"type": "string", "description": "Optional comma separated tags, like house, garden"},
+			"color":          jsonSchema{"type": "string", "pattern": colorPattern.String(), "description": "Optional color to mark the timer with"},
+			"icon":           jsonSchema{"type": "string", "description": "Optional Bootstrap Icon, one of GET /icons"},
+			"targetCount":    jsonSchema{"type": "integer", "minimum": 1, "maximum": maxTargetCount, "description": "Optional number of times to do the timer every targetPeriod"},
+			"targetPeriod":   jsonSchema{"type": "string", "enum": targetPeriods, "default": UnitWeek},
+			"frequencyUnit": jsonSchema{
+				"type": "string", "enum": []string{UnitDay, UnitWeek, UnitMonth, UnitYear, UnitBusinessDay},
+				"description": "Months and years follow the calendar, business days count Monday to Friday. The length of a unit in nanoseconds is still accepted from older forms",
+			},
+			"idempotencyKey": jsonSchema{"type": "string", "description": "Same as the Idempotency-Key header"},
+		},
+	}
+)
+
+// openAPI describes every route registered in Server.routes, failing for a JSON type that schemaOf can't describe.
+func openAPI() (openAPIDoc, error) {
+	var b schemaBuilder
+	timerJSONOrHTML := map[string]openAPIMedia{"text/html": htmlContent["text/html"], "application/json": {ref("TimerView")}}
+
+	doc := openAPIDoc{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "Count up Timer", Version: "1"},
+		Components: map[string]map[string]jsonSchema{"schemas": {
+			"Timer":        timerSchema(&b),
+			"TimerView":    timerViewSchema(&b),
+			"APITimer":     apiTimerSchema(&b),
+			"ForecastWeek": b.of(reflect.TypeFor[ForecastWeek]()),
+			"Error":        b.of(reflect.TypeFor[apiError]()),
+			"Dashboard":    dashboardSchema(&b),
+			"Export":       b.of(reflect.TypeFor[exportFile]()),
+		}},
+		Paths: map[string]map[string]openAPIOp{
+			"/": {
+				"get": {
+					Summary: "The dashboard, or every timer as JSON for Accept: application/json",
+					Parameters: []openAPIParam{{
+						Name: "state", In: "query", Schema: jsonSchema{"type": "string", "enum": []dueState{stateOK, stateDueSoon, stateOverdue}},
+						Description: "Only the timers that are this urgent",
+					}, {
+						Name: "filter", In: "query", Schema: jsonSchema{"type": "string", "enum": []string{filterAll, string(stateOverdue), string(stateDueSoon)}},
+						Description: "The homepage's filter, in place of state when it's given",
+					}, {
+						Name: "tag", In: "query", Schema: jsonSchema{"type": "string"}, Description: "Only the timers with this tag",
+					}, {
+						Name: "q", In: "query", Schema: jsonSchema{"type": "string"}, Description: "Only the timers with this in their name or description, ignoring case",
+					}, {
+						Name: "sort", In: "query", Schema: jsonSchema{"type": "string", "enum": sortValues()},
+						Description: "List them in this order rather than the most urgent first, pinned timers still first",
+					}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The timers", Content: map[string]openAPIMedia{
+							"text/html":        htmlContent["text/html"],
+							"application/json": {jsonSchema{"type": "array", "items": ref("TimerView")}},
+						}},
+						"304": {Description: "Nothing changed, and no timer came due, since If-Modified-Since"},
+						"400": textError,
+						"406": textError,
+					},
+				},
+			},
+			"/timer": {
+				"post": {
+					Summary:     "Create a timer from the create form",
+					Parameters:  []openAPIParam{idempotencyKeyParam},
+					RequestBody: &openAPIBody{Required: true, Content: map[string]openAPIMedia{"application/x-www-form-urlencoded": {timerFormSchema}}},
+					Responses: map[string]openAPIResponse{
+						"201": {Description: "The new timer's fragment", Headers: map[string]openAPIHeader{
+							"Location":  locationHeader["Location"],
+							"HX-Reswap": {Description: "innerHTML for the first timer, so that it replaces the empty list's call to create one", Schema: jsonSchema{"type": "string"}},
+						}, Content: htmlContent},
+						"200": {Description: "The timer created by an earlier request with the same idempotency key", Headers: locationHeader, Content: htmlContent},
+						"400": {
+							Description: "The error messages, or for htmx requests the form again with each beside its field",
+							Headers: map[string]openAPIHeader{
+								"HX-Retarget": {Description: "The form, for htmx requests", Schema: jsonSchema{"type": "string"}},
+								"HX-Reswap":   {Description: "outerHTML, for htmx requests", Schema: jsonSchema{"type": "string"}},
+							},
+							Content: map[string]openAPIMedia{"text/plain": {jsonSchema{"type": "string"}}, "text/html": {jsonSchema{"type": "string"}}},
+						},
+					},
+				},
+			},
+			"/timer/{id}": {
+				"get": {
+					Summary:    "A timer's page, its fragment for htmx requests, or JSON for Accept: application/json",
+					Parameters: []openAPIParam{idParam},
+					Responses:  map[string]openAPIResponse{"200": {Description: "The timer", Content: timerJSONOrHTML}, "400": textError, "404": textError, "406": textError},
+				},
+				"delete": {
+					Summary:    "Delete a timer, it can be restored for 30 days",
+					Parameters: []openAPIParam{idParam},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "Deleted, with an Undo toast for htmx requests", Content: htmlContent},
+						"204": {Description: "Deleted"},
+						"400": textError,
+						"404": textError,
+					},
+				},
+			},
+			"/timer/{id}/restore": {
+				"post": {
+					Summary:    "Undo deleting a timer",
+					Parameters: []openAPIParam{idParam},
+					Responses:  map[string]openAPIResponse{"200": {Description: "The restored timer's fragment", Content: htmlContent}, "400": textError, "404": textError},
+				},
+			},
+			"/timer/{id}/reset": {
+				"post": {
+					Summary: "Record that a timer was done now, or earlier",
+					Parameters: []openAPIParam{idParam, {
+						Name: "at", In: "query", Schema: doneAtSchema,
+						Description: "When it was done, instead of the form field",
+					}},
+					RequestBody: &openAPIBody{Content: map[string]openAPIMedia{"application/x-www-form-urlencoded": {jsonSchema{
+						"type": "object", "properties": jsonSchema{"at": doneAtSchema, "note": jsonSchema{"type": "string", "maxLength": maxNoteLength, "description": "Optional note for the timer's history"}, "kind": jsonSchema{"type": "string", "enum": []string{CompletionDone, CompletionPartial}, "default": CompletionDone, "description": "partial when it was only partly done"}},
+					}}}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The timer's card, reset", Headers: timerUpdatedHeaders, Content: htmlContent},
+						"400": textError,
+						"404": textError,
+						"409": textError,
+					},
+				},
+			},
+			"/timer/{id}/history": {
+				"get": {
+					Summary:    "Each time a timer was done, newest first, as HTML or as JSON for Accept: application/json",
+					Parameters: []openAPIParam{idParam},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The completions", Content: map[string]openAPIMedia{
+							"text/html":        htmlContent["text/html"],
+							"application/json": {jsonSchema{"type": "array", "items": b.of(reflect.TypeFor[completion]())}},
+						}},
+						"400": textError,
+						"404": textError,
+						"406": textError,
+					},
+				},
+				"post": {
+					Summary:    "Add times that a timer was done in the past, all of them or none if one is in the future or within a minute of another. Its last time moves on to the latest",
+					Parameters: []openAPIParam{idParam},
+					RequestBody: &openAPIBody{Content: map[string]openAPIMedia{
+						"application/x-www-form-urlencoded": {jsonSchema{"type": "object", "properties": jsonSchema{"completed_at": jsonSchema{"type": "array", "items": doneAtSchema, "maxItems": maxBackfill}}}},
+						"application/json":                  {jsonSchema{"type": "array", "items": doneAtSchema, "maxItems": maxBackfill}},
+					}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The history with the times added, as with GET", Headers: map[string]openAPIHeader{"HX-Trigger": {Description: "timerBackfilled/{id}, for the rest of the timer's page but its history, and timersChanged, for what shows them all", Schema: jsonSchema{"type": "string"}}}, Content: map[string]openAPIMedia{
+							"text/html":        htmlContent["text/html"],
+							"application/json": {jsonSchema{"type": "array", "items": b.of(reflect.TypeFor[completion]())}},
+						}},
+						"400": textError,
+						"404": textError,
+						"406": textError,
+						"409": textError,
+					},
+				},
+			},
+			"/timer/{id}/stats": {
+				"get": {
+					Summary:    "How well a timer is being kept up with, from its history",
+					Parameters: []openAPIParam{idParam},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The stats", Content: jsonContent(b.of(reflect.TypeFor[timerStats]()))},
+						"400": textError,
+						"404": textError,
+						"406": textError,
+					},
+				},
+			},
+			"/timer/{id}/history.csv": {
+				"get": {
+					Summary:    "Each time a timer was done, oldest first, as a CSV download",
+					Parameters: append([]openAPIParam{idParam}, historyRangeParams...),
+					Responses: map[string]openAPIResponse{
+						"200": historyCSVResponse,
+						"400": textError,
+						"404": textError,
+					},
+				},
+			},
+			"/timer/{id}/heatmap": {
+				"get": {
+					Summary: "How many times a timer was done on each day, for a heatmap, oldest first and with every day in it",
+					Parameters: []openAPIParam{
+						idParam,
+						{Name: "days", In: "query", Schema: jsonSchema{"type": "integer", "minimum": 1, "maximum": heatmapMaxDays, "default": 365}, Description: "How many days up to and including today"},
+						{Name: "tz", In: "query", Schema: jsonSchema{"type": "string"}, Description: "The time zone that days are in, like America/New_York. -timezone when it's left out"},
+					},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The days", Content: jsonContent(jsonSchema{"type": "array", "items": b.of(reflect.TypeFor[heatmapDay]())})},
+						"400": textError,
+						"404": textError,
+						"406": textError,
+					},
+				},
+			},
+			"/timer/{id}/sparkline": {
+				"get": {
+					Summary: "The hours between a timer's last few completions, oldest first, and how many it's meant to have between them, for a bar chart",
+					Parameters: []openAPIParam{
+						idParam,
+						{Name: "n", In: "query", Schema: jsonSchema{"type": "integer", "minimum": 2, "maximum": sparklineMax, "default": sparklineDefault}, Description: "How many of the last completions, there's a gap fewer than that"},
+					},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The gaps, none until it was done twice", Content: jsonContent(b.of(reflect.TypeFor[sparkline]()))},
+						"400": textError,
+						"404": textError,
+						"406": textError,
+					},
+				},
+			},
+			"/timer/{id}/badge.svg": {
+				"get": {
+					Summary: "A shields.io style badge of when a timer was done or how overdue it is, colored by how urgent it is",
+					Parameters: []openAPIParam{
+						idParam,
+						{Name: "label", In: "query", Schema: jsonSchema{"type": "string"}, Description: "The left half's text, the timer's name by default"},
+					},
+					Responses: map[string]openAPIResponse{
+						"200": badgeResponse,
+						"400": textError,
+						"404": {Description: "A grey badge saying that the timer wasn't found", Content: badgeResponse.Content},
+					},
+				},
+			},
+			"/timer/{id}/widget": {
+				"get": {
+					Summary: "A timer on a page of its own that reloads itself, for an iframe on another site",
+					Parameters: []openAPIParam{
+						idParam,
+						{Name: "compact", In: "query", Schema: jsonSchema{"type": "boolean", "default": false}, Description: "On a single line"},
+					},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The widget, which any site can frame", Content: htmlContent},
+						"400": textError,
+						"404": textError,
+						"406": textError,
+					},
+				},
+			},
+			"/timer/{id}/skip": {
+				"post": {
+					Summary:    "Let the next occurrence of a timer go without doing it, so it's due a period later",
+					Parameters: []openAPIParam{idParam},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The timer's card, skipped", Headers: timerUpdatedHeaders, Content: htmlContent},
+						"400": textError,
+						"404": textError,
+						"409": textError,
+					},
+				},
+			},
+			"/timer/{id}/pin": {
+				"post": {
+					Summary:    "Pin a timer to the top of the homepage, or unpin it when it's pinned",
+					Parameters: []openAPIParam{idParam},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The timer, pinned or not", Headers: refreshHeader, Content: jsonContent(ref("Timer"))},
+						"400": textError,
+						"404": textError,
+					},
+				},
+			},
+			"/timer/{id}/snooze": {
+				"post": {
+					Summary: "Put a timer off for a while without doing it, until it's reset",
+					Parameters: []openAPIParam{idParam, {
+						Name: "for", In: "query", Schema: snoozeForSchema,
+						Description: "How long to snooze for, instead of the form field",
+					}},
+					RequestBody: &openAPIBody{Content: map[string]openAPIMedia{"application/x-www-form-urlencoded": {jsonSchema{
+						"type": "object", "properties": jsonSchema{"for": snoozeForSchema},
+					}}}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The timer's card, snoozed", Headers: timerUpdatedHeaders, Content: htmlContent},
+						"400": textError,
+						"404": textError,
+						"409": textError,
+					},
+				},
+			},
+			"/timer/{id}/pause": {
+				"post": {
+					Summary:    "Stop a timer from coming due until it's unpaused",
+					Parameters: []openAPIParam{idParam},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The timer's card, paused or already was", Headers: timerUpdatedHeaders, Content: htmlContent},
+						"400": textError,
+						"404": textError,
+					},
+				},
+			},
+			"/timer/{id}/unpause": {
+				"post": {
+					Summary: "Let a paused timer come due again",
+					Parameters: []openAPIParam{idParam, {
+						Name: "shift", In: "query", Schema: jsonSchema{"type": "boolean"},
+						Description: "Put the schedule back by as long as it was paused, in pausedFor, so that it isn't due straight away",
+					}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The timer's card, unpaused or wasn't paused", Headers: timerUpdatedHeaders, Content: htmlContent},
+						"400": textError,
+						"404": textError,
+					},
+				},
+			},
+			"/timer/{id}/duplicate": {
+				"post": {
+					Summary:    "Copy a timer, the copy hasn't been done yet",
+					Parameters: []openAPIParam{idParam},
+					Responses:  map[string]openAPIResponse{"201": {Description: "The copy's fragment", Headers: locationHeader, Content: htmlContent}, "400": textError, "404": textError},
+				},
+			},
+			"/timers/order": {
+				"post": {
+					Summary: "Put timers in the homepage's order, in the positions they had between them",
+					RequestBody: &openAPIBody{Required: true, Content: map[string]openAPIMedia{"application/x-www-form-urlencoded": {jsonSchema{
+						"type": "object", "required": []string{"id"},
+						"properties": jsonSchema{"id": jsonSchema{"type": "array", "items": jsonSchema{"type": "integer"}, "description": "The timers' ids, first to last"}},
+					}}}},
+					Responses: map[string]openAPIResponse{
+						"204": {Description: "Reordered"},
+						"400": textError,
+						"404": textError,
+					},
+				},
+			},
+			"/pause-all": {
+				"post": {
+					Summary:   "Go on vacation: pause every repeating timer that isn't paused, until POST /resume-all. Does nothing while on vacation",
+					Responses: map[string]openAPIResponse{"200": {Description: "On vacation", Headers: refreshHeader}},
+				},
+			},
+			"/resume-all": {
+				"post": {
+					Summary:   "End the vacation: unpause the timers that it paused, with their schedules put back by its length. Does nothing when not on vacation",
+					Responses: map[string]openAPIResponse{"200": {Description: "Back from vacation", Headers: refreshHeader}},
+				},
+			},
+			"/tags": {
+				"get": {
+					Summary: "The tags in use, as datalist options or as JSON for Accept: application/json",
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The tags by name", Content: map[string]openAPIMedia{
+							"text/html":        htmlContent["text/html"],
+							"application/json": {jsonSchema{"type": "array", "items": jsonSchema{"type": "string"}}},
+						}},
+						"406": textError,
+					},
+				},
+			},
+			"/icons": {
+				"get": {
+					Summary:    "The icons that a timer can have, as datalist options or as JSON for Accept: application/json",
+					Parameters: []openAPIParam{{Name: "q", In: "query", Schema: jsonSchema{"type": "string"}, Description: "Only the icons with this in their name"}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The icons by name", Content: map[string]openAPIMedia{
+							"text/html":        htmlContent["text/html"],
+							"application/json": {jsonSchema{"type": "array", "items": jsonSchema{"type": "string"}}},
+						}},
+						"406": textError,
+					},
+				},
+			},
+			"/forecast": {
+				"get": {
+					Summary:    "When timers come due over the coming weeks",
+					Parameters: []openAPIParam{{Name: "weeks", In: "query", Schema: jsonSchema{"type": "integer", "minimum": 1, "maximum": forecastMaxWeeks, "default": 4}}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The forecast", Content: map[string]openAPIMedia{
+							"text/html":        htmlContent["text/html"],
+							"application/json": {jsonSchema{"type": "array", "items": ref("ForecastWeek")}},
+						}},
+						"400": textError,
+						"406": textError,
+					},
+				},
+			},
+			"/print": {
+				"get": {
+					Summary:    "A chore sheet to print, of the timers that aren't paused or finished by when they're due",
+					Parameters: []openAPIParam{{Name: "tag", In: "query", Schema: jsonSchema{"type": "string"}, Description: "Only the timers with this tag"}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The chore sheet", Content: htmlContent},
+						"406": textError,
+					},
+				},
+			},
+			"/timers": {
+				"get": {
+					Summary: "The next chunk of the homepage's list in its default order or the user's own, as a fragment that ends with what loads the chunk after it",
+					Parameters: []openAPIParam{{
+						Name: "cursor", In: "query", Required: true, Schema: jsonSchema{"type": "string"}, Description: "Where the chunk before ended, from its URL",
+					}, {
+						Name: "sort", In: "query", Schema: jsonSchema{"type": "string", "enum": []string{sortPosition}}, Description: "The user's own order rather than the most urgent first",
+					}, {
+						Name: "tag", In: "query", Schema: jsonSchema{"type": "string"}, Description: "Only the timers with this tag",
+					}, {
+						Name: "q", In: "query", Schema: jsonSchema{"type": "string"}, Description: "Only the timers with this in their name or description, ignoring case",
+					}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The chunk, empty after the end of the list", Content: htmlContent},
+						"400": textError,
+					},
+				},
+			},
+			"/timers/recent-completions": {
+				"get": {
+					Summary: "The latest times that timers which haven't been deleted were done, newest first, as the homepage's fragment",
+					Parameters: []openAPIParam{{
+						Name: "limit", In: "query", Schema: jsonSchema{"type": "integer", "minimum": 1, "maximum": maxRecentLimit, "default": defaultRecentLimit}, Description: "How many to list",
+					}, {
+						Name: "skipped", In: "query", Schema: jsonSchema{"type": "boolean", "default": false}, Description: "Whether to list skipped occurrences too",
+					}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The completions", Content: htmlContent},
+						"400": textError,
+					},
+				},
+			},
+			"/summary": {
+				"get": {
+					Summary: "How many timers are overdue, due later today and ok, leaving out paused, finished and deleted ones, as the homepage's badges or as JSON for Accept: application/json",
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The summary", Content: map[string]openAPIMedia{
+							"text/html":        htmlContent["text/html"],
+							"application/json": {b.of(reflect.TypeFor[timerSummary]())},
+						}, Headers: map[string]openAPIHeader{"HX-Trigger": {Description: `With text/html, {"pageTitle": ...}, the homepage's title with the number of overdue timers in front`, Schema: jsonSchema{"type": "string"}}}},
+						"406": textError,
+					},
+				},
+			},
+			"/schedule/preview": {
+				"get": {
+					Summary:    "How the create form's schedule text will be read, or why it can't be, as a fragment",
+					Parameters: []openAPIParam{{Name: "schedule", In: "query", Schema: jsonSchema{"type": "string"}}},
+					Responses:  map[string]openAPIResponse{"200": {Description: "The preview, empty for no text", Content: htmlContent}},
+				},
+			},
+			"/admin/env": {
+				"get": {
+					Summary: "The effective flags, schema and sqlite versions, pragmas, templates and features, with secrets redacted",
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The report", Content: jsonContent(b.of(reflect.TypeFor[envReport]()))},
+						"401": textError,
+					},
+				},
+			},
+			"/admin/caldav-sync": {
+				"post": {
+					Summary: "Push every timer to the -caldav-url calendar now and delete the events of deleted timers",
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "What was synced", Content: jsonContent(b.of(reflect.TypeFor[caldavReconcileResult]()))},
+						"404": textError,
+					},
+				},
+			},
+			"/admin/time-offset": {
+				"post": {
+					Summary: "Shift the server's time from the real time, only with -demo",
+					RequestBody: &openAPIBody{Required: true, Content: map[string]openAPIMedia{"application/x-www-form-urlencoded": {jsonSchema{
+						"type": "object", "required": []string{"offset"},
+						"properties": jsonSchema{"offset": jsonSchema{"type": "string", "description": "A Go duration like 72h or -30m, 0 for the real time"}},
+					}}}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The new offset and time", Content: jsonContent(b.of(reflect.TypeFor[timeOffset]()))},
+						"400": textError,
+						"401": textError,
+						"404": textError,
+					},
+				},
+			},
+			"/history.csv": {
+				"get": {
+					Summary:    "Each time any timer was done, oldest first, as a CSV download. Deleted timers are left out",
+					Parameters: historyRangeParams,
+					Responses: map[string]openAPIResponse{
+						"200": historyCSVResponse,
+						"400": textError,
+					},
+				},
+			},
+			"/export": {
+				"get": {
+					Summary:   "Every timer as JSON that POST /import reads back",
+					Responses: map[string]openAPIResponse{"200": {Description: "The export", Content: jsonContent(ref("Export"))}},
+				},
+			},
+			"/import": {
+				"post": {
+					Summary: "Add the timers from an export in one transaction",
+					Parameters: []openAPIParam{{
+						Name: "conflict", In: "query", Description: "What to do with a timer named like an existing one",
+						Schema: jsonSchema{"type": "string", "enum": []string{ConflictSkip, ConflictReplace, ConflictDuplicate}, "default": ConflictSkip},
+					}},
+					RequestBody: &openAPIBody{Required: true, Content: map[string]openAPIMedia{
+						"application/json":    {ref("Export")},
+						"multipart/form-data": {jsonSchema{"type": "object", "properties": jsonSchema{"file": jsonSchema{"type": "string", "format": "binary"}}}},
+					}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "What happened to the timers", Content: jsonContent(b.of(reflect.TypeFor[importResult]()))},
+						"400": textError,
+						"409": textError,
+					},
+				},
+			},
+			"/import.csv": {
+				"post": {
+					Summary: "Add timers from the rows of a CSV with a header row in one transaction, reporting the rows left out",
+					Parameters: []openAPIParam{
+						{
+							Name: "mapping", In: "query", Description: "Headers for fields named differently, like name:Chore,frequency:Every",
+							Schema: jsonSchema{"type": "string"},
+						},
+						{
+							Name: "strict", In: "query", Description: "1 to import nothing if any row can't be parsed",
+							Schema: jsonSchema{"type": "string", "enum": []string{"1"}},
+						},
+					},
+					RequestBody: &openAPIBody{Required: true, Content: map[string]openAPIMedia{
+						"multipart/form-data": {jsonSchema{"type": "object", "properties": jsonSchema{"file": jsonSchema{
+							"type": "string", "format": "binary",
+							"description": "Columns named name, description, lasttime (like 2025-03-05) and frequency (like 3 days), in any case",
+						}}}},
+					}},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The timers created and the rows left out", Content: jsonContent(b.of(reflect.TypeFor[csvImportResult]()))},
+						"400": textError,
+						"409": textError,
+					},
+				},
+			},
+			"/export/snapshot.zip": {
+				"get": {
+					Summary:   "A zip of static HTML pages for every timer",
+					Responses: map[string]openAPIResponse{"200": {Description: "The snapshot", Content: map[string]openAPIMedia{"application/zip": {jsonSchema{"type": "string", "format": "binary"}}}}},
+				},
+			},
+			"/d/{token}": {
+				"get": {
+					Summary:    "Redirects to the dashboard's URL, which ends in a slash",
+					Parameters: []openAPIParam{tokenParam},
+					Responses:  map[string]openAPIResponse{"301": {Description: "The dashboard is at /d/{token}/"}},
+				},
+			},
+			"/d/{token}/": {
+				"get": {
+					Summary:    "A public dashboard, read-only and with only its own timers",
+					Parameters: []openAPIParam{tokenParam},
+					Responses:  map[string]openAPIResponse{"200": {Description: "The dashboard", Content: htmlContent}, "404": textError},
+				},
+			},
+			"/d/{token}/{page}": {
+				"get": {
+					Summary:    "A page of a public dashboard, index.html or timer-{id}.html for a timer on it",
+					Parameters: []openAPIParam{tokenParam, {Name: "page", In: "path", Required: true, Schema: jsonSchema{"type": "string"}}},
+					Responses:  map[string]openAPIResponse{"200": {Description: "The page", Content: htmlContent}, "404": textError},
+				},
+			},
+			"/dashboards": {
+				"get": {
+					Summary:   "The page for managing public dashboards",
+					Responses: map[string]openAPIResponse{"200": {Description: "The page", Content: htmlContent}},
+				},
+				"post": {
+					Summary: "Create a public dashboard from the page's form, of either the checked timers or a tag",
+					RequestBody: &openAPIBody{Required: true, Content: map[string]openAPIMedia{"application/x-www-form-urlencoded": {jsonSchema{
+						"type": "object",
+						"properties": jsonSchema{
+							"name":    jsonSchema{"type": "string"},
+							"tag":     jsonSchema{"type": "string"},
+							"timerId": jsonSchema{"type": "array", "items": jsonSchema{"type": "integer"}},
+						},
+						"required": []string{"name"},
+					}}}},
+					Responses: map[string]openAPIResponse{
+						"201": {Description: "The list of dashboards with the new one in it", Headers: map[string]openAPIHeader{"Location": {Description: "The dashboard's public URL", Schema: jsonSchema{"type": "string"}}}, Content: htmlContent},
+						"400": textError,
+					},
+				},
+			},
+			"/dashboards/list": {
+				"get": {
+					Summary:   "The list of public dashboards for their page, which needs the API token since it has their tokens",
+					Responses: map[string]openAPIResponse{"200": {Description: "The list", Content: htmlContent}, "401": textError},
+				},
+			},
+			"/api/v1/dashboards": {
+				"get": {
+					Summary:   "Every public dashboard, which needs the API token since it has their tokens",
+					Responses: map[string]openAPIResponse{"200": {Description: "The dashboards", Content: jsonContent(jsonSchema{"type": "array", "items": ref("Dashboard")})}, "401": jsonError},
+				},
+				"post": {
+					Summary:     "Create a public dashboard of the timers with timerIds or of the ones tagged tag, the token is generated",
+					RequestBody: &openAPIBody{Required: true, Content: jsonContent(ref("Dashboard"))},
+					Responses: map[string]openAPIResponse{
+						"201": {Description: "The new dashboard", Headers: map[string]openAPIHeader{"Location": {Description: "The dashboard's public URL", Schema: jsonSchema{"type": "string"}}}, Content: jsonContent(ref("Dashboard"))},
+						"400": jsonError,
+					},
+				},
+			},
+			"/api/v1/dashboards/{id}": {
+				"delete": {
+					Summary:    "Revoke a public dashboard",
+					Parameters: []openAPIParam{idParam},
+					Responses:  map[string]openAPIResponse{"204": {Description: "Deleted"}, "400": jsonError, "404": jsonError},
+				},
+			},
+			"/api/openapi.json": {
+				"get": {
+					Summary:   "This document",
+					Responses: map[string]openAPIResponse{"200": {Description: "An OpenAPI 3 document", Content: jsonContent(jsonSchema{"type": "object"})}},
+				},
+			},
+			"/api/instance-stats": {
+				"get": {
+					Summary: "The version, uptime, timer counts, database size and last notifications of this server, for `countup fleet`. Only served with -instance-stats",
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The stats", Content: jsonContent(b.of(reflect.TypeFor[instanceStats]()))},
+						"401": jsonError,
+						"404": jsonError,
+					},
+				},
+			},
+			"/api/v1/timers": {
+				"get": {
+					Summary:    "Every timer",
+					Parameters: []openAPIParam{humanizeParam},
+					Responses:  map[string]openAPIResponse{"200": {Description: "The timers", Content: jsonContent(jsonSchema{"type": "array", "items": ref("APITimer")})}},
+				},
+				"post": {
+					Summary:     "Create a timer",
+					Parameters:  []openAPIParam{idempotencyKeyParam, humanizeParam},
+					RequestBody: &openAPIBody{Required: true, Content: jsonContent(ref("Timer"))},
+					Responses: map[string]openAPIResponse{
+						"201": {Description: "The new timer", Headers: locationHeader, Content: jsonContent(ref("APITimer"))},
+						"200": {Description: "The timer created by an earlier request with the same idempotency key", Headers: locationHeader, Content: jsonContent(ref("APITimer"))},
+						"400": jsonError,
+					},
+				},
+			},
+			"/api/v1/timers/{id}": {
+				"get": {
+					Summary:    "A timer",
+					Parameters: []openAPIParam{idParam, humanizeParam},
+					Responses:  map[string]openAPIResponse{"200": {Description: "The timer", Content: jsonContent(ref("APITimer"))}, "400": jsonError, "404": jsonError},
+				},
+				"put": {
+					Summary:     "Replace every field of a timer. The body's version has to be the timer's, which goes up by one",
+					Parameters:  []openAPIParam{idParam, humanizeParam},
+					RequestBody: &openAPIBody{Required: true, Content: jsonContent(ref("Timer"))},
+					Responses: map[string]openAPIResponse{
+						"200": {Description: "The updated timer", Content: jsonContent(ref("APITimer"))},
+						"400": jsonError,
+						"404": jsonError,
+						"409": {Description: "The timer was edited since the version in the body, it's the timer as it is now", Content: jsonContent(ref("APITimer"))},
+						"428": jsonError,
+					},
+				},
+				"delete": {
+					Summary:    "Delete a timer",
+					Parameters: []openAPIParam{idParam},
+					Responses:  map[string]openAPIResponse{"204": {Description: "Deleted"}, "400": jsonError, "404": jsonError},
+				},
+			},
+			"/api/v1/timers/{id}/reset": {
+				"post": {
+					Summary:    "Record that a timer was done now",
+					Parameters: []openAPIParam{idParam, humanizeParam},
+					Responses:  map[string]openAPIResponse{"200": {Description: "The reset timer", Content: jsonContent(ref("APITimer"))}, "400": jsonError, "404": jsonError, "409": jsonError},
+				},
+			},
+		},
+	}
+	return doc, b.err
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOpenAPIDocument tests that the served document parses and describes exactly the registered routes
+func TestOpenAPIDocument(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+
+	req := httptest.NewRequest("GET", "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	decodeResponse(t, w, &doc)
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("Expected an OpenAPI 3 document, got version %q", doc.OpenAPI)
+	}
+
+	// Every registered route is documented...
+	registered := map[string]bool{}
+	for _, pattern := range s.routes().patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		path = strings.TrimSuffix(path, "{$}")
+		registered[strings.ToLower(method)+" "+path] = true
+		if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("Route %q is not in the document", pattern)
+		}
+	}
+	// ...and nothing else is.
+	for path, ops := range doc.Paths {
+		for method, op := range ops {
+			if !registered[method+" "+path] {
+				t.Errorf("The document has %s %s which isn't a route", method, path)
+			}
+			if _, ok := op["responses"]; !ok {
+				t.Errorf("%s %s has no responses", method, path)
+			}
+		}
+	}
+
+	// The Timer schemas have the same fields that a timer and its view marshal with. A paused timer has no nextDue, so
+	// pausedAt and pausedFor come from a copy that is.
+	c := CountDown{LastTime: time.Now(), Frequency: time.Hour, FrequencyValue: 1, FrequencyUnit: UnitDay, ReferenceURL: "https://example.com", CreatedAt: time.Now(), DueTimeOfDay: new(int), Cron: "0 9 * * mon,thu", SkipWeekends: true, Anchor: time.Now(),
+		EndsAt: time.Now(), MaxCompletions: 3, Completions: 1, SkippedUntil: time.Now(), SnoozedUntil: time.Now(), Grace: time.Hour, Priority: PriorityHigh, Tags: []string{"car"}, Color: "#1e90ff", Icon: "car-front", Pinned: true, UpdatedAt: time.Now(), Version: 2, TimesDone: 4, Streak: 3,
+		TargetCount: 3, TargetPeriod: UnitWeek, DoneThisPeriod: 2}
+	paused := c
+	paused.PausedAt, paused.PausedFor = time.Now(), time.Hour
+	for schema, vs := range map[string][]any{
+		"Timer":     {c, paused},
+		"TimerView": {newTimerView(c), newTimerView(paused)},
+		"APITimer":  {apiTimer{c, true}, apiTimer{paused, true}},
+	} {
+		fields := map[string]any{}
+		for _, v := range vs {
+			b, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if err := json.Unmarshal(b, &fields); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+		}
+		var expected, got []string
+		for name := range fields {
+			expected = append(expected, name)
+		}
+		for name := range doc.Components.Schemas[schema].Properties {
+			got = append(got, name)
+		}
+		sort.Strings(expected)
+		sort.Strings(got)
+		if strings.Join(expected, ",") != strings.Join(got, ",") {
+			t.Errorf("Expected %s properties %v, got %v", schema, expected, got)
+		}
+	}
+}
+
+// TestSchemaOfUnknownType tests that a JSON type with a field that schemaOf can't describe is an error naming the field
+func TestSchemaOfUnknownType(t *testing.T) {
+	type withChannel struct {
+		Name    string   `json:"name"`
+		Updates chan int `json:"updates"`
+	}
+	if _, err := schemaOf(reflect.TypeFor[[]withChannel]()); err == nil || !strings.Contains(err.Error(), "withChannel.Updates") {
+		t.Errorf("Expected an error for the channel, got %v", err)
+	}
+
+	var b schemaBuilder
+	if s := b.of(reflect.TypeFor[withChannel]()); b.err == nil || s["properties"] == nil {
+		t.Errorf("Expected an empty object and the error, got %v, %v", s, b.err)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Operations that rewrite timers wholesale take the operation lock so that they never run at the same time as each
+// other, whether from this server, another one on the same database, or a command. Ordinary edits don't take it.
+const (
+	OperationMigrate  = "migrate"
+	OperationRecreate = "recreate" // -db-recreate
+	OperationImport   = "import"
+	OperationPurge    = "purge" // Of timers deleted more than deletedTimerRetention ago.
+)
+
+var (
+	// How often a holder touches its heartbeat, and how long after the last one that the lock can be taken over from
+	// a holder that must have died.
+	operationLockHeartbeat = 10 * time.Second
+	operationLockTimeout   = time.Minute
+)
+
+// The lock is a single row, which exists only while it's held. The table isn't a migration so that migrations can
+// take the lock too.
+const operationLockTable = `CREATE TABLE IF NOT EXISTS operation_lock (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	owner TEXT NOT NULL,
+	operation TEXT NOT NULL,
+	acquired_at TEXT NOT NULL,
+	heartbeat TEXT NOT NULL
+);`
+
+// Distinguishes the holders in one process, tests have more than one.
+var operationLockOwners atomic.Int64
+
+// operationLock is a held lock, see acquireOperationLock.
+type operationLock struct {
+	db    *sql.DB
+	owner string
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// acquireOperationLock takes the operation lock for operation, or fails with a 409 naming who has it. A lock whose
+// heartbeat is older than operationLockTimeout is taken over. The lock must be released.
+func acquireOperationLock(ctx context.Context, db *sql.DB, operation string) (*operationLock, error) {
+	if _, err := db.ExecContext(ctx, operationLockTable); err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	l := &operationLock{
+		db:    db,
+		owner: fmt.Sprintf("%s:%d:%d", host, os.Getpid(), operationLockOwners.Add(1)),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	// One statement, so that two acquirers can't both see the lock free.
+	now := updatedAt()
+	stale := clock.Now().Add(-operationLockTimeout).UTC().Format(updatedAtLayout)
+	res, err := db.ExecContext(ctx, `
+	INSERT INTO operation_lock (id, owner, operation, acquired_at, heartbeat) VALUES (1, ?, ?, ?, ?)
+	ON CONFLICT (id) DO UPDATE SET owner = excluded.owner, operation = excluded.operation, acquired_at = excluded.acquired_at, heartbeat = excluded.heartbeat
+	WHERE heartbeat < ?`, l.owner, operation, now, now, stale)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, heldLockError(ctx, db, operation)
+	}
+
+	go l.beat()
+	return l, nil
+}
+
+// heldLockError describes who holds the lock that operation couldn't get.
+func heldLockError(ctx context.Context, db *sql.DB, operation string) error {
+	var owner, held, acquiredAt, heartbeat string
+	err := db.QueryRowContext(ctx, `SELECT owner, operation, acquired_at, heartbeat FROM operation_lock`).Scan(&owner, &held, &acquiredAt, &heartbeat)
+	if err == sql.ErrNoRows {
+		// Released since, but it was held.
+		return httpError{http.StatusConflict, fmt.Errorf("Can't %s while another operation is running, try again", operation)}
+	} else if err != nil {
+		return err
+	}
+	return httpError{http.StatusConflict, fmt.Errorf("Can't %s while %s is running, held by %s since %s with its last heartbeat at %s", operation, held, owner, acquiredAt, heartbeat)}
+}
+
+// beat keeps the lock's heartbeat fresh until release.
+func (l *operationLock) beat() {
+	defer close(l.done)
+	t := time.NewTicker(operationLockHeartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-t.C:
+			if _, err := l.db.Exec(`UPDATE operation_lock SET heartbeat = ? WHERE owner = ?`, updatedAt(), l.owner); err != nil {
+				log.Printf("Error updating the operation lock's heartbeat: %v\n", err)
+			}
+		}
+	}
+}
+
+// release gives the lock up. It's a no-op for a lock that was taken over. Failing to only delays the next operation
+// until the lock times out, so that's logged rather than returned.
+func (l *operationLock) release() {
+	close(l.stop)
+	<-l.done
+	if _, err := l.db.Exec(`DELETE FROM operation_lock WHERE owner = ?`, l.owner); err != nil {
+		log.Printf("Error releasing the operation lock: %v\n", err)
+	}
+}
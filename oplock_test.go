@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestOperationLock tests that only one of two concurrent acquirers gets the lock, and that it can be had again once
+// released or once its holder stops heartbeating
+func TestOperationLock(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	shifted := &offsetClock{base: fixedClock(time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC))}
+	clock = shifted
+	db := setupTestDB(t)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	locks := make([]*operationLock, 2)
+	errs := make([]error, 2)
+	for i, op := range []string{OperationImport, OperationPurge} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			locks[i], errs[i] = acquireOperationLock(t.Context(), db, op)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var held *operationLock
+	var contended error
+	for i := range locks {
+		if errs[i] == nil {
+			held = locks[i]
+		} else {
+			contended = errs[i]
+		}
+	}
+	if held == nil || contended == nil {
+		t.Fatalf("Expected exactly one acquirer to get the lock, got errors %v", errs)
+	}
+	var httpErr httpError
+	if !errors.As(contended, &httpErr) || httpErr.code != http.StatusConflict {
+		t.Fatalf("Expected a 409 for the other acquirer, got %v", contended)
+	}
+	if !strings.Contains(contended.Error(), held.owner) {
+		t.Errorf("Expected the error to name the holder %q, got %q", held.owner, contended)
+	}
+
+	held.release()
+	again, err := acquireOperationLock(t.Context(), db, OperationImport)
+	if err != nil {
+		t.Fatalf("Expected the released lock to be free, got %v", err)
+	}
+
+	// The holder's heartbeat hasn't been seen for longer than the timeout, as if it had died.
+	if _, err := acquireOperationLock(t.Context(), db, OperationPurge); err == nil {
+		t.Fatal("Expected a held lock to be refused")
+	}
+	shifted.SetOffset(operationLockTimeout + time.Second)
+	takeover, err := acquireOperationLock(t.Context(), db, OperationPurge)
+	if err != nil {
+		t.Fatalf("Expected a stale lock to be taken over, got %v", err)
+	}
+	// Releasing the lock that was taken over mustn't free the new holder's.
+	again.release()
+	if _, err := acquireOperationLock(t.Context(), db, OperationImport); err == nil {
+		t.Error("Expected the lock to still be held after its old holder released it")
+	}
+	takeover.release()
+}
+
+// TestImportLocked tests that an import is refused while another destructive operation is running
+func TestImportLocked(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	lock, err := acquireOperationLock(t.Context(), db, OperationPurge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.release()
+
+	req := httptest.NewRequest("POST", "/import", strings.NewReader(`{"version": 1, "timers": [{"name": "Locked out"}]}`))
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status Conflict, got %v: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "purge") {
+		t.Errorf("Expected the running operation in the error, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// The homepage lists timers in an order of the user's own, which dragging them around changes. Each timer's position
+// is unique, 0 at the top, with new timers going in at the top as the create form adds them there. Pinned timers
+// still come first, see pin.go.
+
+// reorderTimers puts the timers with ids in that order, in the positions that they had between them, so that a list
+// of some of the timers, like the ones with a tag, leaves the others where they are. It's one statement in a
+// transaction, so reorders that race each other can't leave two timers in the same position, the last one wins.
+func (s *Server) reorderTimers(ctx context.Context, ids []int64) error {
+	list, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE timer SET position = slot.position, updated_at = ?
+		FROM (
+			SELECT position, ROW_NUMBER() OVER (ORDER BY position) - 1 AS n
+			FROM timer WHERE deleted_at IS NULL AND id IN (SELECT value FROM json_each(?))
+		) AS slot
+		JOIN json_each(?) AS listed ON listed.key = slot.n
+		WHERE timer.id = listed.value AND timer.deleted_at IS NULL`, updatedAt(), string(list), string(list))
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows != int64(len(ids)) {
+		return httpError{http.StatusNotFound, fmt.Errorf("Some of the timers %v don't exist", ids)}
+	}
+	return tx.Commit()
+}
+
+// reorderHandler reorders the timers with the form's ids, as the homepage sends them after a timer is dragged.
+func (s *Server) reorderHandler(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing form : %w", err)}
+	}
+	var ids []int64
+	for _, text := range r.Form["id"] {
+		id, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing timer id %q: %w", text, err)}
+		}
+		if slices.Contains(ids, id) {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Timer %d is in the order more than once", id)}
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return httpError{http.StatusBadRequest, errors.New("No timers to put in order, expected an id for each")}
+	}
+	if err := s.reorderTimers(r.Context(), ids); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
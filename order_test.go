@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestReorder tests that new timers go at the top and that reordering puts them in the order given, keeping each
+// position unique however the reorders race
+func TestReorder(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	for _, body := range []string{
+		`{"name":"Water plants","frequency":"2 days","tags":["house"]}`,
+		`{"name":"Oil change","frequency":"3 months"}`,
+		`{"name":"Dust the shelves","frequency":"1 month","tags":["house"]}`,
+		`{"name":"Floss","frequency":"1 day"}`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", body); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create %s: %v %s", body, w.Code, w.Body.String())
+		}
+	}
+	reorder := func(ids ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/timers/order", strings.NewReader(url.Values{"id": ids}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	names := func() []string {
+		timers, err := s.listTimers(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, c := range timers {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+	check := func(expected ...string) {
+		t.Helper()
+		if got := names(); !slices.Equal(got, expected) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+
+	check("Floss", "Dust the shelves", "Oil change", "Water plants")
+
+	if w := reorder("2", "4", "1", "3"); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status No Content, got %v: %s", w.Code, w.Body.String())
+	}
+	check("Oil change", "Floss", "Water plants", "Dust the shelves")
+
+	// Reordering the timers tagged house leaves the others where they are.
+	reorder("3", "1")
+	check("Oil change", "Floss", "Dust the shelves", "Water plants")
+
+	for _, ids := range [][]string{{}, {"1", "x"}, {"1", "2", "1"}} {
+		if w := reorder(ids...); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %v to be a Bad Request, got %v: %s", ids, w.Code, w.Body.String())
+		}
+	}
+	if w := reorder("1", "99", "2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing timer to be Not Found, got %v: %s", w.Code, w.Body.String())
+	}
+	check("Oil change", "Floss", "Dust the shelves", "Water plants")
+
+	var wg sync.WaitGroup
+	for _, ids := range [][]string{{"1", "2", "3", "4"}, {"4", "3", "2", "1"}, {"3", "1"}, {"2", "4"}, {"1", "4", "2", "3"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := reorder(ids...); w.Code != http.StatusNoContent {
+				t.Errorf("Expected status No Content for %v, got %v: %s", ids, w.Code, w.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+	var timers, positions int
+	if err := db.QueryRow(`SELECT COUNT(*), COUNT(DISTINCT position) FROM timer`).Scan(&timers, &positions); err != nil {
+		t.Fatal(err)
+	}
+	if timers != 4 || positions != 4 {
+		t.Errorf("Expected 4 timers in 4 positions, got %d in %d", timers, positions)
+	}
+
+	// A new timer goes in at the top, the way the create form shows it.
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Descale kettle","frequency":"1 month"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+	}
+	if got := names(); got[0] != "Descale kettle" {
+		t.Errorf("Expected the new timer first, got %v", got)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// parseOutboundProxy parses -outbound-proxy, an http, https or socks5 URL. Empty is nil, for the proxy that
+// $HTTP_PROXY, $HTTPS_PROXY and $NO_PROXY pick.
+func parseOutboundProxy(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("%q must start with http://, https:// or socks5://", u.Redacted())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%q has no host", u.Redacted())
+	}
+	return u, nil
+}
+
+// outboundClient is the client for every request that the server makes itself, e.g. to CalDAV. It goes through
+// -outbound-proxy when that's set, and the proxy from the environment otherwise.
+func (s *Server) outboundClient(timeout time.Duration) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if s.outboundProxy != nil {
+		t.Proxy = http.ProxyURL(s.outboundProxy)
+	}
+	return &http.Client{Transport: t, Timeout: timeout}
+}
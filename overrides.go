@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// templateFixtures holds data of the right shape for each template that can be overridden.
+// Overrides are executed against it at startup so that a reference to a field that doesn't exist fails right away
+// rather than on the first request.
+func templateFixtures() map[string]any {
+	now := clock.Now()
+	c := CountDown{
+		Id: 1, Name: "Water plants", Description: "The ones by the window", Tags: []string{"garden", "house"},
+		LastTime: now.Add(-48 * time.Hour), Frequency: 24 * time.Hour, ReferenceURL: "https://example.com",
+	}
+	v := newTimerView(c)
+	// The cards themselves aren't rendered yet, only the shape of the list matters.
+	list := homePageData{Vacation: now, Tag: "house", Query: "plants", Summary: timerSummary{Overdue: 1, DueToday: 2, OK: 3}, More: "/timers?cursor=MC4wLjI"}
+	for _, v := range newTimerViews([]CountDown{c, {Id: 2, Name: "Never done"}}) {
+		list.Cards = append(list.Cards, timerCard{timerView: v, Bucket: v.CountDown.bucket(now)})
+	}
+	return map[string]any{
+		"timer":      v,
+		"header":     "Countdown",
+		"footer":     nil,
+		"timerlist":  list,
+		"timerchunk": timerChunk{list.Cards, true, bucketOverdue, list.More},
+		"homepage":   list,
+		"timerform":  timerFormView{Prefix: createFormPrefix, Errors: fieldErrors{{"name", "A timer needs a name"}}},
+		"timerpage":  v,
+		"widget":     widgetView{v, true},
+		"undotoast":  c,
+		"carderror":  c.Id,
+		"errortoast": errorToastView{"POST", "/timer/1/reset", http.StatusNotFound, "Timer 1 not found"},
+
+		"schedulepreview":   schedulePreview{Schedule: schedule{3, UnitWeek, ""}},
+		"tagoptions":        []string{"car", "house"},
+		"iconoptions":       []string{"droplet", "droplet-half"},
+		"forecast":          forecast([]CountDown{c}, now, 2),
+		"dashboards":        dashboardsPage{[]CountDown{c}, []string{"house"}},
+		"dashboardlist":     []Dashboard{{Id: 1, Name: "Aquarium", Token: "abc", TimerIds: []int64{c.Id}}, {Id: 2, Name: "House", Token: "def", Tag: "house"}},
+		"print":             newChoreSheet([]CountDown{c}, now, "house"),
+		"summary":           timerSummary{Overdue: 1, DueToday: 2, OK: 3},
+		"history":           historyData{c.Id, []completion{{CompletedAt: now, Kind: CompletionDone, Note: "With the plant food"}}, timerStats{Count: 2, Checked: 1, Streak: 1, BestStreak: 1}},
+		"recentcompletions": recentCompletions{[]recentCompletion{{completion{CompletedAt: now, Kind: CompletionDone}, c.Id, c.Name}}, defaultRecentLimit, false},
+	}
+}
+
+// overrideTemplates replaces individual templates with the files in dir named after them, e.g. timer.html replaces
+// just the timer card, and every other template still comes from this binary. An empty dir overrides nothing.
+// funcs replaces template functions in both sets, e.g. readOnly.
+// It returns the live and static template sets along with the names of the overridden templates.
+func overrideTemplates(dir string, funcs template.FuncMap) (live, static *localized, overridden []string, err error) {
+	var files []string
+	if dir != "" {
+		if files, err = filepath.Glob(filepath.Join(dir, "*.html")); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	base, err := baseTemplates.Clone()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fixtures := templateFixtures()
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+		if _, ok := fixtures[name]; !ok {
+			return nil, nil, nil, fmt.Errorf("Error overriding templates: %s doesn't name a template", file)
+		}
+
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if _, err := base.New(name).Parse(string(b)); err != nil {
+			return nil, nil, nil, fmt.Errorf("Error parsing %s: %w", file, err)
+		}
+		overridden = append(overridden, name)
+	}
+	sort.Strings(overridden)
+
+	staticFuncs := maps.Clone(funcs)
+	if staticFuncs == nil {
+		staticFuncs = template.FuncMap{}
+	}
+	staticFuncs["static"] = func() bool { return true }
+	live, static = newLocalized(base, funcs), newLocalized(base, staticFuncs)
+
+	// Every template could include an overridden one, so check them all.
+	for name, data := range fixtures {
+		for _, t := range []*template.Template{live.in(locale(language)), static.in(locale(language))} {
+			if err := t.ExecuteTemplate(io.Discard, name, data); err != nil {
+				return nil, nil, nil, fmt.Errorf("Error checking overridden templates: %w", err)
+			}
+		}
+	}
+	return live, static, overridden, nil
+}
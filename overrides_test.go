@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOverrideTemplates tests replacing just the timer card while every other template comes from the binary
+func TestOverrideTemplates(t *testing.T) {
+	dir := t.TempDir()
+	card := `<div class="timer my-card">{{.Name}} is {{if static}}frozen{{else}}live{{end}}</div>`
+	if err := os.WriteFile(filepath.Join(dir, "timer.html"), []byte(card), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("Not a template"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	s := &Server{db: db}
+
+	var overridden []string
+	var err error
+	if s.templates, s.staticTemplates, overridden, err = overrideTemplates(dir, nil); err != nil {
+		t.Fatalf("Failed to override templates: %v", err)
+	}
+	if strings.Join(overridden, ",") != "timer" {
+		t.Errorf("Expected only timer to be overridden, got %v", overridden)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, testTimers[0].Name+" is live") {
+		t.Errorf("Expected the overridden card on the homepage")
+	}
+	if !strings.Contains(body, "Count up Timer") || !strings.Contains(body, `id="createTimer"`) {
+		t.Errorf("Expected the built in layout and form around the overridden card")
+	}
+
+	// Snapshots use the override too.
+	files, err := s.snapshot(t.Context())
+	if err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+	if !strings.Contains(string(files["index.html"]), testTimers[0].Name+" is frozen") {
+		t.Errorf("Expected the overridden card in the snapshot")
+	}
+
+	// The built in templates are untouched.
+	w = httptest.NewRecorder()
+	(&Server{db: db}).mux().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(w.Body.String(), "my-card") {
+		t.Errorf("Expected the override to only apply to its server")
+	}
+}
+
+// TestOverrideTemplatesErrors tests that broken overrides fail at startup
+func TestOverrideTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+	}{
+		{"unknown field", "timer.html", `{{.Nope}}`},
+		{"syntax error", "timer.html", `{{if}}`},
+		{"unknown template", "timers.html", `{{.Name}}`},
+		{"wrong data", "homepage.html", `{{.Name}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, _, err := overrideTemplates(dir, nil); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Paused reports whether the timer is paused, see pauseTimer. A paused timer is never due, nor overdue or stale.
+func (c CountDown) Paused() bool {
+	return !c.PausedAt.IsZero()
+}
+
+// pausedStatus says since when a paused timer is, in place of when it's due, in l.
+func (c CountDown) pausedStatus(l locale) string {
+	return l.tr("Paused since %s", c.PausedAt.In(location).Format("Mon Jan 2, 2006"))
+}
+
+// pausedError is the 409 for skipping or snoozing a paused timer, which isn't coming due anyway.
+func pausedError(c CountDown) error {
+	return httpError{http.StatusConflict, fmt.Errorf("%q is paused, resume it first. %s", c.Name, c.pausedStatus("en"))}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestPauseTimer tests that a paused timer is never due, and unpausing it with and without shifting its schedule
+func TestPauseTimer(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 11, 1, 10, 0, 0, 0, time.UTC)
+	shifted := &offsetClock{base: fixedClock(now)}
+	clock = shifted
+	day := 24 * time.Hour
+
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	post := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, httptest.NewRequest("POST", target, nil))
+		return w
+	}
+	get := func(id int64) CountDown {
+		c, err := s.getTimer(t.Context(), id)
+		if err != nil {
+			t.Fatalf("Failed to read the timer: %v", err)
+		}
+		return c
+	}
+
+	fig := CountDown{Name: "Water the fig tree", LastTime: now.Add(-10 * day), Frequency: 7 * day}
+	if err := s.createTimer(t.Context(), &fig); err != nil {
+		t.Fatalf("Failed to create a timer: %v", err)
+	}
+	path := fmt.Sprintf("/timer/%d", fig.Id)
+
+	for range 2 {
+		w := post(path + "/pause")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if got, expected := w.Header().Get("HX-Trigger"), fmt.Sprintf("timerUpdate/%d, timersChanged", fig.Id); got != expected {
+			t.Errorf("Expected HX-Trigger %q, got %q", expected, got)
+		}
+	}
+	c := get(fig.Id)
+	if !c.Paused() || !c.PausedAt.Equal(now) {
+		t.Fatalf("Expected the timer to be paused since %v, got %v", now, c.PausedAt)
+	}
+	if c.Overdue() || !c.NextDue().IsZero() || c.DueStatus() != "Paused since Sat Nov 1, 2025" {
+		t.Errorf("Expected a paused timer to never be due, got %v: %q", c.NextDue(), c.DueStatus())
+	}
+	if w := post(path + "/skip"); w.Code != http.StatusConflict {
+		t.Errorf("Expected skipping a paused timer to be a Conflict, got %v: %s", w.Code, w.Body.String())
+	}
+
+	// A winter later, it's due as long after being resumed as it was when it was paused: 3 days overdue.
+	shifted.SetOffset(120 * day)
+	if w := post(path + "/unpause?shift=1"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	c = get(fig.Id)
+	if c.Paused() || !c.LastTime.Equal(fig.LastTime) || c.PausedFor != 120*day {
+		t.Fatalf("Expected the schedule to be put back by 120 days with the same last time, got %v, %v", c.PausedFor, c.LastTime)
+	}
+	if c.DueStatus() != "Overdue by 3 days" {
+		t.Errorf("Expected it to be as overdue as when it was paused, got %q", c.DueStatus())
+	}
+	if w := post(path + "/unpause"); w.Code != http.StatusOK || get(fig.Id).PausedFor != c.PausedFor {
+		t.Errorf("Expected unpausing a timer that isn't paused to do nothing, got %v: %s", w.Code, w.Body.String())
+	}
+
+	// Without shift the time paused isn't made up for.
+	post(path + "/pause")
+	shifted.SetOffset(150 * day)
+	if w := post(path + "/unpause"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if got := get(fig.Id); got.PausedFor != c.PausedFor || !got.Overdue() {
+		t.Errorf("Expected an overdue timer put back by %v, got %v", c.PausedFor, got.PausedFor)
+	}
+
+	// Being done again starts its schedule over.
+	if err := s.resetTimer(t.Context(), fig.Id, clock.Now(), CompletionDone, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(fig.Id); got.PausedFor != 0 || !got.NextDue().Equal(clock.Now().Add(7*day)) {
+		t.Errorf("Expected it to be due a week after being done, got %v", got.NextDue())
+	}
+
+	once := CountDown{Name: "Renew passport", LastTime: now}
+	if err := s.createTimer(t.Context(), &once); err != nil {
+		t.Fatalf("Failed to create a timer: %v", err)
+	}
+	for _, tt := range []struct {
+		name, target   string
+		expectedStatus int
+	}{
+		{"one-time timer", fmt.Sprintf("/timer/%d/pause", once.Id), http.StatusBadRequest},
+		{"unknown timer", "/timer/999/pause", http.StatusNotFound},
+		{"bad shift", path + "/unpause?shift=maybe", http.StatusBadRequest},
+	} {
+		if w := post(tt.target); w.Code != tt.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.expectedStatus, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+// Pinned timers are the favorites that the homepage lists before the others, under a heading of their own, whatever
+// their priority. Pinning only changes where a timer is listed, so it isn't an event.
+
+// togglePin pins the timer with id, or unpins it when it's pinned, and returns it as it is now.
+func (s *Server) togglePin(ctx context.Context, id int64) (CountDown, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE timer SET pinned = NOT pinned, updated_at = ? WHERE id = ? AND deleted_at IS NULL`, updatedAt(), id)
+	if err != nil {
+		return CountDown{}, err
+	}
+	if err := checkOneRow(result, id); err != nil {
+		return CountDown{}, err
+	}
+	return s.getTimer(ctx, id)
+}
+
+// pinHandler toggles whether a timer is pinned and has htmx reload the page, since the timer moves in the list.
+func (s *Server) pinHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	c, err := s.togglePin(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("HX-Refresh", "true")
+	return encodeJSON(w, c)
+}
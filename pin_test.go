@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// TestPin tests pinning timers to the top of the homepage, unpinning them and that it survives an export and import
+func TestPin(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	for _, body := range []string{
+		`{"name":"Change the smoke detector batteries","frequency":"1 year","priority":1}`,
+		`{"name":"Water plants","frequency":"2 days"}`,
+		`{"name":"Dust the shelves","frequency":"1 month","priority":-1}`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", body); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create %s: %v %s", body, w.Code, w.Body.String())
+		}
+	}
+	pin := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, httptest.NewRequest("POST", target, nil))
+		return w
+	}
+	names := func(s *Server) []string {
+		timers, err := s.listTimers(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, c := range timers {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	w := pin("/timer/3/pin")
+	var c CountDown
+	decodeResponse(t, w, &c)
+	if w.Code != http.StatusOK || w.Header().Get("HX-Refresh") != "true" || !c.Pinned {
+		t.Fatalf("Expected the timer pinned with a refresh, got %v %q: %+v", w.Code, w.Header().Get("HX-Refresh"), c)
+	}
+	pin("/timer/1/pin")
+	// Pinned first, newest first as ever otherwise.
+	if got, expected := names(s), []string{"Dust the shelves", "Change the smoke detector batteries", "Water plants"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Editing a timer doesn't unpin it.
+	if w := serveAPI(t, s, "PUT", "/api/v1/timers/3", `{"name":"Dust the shelves","frequency":"2 months","priority":-1,"version":1}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if c, err := s.getTimer(t.Context(), 3); err != nil || !c.Pinned {
+		t.Errorf("Expected the edited timer to still be pinned, got %+v, %v", c, err)
+	}
+
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+	target := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, target, "POST", "/import", w.Body.String()); w.Code != http.StatusOK {
+		t.Fatalf("Expected the import to be OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if got, expected := names(target), names(s); !slices.Equal(got, expected) {
+		t.Errorf("Expected the imported timers pinned as they were, %v, got %v", expected, got)
+	}
+
+	if w := pin("/timer/1/pin"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if got, expected := names(s), []string{"Dust the shelves", "Water plants", "Change the smoke detector batteries"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected %v after unpinning, got %v", expected, got)
+	}
+	if w := pin("/timer/99/pin"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected pinning a missing timer to be Not Found, got %v: %s", w.Code, w.Body.String())
+	}
+}
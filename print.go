@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"time"
+)
+
+// GET /print is a chore sheet to print and pin to the fridge: a table of the timers with a box to tick for each,
+// in the homepage's sections by when they're due. Paused and finished timers are left out since there's nothing to
+// tick off for them. Its dates are written server side, since paper can't keep "3 days ago" current.
+
+// choreSection is the timers in one of the chore sheet's sections, by when they're next due.
+type choreSection struct {
+	Bucket dueBucket
+	Timers []CountDown
+}
+
+// choreSheet is the chore sheet as of Printed, of only the timers tagged Tag when it isn't empty.
+type choreSheet struct {
+	Tag      string
+	Printed  time.Time
+	Sections []choreSection
+}
+
+// choreBuckets are the chore sheet's sections in the order that it lists them.
+var choreBuckets = []dueBucket{bucketOverdue, bucketToday, bucketThisWeek, bucketLater, bucketNoSchedule}
+
+// newChoreSheet groups timers by their buckets as of now, leaving out the ones that are paused or finished, and
+// sections without any timers.
+func newChoreSheet(timers []CountDown, now time.Time, tag string) choreSheet {
+	byBucket := map[dueBucket][]CountDown{}
+	for _, c := range timers {
+		if c.Paused() || c.Finished() {
+			continue
+		}
+		b := c.bucket(now)
+		byBucket[b] = append(byBucket[b], c)
+	}
+	sheet := choreSheet{Tag: tag, Printed: now}
+	for _, b := range choreBuckets {
+		timers := byBucket[b]
+		if len(timers) == 0 {
+			continue
+		}
+		if b != bucketNoSchedule {
+			slices.SortStableFunc(timers, func(a, b CountDown) int { return a.NextDue().Compare(b.NextDue()) })
+		}
+		sheet.Sections = append(sheet.Sections, choreSection{b, timers})
+	}
+	return sheet
+}
+
+// printHandler serves the chore sheet, of only the timers tagged ?tag= when it's given.
+func (s *Server) printHandler(w http.ResponseWriter, r *http.Request) error {
+	if _, err := negotiate(w, r, "text/html"); err != nil {
+		return err
+	}
+	tag := normalizeTag(r.URL.Query().Get("tag"))
+	timers, err := s.listTaggedTimers(r.Context(), tag, "", ownOrder)
+	if err != nil {
+		return err
+	}
+	return s.render(r.Context(), w, "print", newChoreSheet(timers, clock.Now(), tag))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestChoreSheet tests that the chore sheet lists the timers in sections by when they're due, with their dates written
+// out, without the paused ones and only those with ?tag= when it's given
+func TestChoreSheet(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	clock = fixedClock(time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC))
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+
+	s := &Server{db: setupTestDB(t)}
+	now := clock.Now()
+	day := 24 * time.Hour
+	for _, c := range []CountDown{
+		{Name: "Dust the shelves", LastTime: now.Add(-2 * day), Frequency: 30 * day, Tags: []string{"house"}},
+		{Name: "Water plants", LastTime: now.Add(-3 * day), Frequency: day, Tags: []string{"house"}},
+		{Name: "Oil change", LastTime: now.Add(-10 * day), Frequency: 90 * day, Tags: []string{"car"}},
+		{Name: "Descale kettle", LastTime: now.Add(-day), Frequency: 7 * day, Tags: []string{"house"}},
+		{Name: "Renew passport"},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w := serveAPI(t, s, "POST", "/timer/4/pause", ""); w.Code != http.StatusOK {
+		t.Fatalf("Failed to pause the timer: %v %s", w.Code, w.Body.String())
+	}
+
+	w := serveAPI(t, s, "GET", "/print", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected OK, got %v: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "Descale kettle") {
+		t.Errorf("Expected no paused timers, got %s", body)
+	}
+	// In order of the sections and of when they're due within them.
+	last := -1
+	for _, expected := range []string{">Overdue<", "Water plants", "Sun Mar 2, 2025", ">Later<", "Dust the shelves", "Thu Apr 3, 2025", "Oil change", ">No schedule<", "Renew passport", "Never"} {
+		i := strings.Index(body, expected)
+		if i < last {
+			t.Errorf("Expected %q after what's before it, got %s", expected, body)
+		}
+		last = i
+	}
+
+	body = serveAPI(t, s, "GET", "/print?tag=House", "").Body.String()
+	if !strings.Contains(body, "Chores tagged house") || !strings.Contains(body, "Dust the shelves") || strings.Contains(body, "Oil change") || strings.Contains(body, "Renew passport") {
+		t.Errorf("Expected only the timers tagged house, got %s", body)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// How much a timer matters, which the dashboard badges. A timer without one is normal.
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+var priorityNames = map[string]int{"low": PriorityLow, "normal": PriorityNormal, "high": PriorityHigh}
+
+// parsePriority reads the create form's priority, low, normal or high, normal when it's left out.
+func parsePriority(c *CountDown, text string) error {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return nil
+	}
+	p, ok := priorityNames[text]
+	if !ok {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing priority %q: expected low, normal or high", text)}
+	}
+	c.Priority = p
+	return nil
+}
+
+// validatePriority fails with a 400 for anything but the three priorities.
+func validatePriority(c CountDown) error {
+	if c.Priority < PriorityLow || c.Priority > PriorityHigh {
+		return httpError{http.StatusBadRequest, fmt.Errorf("A priority is %d for low, %d for normal or %d for high, not %d", PriorityLow, PriorityNormal, PriorityHigh, c.Priority)}
+	}
+	return nil
+}
+
+// PriorityName is low, normal or high.
+func (c CountDown) PriorityName() string {
+	switch c.Priority {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
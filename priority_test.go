@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestPriority tests that timers are created with a priority and badged with it, but listed in their own order
+func TestPriority(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	create := func(name, priority string) *httptest.ResponseRecorder {
+		form := url.Values{"name": {name}, "lasttime": {"2025-03-01T09:00"}, "frequency": {"1 month"}}
+		if priority != "" {
+			form.Set("priority", priority)
+		}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	create("Dust the shelves", "low")
+	create("Clean the oven", "")
+	if w := create("Change the smoke detector batteries", "high"); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "High priority") {
+		t.Errorf("Expected a high priority badge, got %v: %s", w.Code, w.Body.String())
+	}
+	create("Wash the car", "normal")
+	if w := create("Polish the silver", "urgent"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown priority to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+
+	timers, err := s.listTimers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range timers {
+		names = append(names, c.PriorityName()+" "+c.Name)
+	}
+	expected := []string{"normal Wash the car", "high Change the smoke detector batteries", "normal Clean the oven", "low Dust the shelves"}
+	if strings.Join(names, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Oil change","frequency":"3 months","priority":2}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a priority out of range to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+	w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Oil change","frequency":"3 months","priority":-1}`)
+	var c CountDown
+	decodeResponse(t, w, &c)
+	if w.Code != http.StatusCreated || c.Priority != PriorityLow {
+		t.Errorf("Expected a low priority timer, got %v: %+v", w.Code, c)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// The homepage ends with a strip of the times that timers were done most recently, so that doing one is acknowledged
+// somewhere other than on its card. It's loaded with the page and again on the timersChanged event that every change
+// to a timer's card sends along with timerUpdate/{id}. There are no users, so it says when but not who.
+
+// How many completions the strip lists unless ?limit= says otherwise, and the most that it can say.
+const (
+	defaultRecentLimit = 10
+	maxRecentLimit     = 100
+)
+
+// recentCompletion is a completion with the timer it's of, for the strip.
+type recentCompletion struct {
+	completion
+	TimerId   int64
+	TimerName string
+}
+
+// recentCompletions is what the strip shows.
+type recentCompletions struct {
+	Completions []recentCompletion
+	Limit       int
+	Skipped     bool // Whether skipped occurrences are listed too, they're left out unless asked for.
+}
+
+// URL is where the strip loads from again, as it is.
+func (r recentCompletions) URL() string {
+	query := url.Values{"limit": {strconv.Itoa(r.Limit)}}
+	if r.Skipped {
+		query.Set("skipped", "1")
+	}
+	return "/timers/recent-completions?" + query.Encode()
+}
+
+// listRecentCompletions is the at most limit latest completions of the timers that haven't been deleted, newest
+// first, with skips only when skipped is set.
+func (s *Server) listRecentCompletions(ctx context.Context, limit int, skipped bool) ([]recentCompletion, error) {
+	query := `SELECT completion.id, completion.completed_at, completion.kind, completion.note, completion.due_at, timer.id, timer.name
+		FROM completion JOIN timer ON timer.id = completion.timer_id WHERE timer.deleted_at IS NULL`
+	if !skipped {
+		query += ` AND completion.kind != '` + CompletionSkipped + `'`
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY completion.completed_at DESC, completion.id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recent := []recentCompletion{}
+	for rows.Next() {
+		var r recentCompletion
+		if r.completion, err = scanCompletion(rows, &r.TimerId, &r.TimerName); err != nil {
+			return nil, err
+		}
+		recent = append(recent, r)
+	}
+	return recent, rows.Err()
+}
+
+// recentCompletionsHandler renders the strip for ?limit=, from 1 to maxRecentLimit, and ?skipped=, whether to list
+// skips too.
+func (s *Server) recentCompletionsHandler(w http.ResponseWriter, r *http.Request) error {
+	recent := recentCompletions{Limit: defaultRecentLimit}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRecentLimit {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'limit' %q: expected a number from 1 to %d", v, maxRecentLimit)}
+		}
+		recent.Limit = n
+	}
+	if v := r.URL.Query().Get("skipped"); v != "" {
+		var err error
+		if recent.Skipped, err = strconv.ParseBool(v); err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'skipped': %w", err)}
+		}
+	}
+	var err error
+	if recent.Completions, err = s.listRecentCompletions(r.Context(), recent.Limit, recent.Skipped); err != nil {
+		return err
+	}
+	return s.render(r.Context(), w, "recentcompletions", recent)
+}
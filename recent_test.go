@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestRecentCompletions tests that the homepage's strip lists the latest completions of every timer, newest first,
+// leaving out skips unless asked and the timers that have been deleted, and that changing a timer reloads it
+func TestRecentCompletions(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	serve := func(method, target string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	// Each entry as its timer's link, with " skipped" after the skips.
+	entry := regexp.MustCompile(`(?s)<li.*?href="/timer/(\d+)".*?</li>`)
+	recent := func(query string) string {
+		w := serve("GET", "/timers/recent-completions"+query, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to GET the recent completions%s: %v %s", query, w.Code, w.Body.String())
+		}
+		var got []string
+		for _, m := range entry.FindAllStringSubmatch(w.Body.String(), -1) {
+			if strings.Contains(m[0], "Skipped") {
+				m[1] += " skipped"
+			}
+			got = append(got, m[1])
+		}
+		return strings.Join(got, ",")
+	}
+
+	for _, form := range []url.Values{
+		{"name": {"Water plants"}, "lasttime": {"2025-03-01T09:00"}, "frequency": {"2 days"}},
+		{"name": {"Floss"}, "lasttime": {"2025-03-02T09:00"}, "frequency": {"1 day"}},
+	} {
+		if w := serve("POST", "/timer", form); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+		}
+	}
+	w := serve("POST", "/timer/1/reset", url.Values{"at": {"2025-03-04T09:00"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to reset: %v %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("HX-Trigger"); !strings.Contains(got, "timersChanged") {
+		t.Errorf("Expected a reset to trigger timersChanged for the strip, got %q", got)
+	}
+	if w := serve("POST", "/timer/2/skip", nil); w.Code != http.StatusOK {
+		t.Fatalf("Failed to skip: %v %s", w.Code, w.Body.String())
+	}
+
+	for _, c := range []struct{ query, expected string }{
+		{"", "1,2,1"},
+		{"?skipped=1", "2 skipped,1,2,1"},
+		{"?limit=2", "1,2"},
+		{"?limit=2&skipped=true", "2 skipped,1"},
+	} {
+		if got := recent(c.query); got != c.expected {
+			t.Errorf("Expected the recent completions%s to be %q, got %q", c.query, c.expected, got)
+		}
+	}
+	for _, query := range []string{"?limit=0", "?limit=101", "?limit=ten", "?skipped=maybe"} {
+		if w := serve("GET", "/timers/recent-completions"+query, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be a Bad Request, got %v: %s", query, w.Code, w.Body.String())
+		}
+	}
+
+	if w := serve("DELETE", "/timer/2", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status No Content, got %v: %s", w.Code, w.Body.String())
+	}
+	if got, expected := recent("?skipped=1"), "1,1"; got != expected {
+		t.Errorf("Expected a deleted timer's completions to be left out, got %q", got)
+	}
+}
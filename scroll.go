@@ -0,0 +1,305 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// In its default order and the user's own, the homepage shows the first timerChunkSize timers and htmx loads the next
+// ones from GET /timers?cursor= as the end of the list scrolls into view, with the page's ?sort=. The cursor is the
+// last timer shown rather than how many were shown, and the next chunk starts after wherever that timer is now, so
+// timers added in the meantime, which move every timer below them down a place, don't shift it over. The user's own
+// order is a keyset on (position, id) in SQL. The default order is one on (how urgent, when it's due, position, id)
+// that only Go can work out, from schedules, cron expressions and all, so it still reads every timer's schedule but
+// only reads the rest of a chunk of them.
+
+// How many timers the homepage lists at a time.
+const timerChunkSize = 25
+
+// listCursor is the last timer of a chunk of the list and its place in the user's own order, pinned timers first,
+// which the next chunk carries on from if the timer has since gone.
+type listCursor struct {
+	Pinned   bool
+	Position int
+	Id       int64
+}
+
+// cursorOf is the place of c in the user's own order.
+func cursorOf(c CountDown) listCursor {
+	return listCursor{c.Pinned, c.Position, c.Id}
+}
+
+// String is the cursor as it goes in ?cursor=, opaque so that clients don't come to rely on what's in it.
+func (c listCursor) String() string {
+	pinned := 0
+	if c.Pinned {
+		pinned = 1
+	}
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d.%d.%d", pinned, c.Position, c.Id))
+}
+
+// parseCursor reads a cursor written by String, failing with a 400 for anything else.
+func parseCursor(s string) (listCursor, error) {
+	parts, err := parseCursorParts(s, 3)
+	if err != nil {
+		return listCursor{}, err
+	}
+	return listCursor{parts[0] == 1, int(parts[1]), parts[2]}, nil
+}
+
+// parseCursorParts reads the n numbers of a cursor, the first of which is 0 or 1 for whether the timer is pinned,
+// failing with a 400 for anything else.
+func parseCursorParts(s string, n int) ([]int64, error) {
+	invalid := httpError{http.StatusBadRequest, fmt.Errorf("Error parsing cursor %q: expected one from the end of the list", s)}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	parts := strings.Split(string(b), ".")
+	if len(parts) != n || (parts[0] != "0" && parts[0] != "1") {
+		return nil, invalid
+	}
+	numbers := make([]int64, n)
+	for i, p := range parts {
+		bits := 64
+		if i == 1 {
+			bits = strconv.IntSize // The position is an int.
+		}
+		if numbers[i], err = strconv.ParseInt(p, 10, bits); err != nil {
+			return nil, invalid
+		}
+	}
+	return numbers, nil
+}
+
+// urgencyCursor is a listCursor in the default order, which also has how urgent the timer was and when it was due.
+type urgencyCursor struct {
+	listCursor
+	Rank int   // See urgencyRank.
+	Due  int64 // In Unix nanoseconds, 0 for a timer that's never due.
+}
+
+// urgencyCursorOf is the place of c in the default order as of now.
+func urgencyCursorOf(c CountDown, now time.Time) urgencyCursor {
+	cursor := urgencyCursor{listCursor: cursorOf(c), Rank: urgencyRank(c, now)}
+	if c.repeats() {
+		cursor.Due = c.NextDue().UnixNano()
+	}
+	return cursor
+}
+
+// String is the cursor as it goes in ?cursor=, see listCursor.String.
+func (c urgencyCursor) String() string {
+	pinned := 0
+	if c.Pinned {
+		pinned = 1
+	}
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d.%d.%d.%d.%d", pinned, c.Position, c.Id, c.Rank, c.Due))
+}
+
+// parseUrgencyCursor reads a cursor written by urgencyCursor.String, failing with a 400 for anything else.
+func parseUrgencyCursor(s string) (urgencyCursor, error) {
+	parts, err := parseCursorParts(s, 5)
+	if err != nil {
+		return urgencyCursor{}, err
+	}
+	return urgencyCursor{listCursor{parts[0] == 1, int(parts[1]), parts[2]}, int(parts[3]), parts[4]}, nil
+}
+
+// compare orders cursors like sortByUrgency orders their timers, by position and then id where it leaves timers in the
+// order that they were queried in.
+func (c urgencyCursor) compare(d urgencyCursor) int {
+	if c.Pinned != d.Pinned {
+		if c.Pinned {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Or(cmp.Compare(c.Rank, d.Rank), cmp.Compare(c.Due, d.Due), cmp.Compare(c.Position, d.Position), cmp.Compare(c.Id, d.Id))
+}
+
+// timerChunk is part of the homepage's list: the cards after a place in it and where the ones after them are.
+type timerChunk struct {
+	Cards       []timerCard
+	AfterPinned bool      // Whether the timer before the first card is pinned, so its section's heading is already shown.
+	AfterBucket dueBucket // The section of the timer before the first card, whose heading is already shown.
+	More        string    // The URL of the next chunk, empty at the end of the list.
+}
+
+// listTimerChunk is listTaggedTimers in the user's own order for the at most timerChunkSize timers after the cursor,
+// or from the start when it's nil, with the cursor of the last one when there are more after it.
+func (s *Server) listTimerChunk(ctx context.Context, tag, q string, after *listCursor) ([]CountDown, *listCursor, error) {
+	query, args := filterTimers(tag, q)
+	if after != nil {
+		var pinned bool
+		var position int
+		switch err := s.db.QueryRowContext(ctx, `SELECT pinned, position FROM timer WHERE id = ?`, after.Id).Scan(&pinned, &position); {
+		case err == nil:
+			after = &listCursor{pinned, position, after.Id}
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, nil, err
+		}
+		// The same as ownOrder's ORDER BY, NOT pinned putting them first.
+		query += ` AND (NOT pinned, position, id) > (NOT ?, ?, ?)`
+		args = append(args, after.Pinned, after.Position, after.Id)
+	}
+	timers, err := queryTimers(ctx, s.db, `SELECT `+timerColumns+` FROM timer WHERE deleted_at IS NULL`+query+ownOrder.orderBy()+` LIMIT ?`,
+		append(args, timerChunkSize+1)...)
+	if err != nil || len(timers) <= timerChunkSize {
+		return timers, nil, err
+	}
+	timers = timers[:timerChunkSize]
+	next := cursorOf(timers[len(timers)-1])
+	return timers, &next, nil
+}
+
+// listUrgentChunk is listTimerChunk in the default order. It also has the timer before the chunk, nil at the start
+// of the list, for the headings that are already shown.
+func (s *Server) listUrgentChunk(ctx context.Context, tag, q string, after *urgencyCursor) (*CountDown, []CountDown, *urgencyCursor, error) {
+	query, args := filterTimers(tag, q)
+	timers, err := queryTimers(ctx, s.db, `SELECT `+scheduleColumns+` FROM timer WHERE deleted_at IS NULL`+query+timerSort{}.orderBy(), args...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	timerSort{}.sortTimers(timers)
+	now := clock.Now()
+	start := 0
+	if after != nil {
+		start = slices.IndexFunc(timers, func(c CountDown) bool { return c.Id == after.Id }) + 1
+		if start == 0 {
+			start = slices.IndexFunc(timers, func(c CountDown) bool { return urgencyCursorOf(c, now).compare(*after) > 0 })
+		}
+		if start < 0 {
+			return nil, nil, nil, nil
+		}
+	}
+	var before *CountDown
+	if start > 0 {
+		before = &timers[start-1]
+	}
+	timers = timers[start:]
+	var next *urgencyCursor
+	if len(timers) > timerChunkSize {
+		timers = timers[:timerChunkSize]
+		cursor := urgencyCursorOf(timers[len(timers)-1], now)
+		next = &cursor
+	}
+	if err := s.readInFull(ctx, timers); err != nil {
+		return nil, nil, nil, err
+	}
+	return before, timers, next, nil
+}
+
+// readInFull reads the rest of timers that were queried with scheduleColumns, in place. Any deleted since are left as
+// they were.
+func (s *Server) readInFull(ctx context.Context, timers []CountDown) error {
+	ids := make([]int64, len(timers))
+	for i, c := range timers {
+		ids[i] = c.Id
+	}
+	list, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	full, err := queryTimers(ctx, s.db, `SELECT `+timerColumns+` FROM timer WHERE id IN (SELECT value FROM json_each(?))`, string(list))
+	if err != nil {
+		return err
+	}
+	byID := make(map[int64]CountDown, len(full))
+	for _, c := range full {
+		byID[c.Id] = c
+	}
+	for i, c := range timers {
+		if read, ok := byID[c.Id]; ok {
+			timers[i] = read
+		}
+	}
+	return nil
+}
+
+// chunkURL is the URL of the chunk of the list in the sort after the cursor, empty for none.
+func chunkURL(tag, q string, sort timerSort, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	query := url.Values{"cursor": {cursor}}
+	if sort != (timerSort{}) {
+		query.Set("sort", sort.String())
+	}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if q != "" {
+		query.Set("q", q)
+	}
+	return "/timers?" + query.Encode()
+}
+
+// timerChunkHandler responds with the "timerchunk" after ?cursor= in the default order or ?sort=position, which is
+// only ever appended to the homepage's list. It's empty after the end of the list, which takes the element that loaded
+// it away.
+func (s *Server) timerChunkHandler(w http.ResponseWriter, r *http.Request) error {
+	sort, err := parseSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		return err
+	}
+	tag := normalizeTag(r.URL.Query().Get("tag"))
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	var chunk timerChunk
+	var timers []CountDown
+	switch sort {
+	case ownOrder:
+		after, err := parseCursor(r.URL.Query().Get("cursor"))
+		if err != nil {
+			return err
+		}
+		var next *listCursor
+		if timers, next, err = s.listTimerChunk(r.Context(), tag, q, &after); err != nil {
+			return err
+		}
+		chunk.AfterPinned = after.Pinned
+		if next != nil {
+			chunk.More = chunkURL(tag, q, sort, next.String())
+		}
+	case timerSort{}:
+		after, err := parseUrgencyCursor(r.URL.Query().Get("cursor"))
+		if err != nil {
+			return err
+		}
+		var before *CountDown
+		var next *urgencyCursor
+		if before, timers, next, err = s.listUrgentChunk(r.Context(), tag, q, &after); err != nil {
+			return err
+		}
+		if before != nil {
+			chunk.AfterPinned = before.Pinned
+			if !before.Pinned {
+				chunk.AfterBucket = before.urgencyBucket(clock.Now())
+			}
+		}
+		if next != nil {
+			chunk.More = chunkURL(tag, q, sort, next.String())
+		}
+	default:
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'sort' %q: only the default order and position load in chunks", sort)}
+	}
+	if len(timers) == 0 {
+		return nil
+	}
+	chunk.Cards = renderCards(r.Context(), s.render, newTimerViews(timers))
+	if sort == (timerSort{}) {
+		groupByUrgency(chunk.Cards, clock.Now())
+	}
+	return s.render(r.Context(), w, "timerchunk", chunk)
+}
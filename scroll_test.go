@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestListCursor tests that cursors read back as they were written and that anything else is a 400
+func TestListCursor(t *testing.T) {
+	for _, c := range []listCursor{{}, {true, 0, 1}, {false, 41, 9000}, {true, -3, 7}} {
+		got, err := parseCursor(c.String())
+		if err != nil || got != c {
+			t.Errorf("Expected %q to read back as %+v, got %+v, %v", c.String(), c, got, err)
+		}
+	}
+	for _, s := range []string{"", "!!", "MS4y", "Mi4wLjE", "MS54LjE", "MS4wLjEuMg", "MS4wLg"} {
+		if _, err := parseCursor(s); err == nil {
+			t.Errorf("Expected %q to be an error", s)
+		}
+	}
+	if w := serveAPI(t, &Server{db: setupTestDB(t)}, "GET", "/timers?cursor=nope", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a bad cursor to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestTimerChunks tests following the list of the homepage from chunk to chunk to its end, and that timers added in
+// the meantime don't shift the next chunk over
+func TestTimerChunks(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	for i := range 30 {
+		c := CountDown{Name: fmt.Sprintf("Timer %02d", i), Position: i, Pinned: i == 7}
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(target string) string {
+		t.Helper()
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status OK, got %v: %s", target, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+	more := regexp.MustCompile(`hx-get="(/timers\?[^"]*)" hx-trigger="revealed"`)
+	ids := regexp.MustCompile(`<div id="timer-(\d+)"`)
+
+	first := get("/?sort=position")
+	if n := len(ids.FindAllString(first, -1)); n != timerChunkSize {
+		t.Errorf("Expected the first %d timers, got %d", timerChunkSize, n)
+	}
+	if !strings.Contains(first, "Pinned") || !strings.Contains(first, "Timer 07") || strings.Contains(first, "Timer 29") {
+		t.Errorf("Expected the pinned timer first and the last ones left out, got %s", first)
+	}
+	next := more.FindStringSubmatch(first)
+	if next == nil {
+		t.Fatalf("Expected the list to load more, got %s", first)
+	}
+
+	// At the top of the list, so before the cursor.
+	if err := s.createTimer(t.Context(), &CountDown{Name: "Timer new", Position: -1}); err != nil {
+		t.Fatal(err)
+	}
+	second := get(strings.ReplaceAll(next[1], "&amp;", "&"))
+	if got := len(ids.FindAllString(second, -1)); got != 5 || !strings.Contains(second, "Timer 25") || !strings.Contains(second, "Timer 29") {
+		t.Errorf("Expected the last 5 timers, got %d: %s", got, second)
+	}
+	if strings.Contains(second, "Pinned") || strings.Contains(second, "Everything else") || more.MatchString(second) {
+		t.Errorf("Expected no headings and nothing more to load, got %s", second)
+	}
+
+	last, err := s.getTimer(t.Context(), 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := get("/timers?sort=position&cursor=" + cursorOf(last).String()); body != "" {
+		t.Errorf("Expected nothing after the end of the list, got %s", body)
+	}
+	// A timer that's gone is carried on from where it was.
+	if body := get("/timers?sort=position&cursor=" + (listCursor{false, 27, 99}).String()); !strings.Contains(body, "Timer 27") || strings.Contains(body, "Timer 26") {
+		t.Errorf("Expected the timers after position 27, got %s", body)
+	}
+	if body := get("/?sort=name"); strings.Contains(body, "revealed") || len(ids.FindAllString(body, -1)) != 31 {
+		t.Errorf("Expected every timer in any other order, got %s", body)
+	}
+}
+
+// TestUrgencyCursor tests that cursors of the default order read back as they were written, and that ones of the
+// user's own order don't
+func TestUrgencyCursor(t *testing.T) {
+	for _, c := range []urgencyCursor{{}, {listCursor{true, 0, 1}, 2, 1741168800000000000}, {listCursor{false, 41, 9000}, 3, 0}, {listCursor{false, -3, 7}, 0, -5}} {
+		got, err := parseUrgencyCursor(c.String())
+		if err != nil || got != c {
+			t.Errorf("Expected %q to read back as %+v, got %+v, %v", c.String(), c, got, err)
+		}
+	}
+	if _, err := parseUrgencyCursor(listCursor{true, 0, 1}.String()); err == nil {
+		t.Errorf("Expected a cursor of the user's own order to be an error")
+	}
+}
+
+// TestUrgentChunks tests following the homepage's default order from chunk to chunk, with its sections' headings only
+// once each, that the cards are read in full, and that an overdue timer added in the meantime, which goes at the top,
+// doesn't shift the next chunk over
+func TestUrgentChunks(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	s := &Server{db: setupTestDB(t)}
+	day := 24 * time.Hour
+	// Due 1 to 30 days from now, in the opposite order to their positions, and one of them pinned.
+	for i := range 30 {
+		c := CountDown{Name: fmt.Sprintf("Timer %02d", i), LastTime: now.Add(time.Duration(i-99) * day), Frequency: 100 * day, Position: 30 - i, Pinned: i == 20}
+		if i == 27 {
+			c.Tags = []string{"garden"}
+		}
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(target string) string {
+		t.Helper()
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status OK, got %v: %s", target, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+	more := regexp.MustCompile(`hx-get="(/timers\?[^"]*)" hx-trigger="revealed"`)
+	names := regexp.MustCompile(`Timer \d\d`)
+
+	first := get("/")
+	if got := slices.Compact(names.FindAllString(first, -1)); len(got) != timerChunkSize || got[0] != "Timer 20" || got[1] != "Timer 00" || got[24] != "Timer 24" {
+		t.Errorf("Expected the pinned timer and then the first %d by when they're due, got %v", timerChunkSize-1, got)
+	}
+	next := more.FindStringSubmatch(first)
+	if next == nil || strings.Contains(next[1], "sort=") {
+		t.Fatalf("Expected the list to load more in the default order, got %s", first)
+	}
+
+	if err := s.createTimer(t.Context(), &CountDown{Name: "Timer new", LastTime: now.Add(-200 * day), Frequency: 100 * day}); err != nil {
+		t.Fatal(err)
+	}
+	second := get(strings.ReplaceAll(next[1], "&amp;", "&"))
+	if got := slices.Compact(names.FindAllString(second, -1)); !slices.Equal(got, []string{"Timer 25", "Timer 26", "Timer 27", "Timer 28", "Timer 29"}) {
+		t.Errorf("Expected the timers after the cursor, got %v", got)
+	}
+	if !strings.Contains(second, "garden") {
+		t.Errorf("Expected the cards' tags, got %s", second)
+	}
+	if strings.Contains(second, "Timer new") || strings.Contains(second, "Pinned") || strings.Contains(second, ">Later</h2>") || more.MatchString(second) {
+		t.Errorf("Expected no headings that were already shown and nothing more to load, got %s", second)
+	}
+}
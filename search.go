@@ -0,0 +1,15 @@
+package main
+
+import "strings"
+
+// GET /?q= lists only the timers with q somewhere in their name or description, ignoring case. The homepage's search
+// box swaps in the list as it's typed in.
+
+// likeEscaper escapes the characters that are special to LIKE, with \ as its ESCAPE, so that q is matched as it's
+// written.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePattern is the LIKE pattern for text containing q.
+func likePattern(q string) string {
+	return "%" + likeEscaper.Replace(q) + "%"
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestHomepageSearch tests that ?q= matches names and descriptions ignoring case, that LIKE's wildcards in it are
+// matched as they're written, and that htmx gets only the list back
+func TestHomepageSearch(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	for _, c := range []CountDown{
+		{Name: "Furnace Filter"},
+		{Name: "Fridge", Description: "Change the water filter"},
+		{Name: "Top up 100% juice"},
+		{Name: "Clean car_seats"},
+		{Name: "Water plants"},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(q string) []string {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/?sort=name&q="+url.QueryEscape(q), nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		var timers []CountDown
+		decodeResponse(t, w, &timers)
+		var names []string
+		for _, c := range timers {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	for q, expected := range map[string][]string{
+		"FILTER": {"Fridge", "Furnace Filter"},
+		"water":  {"Fridge", "Water plants"},
+		"%":      {"Top up 100% juice"},
+		"_":      {"Clean car_seats"},
+		"p_u":    nil, // Would match "Top up" if _ were a wildcard.
+		"":       {"Clean car_seats", "Fridge", "Furnace Filter", "Top up 100% juice", "Water plants"},
+	} {
+		if got := search(q); !slices.Equal(got, expected) {
+			t.Errorf("Searched for %q, expected %v, got %v", q, expected, got)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/?q=filter", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "<html") || !strings.Contains(body, `id="timerList"`) || !strings.Contains(body, "Furnace Filter") || strings.Contains(body, "Water plants") {
+		t.Errorf("Expected only the list of the matching timers, got %s", body)
+	}
+	if body := serveAPI(t, s, "GET", "/?q=furnace", "").Body.String(); !strings.Contains(body, `name="q" value="furnace"`) {
+		t.Errorf("Expected the search box to show the search, got %s", body)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// snapshot renders a read-only copy of the dashboard as static HTML files keyed by their relative path.
+// index.html lists every timer and links to a timer-{id}.html page per timer.
+func (s *Server) snapshot(ctx context.Context) (map[string][]byte, error) {
+	timers, err := s.listTimers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.renderSnapshot(ctx, timers)
+}
+
+// renderSnapshot renders the snapshot pages for just the given timers.
+func (s *Server) renderSnapshot(ctx context.Context, timers []CountDown) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	render := func(name, template string, data any) error {
+		var buf bytes.Buffer
+		if err := s.renderStatic(ctx, &buf, template, data); err != nil {
+			return fmt.Errorf("Error rendering %s: %w", name, err)
+		}
+		files[name] = buf.Bytes()
+		return nil
+	}
+
+	views := newTimerViews(timers)
+	if err := render("index.html", "homepage", homePageData{Cards: renderCards(ctx, s.renderStatic, views), Empty: len(views) == 0}); err != nil {
+		return nil, err
+	}
+	for _, v := range views {
+		if err := render(fmt.Sprintf("timer-%d.html", v.Id), "timerpage", v); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// writeSnapshotZip writes the snapshot files to w as a zip archive.
+func writeSnapshotZip(w io.Writer, files map[string][]byte) error {
+	// Sort the names so that the archive lists pages in a stable order.
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: clock.Now()})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// writeSnapshotDir writes the snapshot files into dir, creating it if needed.
+func writeSnapshotDir(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, b := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) snapshotHandler(w http.ResponseWriter, r *http.Request) error {
+	files, err := s.snapshot(r.Context())
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="countup-snapshot.zip"`)
+	return writeSnapshotZip(w, files)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestSnapshotZip tests GET /export/snapshot.zip by unzipping the response
+func TestSnapshotZip(t *testing.T) {
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+
+	req := httptest.NewRequest("GET", "/export/snapshot.zip", nil)
+	w := httptest.NewRecorder()
+	(&Server{db: db}).mux().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Expected Content-Type application/zip, got %q", ct)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open zip: %v", err)
+	}
+	pages := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f.Name, err)
+		}
+		pages[f.Name] = string(b)
+	}
+
+	index, ok := pages["index.html"]
+	if !ok {
+		t.Fatalf("Snapshot is missing index.html, got %v", zr.File)
+	}
+	for _, timer := range testTimers {
+		if !strings.Contains(index, timer.Name) {
+			t.Errorf("index.html does not contain timer name: %s", timer.Name)
+		}
+
+		page := fmt.Sprintf("timer-%d.html", timer.Id)
+		if !strings.Contains(index, `href="`+page+`"`) {
+			t.Errorf("index.html does not link to %s", page)
+		}
+		if !strings.Contains(pages[page], timer.Name) {
+			t.Errorf("%s does not contain timer name: %s", page, timer.Name)
+		}
+	}
+
+	// Nothing in the snapshot should talk to the server.
+	for name, page := range pages {
+		for _, forbidden := range []string{"htmx", "hx-", "<form", "data-format-distance-to-now"} {
+			if strings.Contains(page, forbidden) {
+				t.Errorf("%s contains %q", name, forbidden)
+			}
+		}
+	}
+}
+
+// TestSnapshotDoesNotChangeLiveTemplates tests that the static clone leaves the served templates alone
+func TestSnapshotDoesNotChangeLiveTemplates(t *testing.T) {
+	db := setupTestDB(t)
+	insertTestData(t, db)
+	s := &Server{db: db}
+
+	if _, err := s.snapshot(t.Context()); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "hx-post") {
+		t.Errorf("Expected the live homepage to keep its htmx attributes")
+	}
+}
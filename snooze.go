@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// snoozeEnd is how long after now a snooze for text ends, text being like 2d, 1w, 3 days or a Go duration like 3h.
+// Months and years follow the calendar as they do for frequencies. It fails with a 400.
+func snoozeEnd(now time.Time, text string) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(text))
+	if s == "" {
+		return time.Time{}, httpError{http.StatusBadRequest, errors.New("Say how long to snooze for, like 2d or 3 hours")}
+	}
+	// The short units that Go durations don't have.
+	if n, ok := strings.CutSuffix(s, "d"); ok && isDigits(n) {
+		s = n + " days"
+	} else if n, ok := strings.CutSuffix(s, "w"); ok && isDigits(n) {
+		s = n + " weeks"
+	}
+
+	var c CountDown
+	if err := parseFrequency(&c, s); err != nil || c.Frequency <= 0 {
+		return time.Time{}, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing how long to snooze for %q: expected something like 2d, 3 hours or 1 week", text)}
+	}
+	return c.addFrequency(now), nil
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// Snoozed reports whether the timer is due later than it would be because of a snooze that hasn't ended.
+func (c CountDown) Snoozed() bool {
+	return c.repeats() && c.SnoozedUntil.After(clock.Now()) && c.SnoozedUntil.After(c.unsnoozedDue())
+}
+
+// snoozeStatus is what the card says about a snooze in l, empty when the timer isn't Snoozed.
+func (c CountDown) snoozeStatus(l locale) string {
+	if !c.Snoozed() {
+		return ""
+	}
+	return l.tr("Snoozed until %s", c.SnoozedUntil.In(location).Format("Mon Jan 2, 2006 3:04 PM"))
+}
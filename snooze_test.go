@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSnoozeEnd tests reading how long to snooze for
+func TestSnoozeEnd(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	now := time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		text     string
+		expected time.Time
+	}{
+		{"2d", now.AddDate(0, 0, 2)},
+		{"1w", now.AddDate(0, 0, 7)},
+		{"3 days", now.AddDate(0, 0, 3)},
+		{" 3h ", now.Add(3 * time.Hour)},
+		{"1 month", time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := snoozeEnd(now, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	for _, text := range []string{"", "soon", "d", "-2d", "0h"} {
+		if _, err := snoozeEnd(now, text); err == nil {
+			t.Errorf("Expected an error snoozing for %q", text)
+		}
+	}
+}
+
+// TestSnoozeTimerHandler tests that snoozing puts a due timer off without it being done, and that doing it ends the
+// snooze
+func TestSnoozeTimerHandler(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	post := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	timer := CountDown{Name: "Water plants", LastTime: time.Now().Add(-72 * time.Hour).Truncate(time.Second), Frequency: 24 * time.Hour}
+	if err := s.createTimer(t.Context(), &timer); err != nil {
+		t.Fatalf("Failed to create a timer: %v", err)
+	}
+	snooze := fmt.Sprintf("/timer/%d/snooze", timer.Id)
+
+	w := post(snooze+"?for=2d", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if got, expected := w.Header().Get("HX-Trigger"), fmt.Sprintf("timerUpdate/%d, timersChanged", timer.Id); got != expected {
+		t.Errorf("Expected HX-Trigger %q, got %q", expected, got)
+	}
+	c, err := s.getTimer(t.Context(), timer.Id)
+	if err != nil {
+		t.Fatalf("Failed to read the timer: %v", err)
+	}
+	if c.Overdue() || !c.Snoozed() || !c.LastTime.Equal(timer.LastTime) {
+		t.Fatalf("Expected a snoozed timer that isn't overdue nor done, got %+v", c)
+	}
+	if until := time.Until(c.NextDue()); until < 47*time.Hour || until > 48*time.Hour {
+		t.Errorf("Expected it to be due in 2 days, got %v", until)
+	}
+
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/timer/%d", timer.Id), nil))
+	if !strings.Contains(w.Body.String(), "Snoozed until "+c.SnoozedUntil.In(location).Format("Mon Jan 2, 2006 3:04 PM")) {
+		t.Errorf("Expected the card to say until when it's snoozed, got %s", w.Body.String())
+	}
+
+	// The form field works as well as the query, and a new snooze replaces the old one.
+	if w := post(snooze, url.Values{"for": {"3h"}}.Encode()); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if c, _ = s.getTimer(t.Context(), timer.Id); time.Until(c.NextDue()) > 3*time.Hour {
+		t.Errorf("Expected it to be due in 3 hours, got %v", c.NextDue())
+	}
+
+	if w := post(fmt.Sprintf("/timer/%d/reset", timer.Id), ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if c, _ = s.getTimer(t.Context(), timer.Id); !c.SnoozedUntil.IsZero() || c.Snoozed() {
+		t.Errorf("Expected doing the timer to end the snooze, got %v", c.SnoozedUntil)
+	}
+
+	once := CountDown{Name: "Renew passport", LastTime: time.Now()}
+	if err := s.createTimer(t.Context(), &once); err != nil {
+		t.Fatalf("Failed to create a timer: %v", err)
+	}
+	for _, tt := range []struct {
+		name, target   string
+		expectedStatus int
+	}{
+		{"no duration", snooze, http.StatusBadRequest},
+		{"bad duration", snooze + "?for=later", http.StatusBadRequest},
+		{"one-time timer", fmt.Sprintf("/timer/%d/snooze?for=1d", once.Id), http.StatusBadRequest},
+		{"unknown timer", "/timer/999/snooze?for=1d", http.StatusNotFound},
+	} {
+		if w := post(tt.target, ""); w.Code != tt.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.expectedStatus, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The homepage lists the most urgent timers first: the overdue ones, the most overdue at the top, then the ones due
+// soon and then the rest by when they're next due, with the ones that are never due last. GET /?sort= lists them in
+// the user's own order, by when they're next due, their name, when they were last done or when they were created
+// instead, with a - in front for the other way around. Pinned timers come first whatever the order. Dragging a timer
+// is only turned on in the user's own order, since that's what it changes.
+
+// timerSort is a parsed ?sort=, the zero value for the homepage's default of the most urgent first.
+type timerSort struct {
+	Key  string
+	Desc bool
+}
+
+// The keys of ?sort= that aren't just a column.
+const (
+	sortNextDue  = "nextdue"  // Only Go works out when timers are due.
+	sortPosition = "position" // The user's own order, see order.go.
+)
+
+// ownOrder is the order that lists of timers are in, other than the homepage.
+var ownOrder = timerSort{Key: sortPosition}
+
+// timerSortColumns maps the keys of ?sort= to what the SQL orders them by, so that the query only ever has these in
+// it and never the text of the request. Timers that were never done go last either way.
+var timerSortColumns = map[string]string{
+	sortPosition: "position",
+	sortNextDue:  "",
+	"name":       "name COLLATE NOCASE",
+	"lasttime":   "julianday(lasttime)", // lasttime keeps its offset, so it doesn't sort as text.
+	"created":    "created_at",
+}
+
+// sortOption is one of the homepage's choices of order.
+type sortOption struct {
+	Value string
+	Label string
+}
+
+// sortOptions are the orders that the homepage's dropdown offers, its default first.
+var sortOptions = []sortOption{
+	{"", "Most urgent"},
+	{"position", "My order"},
+	{"nextdue", "Due soonest"},
+	{"-nextdue", "Due latest"},
+	{"name", "Name, A to Z"},
+	{"-name", "Name, Z to A"},
+	{"lasttime", "Done longest ago"},
+	{"-lasttime", "Done most recently"},
+	{"-created", "Newest"},
+	{"created", "Oldest"},
+}
+
+// sortValues is every ?sort= but the empty one.
+func sortValues() []string {
+	var values []string
+	for key := range timerSortColumns {
+		values = append(values, key, "-"+key)
+	}
+	slices.Sort(values)
+	return values
+}
+
+// parseSort reads ?sort=, failing with a 400 for a key that isn't in timerSortColumns.
+func parseSort(s string) (timerSort, error) {
+	if s == "" {
+		return timerSort{}, nil
+	}
+	sort := timerSort{Key: strings.TrimPrefix(s, "-"), Desc: strings.HasPrefix(s, "-")}
+	if _, ok := timerSortColumns[sort.Key]; !ok {
+		return sort, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'sort' %q: expected one of %s", s, strings.Join(sortValues(), ", "))}
+	}
+	return sort, nil
+}
+
+// String is the sort as it's written in ?sort=.
+func (s timerSort) String() string {
+	if s.Desc {
+		return "-" + s.Key
+	}
+	return s.Key
+}
+
+// orderBy is the ORDER BY clause for the sort, pinned timers first and then by id for timers that are otherwise equal.
+// The orders that only Go can work out start from the user's own, so that timers that are equal stay in it.
+func (s timerSort) orderBy() string {
+	column := timerSortColumns[s.Key]
+	if column == "" {
+		return ` ORDER BY pinned DESC, position, id`
+	}
+	direction := " ASC"
+	if s.Desc {
+		direction = " DESC"
+	}
+	return ` ORDER BY pinned DESC, ` + column + direction + ` NULLS LAST, id`
+}
+
+// sortByNextDue sorts timers by when they're next due in place, keeping pinned ones first. Timers that aren't ever
+// due go last, in the order they were in.
+func sortByNextDue(timers []CountDown, desc bool) {
+	slices.SortStableFunc(timers, func(a, b CountDown) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		if a.repeats() != b.repeats() {
+			if a.repeats() {
+				return -1
+			}
+			return 1
+		}
+		if !a.repeats() {
+			return 0
+		}
+		n := a.NextDue().Compare(b.NextDue())
+		if desc {
+			n = -n
+		}
+		return n
+	})
+}
+
+// sortByUrgency sorts timers with the most urgent as of now first in place, keeping pinned ones first: overdue, then
+// due soon, then the rest that repeat, each by when they're due, and then the ones that are never due in the order
+// they were in.
+func sortByUrgency(timers []CountDown, now time.Time) {
+	slices.SortStableFunc(timers, func(a, b CountDown) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		if n := cmp.Compare(urgencyRank(a, now), urgencyRank(b, now)); n != 0 || !a.repeats() {
+			return n
+		}
+		return a.NextDue().Compare(b.NextDue())
+	})
+}
+
+// urgencyRank is where c goes as of now in the default order, before any timers with a higher rank: 0 when it's
+// overdue, 1 when it's due soon, 2 for the rest that repeat and 3 when it's never due.
+func urgencyRank(c CountDown, now time.Time) int {
+	if !c.repeats() {
+		return 3
+	}
+	return map[dueState]int{stateOverdue: 0, stateDueSoon: 1, stateOK: 2}[c.state(now)]
+}
+
+// sortTimers finishes sorting timers that were queried with the sort's orderBy.
+func (s timerSort) sortTimers(timers []CountDown) {
+	switch s.Key {
+	case "":
+		sortByUrgency(timers, clock.Now())
+	case sortNextDue:
+		sortByNextDue(timers, s.Desc)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseSort tests the keys of ?sort= and that anything else is a 400 rather than part of the query
+func TestParseSort(t *testing.T) {
+	for s, expected := range map[string]timerSort{"": {}, "name": {"name", false}, "-nextdue": {"nextdue", true}, "-created": {"created", true}, "position": {"position", false}} {
+		got, err := parseSort(s)
+		if err != nil || got != expected || got.String() != s {
+			t.Errorf("Expected %q to be %+v, got %+v, %v", s, expected, got, err)
+		}
+	}
+	for _, s := range []string{"-", "--name", "Name", "due", "name; DROP TABLE timer", "id"} {
+		if _, err := parseSort(s); err == nil {
+			t.Errorf("Expected %q to be an error", s)
+		}
+	}
+}
+
+// TestSortedHomepage tests each order of the homepage, with pinned timers first and the never due ones last
+func TestSortedHomepage(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	now := clock.Now()
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []CountDown{
+		// Done an hour after "bins", though its time is earlier as text.
+		{Name: "bins", LastTime: now.Add(-3 * time.Hour).UTC(), Frequency: 7 * 24 * time.Hour},
+		{Name: "Aquarium", LastTime: now.Add(-2 * time.Hour).In(newYork), Frequency: 24 * time.Hour},
+		{Name: "Haircut"},
+		{Name: "Car wash", LastTime: now.Add(-time.Hour), Frequency: 30 * 24 * time.Hour},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(target string) []string {
+		t.Helper()
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		var timers []CountDown
+		decodeResponse(t, w, &timers)
+		var names []string
+		for _, c := range timers {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	for sort, expected := range map[string][]string{
+		"":          {"Aquarium", "bins", "Car wash", "Haircut"},
+		"position":  {"Car wash", "Haircut", "Aquarium", "bins"},
+		"name":      {"Aquarium", "bins", "Car wash", "Haircut"},
+		"-name":     {"Haircut", "Car wash", "bins", "Aquarium"},
+		"nextdue":   {"Aquarium", "bins", "Car wash", "Haircut"},
+		"-nextdue":  {"Car wash", "bins", "Aquarium", "Haircut"},
+		"lasttime":  {"bins", "Aquarium", "Car wash", "Haircut"},
+		"-lasttime": {"Car wash", "Aquarium", "bins", "Haircut"},
+	} {
+		if got := get("/?sort=" + sort); !slices.Equal(got, expected) {
+			t.Errorf("Sorted by %q, expected %v, got %v", sort, expected, got)
+		}
+	}
+
+	if _, err := s.togglePin(t.Context(), 3); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := get("/?sort=nextdue"), []string{"Haircut", "Aquarium", "bins", "Car wash"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected the pinned timer first, got %v", got)
+	}
+
+	if w := serveAPI(t, s, "GET", "/?sort=due", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown sort to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+	body := serveAPI(t, s, "GET", "/?sort=-name", "").Body.String()
+	if !strings.Contains(body, `<option value="-name" selected>`) || !strings.Contains(body, `data-sort="-name"`) {
+		t.Errorf("Expected the dropdown to show the sort, got %s", body)
+	}
+}
+
+// TestSortByUrgency tests that overdue timers come first, the most overdue at the top, then the ones due soon, then
+// the rest by when they're due and the never due ones last, with pinned timers before all of them
+func TestSortByUrgency(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	timers := []CountDown{
+		{Name: "Haircut", LastTime: now.Add(-40 * day)},
+		{Name: "Coffee", LastTime: now.Add(-time.Hour), Frequency: day},
+		// Due in 2 days but within its grace, so it comes before Coffee which is due sooner.
+		{Name: "Passport", LastTime: now.Add(-28 * day), Frequency: 30 * day, Grace: 3 * day},
+		{Name: "Floss", LastTime: now.Add(-26 * time.Hour), Frequency: day},
+		{Name: "Gutters", LastTime: now},
+		{Name: "Water plants", LastTime: now.Add(-4 * day), Frequency: day},
+		{Name: "Bins", LastTime: now.Add(-2 * time.Hour), Frequency: 7 * day},
+	}
+	sortByUrgency(timers, now)
+	var names []string
+	for _, c := range timers {
+		names = append(names, c.Name)
+	}
+	if expected := []string{"Water plants", "Floss", "Passport", "Coffee", "Bins", "Haircut", "Gutters"}; !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	timers[4].Pinned = true
+	sortByUrgency(timers, now)
+	if timers[0].Name != "Bins" {
+		t.Errorf("Expected the pinned timer first, got %s", timers[0].Name)
+	}
+}
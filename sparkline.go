@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sparkline is the gaps between a timer's last few completions, oldest first, next to how often it's meant to be done,
+// for the small bar chart on its page. They're in hours rather than nanoseconds like the rest of the API, since
+// that's what a chart wants.
+type sparkline struct {
+	Intervals   []float64 `json:"intervals"`             // Between each completion and the one before it.
+	TargetHours float64   `json:"targetHours,omitempty"` // The timer's period, left out for one without a schedule.
+}
+
+// How many completions a sparkline is of by default, and at the most.
+const (
+	sparklineDefault = 20
+	sparklineMax     = 366
+)
+
+// computeSparkline works out the gaps between the last n of c's completions, in any order. Skips are left out as
+// with its stats, and there are no gaps at all until it was done twice.
+func computeSparkline(c CountDown, completions []completion, n int) sparkline {
+	done := doneInOrder(completions)
+	done = done[max(0, len(done)-n):]
+	line := sparkline{Intervals: []float64{}, TargetHours: hours(c.period())}
+	for i := 1; i < len(done); i++ {
+		line.Intervals = append(line.Intervals, hours(done[i].CompletedAt.Sub(done[i-1].CompletedAt)))
+	}
+	return line
+}
+
+// period is how long c has between being due now, 0 when it has no schedule. A cron schedule's can vary, like
+// weekdays only, so it's the one from its next due time.
+func (c CountDown) period() time.Duration {
+	if c.Cron != "" {
+		due := c.NextDue()
+		return c.after(due).Sub(due)
+	}
+	return c.Frequency
+}
+
+// hours is d in hours, to the hundredth.
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+// sparklineHandler serves the gaps between a timer's last ?n completions as JSON.
+func (s *Server) sparklineHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	n := sparklineDefault
+	if v := r.URL.Query().Get("n"); v != "" {
+		if n, err = strconv.Atoi(v); err != nil || n < 2 || n > sparklineMax {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'n': must be a number from 2 to %d", sparklineMax)}
+		}
+	}
+	if _, err := negotiate(w, r, "application/json"); err != nil {
+		return err
+	}
+	c, err := s.getTimer(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	completions, err := s.listCompletions(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, computeSparkline(c, completions, n))
+}
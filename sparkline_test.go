@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestComputeSparkline tests the gaps between the last completions, without skips, and the period they're against
+func TestComputeSparkline(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	done := func(hours float64, kind string) completion {
+		return completion{CompletedAt: start.Add(time.Duration(hours * float64(time.Hour))), Kind: kind}
+	}
+	daily := CountDown{Frequency: 24 * time.Hour, LastTime: start}
+	// Newest first, as listCompletions has them.
+	history := []completion{done(100, CompletionDone), done(90, CompletionSkipped), done(72.5, CompletionPartial), done(48, CompletionDone), done(20, CompletionDone), done(0, CompletionDone)}
+
+	for _, test := range []struct {
+		name     string
+		c        CountDown
+		history  []completion
+		n        int
+		expected sparkline
+	}{
+		{"never done", daily, nil, 20, sparkline{[]float64{}, 24}},
+		{"done once", daily, history[:1], 20, sparkline{[]float64{}, 24}},
+		{"every gap", daily, history, 20, sparkline{[]float64{20, 28, 24.5, 27.5}, 24}},
+		{"the last three", daily, history, 3, sparkline{[]float64{24.5, 27.5}, 24}},
+		{"no schedule", CountDown{}, history, 20, sparkline{[]float64{20, 28, 24.5, 27.5}, 0}},
+	} {
+		got := computeSparkline(test.c, test.history, test.n)
+		if !slices.Equal(got.Intervals, test.expected.Intervals) || got.TargetHours != test.expected.TargetHours {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, got)
+		}
+	}
+
+	// Every weekday at 9, due on Friday so the next gap is over the weekend.
+	weekdays := CountDown{Cron: "0 9 * * 1-5", LastTime: time.Date(2025, 3, 6, 9, 0, 0, 0, time.UTC)}
+	if got := computeSparkline(weekdays, nil, 20).TargetHours; got != 72 {
+		t.Errorf("Expected the cron schedule's next period of 72 hours, got %v", got)
+	}
+}
+
+// TestSparklineHandler tests ?n and that a timer that was barely done is an empty array rather than an error
+func TestSparklineHandler(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Floss","frequency":"1 day"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	var line sparkline
+	decodeResponse(t, serveAPI(t, s, "GET", "/timer/1/sparkline", ""), &line)
+	if line.Intervals == nil || len(line.Intervals) != 0 || line.TargetHours != 24 {
+		t.Errorf("Expected no gaps against 24 hours, got %+v", line)
+	}
+
+	now := clock.Now()
+	for _, done := range []time.Time{now.Add(-50 * time.Hour), now.Add(-26 * time.Hour), now} {
+		if err := s.resetTimer(t.Context(), 1, done, CompletionDone, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	decodeResponse(t, serveAPI(t, s, "GET", "/timer/1/sparkline?n=2", ""), &line)
+	if !slices.Equal(line.Intervals, []float64{26}) {
+		t.Errorf("Expected the last gap of 26 hours, got %+v", line)
+	}
+
+	for _, target := range []string{"/timer/1/sparkline?n=1", "/timer/1/sparkline?n=1000", "/timer/1/sparkline?n=all"} {
+		if w := serveAPI(t, s, "GET", target, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be a Bad Request, got %v: %s", target, w.Code, w.Body.String())
+		}
+	}
+	if w := serveAPI(t, s, "GET", "/timer/99/sparkline", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing timer to be Not Found, got %v: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// The stats are only served with -instance-stats, and need the -api-token like /admin/ does. See `countup fleet`.
+const instanceStatsPath = "/api/instance-stats"
+
+// instanceStats is how an instance is doing, for comparing several of them side by side with `countup fleet`.
+type instanceStats struct {
+	Version       string    `json:"version"`
+	StartedAt     time.Time `json:"startedAt"`
+	UptimeSeconds int64     `json:"uptimeSeconds"`
+	Timers        int       `json:"timers"`
+	Overdue       int       `json:"overdue"`
+	DBSizeBytes   int64     `json:"dbSizeBytes"`
+	// The last delivery to each of hooks and caldav, for the ones that are turned on.
+	Notifications map[string]*deliveryStatus `json:"notifications"`
+}
+
+// deliveryStatus is how the last attempt at sending something out of the server went.
+type deliveryStatus struct {
+	At    time.Time `json:"at"`
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+}
+
+// delivery records the last deliveryStatus of a hookRunner or caldavSyncer, whose workers record while the stats read.
+type delivery struct {
+	mu   sync.Mutex
+	last *deliveryStatus
+}
+
+func (d *delivery) record(err error) {
+	status := &deliveryStatus{At: clock.Now(), OK: err == nil}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.last = status
+}
+
+// status is the last delivery, nil if nothing has been sent yet.
+func (d *delivery) status() *deliveryStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.last
+}
+
+// buildVersion is the module version that the binary was built from, or its commit when built from a checkout.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	version, modified := "", false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			version = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if version == "" {
+		return "(devel)"
+	}
+	if modified {
+		version += "+dirty"
+	}
+	return version
+}
+
+// instanceStats gathers the stats from the timers, the database file and the notifiers.
+func (s *Server) instanceStats(ctx context.Context) (instanceStats, error) {
+	now := clock.Now()
+	stats := instanceStats{
+		Version:       buildVersion(),
+		StartedAt:     s.startedAt,
+		UptimeSeconds: int64(now.Sub(s.startedAt) / time.Second),
+		Notifications: map[string]*deliveryStatus{},
+	}
+
+	timers, err := s.listTimers(ctx)
+	if err != nil {
+		return stats, err
+	}
+	stats.Timers = len(timers)
+	for _, c := range timers {
+		if c.Overdue() {
+			stats.Overdue++
+		}
+	}
+
+	// Pages in use and free ones, the size of the file less any -wal.
+	if err := s.db.QueryRowContext(ctx, `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`).Scan(&stats.DBSizeBytes); err != nil {
+		return stats, err
+	}
+
+	if s.hooks != nil {
+		stats.Notifications["hooks"] = s.hooks.delivered.status()
+	}
+	if s.caldav != nil {
+		stats.Notifications["caldav"] = s.caldav.delivered.status()
+	}
+	return stats, nil
+}
+
+func (s *Server) instanceStatsHandler(w http.ResponseWriter, r *http.Request) (int, any, error) {
+	if !s.serveInstanceStats {
+		return 0, nil, httpError{http.StatusNotFound, errors.New("This server doesn't serve its stats, see -instance-stats")}
+	}
+	stats, err := s.instanceStats(r.Context())
+	return http.StatusOK, stats, err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestInstanceStats tests that the stats are only served when turned on and with the token, and what they count
+func TestInstanceStats(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db, apiToken: "s3cret", startedAt: time.Now().Add(-time.Hour), hooks: newHookRunner("exit 1", EventCreated, 5*time.Second)}
+	get := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/instance-stats", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("Bearer s3cret"); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status Not Found without -instance-stats, got %v: %s", w.Code, w.Body.String())
+	}
+	s.serveInstanceStats = true
+	if w := get(""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status Unauthorized without the token, got %v: %s", w.Code, w.Body.String())
+	}
+
+	for _, c := range []CountDown{
+		{Name: "Water plants", LastTime: time.Now().Add(-72 * time.Hour), Frequency: 24 * time.Hour},
+		{Name: "Change sheets", LastTime: time.Now(), Frequency: 7 * 24 * time.Hour},
+		{Name: "Renew passport", LastTime: time.Now()},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatalf("Failed to create a timer: %v", err)
+		}
+	}
+	s.hooks.Close() // Waits for the hook to have run for every timer.
+
+	w := get("Bearer s3cret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	var stats instanceStats
+	decodeResponse(t, w, &stats)
+	if stats.Timers != 3 || stats.Overdue != 1 {
+		t.Errorf("Expected 3 timers with 1 overdue, got %d with %d", stats.Timers, stats.Overdue)
+	}
+	if stats.UptimeSeconds < 3600 || stats.Version == "" || stats.DBSizeBytes <= 0 {
+		t.Errorf("Expected an uptime of an hour, a version and a database size, got %+v", stats)
+	}
+	if hooks := stats.Notifications["hooks"]; hooks == nil || hooks.OK || hooks.Error == "" {
+		t.Errorf("Expected the failed hook in the notifications, got %+v", hooks)
+	}
+	if _, ok := stats.Notifications["caldav"]; ok {
+		t.Error("Expected no CalDAV notifications without -caldav-url")
+	}
+}
@@ -0,0 +1,622 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The columns of the timer table in the order that scanTimer expects them.
+const timerColumns = timerRowColumns + `, ` + tagsColumn + `, ` + timesDoneColumn + `, ` + periodColumn
+
+// timerRowColumns are the timer's own columns in timerColumns, without the ones that go through other tables.
+const timerRowColumns = `id, name, description, lasttime, frequency, frequency_value, frequency_unit, reference_url, created_at, updated_at, due_time_of_day, cron, skip_weekends, anchor, ends_at, max_completions, completions, skipped_until, snoozed_until, paused_at, grace, priority, color, icon, pinned, position, version, target_count, target_period, streak, paused_for`
+
+// scheduleColumns stand in for timerColumns where only when timers are due matters, leaving out their tags, how many
+// times they were done and how many times this period.
+const scheduleColumns = timerRowColumns + `, NULL, 0, NULL`
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTimer reads a row selected with timerColumns into a CountDown.
+func scanTimer(row scanner) (CountDown, error) {
+	var c CountDown
+	var created, updated, anchor, endsAt, skippedUntil, snoozedUntil, pausedAt string
+	var dueTimeOfDay sql.NullInt64
+	var lastTime, tags, period sql.NullString
+	var streak sql.NullInt64
+	if err := row.Scan(&c.Id, &c.Name, &c.Description, &lastTime, &c.Frequency, &c.FrequencyValue, &c.FrequencyUnit, &c.ReferenceURL, &created, &updated, &dueTimeOfDay, &c.Cron, &c.SkipWeekends, &anchor,
+		&endsAt, &c.MaxCompletions, &c.Completions, &skippedUntil, &snoozedUntil, &pausedAt, &c.Grace, &c.Priority, &c.Color, &c.Icon, &c.Pinned, &c.Position, &c.Version, &c.TargetCount, &c.TargetPeriod, &streak, &c.PausedFor, &tags, &c.TimesDone, &period); err != nil {
+		return c, err
+	}
+	if dueTimeOfDay.Valid {
+		minutes := int(dueTimeOfDay.Int64)
+		c.DueTimeOfDay = &minutes
+	}
+	if created != "" {
+		var err error
+		if c.CreatedAt, err = time.Parse(time.RFC3339, created); err != nil {
+			return c, err
+		}
+	}
+	if updated != "" {
+		var err error
+		if c.UpdatedAt, err = time.Parse(updatedAtLayout, updated); err != nil {
+			return c, err
+		}
+	}
+	if anchor != "" {
+		var err error
+		if c.Anchor, err = time.Parse(time.RFC3339, anchor); err != nil {
+			return c, err
+		}
+	}
+	if endsAt != "" {
+		var err error
+		if c.EndsAt, err = time.Parse(time.RFC3339, endsAt); err != nil {
+			return c, err
+		}
+	}
+	if skippedUntil != "" {
+		var err error
+		if c.SkippedUntil, err = time.Parse(time.RFC3339, skippedUntil); err != nil {
+			return c, err
+		}
+	}
+	if snoozedUntil != "" {
+		var err error
+		if c.SnoozedUntil, err = time.Parse(time.RFC3339, snoozedUntil); err != nil {
+			return c, err
+		}
+	}
+	if pausedAt != "" {
+		var err error
+		if c.PausedAt, err = time.Parse(time.RFC3339, pausedAt); err != nil {
+			return c, err
+		}
+	}
+	if tags.Valid {
+		c.Tags = strings.Split(tags.String, ",")
+	}
+
+	// Timers that have never been done have a NULL lasttime.
+	if lastTime.Valid {
+		var err error
+		if c.LastTime, err = time.Parse(time.RFC3339, lastTime.String); err != nil {
+			return c, err
+		}
+	}
+	now := clock.Now()
+	c.Streak = c.streakAt(int(streak.Int64), now)
+	if period.Valid {
+		completions, err := parsePeriodColumn(period.String)
+		if err != nil {
+			return c, err
+		}
+		c.DoneThisPeriod = c.doneInPeriod(completions, now)
+	}
+	return c, nil
+}
+
+// formatLastTime is the inverse of the parsing of the times stored as text in scanTimer, empty for the zero time.
+func formatLastTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// nullTime is t for the lasttime column, which is NULL rather than empty for the zero time.
+func nullTime(t time.Time) sql.NullString {
+	return sql.NullString{String: formatLastTime(t), Valid: !t.IsZero()}
+}
+
+// The layout of updated_at, fixed width and always UTC so that MAX() orders the strings by time.
+const updatedAtLayout = "2006-01-02T15:04:05.000000000Z"
+
+// updatedAt is the value for updated_at in every statement that changes a timer.
+func updatedAt() string {
+	return clock.Now().UTC().Format(updatedAtLayout)
+}
+
+// lastModified is when any timer last changed, including being deleted, or a vacation started or ended. It's zero if
+// that isn't known.
+func (s *Server) lastModified(ctx context.Context) (time.Time, error) {
+	var max string
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(updated_at), '') FROM (SELECT updated_at FROM timer UNION ALL SELECT updated_at FROM vacation)`).Scan(&max); err != nil || max == "" {
+		return time.Time{}, err
+	}
+	return time.Parse(updatedAtLayout, max)
+}
+
+// timerID parses the {id} path value of a request, failing with a 400.
+func timerID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing id : %w", err)}
+	}
+	return id, nil
+}
+
+// doneAt is when a reset says the timer was done: the optional at form field, otherwise now. See parseDoneTime.
+func doneAt(r *http.Request) (time.Time, error) {
+	at := r.FormValue("at")
+	if at == "" {
+		return clock.Now(), nil
+	}
+	return parseDoneTime(at)
+}
+
+// parseDoneTime reads when a timer was done, as a datetime-local input in location or RFC 3339. It fails with a 400
+// for a time in the future.
+func parseDoneTime(at string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02T15:04", at, location)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, at); err != nil {
+			return t, httpError{http.StatusBadRequest, fmt.Errorf("Error parsing when it was done %q: expected a time like 2025-03-10T21:30", at)}
+		}
+	}
+	if t.After(clock.Now()) {
+		return t, httpError{http.StatusBadRequest, fmt.Errorf("It can't have been done in the future, at %s", t.In(location).Format("Mon Jan 2, 2006 3:04 PM"))}
+	}
+	return t, nil
+}
+
+// checkOneRow turns the result of a statement that targets a single timer into a 404 when nothing matched.
+func checkOneRow(result sql.Result, id int64) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return httpError{http.StatusNotFound, fmt.Errorf("No timer with id: %d", id)}
+	}
+	if rows > 1 {
+		return fmt.Errorf("Expected only 1 row to be affected, but instead %d were", rows)
+	}
+	return nil
+}
+
+func (s *Server) listTimers(ctx context.Context) ([]CountDown, error) {
+	return s.listTaggedTimers(ctx, "", "", ownOrder)
+}
+
+// countTimers is how many timers there are, not counting deleted ones.
+func (s *Server) countTimers(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM timer WHERE deleted_at IS NULL`).Scan(&n)
+	return n, err
+}
+
+// listTaggedTimers is listTimers for only the timers tagged tag that match the search q, see search.go, in the order
+// of sort. Either being empty leaves the timers unfiltered by it.
+func (s *Server) listTaggedTimers(ctx context.Context, tag, q string, sort timerSort) ([]CountDown, error) {
+	query, args := filterTimers(tag, q)
+	timers, err := queryTimers(ctx, s.db, `SELECT `+timerColumns+` FROM timer WHERE deleted_at IS NULL`+query+sort.orderBy(), args...)
+	if err != nil {
+		return nil, err
+	}
+	sort.sortTimers(timers)
+	return timers, nil
+}
+
+// filterTimers is the conditions to add to a query of timers, and their arguments, for only the ones tagged tag that
+// match the search q.
+func filterTimers(tag, q string) (string, []any) {
+	var query string
+	var args []any
+	if tag != "" {
+		query += ` AND id IN (SELECT timer_id FROM timer_tag JOIN tag ON tag.id = timer_tag.tag_id WHERE tag.name = ?)`
+		args = append(args, normalizeTag(tag))
+	}
+	if q != "" {
+		query += ` AND (name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`
+		args = append(args, likePattern(q), likePattern(q))
+	}
+	return query, args
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryTimers reads the timers that query, which selects timerColumns, returns.
+func queryTimers(ctx context.Context, db querier, query string, args ...any) ([]CountDown, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var timers []CountDown
+	for rows.Next() {
+		c, err := scanTimer(rows)
+		if err != nil {
+			return nil, err
+		}
+		timers = append(timers, c)
+	}
+	return timers, rows.Err()
+}
+
+func (s *Server) getTimer(ctx context.Context, id int64) (CountDown, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+timerColumns+` FROM timer WHERE id = ? AND deleted_at IS NULL`, id)
+	c, err := scanTimer(row)
+	if err == sql.ErrNoRows {
+		return c, httpError{http.StatusNotFound, fmt.Errorf("No timer with id: %d", id)}
+	}
+	return c, err
+}
+
+// createTimer inserts c and fills in its Id.
+func (s *Server) createTimer(ctx context.Context, c *CountDown) error {
+	if err := validateTimer(*c); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := insertTimer(ctx, tx, c); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.emit(EventCreated, *c)
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// queryExecer is what both querier and execer need, like a transaction.
+type queryExecer interface {
+	querier
+	execer
+}
+
+// insertTimer is the INSERTs behind createTimer, without the validation and event, so db should be a transaction.
+// c's frequency and tags are normalized to what is stored, its CreatedAt is set unless it's an imported timer that
+// has one, and its UpdatedAt is set. It goes in at c.Position, the top for a new timer, moving the timers from there
+// down one.
+func insertTimer(ctx context.Context, db execer, c *CountDown) error {
+	c.normalizeFrequency()
+	c.Tags = normalizeTags(c.Tags)
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = clock.Now().UTC().Truncate(time.Second)
+	}
+	c.UpdatedAt = clock.Now().UTC()
+	c.Version = 1 // As the column's default, a copy or an imported timer starts again.
+	if _, err := db.ExecContext(ctx, `UPDATE timer SET position = position + 1 WHERE position >= ?`, c.Position); err != nil {
+		return err
+	}
+	result, err := db.ExecContext(ctx,
+		`INSERT INTO timer (name, description, lasttime, frequency, frequency_value, frequency_unit, reference_url, updated_at, created_at, due_time_of_day, cron, skip_weekends, anchor, ends_at, max_completions, completions, skipped_until, snoozed_until, paused_at, grace, priority, color, icon, pinned, position, target_count, target_period, streak, paused_for) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,0,?);`,
+		c.Name, c.Description, nullTime(c.LastTime), c.Frequency, c.FrequencyValue, c.FrequencyUnit, c.ReferenceURL, c.UpdatedAt.Format(updatedAtLayout),
+		c.CreatedAt.UTC().Format(time.RFC3339), c.DueTimeOfDay, c.Cron, c.SkipWeekends, formatLastTime(c.Anchor), formatLastTime(c.EndsAt), c.MaxCompletions, c.Completions, formatLastTime(c.SkippedUntil), formatLastTime(c.SnoozedUntil), formatLastTime(c.PausedAt), c.Grace, c.Priority, c.Color, c.Icon, c.Pinned, c.Position, c.TargetCount, c.TargetPeriod, c.PausedFor)
+	if err != nil {
+		return err
+	}
+
+	if c.Id, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	// The time it was last done starts its history, as for the timers from before there was one.
+	if !c.LastTime.IsZero() {
+		if err := addCompletion(ctx, db, c.Id, CompletionDone, c.LastTime, time.Time{}, ""); err != nil {
+			return err
+		}
+	}
+	return setTimerTags(ctx, db, c.Id, c.Tags)
+}
+
+// updateTimer overwrites every stored field of the timer with c.Id but CreatedAt, Completions, which only resets
+// count, PausedAt and PausedFor, which only pausing and unpausing change, though a new LastTime clears PausedFor, and Pinned and Position, which only pinning and reordering
+// do. c's frequency and tags are normalized to what is stored, and its UpdatedAt is set. c.Version has to be the
+// timer's version, see checkVersion, and goes up by one.
+func (s *Server) updateTimer(ctx context.Context, c *CountDown) error {
+	if err := validateTimer(*c); err != nil {
+		return err
+	}
+
+	c.normalizeFrequency()
+	c.Tags = normalizeTags(c.Tags)
+	c.UpdatedAt = clock.Now().UTC()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx,
+		`UPDATE timer SET name = ?, description = ?, lasttime = ?, frequency = ?, frequency_value = ?, frequency_unit = ?, reference_url = ?, due_time_of_day = ?, cron = ?, skip_weekends = ?, anchor = ?, ends_at = ?, max_completions = ?, skipped_until = ?, snoozed_until = ?, grace = ?, priority = ?, color = ?, icon = ?, target_count = ?, target_period = ?, paused_for = CASE WHEN lasttime IS ? THEN paused_for ELSE 0 END, version = version + 1, updated_at = ? WHERE id = ? AND version = ? AND deleted_at IS NULL`,
+		c.Name, c.Description, nullTime(c.LastTime), c.Frequency, c.FrequencyValue, c.FrequencyUnit, c.ReferenceURL, c.DueTimeOfDay, c.Cron, c.SkipWeekends, formatLastTime(c.Anchor),
+		formatLastTime(c.EndsAt), c.MaxCompletions, formatLastTime(c.SkippedUntil), formatLastTime(c.SnoozedUntil), c.Grace, c.Priority, c.Color, c.Icon, c.TargetCount, c.TargetPeriod, nullTime(c.LastTime), c.UpdatedAt.Format(updatedAtLayout), c.Id, c.Version)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		tx.Rollback()
+		current, err := s.getTimer(ctx, c.Id)
+		if err != nil {
+			return err
+		}
+		return checkVersion(current, c.Version)
+	}
+	if err := setTimerTags(ctx, tx, c.Id, c.Tags); err != nil {
+		return err
+	}
+	// Completions from before due times were kept go by the schedule.
+	if err := storeStreak(ctx, tx, *c); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	c.Version++
+	s.emit(EventUpdated, *c)
+	return nil
+}
+
+// deleteTimer hides the timer everywhere until it is restored with restoreTimer or purged for good by
+// purgeDeletedTimers.
+func (s *Server) deleteTimer(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE timer SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		clock.Now().UTC().Format(time.RFC3339), updatedAt(), id) // UTC so that purgeDeletedTimers can compare them as strings.
+	if err != nil {
+		return err
+	}
+	if err := checkOneRow(result, id); err != nil {
+		return err
+	}
+	s.emit(EventDeleted, CountDown{Id: id})
+	return nil
+}
+
+// restoreTimer undoes deleteTimer, as long as the timer hasn't been purged yet.
+func (s *Server) restoreTimer(ctx context.Context, id int64) (CountDown, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE timer SET deleted_at = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NOT NULL`, updatedAt(), id)
+	if err != nil {
+		return CountDown{}, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return CountDown{}, err
+	}
+	if rows == 0 {
+		return CountDown{}, httpError{http.StatusNotFound, fmt.Errorf("No deleted timer with id: %d", id)}
+	}
+
+	c, err := s.getTimer(ctx, id)
+	if err != nil {
+		return c, err
+	}
+	s.emit(EventRestored, c)
+	return c, nil
+}
+
+// How long deleted timers can still be restored for.
+const deletedTimerRetention = 30 * 24 * time.Hour
+
+// purgeDeletedTimers removes the timers deleted more than deletedTimerRetention ago, returning how many there were.
+func (s *Server) purgeDeletedTimers(ctx context.Context) (int64, error) {
+	lock, err := acquireOperationLock(ctx, s.db, OperationPurge)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.release()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM timer WHERE deleted_at < ?`,
+		clock.Now().Add(-deletedTimerRetention).UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	// Their timer_tag rows went with them, which may leave tags that no timer has.
+	if err := dropUnusedTags(ctx, s.db); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// resetTimer records that the timer was done at t, fully or partly as kind says, with an optional note for its
+// history, failing with a 400 for a note that's too long and a 409 for a finished timer. A t from before the timer was
+// last done only goes in its history, as backfillTimer would have it.
+func (s *Server) resetTimer(ctx context.Context, id int64, t time.Time, kind, note string) error {
+	if err := validateNote(note); err != nil {
+		return err
+	}
+	c, err := s.getTimer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.Finished() {
+		return finishedError(c)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	latest := !t.Before(c.LastTime)
+	var result sql.Result
+	if latest {
+		// Being done puts the schedule back on track, so skips and snoozes are forgotten.
+		result, err = tx.ExecContext(ctx, `UPDATE timer SET lasttime = ?, paused_for = 0, completions = completions + 1, skipped_until = '', snoozed_until = '', updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+			nullTime(t), updatedAt(), id)
+	} else {
+		result, err = tx.ExecContext(ctx, `UPDATE timer SET completions = completions + 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL`, updatedAt(), id)
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkOneRow(result, id); err != nil {
+		return err
+	}
+	// When an earlier time was due isn't known any more, so it goes by the schedule like a backfilled one.
+	var due time.Time
+	if latest {
+		due = c.dueWhenDone(t)
+	}
+	if err := addCompletion(ctx, tx, id, kind, t, due, note); err != nil {
+		return err
+	}
+	if err := storeStreak(ctx, tx, c); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	c.Completions++
+	if latest {
+		c.LastTime, c.PausedFor = t, 0
+		c.SkippedUntil, c.SnoozedUntil = time.Time{}, time.Time{}
+	}
+	s.emit(EventReset, c)
+	return nil
+}
+
+// skipTimer lets the timer's next occurrence go without doing it, so it's due one period later and LastTime still
+// says when it was really done. A timer that doesn't repeat has no occurrence to skip, that's a 400.
+func (s *Server) skipTimer(ctx context.Context, id int64) error {
+	c, err := s.getTimer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !c.scheduled() {
+		return httpError{http.StatusBadRequest, fmt.Errorf("%q doesn't repeat, there's nothing to skip", c.Name)}
+	}
+	if c.Finished() {
+		return finishedError(c)
+	}
+	if c.Paused() {
+		return pausedError(c)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	now, due := clock.Now(), c.NextDue()
+	c.SkippedUntil = c.skipWeekend(c.after(due))
+	result, err := tx.ExecContext(ctx, `UPDATE timer SET skipped_until = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		formatLastTime(c.SkippedUntil), updatedAt(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkOneRow(result, id); err != nil {
+		return err
+	}
+	if err := addCompletion(ctx, tx, id, CompletionSkipped, now, due, ""); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.emit(EventSkipped, c)
+	return nil
+}
+
+// snoozeTimer puts a timer that can't be done now off until d from now, without it counting as done. It's due then
+// unless it would have been due later anyway, and resetting it ends the snooze. A timer that doesn't repeat is never
+// due, so there's nothing to snooze, that's a 400.
+func (s *Server) snoozeTimer(ctx context.Context, id int64, d string) error {
+	c, err := s.getTimer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !c.scheduled() {
+		return httpError{http.StatusBadRequest, fmt.Errorf("%q doesn't repeat, there's nothing to snooze", c.Name)}
+	}
+	if c.Finished() {
+		return finishedError(c)
+	}
+	if c.Paused() {
+		return pausedError(c)
+	}
+	if c.SnoozedUntil, err = snoozeEnd(clock.Now(), d); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE timer SET snoozed_until = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		formatLastTime(c.SnoozedUntil), updatedAt(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkOneRow(result, id); err != nil {
+		return err
+	}
+	s.emit(EventSnoozed, c)
+	return nil
+}
+
+// pauseTimer stops a timer from coming due until unpauseTimer, e.g. for watering a plant that's dormant for the
+// winter. Pausing a paused timer leaves it paused since when it was. A timer that doesn't repeat is never due, so there's
+// nothing to pause, that's a 400.
+func (s *Server) pauseTimer(ctx context.Context, id int64) error {
+	c, err := s.getTimer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !c.scheduled() {
+		return httpError{http.StatusBadRequest, fmt.Errorf("%q doesn't repeat, there's nothing to pause", c.Name)}
+	}
+	if c.Paused() {
+		return nil
+	}
+
+	c.PausedAt = clock.Now()
+	result, err := s.db.ExecContext(ctx, `UPDATE timer SET paused_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		formatLastTime(c.PausedAt), updatedAt(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkOneRow(result, id); err != nil {
+		return err
+	}
+	s.emit(EventPaused, c)
+	return nil
+}
+
+// unpauseTimer lets a paused timer come due again. With shift its schedule is put back by as long as it was paused, so
+// that it's due as long after being resumed as it was from being paused, rather than straight away. LastTime is left
+// as when it was really done. Unpausing a timer that isn't paused does nothing.
+func (s *Server) unpauseTimer(ctx context.Context, id int64, shift bool) error {
+	c, err := s.getTimer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !c.Paused() {
+		return nil
+	}
+
+	if shift && !c.LastTime.IsZero() {
+		c.PausedFor += clock.Now().Sub(c.PausedAt)
+	}
+	c.PausedAt = time.Time{}
+	result, err := s.db.ExecContext(ctx, `UPDATE timer SET paused_for = ?, paused_at = '', paused_by_vacation = 0, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		c.PausedFor, updatedAt(), id)
+	if err != nil {
+		return err
+	}
+	if err := checkOneRow(result, id); err != nil {
+		return err
+	}
+	s.emit(EventUnpaused, c)
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+// A streak is how many times in a row a timer was done before it was overdue. Each completion keeps when the timer
+// was due as it was done, so that snoozing, skipping and vacations count the way they did on the dashboard at the
+// time: a timer done before its snooze ran out was done on time. Completions from before that was kept go by the
+// schedule alone. Being due soon, within its grace, is still on time, only overdue breaks a streak. A skipped
+// occurrence neither breaks a streak nor adds to it, and nor does one that was only partly done on time.
+//
+// Working a streak out takes the whole history, too much to read for every timer on every page, so the streak as of
+// the last completion is kept on the timer's row by storeStreak whenever its history or schedule changes. Only
+// whether it's overdue since is left for when it's read.
+
+// storeStreak keeps c's current streak as of its last completion on its row, for scanTimer, so db should be the
+// transaction that changed c's history or schedule.
+func storeStreak(ctx context.Context, db queryExecer, c CountDown) error {
+	completions, err := queryCompletions(ctx, db, c.Id)
+	if err != nil {
+		return err
+	}
+	streak, _ := countStreaks(c, completions)
+	_, err = db.ExecContext(ctx, `UPDATE timer SET streak = ? WHERE id = ?`, streak, c.Id)
+	return err
+}
+
+// storeMissingStreaks works out the streaks of the timers from before they were kept, deleted ones too so that they
+// have theirs when restored.
+func (s *Server) storeMissingStreaks(ctx context.Context) error {
+	timers, err := queryTimers(ctx, s.db, `SELECT `+timerColumns+` FROM timer WHERE streak IS NULL`)
+	if err != nil {
+		return err
+	}
+	for _, c := range timers {
+		if err := storeStreak(ctx, s.db, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dueWhenDone is when c is due for a completion at t. A paused timer isn't due at all, so being done while paused is
+// on time, and one without a schedule has no due time.
+func (c CountDown) dueWhenDone(t time.Time) time.Time {
+	switch {
+	case !c.scheduled():
+		return time.Time{}
+	case c.Paused():
+		return t
+	}
+	return c.NextDue()
+}
+
+// onTime reports whether c was done no later than it was due for done, when prev is the completion before it.
+func (c CountDown) onTime(prev, done completion) bool {
+	due := done.DueAt
+	if due.IsZero() {
+		due = c.skipWeekend(c.after(prev.CompletedAt))
+	}
+	return !done.CompletedAt.After(due)
+}
+
+// doneInOrder is the completions that weren't skips, oldest first.
+func doneInOrder(completions []completion) []completion {
+	done := slices.DeleteFunc(slices.Clone(completions), func(c completion) bool { return c.Kind == CompletionSkipped })
+	slices.SortFunc(done, func(a, b completion) int {
+		if n := a.CompletedAt.Compare(b.CompletedAt); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+	return done
+}
+
+// computeStreak works out c's current and best streaks from its completions, in any order, as of now.
+func computeStreak(c CountDown, completions []completion, now time.Time) (current, best int) {
+	current, best = countStreaks(c, completions)
+	return c.streakAt(current, now), best
+}
+
+// countStreaks works out c's current and best streaks from its completions, in any order, as of the last one. The
+// first completion only starts the count. Timers without a schedule have no streaks.
+func countStreaks(c CountDown, completions []completion) (current, best int) {
+	if !c.scheduled() {
+		return 0, 0
+	}
+	done := doneInOrder(completions)
+	for i := 1; i < len(done); i++ {
+		switch {
+		case !c.onTime(done[i-1], done[i]):
+			current = 0
+		case done[i].Kind != CompletionPartial:
+			current++
+		}
+		best = max(best, current)
+	}
+	return current, best
+}
+
+// streakAt is c's current streak at now, given the one as of its last completion: it's over as soon as c is
+// overdue, even before it's done late.
+func (c CountDown) streakAt(streak int, now time.Time) int {
+	if c.state(now) == stateOverdue {
+		return 0
+	}
+	return streak
+}
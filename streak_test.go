@@ -0,0 +1,155 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestComputeStreak tests streaks at the edges of each period, within grace, and with the due times that snoozing and
+// pausing leave in the history
+func TestComputeStreak(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	// done is a completion at start plus at, due at start plus due unless that's 0.
+	done := func(at, due time.Duration) completion {
+		c := completion{CompletedAt: start.Add(at)}
+		if due != 0 {
+			c.DueAt = start.Add(due)
+		}
+		return c
+	}
+	kind := func(kind string, c completion) completion {
+		c.Kind = kind
+		return c
+	}
+	every2Days := CountDown{Frequency: 2 * day, LastTime: start.Add(6 * day)}
+	withGrace := every2Days
+	withGrace.Grace = 12 * time.Hour
+	paused := every2Days
+	paused.PausedAt = start.Add(7 * day)
+	snoozed := every2Days
+	snoozed.SnoozedUntil = start.Add(10 * day)
+
+	for _, test := range []struct {
+		name    string
+		c       CountDown
+		history []completion
+		now     time.Time
+		current int
+		best    int
+	}{
+		{"never done", every2Days, nil, start, 0, 0},
+		{"done once", every2Days, []completion{done(0, 0)}, start, 0, 0},
+		{"no schedule", CountDown{LastTime: start.Add(2 * day)}, []completion{done(0, 0), done(day, 0), done(2*day, 0)}, start.Add(2 * day), 0, 0},
+		{"right on time", every2Days, []completion{done(0, 0), done(2*day, 0), done(4*day, 0), done(6*day, 0)}, start.Add(7 * day), 3, 3},
+		{"a second late", every2Days, []completion{done(0, 0), done(2*day, 0), done(4*day+time.Second, 0), done(6*day, 0)}, start.Add(7 * day), 1, 1},
+		// Out of order, as listCompletions has them.
+		{"newest first", every2Days, []completion{done(6*day, 0), done(4*day, 0), done(2*day, 0), done(0, 0)}, start.Add(7 * day), 3, 3},
+		{"missed since", every2Days, []completion{done(0, 0), done(2*day, 0), done(4*day, 0), done(6*day, 0)}, start.Add(8*day + time.Second), 0, 3},
+		{"missed then kept up", every2Days, []completion{done(0, 0), done(day, 0), done(2*day, 0), done(5*day, 0), done(6*day, 0)}, start.Add(7 * day), 1, 2},
+		// Due soon isn't overdue, and the grace before it's due doesn't make it any later.
+		{"within grace", withGrace, []completion{done(0, 0), done(2*day-6*time.Hour, 0), done(4*day-6*time.Hour, 0)}, start.Add(8*day - 6*time.Hour), 2, 2},
+		{"after grace", withGrace, []completion{done(0, 0), done(2*day, 0), done(4*day+6*time.Hour, 0)}, start.Add(7 * day), 0, 1},
+		// Snoozed to four days, then done on the third.
+		{"done while snoozed", every2Days, []completion{done(0, 0), done(3*day, 4*day), done(5*day, 0)}, start.Add(6 * day), 2, 2},
+		{"snoozed now", snoozed, []completion{done(0, 0), done(2*day, 0), done(4*day, 0), done(6*day, 0)}, start.Add(9 * day), 3, 3},
+		// A paused timer is due when it's done, and isn't overdue while it stays paused.
+		{"done while paused", CountDown{Frequency: 2 * day, LastTime: start.Add(11 * day)}, []completion{done(0, 0), done(10*day, 10*day), done(11*day, 0)}, start.Add(12 * day), 2, 2},
+		// Skipping moves the due time on a period, and neither breaks the streak nor adds to it.
+		{"skipped", every2Days, []completion{done(0, 0), done(2*day, 0), kind(CompletionSkipped, done(3*day, 4*day)), done(6*day, 6*day)}, start.Add(7 * day), 2, 2},
+		{"partly done", every2Days, []completion{done(0, 0), done(2*day, 0), kind(CompletionPartial, done(4*day, 0)), done(6*day, 0)}, start.Add(7 * day), 2, 2},
+		{"partly done late", every2Days, []completion{done(0, 0), done(2*day, 0), kind(CompletionPartial, done(4*day+time.Hour, 0)), done(6*day, 0)}, start.Add(7 * day), 1, 1},
+		{"paused now", paused, []completion{done(0, 0), done(2*day, 0), done(4*day, 0), done(6*day, 0)}, start.Add(30 * day), 3, 3},
+	} {
+		if current, best := computeStreak(test.c, test.history, test.now); current != test.current || best != test.best {
+			t.Errorf("%s: expected a streak of %d, %d at best, got %d, %d", test.name, test.current, test.best, current, best)
+		}
+	}
+}
+
+// TestStreak tests that resetting, snoozing and pausing keep a timer's streak going, that being overdue ends it, that
+// the card shows it, and that timers from before streaks were kept get theirs
+func TestStreak(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	shifted := &offsetClock{base: systemClock{}}
+	clock = shifted
+
+	s := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Floss","frequency":"1 day"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	day := 24 * time.Hour
+	base := clock.Now()
+	resetAt := func(offset time.Duration) {
+		t.Helper()
+		shifted.SetOffset(offset)
+		if err := s.resetTimer(t.Context(), 1, base.Add(offset), CompletionDone, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	post := func(target string) {
+		t.Helper()
+		if w := serveAPI(t, s, "POST", target, ""); w.Code != http.StatusOK {
+			t.Fatalf("Failed to POST %s: %v %s", target, w.Code, w.Body.String())
+		}
+	}
+	streak := func() int {
+		t.Helper()
+		c, err := s.getTimer(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Streak
+	}
+
+	for i := range 4 {
+		resetAt(time.Duration(i) * (day - time.Hour))
+	}
+	if got := streak(); got != 3 {
+		t.Errorf("Expected a streak of 3, got %d", got)
+	}
+	if body := serveAPI(t, s, "GET", "/timer/1", "").Body.String(); !strings.Contains(body, "🔥 3") {
+		t.Errorf("Expected the card to show the streak, got %s", body)
+	}
+
+	// Done a day late, but before the snooze ran out.
+	last := 3 * (day - time.Hour)
+	shifted.SetOffset(last + 12*time.Hour)
+	post("/timer/1/snooze?for=2d")
+	resetAt(last + 2*day)
+	// Done with the timer paused, however long after.
+	post("/timer/1/pause")
+	resetAt(last + 10*day)
+	post("/timer/1/unpause")
+	if got := streak(); got != 5 {
+		t.Errorf("Expected snoozing and pausing to keep the streak going, got %d", got)
+	}
+	if _, err := s.db.Exec(`UPDATE timer SET streak = NULL`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.storeMissingStreaks(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if got := streak(); got != 5 {
+		t.Errorf("Expected the streak to be worked out from the history, got %d", got)
+	}
+
+	shifted.SetOffset(last + 11*day + time.Minute)
+	if got := streak(); got != 0 {
+		t.Errorf("Expected being overdue to end the streak, got %d", got)
+	}
+	if body := serveAPI(t, s, "GET", "/timer/1", "").Body.String(); strings.Contains(body, "🔥") {
+		t.Errorf("Expected no streak on the card, got %s", body)
+	}
+	var stats timerStats
+	decodeResponse(t, serveAPI(t, s, "GET", "/timer/1/stats", ""), &stats)
+	if stats.Streak != 0 || stats.BestStreak != 5 {
+		t.Errorf("Expected the best streak to be kept, got %+v", stats)
+	}
+	resetAt(last + 11*day + time.Hour)
+	if got := streak(); got != 0 {
+		t.Errorf("Expected being done late to start over, got %d", got)
+	}
+}
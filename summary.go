@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// timerSummary is how many timers are overdue, due later today and ok, for a glance at the homepage's header.
+// Paused, finished and deleted timers aren't counted, there's nothing to do for them.
+type timerSummary struct {
+	Overdue  int `json:"overdue"`
+	DueToday int `json:"dueToday"`
+	OK       int `json:"ok"`
+}
+
+// summarize counts timers by when they're due as of now, with today ending at midnight in location. Timers without a
+// schedule are never due so they're ok.
+func summarize(timers []CountDown, now time.Time) timerSummary {
+	local := now.In(location)
+	tomorrow := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, location)
+	var summary timerSummary
+	for _, c := range timers {
+		switch {
+		case c.Paused() || c.Finished():
+			continue
+		case c.state(now) == stateOverdue:
+			summary.Overdue++
+		case c.repeats() && c.NextDue().Before(tomorrow):
+			summary.DueToday++
+		default:
+			summary.OK++
+		}
+	}
+	return summary
+}
+
+// Title is the homepage's title, with the number of overdue timers in front when there are any.
+func (s timerSummary) Title() string {
+	if s.Overdue > 0 {
+		return fmt.Sprintf("(%d) Countdown", s.Overdue)
+	}
+	return "Countdown"
+}
+
+// summary summarizes every timer from a single query.
+func (s *Server) summary(ctx context.Context) (timerSummary, error) {
+	timers, err := s.listTimers(ctx)
+	if err != nil {
+		return timerSummary{}, err
+	}
+	return summarize(timers, clock.Now()), nil
+}
+
+// summaryHandler serves the summary as the homepage header's badges, which poll it, or as JSON.
+func (s *Server) summaryHandler(w http.ResponseWriter, r *http.Request) error {
+	ct, err := negotiate(w, r, "text/html", "application/json")
+	if err != nil {
+		return err
+	}
+	summary, err := s.summary(r.Context())
+	if err != nil {
+		return err
+	}
+	if ct == "application/json" {
+		return encodeJSON(w, summary)
+	}
+	// The homepage's script puts the title in the tab, so that it counts what's overdue without reloading the page.
+	trigger, err := json.Marshal(map[string]string{"pageTitle": summary.Title()})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("HX-Trigger", string(trigger))
+	return s.render(r.Context(), w, "summary", summary)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSummarize tests which timers count as overdue, due today and ok, up to midnight in location
+func TestSummarize(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	day := 24 * time.Hour
+	// 8 PM in New York, already tomorrow in UTC.
+	now := time.Date(2025, 3, 5, 20, 0, 0, 0, location)
+
+	for _, test := range []struct {
+		name     string
+		c        CountDown
+		expected timerSummary
+	}{
+		{"overdue", CountDown{LastTime: now.Add(-2 * day), Frequency: day}, timerSummary{Overdue: 1}},
+		{"due before midnight", CountDown{LastTime: now.Add(-day + 3*time.Hour), Frequency: day}, timerSummary{DueToday: 1}},
+		{"due after midnight", CountDown{LastTime: now.Add(-day + 5*time.Hour), Frequency: day}, timerSummary{OK: 1}},
+		{"no schedule", CountDown{LastTime: now.Add(-30 * day)}, timerSummary{OK: 1}},
+		{"paused", CountDown{LastTime: now.Add(-2 * day), Frequency: day, PausedAt: now.Add(-day)}, timerSummary{}},
+		{"finished", CountDown{LastTime: now.Add(-2 * day), Frequency: day, MaxCompletions: 3, Completions: 3}, timerSummary{}},
+	} {
+		if got := summarize([]CountDown{test.c}, now); got != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, got)
+		}
+	}
+
+	if got := (timerSummary{Overdue: 3}).Title(); got != "(3) Countdown" {
+		t.Errorf("Expected the overdue count in the title, got %q", got)
+	}
+	if got := (timerSummary{DueToday: 3, OK: 1}).Title(); got != "Countdown" {
+		t.Errorf("Expected just the name without anything overdue, got %q", got)
+	}
+}
+
+// TestSummaryHandler tests the summary as JSON and as the homepage's badges, without deleted or paused timers
+func TestSummaryHandler(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	lastWeek := clock.Now().Add(-7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	yesterday := clock.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	for _, body := range []string{
+		`{"name":"Floss","frequency":"1 day","lastTime":"` + lastWeek + `"}`,
+		`{"name":"Bins","frequency":"1 day","lastTime":"` + lastWeek + `"}`,
+		`{"name":"Oil change","frequency":"3 months","lastTime":"` + yesterday + `"}`,
+		`{"name":"Gutters","frequency":"1 day","lastTime":"` + lastWeek + `"}`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", body); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+		}
+	}
+	if w := serveAPI(t, s, "POST", "/timer/2/pause", ""); w.Code >= 400 {
+		t.Fatalf("Failed to pause the timer: %v %s", w.Code, w.Body.String())
+	}
+	if w := serveAPI(t, s, "DELETE", "/timer/4", ""); w.Code >= 400 {
+		t.Fatalf("Failed to delete the timer: %v %s", w.Code, w.Body.String())
+	}
+
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	var summary timerSummary
+	decodeResponse(t, get("/summary", "application/json"), &summary)
+	if expected := (timerSummary{Overdue: 1, OK: 1}); summary != expected {
+		t.Errorf("Expected %+v, got %+v", expected, summary)
+	}
+
+	w := get("/summary", "text/html")
+	if body := w.Body.String(); !strings.Contains(body, "1 overdue") || w.Header().Get("HX-Trigger") != `{"pageTitle":"(1) Countdown"}` {
+		t.Errorf("Expected the badges with the title in an HX-Trigger, got %v %s", w.Header(), body)
+	}
+	if body := get("/?tag=nothing", "text/html").Body.String(); !strings.Contains(body, "<title>(1) Countdown</title>") {
+		t.Errorf("Expected the homepage's title to count every overdue timer, got %s", body)
+	}
+
+	if w := serveAPI(t, s, "POST", "/timer/1/reset", ""); w.Code >= 400 {
+		t.Fatalf("Failed to reset the timer: %v %s", w.Code, w.Body.String())
+	}
+	w = get("/summary", "text/html")
+	if body := w.Body.String(); strings.Contains(body, "overdue") || w.Header().Get("HX-Trigger") != `{"pageTitle":"Countdown"}` {
+		t.Errorf("Expected no overdue badge and no count in the title, got %v %s", w.Header(), body)
+	}
+}
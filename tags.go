@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Tags group timers, like house, car or health, for the homepage to show one group at a time with ?tag=. They're
+// kept in the tag table and joined to their timers by timer_tag, which loses a timer's rows when it's purged.
+
+const maxTagLength = 30
+
+// The tags of a timer as one comma separated column, see scanTimer.
+const tagsColumn = `(SELECT group_concat(tag.name, ',' ORDER BY tag.name) FROM timer_tag JOIN tag ON tag.id = timer_tag.tag_id WHERE timer_tag.timer_id = timer.id)`
+
+// parseTags reads the create form's comma separated tags, like "house, garden".
+func parseTags(text string) []string {
+	return normalizeTags(strings.Split(text, ","))
+}
+
+// normalizeTags trims and lowercases tags, so that House and house are the same one, drops empty and repeated ones,
+// and sorts them as they're read back. It's nil for no tags.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, t := range tags {
+		if t = normalizeTag(t); t != "" {
+			normalized = append(normalized, t)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// validateTags fails with a 400 for a tag that's too long or has a comma, which separates them in the form.
+func validateTags(c CountDown) error {
+	for _, t := range c.Tags {
+		switch {
+		case strings.Contains(t, ","):
+			return httpError{http.StatusBadRequest, fmt.Errorf("Tag %q can't have a comma in it", t)}
+		case len(normalizeTag(t)) > maxTagLength:
+			return httpError{http.StatusBadRequest, fmt.Errorf("Tag %q is longer than %d characters", t, maxTagLength)}
+		}
+	}
+	return nil
+}
+
+// setTimerTags replaces the tags of the timer with id, which have been normalized, and drops tags that no timer has
+// any more.
+func setTimerTags(ctx context.Context, db execer, id int64, tags []string) error {
+	if _, err := db.ExecContext(ctx, `DELETE FROM timer_tag WHERE timer_id = ?`, id); err != nil {
+		return err
+	}
+	for _, t := range tags {
+		if _, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO tag (name) VALUES (?)`, t); err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, `INSERT INTO timer_tag (timer_id, tag_id) SELECT ?, id FROM tag WHERE name = ?`, id, t); err != nil {
+			return err
+		}
+	}
+	return dropUnusedTags(ctx, db)
+}
+
+func dropUnusedTags(ctx context.Context, db execer) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM tag WHERE id NOT IN (SELECT tag_id FROM timer_tag)`)
+	return err
+}
+
+// listTags is every tag that a timer that hasn't been deleted has, by name.
+func (s *Server) listTags(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT tag.name
+		FROM tag
+		JOIN timer_tag ON timer_tag.tag_id = tag.id
+		JOIN timer ON timer.id = timer_tag.timer_id AND timer.deleted_at IS NULL
+		ORDER BY tag.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// tagsHandler lists the tags in use, as the options of the create form's datalist or as JSON.
+func (s *Server) tagsHandler(w http.ResponseWriter, r *http.Request) error {
+	ct, err := negotiate(w, r, "text/html", "application/json")
+	if err != nil {
+		return err
+	}
+	tags, err := s.listTags(r.Context())
+	if err != nil {
+		return err
+	}
+	if ct == "application/json" {
+		return encodeJSON(w, tags)
+	}
+	return s.render(r.Context(), w, "tagoptions", tags)
+}
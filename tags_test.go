@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTags tests tagging timers, filtering the homepage by a tag and that tags go with their timers
+func TestTags(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	shifted := &offsetClock{base: systemClock{}}
+	clock = shifted
+
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	create := func(name, tags string) {
+		form := url.Values{"name": {name}, "lasttime": {"2025-03-01T09:00"}, "frequency": {"1 week"}, "tags": {tags}}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if w := serve(req); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create %q: %v %s", name, w.Code, w.Body.String())
+		}
+	}
+	names := func(target string) []string {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", "application/json")
+		w := serve(req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK for %s, got %v: %s", target, w.Code, w.Body.String())
+		}
+		var timers []CountDown
+		decodeResponse(t, w, &timers)
+		var names []string
+		for _, c := range timers {
+			names = append(names, c.Name+" "+strings.Join(c.Tags, ","))
+		}
+		return names
+	}
+	tags := func() []string {
+		req := httptest.NewRequest("GET", "/tags", nil)
+		req.Header.Set("Accept", "application/json")
+		var tags []string
+		decodeResponse(t, serve(req), &tags)
+		return tags
+	}
+
+	create("Water plants", " House, garden,house")
+	create("Oil change", "car")
+	create("Dust the shelves", "house")
+	create("Floss", "")
+
+	if got, expected := names("/?tag=House"), []string{"Dust the shelves house", "Water plants garden,house"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got := names("/?tag=boat"); len(got) != 0 {
+		t.Errorf("Expected no timers tagged boat, got %v", got)
+	}
+	if got, expected := tags(), []string{"car", "garden", "house"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected tags %v, got %v", expected, got)
+	}
+
+	// Following a chip with htmx gets the whole filtered page.
+	req := httptest.NewRequest("GET", "/?tag=car", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Boosted", "true")
+	if body := serve(req).Body.String(); !strings.Contains(body, "<!DOCTYPE html>") || !strings.Contains(body, "Tagged") || strings.Contains(body, "Water plants") {
+		t.Errorf("Expected the page of timers tagged car, got %s", body)
+	}
+
+	// Updating a timer replaces its tags.
+	if w := serveAPI(t, s, "PUT", "/api/v1/timers/2", `{"name":"Oil change","frequency":"3 months","tags":["Car","garage"],"version":1}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if got, expected := names("/?tag=garage"), []string{"Oil change car,garage"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	for _, body := range []string{`{"name":"x","frequency":"1 day","tags":["a,b"]}`, fmt.Sprintf(`{"name":"x","frequency":"1 day","tags":[%q]}`, strings.Repeat("x", maxTagLength+1))} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a bad tag to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// A deleted timer's tags only go once it's purged, so that restoring it brings them back.
+	if w := serve(httptest.NewRequest("DELETE", "/timer/2", nil)); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status No Content, got %v: %s", w.Code, w.Body.String())
+	}
+	if got, expected := tags(), []string{"garden", "house"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected the deleted timer's tags to be left out, got %v", got)
+	}
+	shifted.SetOffset(deletedTimerRetention + time.Hour)
+	if _, err := s.purgeDeletedTimers(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	var rows, stored int
+	if err := db.QueryRow(`SELECT (SELECT COUNT(*) FROM timer_tag WHERE timer_id = 2), (SELECT COUNT(*) FROM tag)`).Scan(&rows, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 0 || stored != 2 {
+		t.Errorf("Expected the purged timer's tags to be gone, got %d of its rows and %d tags", rows, stored)
+	}
+}
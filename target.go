@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A timer can have a target of being done so many times a day, week, month or year, like the gym 3 times a week,
+// which a single due date can't say. Its card shows how much of the target is done so far in the current period
+// rather than when it's next due. Periods are calendar ones in -timezone, with weeks starting on -week-start. Doing it
+// more often than the target is kept in the history like any other time, the card just shows the target as met.
+
+// The most times a period that a target can be.
+const maxTargetCount = 100
+
+// The periods that a target can be for, named like the frequency units.
+var targetPeriods = []string{UnitDay, UnitWeek, UnitMonth, UnitYear}
+
+// weekStart is the day that a target's weeks start on, see -week-start.
+var weekStart = time.Monday
+
+// parseWeekday reads a day of the week like monday or Sun, for -week-start.
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("%q isn't a day of the week", s)
+}
+
+// parseTarget reads the create form's optional number of times and the period that they're in, like 3 and week.
+func parseTarget(c *CountDown, count, period string) error {
+	if count = strings.TrimSpace(count); count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing the target: %w", err)}
+		}
+		c.TargetCount = n
+	}
+	if c.TargetCount != 0 {
+		c.TargetPeriod = strings.ToLower(strings.TrimSpace(period))
+	}
+	return nil
+}
+
+// validateTarget fails with a 400 for a target that isn't a number of times from 1 to maxTargetCount in one of the
+// targetPeriods, or a period without a number of times.
+func validateTarget(c CountDown) error {
+	switch {
+	case c.TargetCount == 0 && c.TargetPeriod == "":
+		return nil
+	case c.TargetCount == 0:
+		return httpError{http.StatusBadRequest, errors.New("A target period needs a number of times too")}
+	case c.TargetCount < 0 || c.TargetCount > maxTargetCount:
+		return httpError{http.StatusBadRequest, fmt.Errorf("A target is from 1 to %d times, not %d", maxTargetCount, c.TargetCount)}
+	case !slices.Contains(targetPeriods, c.TargetPeriod):
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing target period %q: expected one of %s", c.TargetPeriod, strings.Join(targetPeriods, ", "))}
+	}
+	return nil
+}
+
+// periodStart is the start of the target period that t is in.
+func (c CountDown) periodStart(t time.Time) time.Time {
+	t = t.In(location)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+	switch c.TargetPeriod {
+	case UnitWeek:
+		return startOfWeek(day)
+	case UnitMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	case UnitYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, location)
+	}
+	return day
+}
+
+// startOfWeek is the start of the week that day, a midnight in location, is in.
+func startOfWeek(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(weekStart) + 7) % 7))
+}
+
+// periodColumn selects the times that a timer with a target was done, not skipped, as a JSON array for
+// parsePeriodColumn. No period is longer than a year, so only the year up to the latest one is read, and the array
+// is empty for a timer without a target.
+const periodColumn = `(SELECT json_group_array(completed_at) FROM completion WHERE timer.target_count > 0 AND completion.timer_id = timer.id AND completion.kind != '` + CompletionSkipped + `' AND completed_at >= (SELECT strftime('%Y-%m-%dT%H:%M:%SZ', MAX(latest.completed_at), '-1 year') FROM completion latest WHERE latest.timer_id = timer.id))`
+
+// parsePeriodColumn reads periodColumn.
+func parsePeriodColumn(text string) ([]completion, error) {
+	var times []time.Time
+	if err := json.Unmarshal([]byte(text), &times); err != nil {
+		return nil, err
+	}
+	completions := make([]completion, len(times))
+	for i, t := range times {
+		completions[i] = completion{CompletedAt: t, Kind: CompletionDone}
+	}
+	return completions, nil
+}
+
+// doneInPeriod counts the completions, but not skips, in the target period that now is in.
+func (c CountDown) doneInPeriod(completions []completion, now time.Time) int {
+	if c.TargetCount == 0 {
+		return 0
+	}
+	start := c.periodStart(now)
+	var n int
+	for _, done := range completions {
+		if done.Kind != CompletionSkipped && !done.CompletedAt.Before(start) && !done.CompletedAt.After(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// TargetProgress is how many times of the target are done this period, at most the target.
+func (c CountDown) TargetProgress() int {
+	return min(c.DoneThisPeriod, c.TargetCount)
+}
+
+// TargetPercent is TargetProgress as a percentage of the target, for a progress bar.
+func (c CountDown) TargetPercent() int {
+	if c.TargetCount == 0 {
+		return 0
+	}
+	return 100 * c.TargetProgress() / c.TargetCount
+}
+
+// TargetStatus says how much of the target is done, like "2/3 this week". It's empty for a timer without one.
+func (c CountDown) TargetStatus() string {
+	return c.targetStatus(locale(language))
+}
+
+func (c CountDown) targetStatus(l locale) string {
+	if c.TargetCount == 0 {
+		return ""
+	}
+	period := l.tr("this " + c.TargetPeriod)
+	if c.TargetPeriod == UnitDay {
+		period = l.tr("today")
+	}
+	return fmt.Sprintf("%d/%d %s", c.TargetProgress(), c.TargetCount, period)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPeriodStart tests the start of each target period across the week, month and year boundaries, with weeks
+// starting on either Monday or Sunday
+func TestPeriodStart(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	defer func(d time.Weekday) { weekStart = d }(weekStart)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	at := func(s string) time.Time {
+		t.Helper()
+		got, err := time.ParseInLocation(time.DateTime, s, location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	for _, test := range []struct {
+		period    string
+		weekStart time.Weekday
+		now       string
+		expected  string
+	}{
+		{UnitDay, time.Monday, "2025-03-09 23:59:59", "2025-03-09 00:00:00"},
+		// Sunday, Mar 9.
+		{UnitWeek, time.Monday, "2025-03-09 12:00:00", "2025-03-03 00:00:00"},
+		{UnitWeek, time.Sunday, "2025-03-09 12:00:00", "2025-03-09 00:00:00"},
+		{UnitWeek, time.Monday, "2025-03-10 00:00:00", "2025-03-10 00:00:00"},
+		{UnitWeek, time.Sunday, "2025-03-08 23:59:59", "2025-03-02 00:00:00"},
+		// A week that starts in the month before.
+		{UnitWeek, time.Monday, "2025-03-01 08:00:00", "2025-02-24 00:00:00"},
+		{UnitMonth, time.Monday, "2025-03-31 23:00:00", "2025-03-01 00:00:00"},
+		{UnitYear, time.Monday, "2025-12-31 23:59:59", "2025-01-01 00:00:00"},
+	} {
+		weekStart = test.weekStart
+		c := CountDown{TargetCount: 1, TargetPeriod: test.period}
+		// 4 AM in UTC is still the day before in New York.
+		if got := c.periodStart(at(test.now).UTC()); !got.Equal(at(test.expected)) {
+			t.Errorf("%s starting on %v at %s: expected %s, got %v", test.period, test.weekStart, test.now, test.expected, got.In(location))
+		}
+	}
+}
+
+// TestTargetProgress tests that skips and other periods aren't counted, and that going over the target shows it as met
+func TestTargetProgress(t *testing.T) {
+	defer func(d time.Weekday) { weekStart = d }(weekStart)
+	weekStart = time.Monday
+	// Wednesday.
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, location)
+	c := CountDown{TargetCount: 3, TargetPeriod: UnitWeek}
+	completions := []completion{
+		{CompletedAt: now.AddDate(0, 0, -3), Kind: CompletionDone}, // The Sunday before.
+		{CompletedAt: now.AddDate(0, 0, -2), Kind: CompletionDone},
+		{CompletedAt: now.AddDate(0, 0, -1), Kind: CompletionSkipped},
+		{CompletedAt: now, Kind: CompletionPartial},
+	}
+	if c.DoneThisPeriod = c.doneInPeriod(completions, now); c.DoneThisPeriod != 2 {
+		t.Errorf("Expected 2 done this week, got %d", c.DoneThisPeriod)
+	}
+	if got := c.TargetStatus(); got != "2/3 this week" {
+		t.Errorf("Expected 2/3 this week, got %q", got)
+	}
+	if got := c.TargetPercent(); got != 66 {
+		t.Errorf("Expected 66%%, got %d", got)
+	}
+
+	c.DoneThisPeriod = 5
+	if got := c.TargetStatus(); got != "3/3 this week" {
+		t.Errorf("Expected going over to show 3/3 this week, got %q", got)
+	}
+	if got := c.TargetPercent(); got != 100 {
+		t.Errorf("Expected going over to be 100%%, got %d", got)
+	}
+	daily := CountDown{TargetCount: 2, TargetPeriod: UnitDay, DoneThisPeriod: 1}
+	if got := daily.TargetStatus(); got != "1/2 today" {
+		t.Errorf("Expected 1/2 today, got %q", got)
+	}
+	if got := (CountDown{}).doneInPeriod(completions, now); got != 0 {
+		t.Errorf("Expected nothing counted without a target, got %d", got)
+	}
+}
+
+// TestParseWeekday tests -week-start
+func TestParseWeekday(t *testing.T) {
+	for s, expected := range map[string]time.Weekday{"monday": time.Monday, "Sunday": time.Sunday, "sat": time.Saturday, " Wed ": time.Wednesday} {
+		if got, err := parseWeekday(s); err != nil || got != expected {
+			t.Errorf("Expected %q to be %v, got %v, %v", s, expected, got, err)
+		}
+	}
+	for _, s := range []string{"", "mo", "weekend", "7"} {
+		if _, err := parseWeekday(s); err == nil {
+			t.Errorf("Expected %q to be an error", s)
+		}
+	}
+}
+
+// TestTarget tests setting a target through the API and the form, and the card's progress after it's done
+func TestTarget(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	for _, body := range []string{
+		`{"name":"Gym","targetCount":0,"targetPeriod":"week"}`,
+		`{"name":"Gym","targetCount":-1,"targetPeriod":"week"}`,
+		`{"name":"Gym","targetCount":101,"targetPeriod":"week"}`,
+		`{"name":"Gym","targetCount":3,"targetPeriod":"fortnight"}`,
+		`{"name":"Gym","targetCount":3}`,
+	} {
+		if w := serveAPI(t, s, "POST", "/api/v1/timers", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be a Bad Request, got %v: %s", body, w.Code, w.Body.String())
+		}
+	}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Gym","targetCount":3,"targetPeriod":"week"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	for range 2 {
+		if err := s.resetTimer(t.Context(), 1, clock.Now(), CompletionDone, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// It has no schedule to skip, but one in the history isn't counted either way.
+	if err := addCompletion(t.Context(), s.db, 1, CompletionSkipped, clock.Now(), time.Time{}, ""); err != nil {
+		t.Fatal(err)
+	}
+	var c CountDown
+	decodeResponse(t, serveAPI(t, s, "GET", "/api/v1/timers/1", ""), &c)
+	if c.TargetCount != 3 || c.TargetPeriod != UnitWeek || c.DoneThisPeriod != 2 {
+		t.Errorf("Expected 2 of 3 a week done, got %d of %d a %q", c.DoneThisPeriod, c.TargetCount, c.TargetPeriod)
+	}
+	w := serveAPI(t, s, "GET", "/timer/1", "")
+	if body := w.Body.String(); !strings.Contains(body, "2/3 this week") || !strings.Contains(body, `style="width: 66%"`) {
+		t.Errorf("Expected the card to show 2/3 this week, got %s", body)
+	}
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		return w
+	}
+	if w := post(url.Values{"name": {"Stretch"}, "lasttime": {"2025-03-03T09:30"}, "once": {"1"}, "targetCount": {"2"}, "targetPeriod": {"day"}}); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer from the form: %v %s", w.Code, w.Body.String())
+	}
+	decodeResponse(t, serveAPI(t, s, "GET", "/api/v1/timers/2", ""), &c)
+	if c.TargetCount != 2 || c.TargetPeriod != UnitDay {
+		t.Errorf("Expected 2 a day from the form, got %d a %q", c.TargetCount, c.TargetPeriod)
+	}
+	if w := post(url.Values{"name": {"Stretch"}, "lasttime": {"2025-03-03T09:30"}, "once": {"1"}, "targetCount": {"twice"}, "targetPeriod": {"day"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a bad target count to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+}
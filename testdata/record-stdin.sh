@@ -0,0 +1,5 @@
+#!/bin/sh
+# A -hook-command fixture that appends the event it receives on stdin, and its environment, to the file in $1.
+cat >> "$1"
+echo >> "$1"
+env >> "$1"
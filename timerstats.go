@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
+// timerStats is how well a timer is being kept up with, from its history. The intervals are between completions, so
+// they need two of them, and being on time needs a schedule too. Skips are counted but otherwise left out, so that
+// the occurrence skipped isn't a long gap nor a late one.
+type timerStats struct {
+	Count           int           `json:"count"`   // Done or partly done, but not skipped.
+	Partial         int           `json:"partial"` // Of Count.
+	Skipped         int           `json:"skipped"`
+	AverageInterval time.Duration `json:"averageInterval,omitempty"` // Nanoseconds, as with Frequency
+	LongestGap      time.Duration `json:"longestGap,omitempty"`      // Nanoseconds
+	// How many of the completions after the first were done by the time they were due, and the percentage that is.
+	// Both are 0 when Checked is, for a timer without a schedule or with a single completion.
+	OnTime        int `json:"onTime"`
+	Checked       int `json:"checked"`
+	OnTimePercent int `json:"onTimePercent"`
+	// The current and best runs of completions on time, see streak.go.
+	Streak     int `json:"streak"`
+	BestStreak int `json:"bestStreak"`
+	// How late it's done on average, negative when it's early, of the Timed completions whose lateness is known. See
+	// lateness.go.
+	AverageLateness time.Duration `json:"averageLateness"` // Nanoseconds
+	Timed           int           `json:"timed"`
+}
+
+// computeTimerStats works out c's stats from its completions, in any order, as of now. Each completion is on time
+// when it's no later than c was due, as with streaks.
+func computeTimerStats(c CountDown, completions []completion, now time.Time) timerStats {
+	var stats timerStats
+	stats.Streak, stats.BestStreak = computeStreak(c, completions, now)
+	stats.AverageLateness, stats.Timed = averageLateness(completions)
+	done := doneInOrder(completions)
+	stats.Count, stats.Skipped = len(done), len(completions)-len(done)
+	for _, d := range done {
+		if d.Kind == CompletionPartial {
+			stats.Partial++
+		}
+	}
+
+	var total time.Duration
+	for i := 1; i < len(done); i++ {
+		gap := done[i].CompletedAt.Sub(done[i-1].CompletedAt)
+		total += gap
+		stats.LongestGap = max(stats.LongestGap, gap)
+		if c.scheduled() {
+			stats.Checked++
+			if c.onTime(done[i-1], done[i]) {
+				stats.OnTime++
+			}
+		}
+	}
+	if len(done) > 1 {
+		stats.AverageInterval = total / time.Duration(len(done)-1)
+	}
+	if stats.Checked > 0 {
+		stats.OnTimePercent = int(math.Round(100 * float64(stats.OnTime) / float64(stats.Checked)))
+	}
+	return stats
+}
+
+// timerStatsHandler serves a timer's stats as JSON.
+func (s *Server) timerStatsHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	if _, err := negotiate(w, r, "application/json"); err != nil {
+		return err
+	}
+	c, err := s.getTimer(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	completions, err := s.listCompletions(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, computeTimerStats(c, completions, clock.Now()))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestTimerStats tests the average, longest gap and on time percentage of a timer's history, including the histories
+// too short to have them
+func TestTimerStats(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	done := func(days ...int) []completion {
+		var completions []completion
+		for _, d := range days {
+			completions = append(completions, completion{CompletedAt: start.Add(time.Duration(d) * day)})
+		}
+		return completions
+	}
+	every2Days := CountDown{Frequency: 2 * day}
+
+	for _, test := range []struct {
+		name     string
+		c        CountDown
+		history  []completion
+		expected timerStats
+	}{
+		{"never done", every2Days, nil, timerStats{}},
+		{"done once", every2Days, done(0), timerStats{Count: 1}},
+		{"unscheduled", CountDown{}, done(0, 3, 4), timerStats{Count: 3, AverageInterval: 2 * day, LongestGap: 3 * day}},
+		// Out of order, as the history lists it newest first. The gaps are 2, 3, 1 and 4 days.
+		{"scheduled", every2Days, done(10, 0, 2, 5, 6), timerStats{Count: 5, AverageInterval: 10 * day / 4, LongestGap: 4 * day, OnTime: 2, Checked: 4, OnTimePercent: 50, BestStreak: 1}},
+		// The skip on the 3rd day isn't a gap, and the completion after it was due on the 6th.
+		{"skipped", every2Days, []completion{done(0)[0], done(2)[0], {CompletedAt: start.Add(3 * day), Kind: CompletionSkipped}, {CompletedAt: start.Add(6 * day), DueAt: start.Add(6 * day), Kind: CompletionPartial}},
+			timerStats{Count: 3, Partial: 1, Skipped: 1, AverageInterval: 3 * day, LongestGap: 4 * day, OnTime: 2, Checked: 2, OnTimePercent: 100, Streak: 1, BestStreak: 1, Timed: 1}},
+		{"done twice at once", every2Days, done(1, 1), timerStats{Count: 2, OnTime: 1, Checked: 1, OnTimePercent: 100, Streak: 1, BestStreak: 1}},
+	} {
+		if got := computeTimerStats(test.c, test.history, start); got != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, got)
+		}
+	}
+}
+
+// TestTimerStatsHandler tests that the stats are served as JSON
+func TestTimerStatsHandler(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Water plants","frequency":"2 days","lastTime":"2025-03-01T09:00:00Z"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	if err := s.resetTimer(t.Context(), 1, time.Date(2025, 3, 4, 9, 0, 0, 0, time.UTC), CompletionDone, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var stats timerStats
+	decodeResponse(t, serveAPI(t, s, "GET", "/timer/1/stats", ""), &stats)
+	// Due on the 3rd, and done a day late.
+	if expected := (timerStats{Count: 2, AverageInterval: 3 * 24 * time.Hour, LongestGap: 3 * 24 * time.Hour, Checked: 1, AverageLateness: 24 * time.Hour, Timed: 1}); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+	if w := serveAPI(t, s, "GET", "/timer/99/stats", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing timer to be Not Found, got %v: %s", w.Code, w.Body.String())
+	}
+}
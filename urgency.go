@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// dueState is how urgent a timer is, which the dashboard colors it by and ?state= filters on.
+type dueState string
+
+const (
+	stateOK      dueState = "ok"
+	stateDueSoon dueState = "due-soon" // Due within its grace.
+	stateOverdue dueState = "overdue"
+)
+
+// Without a Grace a timer is due soon for the last tenth of its period, e.g. the last 3 days of a month.
+const defaultGraceFraction = 10
+
+// State is how urgent the timer is: overdue past its NextDue, due soon within its grace of it and ok before that.
+// Timers that don't repeat, and finished and paused ones, are never due so they're always ok.
+func (c CountDown) State() dueState {
+	return c.state(clock.Now())
+}
+
+// UrgencyClass is the classes that color the timer's card by its State, so that the page shows how urgent it is
+// before its script runs. Timers that are never due aren't colored.
+func (c CountDown) UrgencyClass() string {
+	return c.urgencyClass(clock.Now())
+}
+
+func (c CountDown) urgencyClass(now time.Time) string {
+	if !c.repeats() {
+		return ""
+	}
+	switch c.state(now) {
+	case stateOverdue:
+		return "border-start border-4 border-danger bg-danger-subtle"
+	case stateDueSoon:
+		return "border-start border-4 border-warning"
+	}
+	return "border-start border-4 border-success"
+}
+
+func (c CountDown) state(now time.Time) dueState {
+	if !c.repeats() {
+		return stateOK
+	}
+	due := c.NextDue()
+	switch {
+	case due.Before(now):
+		return stateOverdue
+	case !due.After(now.Add(c.grace(due))):
+		return stateDueSoon
+	}
+	return stateOK
+}
+
+// grace is how long before due the timer is due soon, its Grace or else a tenth of the period that due starts.
+func (c CountDown) grace(due time.Time) time.Duration {
+	if c.Grace > 0 {
+		return c.Grace
+	}
+	if c.Cron != "" {
+		return c.after(due).Sub(due) / defaultGraceFraction
+	}
+	return c.Frequency / defaultGraceFraction
+}
+
+// parseGrace reads the create form's optional grace, like 2 days or 12h, see parseFrequency.
+func parseGrace(c *CountDown, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	var g CountDown
+	if err := parseFrequency(&g, text); err != nil || g.Frequency <= 0 {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing how long before it's due to warn %q: expected something like 2 days or 12h", text)}
+	}
+	c.Grace = g.Frequency
+	return nil
+}
+
+// validateGrace fails with a 400 for a negative grace, or one on a timer that's never due.
+func validateGrace(c CountDown) error {
+	switch {
+	case c.Grace < 0:
+		return httpError{http.StatusBadRequest, fmt.Errorf("A grace is a positive duration, not %v", c.Grace)}
+	case c.Grace > 0 && c.Frequency <= 0 && c.FrequencyValue <= 0 && c.Cron == "":
+		return httpError{http.StatusBadRequest, errors.New("Only a timer that repeats can have a grace, the rest are never due")}
+	}
+	return nil
+}
+
+// parseState reads ?state=, empty for every timer.
+func parseState(s string) (dueState, error) {
+	switch st := dueState(s); st {
+	case "", stateOK, stateDueSoon, stateOverdue:
+		return st, nil
+	}
+	return "", httpError{http.StatusBadRequest, fmt.Errorf("Error parsing state %q: expected %s, %s or %s", s, stateOK, stateDueSoon, stateOverdue)}
+}
+
+// The homepage's segmented control filters its list by ?filter=, its ?state= with an "all" that the control can send.
+const filterAll = "all"
+
+// filterOption is one of the choices of the homepage's segmented control.
+type filterOption struct {
+	Value string
+	Label string
+}
+
+// filterOptions are the choices of the homepage's segmented control, its default first.
+var filterOptions = []filterOption{{filterAll, "All"}, {string(stateOverdue), "Overdue"}, {string(stateDueSoon), "Due soon"}}
+
+// parseFilter reads ?filter=, empty for every timer.
+func parseFilter(s string) (dueState, error) {
+	switch st := dueState(s); st {
+	case "", filterAll:
+		return "", nil
+	case stateOverdue, stateDueSoon:
+		return st, nil
+	}
+	return "", httpError{http.StatusBadRequest, fmt.Errorf("Error parsing filter %q: expected %s, %s or %s", s, filterAll, stateOverdue, stateDueSoon)}
+}
+
+// inState is the views in state, all of them when it's empty.
+func inState(views []timerView, state dueState) []timerView {
+	if state == "" {
+		return views
+	}
+	filtered := make([]timerView, 0, len(views))
+	for _, v := range views {
+		if v.State == state {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestState tests the boundaries between ok, due soon and overdue
+func TestState(t *testing.T) {
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	last := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tenDaily := CountDown{LastTime: last, Frequency: 10 * day} // Due on the 11th, soon from a day before.
+	graced := tenDaily
+	graced.Grace = 3 * day
+	due := last.Add(10 * day)
+
+	tests := []struct {
+		name     string
+		c        CountDown
+		now      time.Time
+		expected dueState
+	}{
+		{"just done", tenDaily, last, stateOK},
+		{"just before the grace", tenDaily, due.Add(-day - time.Second), stateOK},
+		{"start of the grace", tenDaily, due.Add(-day), stateDueSoon},
+		{"due", tenDaily, due, stateDueSoon},
+		{"just past due", tenDaily, due.Add(time.Second), stateOverdue},
+		{"before its own grace", graced, due.Add(-3*day - time.Second), stateOK},
+		{"start of its own grace", graced, due.Add(-3 * day), stateDueSoon},
+		{"one-time", CountDown{LastTime: last}, due.Add(365 * day), stateOK},
+		{"never done one-time", CountDown{}, last, stateOK},
+		{"paused", CountDown{LastTime: last, Frequency: day, PausedAt: last}, due, stateOK},
+		{"finished", CountDown{LastTime: last, Frequency: day, MaxCompletions: 1, Completions: 1}, due, stateOK},
+		// Due Monday the 3rd at 9:00 after a week, a tenth of which is 16.8 hours.
+		{"cron in its grace", CountDown{LastTime: last, Cron: "0 9 * * mon"}, time.Date(2025, 3, 2, 16, 12, 0, 0, time.UTC), stateDueSoon},
+		{"cron before its grace", CountDown{LastTime: last, Cron: "0 9 * * mon"}, time.Date(2025, 3, 2, 16, 11, 0, 0, time.UTC), stateOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.state(tt.now); got != tt.expected {
+				t.Errorf("Expected %q at %v, got %q", tt.expected, tt.now, got)
+			}
+		})
+	}
+}
+
+// TestGrace tests reading and checking a timer's grace
+func TestGrace(t *testing.T) {
+	var c CountDown
+	if err := parseGrace(&c, "2 days"); err != nil || c.Grace != 48*time.Hour {
+		t.Errorf("Expected a grace of 2 days, got %v, %v", c.Grace, err)
+	}
+	if err := parseGrace(&c, "soon"); err == nil {
+		t.Error("Expected an error reading a grace of soon")
+	}
+
+	for _, tt := range []struct {
+		name string
+		c    CountDown
+	}{
+		{"negative", CountDown{Frequency: time.Hour, Grace: -time.Minute}},
+		{"one-time", CountDown{Grace: time.Hour}},
+	} {
+		if err := validateGrace(tt.c); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if err := validateGrace(CountDown{Cron: "0 9 * * *", Grace: time.Hour}); err != nil {
+		t.Errorf("Expected a repeating timer to take a grace, got %v", err)
+	}
+}
+
+// TestHomepageStateFilter tests that ?state= lists only the timers that are that urgent
+func TestHomepageStateFilter(t *testing.T) {
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	now := time.Now().Truncate(time.Second)
+	for _, c := range []CountDown{
+		{Name: "Water plants", LastTime: now.Add(-3 * 24 * time.Hour), Frequency: 24 * time.Hour},
+		{Name: "Gym", LastTime: now.Add(-23 * time.Hour), Frequency: 24 * time.Hour},
+		{Name: "Coffee", LastTime: now, Frequency: 24 * time.Hour},
+		{Name: "Renew passport", LastTime: now},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatalf("Failed to create %q: %v", c.Name, err)
+		}
+	}
+
+	for state, expected := range map[string][]string{
+		"overdue":  {"Water plants"},
+		"due-soon": {"Gym"},
+		"ok":       {"Coffee", "Renew passport"},
+		"":         {"Water plants", "Gym", "Coffee", "Renew passport"},
+	} {
+		req := httptest.NewRequest("GET", "/?state="+state, nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected status OK, got %v: %s", state, w.Code, w.Body.String())
+		}
+		var got []struct{ Name, State string }
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("%q: expected %v, got %+v", state, expected, got)
+		}
+		for i, name := range expected {
+			if got[i].Name != name || (state != "" && got[i].State != state) {
+				t.Errorf("%q: expected %s, got %+v", state, name, got[i])
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, httptest.NewRequest("GET", "/?state=late", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown state to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestUrgencyClass tests the colors of timers' cards in each state, and that the card says how overdue it is without
+// its script
+func TestUrgencyClass(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+	day := 24 * time.Hour
+
+	overdue := CountDown{Id: 1, Name: "Water plants", LastTime: now.Add(-4 * day), Frequency: day}
+	for _, test := range []struct {
+		name     string
+		c        CountDown
+		expected string
+	}{
+		{"overdue", overdue, "border-start border-4 border-danger bg-danger-subtle"},
+		{"due soon", CountDown{LastTime: now.Add(-27 * day), Frequency: 30 * day}, "border-start border-4 border-warning"},
+		{"ok", CountDown{LastTime: now, Frequency: day}, "border-start border-4 border-success"},
+		{"no schedule", CountDown{LastTime: now.Add(-40 * day)}, ""},
+		{"paused", CountDown{LastTime: now.Add(-4 * day), Frequency: day, PausedAt: now}, ""},
+	} {
+		if got := test.c.UrgencyClass(); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+
+	var buf strings.Builder
+	if err := (&Server{}).render(t.Context(), &buf, "timer", newTimerView(overdue)); err != nil {
+		t.Fatal(err)
+	}
+	if card := buf.String(); !strings.Contains(card, "border-danger bg-danger-subtle") || !strings.Contains(card, ">Overdue by 3 days</span>") {
+		t.Errorf("Expected the card to be red and say how overdue it is, got %s", card)
+	}
+}
+
+// TestHomepageFilter tests that ?filter= composes with ?sort= and ?tag=, and that a filter that leaves nothing says so
+func TestHomepageFilter(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+	day := 24 * time.Hour
+
+	s := &Server{db: setupTestDB(t)}
+	for _, c := range []CountDown{
+		{Name: "Water plants", LastTime: now.Add(-3 * day), Frequency: day, Tags: []string{"house"}},
+		{Name: "Descale kettle", LastTime: now.Add(-10 * day), Frequency: 7 * day, Tags: []string{"house"}},
+		{Name: "Oil change", LastTime: now.Add(-100 * day), Frequency: 90 * day, Tags: []string{"car"}},
+		{Name: "Gym", LastTime: now.Add(-23 * time.Hour), Frequency: day, Tags: []string{"house"}},
+	} {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for target, expected := range map[string][]string{
+		"/?filter=overdue":                     {"Oil change", "Descale kettle", "Water plants"},
+		"/?filter=overdue&sort=name":           {"Descale kettle", "Oil change", "Water plants"},
+		"/?filter=overdue&sort=name&tag=house": {"Descale kettle", "Water plants"},
+		"/?filter=due-soon&tag=house":          {"Gym"},
+		"/?filter=all&tag=car":                 {"Oil change"},
+		"/?filter=all&state=overdue&tag=car":   {"Oil change"},
+	} {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status OK, got %v: %s", target, w.Code, w.Body.String())
+		}
+		var timers []struct{ Name string }
+		decodeResponse(t, w, &timers)
+		var got []string
+		for _, c := range timers {
+			got = append(got, c.Name)
+		}
+		if !slices.Equal(got, expected) {
+			t.Errorf("%s: expected %v, got %v", target, expected, got)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/?filter=overdue&tag=car&sort=name", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if body := w.Body.String(); !strings.Contains(body, "Oil change") || strings.Contains(body, "Nothing overdue") {
+		t.Errorf("Expected the overdue timer tagged car, got %s", body)
+	}
+	clock = fixedClock(now.Add(-10 * day))
+	w = httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if body := w.Body.String(); !strings.Contains(body, "Nothing overdue 🎉") || strings.Contains(body, "Oil change") {
+		t.Errorf("Expected nothing overdue, got %s", body)
+	}
+
+	if w := serveAPI(t, s, "GET", "/?filter=late", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown filter to be a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+}
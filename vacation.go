@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// A vacation pauses every timer at once, like pauseTimer, and resuming from it moves every one of their LastTimes on
+// by how long it lasted so that each is due as long after the vacation as it was before. The timers that it paused
+// have its id in paused_by_vacation, so timers paused before it stay paused after it, and ones created or unpaused
+// during it are left alone.
+
+// vacation is when the current vacation started, zero when there isn't one.
+func (s *Server) vacation(ctx context.Context) (time.Time, error) {
+	var started string
+	err := s.db.QueryRowContext(ctx, `SELECT started_at FROM vacation WHERE ended_at = ''`).Scan(&started)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339, started)
+	return t.In(location), err
+}
+
+// pauseAll starts a vacation, pausing every repeating timer that isn't already. It does nothing while on one.
+func (s *Server) pauseAll(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The unique index on the ongoing vacation makes this a no-op while on one.
+	start := formatLastTime(clock.Now())
+	result, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO vacation (started_at, updated_at) VALUES (?, ?)`, start, updatedAt())
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	const pausable = `paused_at = '' AND deleted_at IS NULL AND (frequency > 0 OR cron != '')`
+	paused, err := queryTimers(ctx, tx, `SELECT `+timerColumns+` FROM timer WHERE `+pausable)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE timer SET paused_at = ?, paused_by_vacation = ?, updated_at = ? WHERE `+pausable, start, id, updatedAt()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, c := range paused {
+		c.PausedAt, _ = time.Parse(time.RFC3339, start)
+		s.emit(EventPaused, c)
+	}
+	return nil
+}
+
+// resumeAll ends the vacation, unpausing the timers that it paused with their schedules put back by its length, all
+// in one transaction. It does nothing when not on one, so resuming twice is the same as once.
+func (s *Server) resumeAll(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	var started string
+	err = tx.QueryRowContext(ctx, `SELECT id, started_at FROM vacation WHERE ended_at = ''`).Scan(&id, &started)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	start, err := time.Parse(time.RFC3339, started)
+	if err != nil {
+		return err
+	}
+	now := clock.Now()
+
+	paused, err := queryTimers(ctx, tx, `SELECT `+timerColumns+` FROM timer WHERE paused_by_vacation = ? AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	for i, c := range paused {
+		if !c.LastTime.IsZero() {
+			c.PausedFor += now.Sub(start)
+		}
+		c.PausedAt = time.Time{}
+		if _, err := tx.ExecContext(ctx, `UPDATE timer SET paused_for = ?, paused_at = '', paused_by_vacation = 0, updated_at = ? WHERE id = ?`,
+			c.PausedFor, updatedAt(), c.Id); err != nil {
+			return err
+		}
+		paused[i] = c
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE vacation SET ended_at = ?, updated_at = ? WHERE id = ?`, formatLastTime(now), updatedAt(), id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, c := range paused {
+		s.emit(EventUnpaused, c)
+	}
+	return nil
+}
+
+// pauseAllHandler and resumeAllHandler have htmx reload the page, since every timer and the banner change.
+func (s *Server) pauseAllHandler(w http.ResponseWriter, r *http.Request) error {
+	if err := s.pauseAll(r.Context()); err != nil {
+		return err
+	}
+	w.Header().Set("HX-Refresh", "true")
+	return nil
+}
+
+func (s *Server) resumeAllHandler(w http.ResponseWriter, r *http.Request) error {
+	if err := s.resumeAll(r.Context()); err != nil {
+		return err
+	}
+	w.Header().Set("HX-Refresh", "true")
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestVacation tests that a vacation pauses every timer and that resuming puts the schedules of the ones that it
+// paused back by its length, only once
+func TestVacation(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	location = time.UTC
+	now := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	shifted := &offsetClock{base: fixedClock(now)}
+	clock = shifted
+	day := 24 * time.Hour
+
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+	create := func(c CountDown) CountDown {
+		if err := s.createTimer(t.Context(), &c); err != nil {
+			t.Fatalf("Failed to create %q: %v", c.Name, err)
+		}
+		return c
+	}
+	get := func(c CountDown) CountDown {
+		got, err := s.getTimer(t.Context(), c.Id)
+		if err != nil {
+			t.Fatalf("Failed to read %q: %v", c.Name, err)
+		}
+		return got
+	}
+
+	plants := create(CountDown{Name: "Water plants", LastTime: now.Add(-3 * day), Frequency: 7 * day})
+	fig := create(CountDown{Name: "Water the fig tree", LastTime: now.Add(-60 * day), Frequency: 7 * day})
+	if err := s.pauseTimer(t.Context(), fig.Id); err != nil {
+		t.Fatal(err)
+	}
+	fig = get(fig)
+
+	if w := serve("GET", "/"); strings.Contains(w.Body.String(), "On vacation") {
+		t.Error("Expected no vacation banner before going away")
+	}
+	w := serve("POST", "/pause-all")
+	if w.Code != http.StatusOK || w.Header().Get("HX-Refresh") != "true" {
+		t.Fatalf("Expected status OK with a refresh, got %v %q: %s", w.Code, w.Header().Get("HX-Refresh"), w.Body.String())
+	}
+	if got := get(plants); !got.Paused() || got.Overdue() {
+		t.Errorf("Expected the timers to be paused on vacation, got %+v", got)
+	}
+	if w := serve("GET", "/"); !strings.Contains(w.Body.String(), "On vacation since Tue Jul 1, 2025") {
+		t.Errorf("Expected the vacation banner, got %s", w.Body.String())
+	}
+
+	// Pausing everything again later doesn't start a new vacation.
+	shifted.SetOffset(day)
+	serve("POST", "/pause-all")
+	if started, err := s.vacation(t.Context()); err != nil || !started.Equal(now) {
+		t.Errorf("Expected the vacation to have started at %v, got %v, %v", now, started, err)
+	}
+
+	shifted.SetOffset(14 * day)
+	during := create(CountDown{Name: "Feed the neighbour's cat", LastTime: now.Add(13 * day), Frequency: day})
+	if w := serve("POST", "/resume-all"); w.Code != http.StatusOK || w.Header().Get("HX-Refresh") != "true" {
+		t.Fatalf("Expected status OK with a refresh, got %v: %s", w.Code, w.Body.String())
+	}
+
+	check := func() {
+		t.Helper()
+		if got := get(plants); got.Paused() || !got.LastTime.Equal(plants.LastTime) || got.PausedFor != 14*day {
+			t.Errorf("Expected the schedule to be put back by the 14 days away, got %v, last done %v", got.PausedFor, got.LastTime)
+		}
+		if got := get(fig); !got.PausedAt.Equal(fig.PausedAt) || !got.LastTime.Equal(fig.LastTime) {
+			t.Errorf("Expected the timer paused before the vacation to be left alone, got %+v", got)
+		}
+		if got := get(during); got.Paused() || !got.LastTime.Equal(during.LastTime) {
+			t.Errorf("Expected the timer created during the vacation to be left alone, got %+v", got)
+		}
+		if started, err := s.vacation(t.Context()); err != nil || !started.IsZero() {
+			t.Errorf("Expected the vacation to be over, got %v, %v", started, err)
+		}
+	}
+	check()
+	if status := get(plants).DueStatus(); status != "Do it again in 4 days" {
+		t.Errorf("Expected it to be due as long after the vacation as before it, got %q", status)
+	}
+
+	// Resuming again changes nothing.
+	shifted.SetOffset(20 * day)
+	if w := serve("POST", "/resume-all"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	check()
+}
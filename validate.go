@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// colorPattern is the only form of color that's stored, which makes it safe to put in a style attribute.
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// validateTimer checks a timer before it is written to the database, failing with a 400.
+func validateTimer(c CountDown) error {
+	if err := validateFrequency(c); err != nil {
+		return err
+	}
+	if err := validateTimeOfDay(c); err != nil {
+		return err
+	}
+	if err := validateCron(c); err != nil {
+		return err
+	}
+	if err := validateAnchor(c); err != nil {
+		return err
+	}
+	if err := validateLimits(c); err != nil {
+		return err
+	}
+	if err := validateGrace(c); err != nil {
+		return err
+	}
+	if err := validatePriority(c); err != nil {
+		return err
+	}
+	if err := validateTags(c); err != nil {
+		return err
+	}
+	if err := validateIcon(c); err != nil {
+		return err
+	}
+	if err := validateTarget(c); err != nil {
+		return err
+	}
+	if c.Color != "" && !colorPattern.MatchString(c.Color) {
+		return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing color %q: expected a hex color like #1e90ff", c.Color)}
+	}
+	if c.ReferenceURL != "" {
+		if _, err := parseHTTPURL(c.ReferenceURL); err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing reference URL: %w", err)}
+		}
+	}
+	return nil
+}
+
+// parseHTTPURL parses an absolute http or https URL, which is all that we'll ever link to or fetch.
+// Everything else, notably javascript: and data: URLs, is rejected.
+func parseHTTPURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%q must start with http:// or https://", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%q has no host", raw)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%q must not contain credentials", raw)
+	}
+	return u, nil
+}
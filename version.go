@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Each timer has a version that goes up by one with every edit, so that an edit made from what a client read earlier
+// can't silently overwrite one made since, from another tab say. An edit has to send the version that it started
+// from and fails with a 409 if the timer has moved on. Resetting, skipping and the other actions on a timer don't
+// check it or change it, they only touch their own fields.
+
+// versionConflictError is an edit of a timer that has been edited since the client read it, Current is the timer as
+// it is now.
+type versionConflictError struct {
+	Current CountDown
+	version int // The version that the edit was made from.
+}
+
+func (e versionConflictError) HTTPStatusCode() int { return http.StatusConflict }
+func (e versionConflictError) Error() string {
+	return fmt.Sprintf("%q was changed since version %d, it's at version %d now. Make the change again to that", e.Current.Name, e.version, e.Current.Version)
+}
+
+// checkVersion fails for an edit of current made from version, which is 0 when the client didn't send one.
+func checkVersion(current CountDown, version int) error {
+	if version == 0 {
+		return httpError{http.StatusPreconditionRequired, fmt.Errorf("An edit needs the version of %q that it was made from, it's at version %d", current.Name, current.Version)}
+	}
+	if version != current.Version {
+		return versionConflictError{current, version}
+	}
+	return nil
+}
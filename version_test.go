@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestEditConflict tests that an edit made from a stale copy of a timer, like one in another tab, is refused with the
+// timer as it is now rather than overwriting the edit made since
+func TestEditConflict(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	if w := serveAPI(t, s, "POST", "/api/v1/timers", `{"name":"Oil change","frequency":"3 months"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create the timer: %v %s", w.Code, w.Body.String())
+	}
+	get := func() CountDown {
+		var c CountDown
+		decodeResponse(t, serveAPI(t, s, "GET", "/api/v1/timers/1", ""), &c)
+		return c
+	}
+
+	// Both tabs load the timer.
+	if c := get(); c.Version != 1 {
+		t.Fatalf("Expected a new timer to be at version 1, got %+v", c)
+	}
+
+	w := serveAPI(t, s, "PUT", "/api/v1/timers/1", `{"name":"Oil change","description":"The truck","frequency":"3 months","version":1}`)
+	var c CountDown
+	decodeResponse(t, w, &c)
+	if w.Code != http.StatusOK || c.Version != 2 {
+		t.Fatalf("Expected the first tab's edit to make version 2, got %v: %s", w.Code, w.Body.String())
+	}
+
+	// Resetting doesn't need the version, nor change it.
+	if w := serveAPI(t, s, "POST", "/api/v1/timers/1/reset", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+
+	// The second tab still has version 1.
+	w = serveAPI(t, s, "PUT", "/api/v1/timers/1", `{"name":"Oil change","description":"The car","frequency":"6 months","version":1}`)
+	decodeResponse(t, w, &c)
+	if w.Code != http.StatusConflict || c.Description != "The truck" || c.Version != 2 {
+		t.Errorf("Expected a conflict with the timer as it is now, got %v: %s", w.Code, w.Body.String())
+	}
+	if w := serveAPI(t, s, "PUT", "/api/v1/timers/1", `{"name":"Oil change","description":"The car","frequency":"6 months"}`); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("Expected an edit without a version to be Precondition Required, got %v: %s", w.Code, w.Body.String())
+	}
+	if c := get(); c.Description != "The truck" || c.Schedule() != "every 3 months" || c.Version != 2 || c.LastTime.IsZero() {
+		t.Errorf("Expected the refused edits to leave the timer be, got %+v", c)
+	}
+
+	// Making the change again to the current version works.
+	if w := serveAPI(t, s, "PUT", "/api/v1/timers/1", `{"name":"Oil change","description":"The car","frequency":"6 months","version":2}`); w.Code != http.StatusOK {
+		t.Errorf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if c := get(); c.Description != "The car" || c.Version != 3 {
+		t.Errorf("Expected the edit at version 3, got %+v", c)
+	}
+}
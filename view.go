@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// timerView is a timer as every representation shows it: pages, htmx fragments and JSON are all rendered from it,
+// so that what the dashboard shows can't drift from what the JSON says, e.g. whether the timer is overdue.
+// The computed fields shadow the CountDown methods of the same name, so templates work with either.
+type timerView struct {
+	CountDown
+	NextDue   time.Time
+	Overdue   bool
+	State     dueState
+	Stale     bool
+	Finished  bool
+	Paused    bool
+	Schedule  string
+	DueStatus string
+}
+
+// newTimerView works out everything that c's representations show, as of now.
+func newTimerView(c CountDown) timerView {
+	return timerView{
+		CountDown: c,
+		NextDue:   c.NextDue(),
+		Overdue:   c.Overdue(),
+		State:     c.State(),
+		Stale:     c.Stale(),
+		Finished:  c.Finished(),
+		Paused:    c.Paused(),
+		Schedule:  c.Schedule(),
+		DueStatus: c.DueStatus(),
+	}
+}
+
+// newTimerViews is newTimerView for a list of timers, which is never nil so that it marshals as [].
+func newTimerViews(timers []CountDown) []timerView {
+	views := make([]timerView, 0, len(timers))
+	for _, c := range timers {
+		views = append(views, newTimerView(c))
+	}
+	return views
+}
+
+// timerCard is a timer in a list, with its card already rendered. It marshals as its timerView.
+type timerCard struct {
+	timerView
+	Card   template.HTML
+	Bucket dueBucket // The section it's listed in, empty when the list isn't in sections. See bucket.go.
+}
+
+// renderCards renders each view's card on its own with render, s.render or s.renderStatic, so that one timer that
+// breaks its card doesn't take the whole list down with it. A card that fails is logged and replaced by the
+// "carderror" card naming the timer.
+func renderCards(ctx context.Context, render func(context.Context, io.Writer, string, any) error, views []timerView) []timerCard {
+	cards := make([]timerCard, 0, len(views))
+	for _, v := range views {
+		var buf bytes.Buffer
+		if err := render(ctx, &buf, "timer", v); err != nil {
+			log.Printf("Error rendering the card of timer %d for request %s: %v\n", v.Id, requestID(ctx), err)
+			buf.Reset()
+			if err := render(ctx, &buf, "carderror", v.Id); err != nil {
+				log.Printf("Error rendering the error card of timer %d for request %s: %v\n", v.Id, requestID(ctx), err)
+				buf.Reset()
+			}
+		}
+		cards = append(cards, timerCard{timerView: v, Card: template.HTML(buf.String())})
+	}
+	return cards
+}
+
+// homePageData is what the homepage shows: every timer's card, or those of the timers with Tag, a banner while
+// on vacation and the summary of every timer in its header.
+type homePageData struct {
+	Cards    []timerCard
+	Vacation time.Time // When the ongoing vacation started in location, zero when there isn't one.
+	Tag      string    // The tag that the list is filtered by, empty for every timer.
+	Query    string    // The search that the list is filtered by, see search.go.
+	Summary  timerSummary
+	Sort     string   // The ?sort= that the cards are in, empty for the homepage's own order. See sort.go.
+	More     string   // The URL of the rest of the list when the cards are only its start, see scroll.go.
+	Empty    bool     // There are no timers at all, so the list asks for the first one instead.
+	State    dueState // The state that the list is filtered by, empty for every timer. See parseFilter.
+}
+
+// Filter is the choice of the segmented control that State is, none for ?state=ok which it doesn't offer.
+func (d homePageData) Filter() string {
+	if d.State == "" {
+		return filterAll
+	}
+	return string(d.State)
+}
+
+// FilterOptions are the choices of the homepage's segmented control.
+func (d homePageData) FilterOptions() []filterOption { return filterOptions }
+
+// Chunk is the cards as the whole list's only chunk, or its first.
+func (d homePageData) Chunk() timerChunk { return timerChunk{Cards: d.Cards, More: d.More} }
+
+// SortOptions are the choices for the homepage's order.
+func (d homePageData) SortOptions() []sortOption { return sortOptions }
+
+// MarshalJSON is just the cards, the JSON of the homepage is the list of timers.
+func (d homePageData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Cards)
+}
+
+// MarshalJSON is CountDown's JSON with the computed fields added, see timerViewSchema.
+func (v timerView) MarshalJSON() ([]byte, error) {
+	type countDown CountDown // Drops the methods so that CountDown.MarshalJSON isn't promoted.
+	return json.Marshal(struct {
+		countDown
+		NextDue   time.Time `json:"nextDue,omitzero"`
+		Overdue   bool      `json:"overdue"`
+		State     dueState  `json:"state"`
+		Stale     bool      `json:"stale"`
+		Finished  bool      `json:"finished"`
+		Paused    bool      `json:"paused"`
+		Schedule  string    `json:"schedule,omitempty"`
+		DueStatus string    `json:"dueStatus,omitempty"`
+	}{countDown(v.CountDown), v.NextDue, v.Overdue, v.State, v.Stale, v.Finished, v.Paused, v.Schedule, v.DueStatus})
+}
+
+// respond renders view in the representation negotiated for r: JSON when ct is application/json, the fragment
+// template for htmx requests, and the page template for everyone else, including htmx following a boosted link.
+// fragment can be empty for routes that htmx never requests.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, ct string, view any, page, fragment string) error {
+	if fragment != "" {
+		w.Header().Add("Vary", "HX-Request")
+		w.Header().Add("Vary", "HX-Boosted")
+	}
+	switch {
+	case ct == "application/json":
+		return encodeJSON(w, view)
+	case fragment != "" && r.Header.Get("HX-Request") != "" && r.Header.Get("HX-Boosted") == "":
+		return s.render(r.Context(), w, fragment, view)
+	}
+	return s.render(r.Context(), w, page, view)
+}
+
+// timerUpdated responds to a change to the timer with id with its card, for htmx to swap in for the one that asked,
+// the timerUpdate/{id} event for anything else on the page that shows the timer, like its history, and the
+// timersChanged event for what shows them all, like the homepage's recent completions.
+func (s *Server) timerUpdated(w http.ResponseWriter, r *http.Request, id int64) error {
+	c, err := s.getTimer(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("HX-Trigger", "timerUpdate/"+strconv.FormatInt(id, 10)+", timersChanged")
+	return s.render(r.Context(), w, "timer", newTimerView(c))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRepresentations tests that the page, the htmx fragment and the JSON of the same route agree about a timer
+func TestRepresentations(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+
+	db := setupTestDB(t)
+	s := &Server{db: db}
+	overdue := CountDown{Name: "Water plants", LastTime: now.Add(-72 * time.Hour), Frequency: 48 * time.Hour}
+	if err := insertTimer(t.Context(), db, &overdue); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	get := func(target, accept string, htmx bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if htmx {
+			req.Header.Set("HX-Request", "true")
+		}
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Fatalf("Expected 200 for %s, got %d: %s", target, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	for _, tt := range []struct {
+		target   string
+		fragment string // What only the fragment starts with.
+	}{
+		{"/", `<div id="timerList"`},
+		{fmt.Sprintf("/timer/%d", overdue.Id), fmt.Sprintf(`<div id="timer-%d"`, overdue.Id)},
+	} {
+		t.Run(tt.target, func(t *testing.T) {
+			page := get(tt.target, "", false)
+			if !strings.HasPrefix(page.Body.String(), "<!DOCTYPE html>") {
+				t.Errorf("Expected a full page, got %q", page.Body.String())
+			}
+			if !slices.Contains(page.Header().Values("Vary"), "HX-Request") {
+				t.Errorf("Expected the page to vary on HX-Request, got %q", page.Header().Values("Vary"))
+			}
+
+			fragment := get(tt.target, "", true)
+			if body := strings.TrimSpace(fragment.Body.String()); !strings.HasPrefix(body, tt.fragment) {
+				t.Errorf("Expected only the fragment for htmx, got %q", body)
+			}
+
+			// The JSON carries what the HTML shows, the homepage's as a list of the one timer.
+			w := get(tt.target, "application/json", false)
+			var fields map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &fields); err != nil {
+				var list []map[string]any
+				if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 {
+					t.Fatalf("Failed to unmarshal %s: %v", w.Body.String(), err)
+				}
+				fields = list[0]
+			}
+			if fields["overdue"] != true || fields["schedule"] != "every 2 days" || fields["dueStatus"] != "Overdue by 1 day" {
+				t.Errorf("Expected the overdue state in the JSON, got %v", fields)
+			}
+			for _, body := range []string{page.Body.String(), fragment.Body.String()} {
+				if !strings.Contains(body, "bg-danger-subtle") || !strings.Contains(body, "Repeats every 2 days") || !strings.Contains(body, "Overdue by 1 day") {
+					t.Errorf("Expected the HTML to show the same state as the JSON, got %q", body)
+				}
+			}
+		})
+	}
+}
+
+// TestCardFailure tests that a timer whose card can't be rendered is replaced by an error card, logged with the
+// request id, and that the rest of the homepage still renders
+func TestCardFailure(t *testing.T) {
+	// A card that only breaks on the poisoned timer, like one with data it doesn't expect.
+	dir := t.TempDir()
+	card := `<div class="timer" id="timer-{{.Id}}">{{.Name}}{{if eq .Name "Poisoned"}}{{index .Description 99}}{{end}}</div>`
+	if err := os.WriteFile(filepath.Join(dir, "timer.html"), []byte(card), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db := setupTestDB(t)
+	testTimers := insertTestData(t, db)
+	poisoned := CountDown{Name: "Poisoned", Frequency: time.Hour}
+	if err := insertTimer(t.Context(), db, &poisoned); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{db: db}
+	var err error
+	if s.templates, s.staticTemplates, _, err = overrideTemplates(dir, nil); err != nil {
+		t.Fatalf("Failed to override templates: %v", err)
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-Id", "poison-1")
+	w := httptest.NewRecorder()
+	s.mux().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, c := range testTimers {
+		if !strings.Contains(body, fmt.Sprintf(`<div class="timer" id="timer-%d">%s</div>`, c.Id, c.Name)) {
+			t.Errorf("Expected the card of %q, got %s", c.Name, body)
+		}
+	}
+	if !strings.Contains(body, fmt.Sprintf("Timer %d couldn&#39;t be shown", poisoned.Id)) {
+		t.Errorf("Expected an error card for timer %d, got %s", poisoned.Id, body)
+	}
+	if !strings.Contains(body, "Count up Timer") {
+		t.Errorf("Expected the rest of the page, got %s", body)
+	}
+	if got := w.Header().Get("X-Request-Id"); got != "poison-1" {
+		t.Errorf("Expected the request id back, got %q", got)
+	}
+	if !strings.Contains(logs.String(), fmt.Sprintf("timer %d for request poison-1", poisoned.Id)) {
+		t.Errorf("Expected the failure logged with the request id, got %q", logs.String())
+	}
+}
+
+// TestCardTimezone tests that the card shows times in location whatever offset they were stored with
+func TestCardTimezone(t *testing.T) {
+	defer func(c Clock) { clock = c }(clock)
+	defer func(l *time.Location) { location = l }(location)
+	var err error
+	if location, err = time.LoadLocation("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	clock = fixedClock(now)
+	// 10 PM on Tuesday in New York, Wednesday in UTC.
+	c := CountDown{Id: 1, Name: "Floss", LastTime: time.Date(2025, 3, 5, 3, 0, 0, 0, time.UTC), Frequency: 24 * time.Hour}
+
+	var live, static bytes.Buffer
+	if err := (&Server{}).render(t.Context(), &live, "timer", newTimerView(c)); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Server{}).renderStatic(t.Context(), &static, "timer", newTimerView(c)); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{">Tue Mar 4, 2025</span>", "Wed Mar 5, 2025 10:00 PM"} {
+		if !strings.Contains(live.String(), expected) {
+			t.Errorf("Expected %q in the card, got %s", expected, live.String())
+		}
+	}
+	if !strings.Contains(static.String(), "Last happened Tue Mar 4, 2025 10:00 PM") {
+		t.Errorf("Expected the last time in New York in the static card, got %s", static.String())
+	}
+}
+
+// TestHomepageEmptyState tests that a fresh database asks for its first timer, which then replaces the ask, and that
+// a list that's only empty for its filters doesn't
+func TestHomepageEmptyState(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	const ask = "No timers yet"
+	if body := serveAPI(t, s, "GET", "/", "").Body.String(); !strings.Contains(body, ask) || !strings.Contains(body, `data-bs-target="#createTimer"`) {
+		t.Errorf("Expected the empty homepage to ask for a timer, got %s", body)
+	}
+
+	create := func(name string) *httptest.ResponseRecorder {
+		form := url.Values{"name": {name}, "lasttime": {"2025-03-10T09:00"}, "frequencyValue": {"1"}, "frequencyUnit": {UnitWeek}}
+		req := httptest.NewRequest("POST", "/timer", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		s.mux().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create %s: %v %s", name, w.Code, w.Body.String())
+		}
+		return w
+	}
+	if got := create("Floss").Header().Get("HX-Reswap"); got != "innerHTML" {
+		t.Errorf("Expected the first timer to replace the list's contents, got HX-Reswap %q", got)
+	}
+	if got := create("Bins").Header().Get("HX-Reswap"); got != "" {
+		t.Errorf("Expected the second timer to go at the top of the list, got HX-Reswap %q", got)
+	}
+
+	for _, target := range []string{"/", "/?q=furnace", "/?state=overdue"} {
+		if body := serveAPI(t, s, "GET", target, "").Body.String(); strings.Contains(body, ask) {
+			t.Errorf("Expected %s not to ask for a timer, got %s", target, body)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GET /timer/{id}/widget is a single timer as a page of its own, to put on a start page or a wall display in an
+// iframe. It has no header, buttons or script, just the timer's name, when it was last done and when it's next due,
+// colored by how urgent it is, and it reloads itself to stay current.
+
+// How often a widget reloads. Its times are only ever to the minute.
+const widgetRefresh = time.Minute
+
+// widgetView is a timer as its widget, ?compact=1 on a single line.
+type widgetView struct {
+	timerView
+	Compact bool
+}
+
+// RefreshSeconds is the widget's meta refresh.
+func (v widgetView) RefreshSeconds() int { return int(widgetRefresh / time.Second) }
+
+// widgetHandler serves a timer's widget. It says outright that any site can frame it, which browsers also take over an
+// X-Frame-Options that a proxy in front adds to every page.
+func (s *Server) widgetHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := timerID(r)
+	if err != nil {
+		return err
+	}
+	var compact bool
+	if v := r.URL.Query().Get("compact"); v != "" {
+		if compact, err = strconv.ParseBool(v); err != nil {
+			return httpError{http.StatusBadRequest, fmt.Errorf("Error parsing query 'compact': %w", err)}
+		}
+	}
+	if _, err := negotiate(w, r, "text/html"); err != nil {
+		return err
+	}
+	c, err := s.getTimer(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Security-Policy", "frame-ancestors *")
+	// It reloads itself, so a cached copy would only show the same times again.
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return s.render(r.Context(), w, "widget", widgetView{newTimerView(c), compact})
+}
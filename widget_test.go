@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWidget tests that a timer's widget can be framed by any site, reloads itself and has nothing of the rest of the
+// app on it
+func TestWidget(t *testing.T) {
+	s := &Server{db: setupTestDB(t)}
+	c := CountDown{Name: "Water plants", LastTime: clock.Now().Add(-3 * 24 * time.Hour), Frequency: 24 * time.Hour}
+	if err := s.createTimer(t.Context(), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serveAPI(t, s, "GET", "/timer/1/widget", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); csp != "frame-ancestors *" {
+		t.Errorf("Expected any site to be able to frame the widget, got %q", csp)
+	}
+	body := w.Body.String()
+	for _, expected := range []string{`<meta http-equiv="refresh" content="60">`, "Water plants", "Last happened", "Overdue by 2 days", "border-danger"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in the widget, got %s", expected, body)
+		}
+	}
+	for _, unexpected := range []string{"htmx", "<header", "modal", "hx-post"} {
+		if strings.Contains(body, unexpected) {
+			t.Errorf("Expected no %q in the widget, got %s", unexpected, body)
+		}
+	}
+
+	if body := serveAPI(t, s, "GET", "/timer/1/widget?compact=1", "").Body.String(); !strings.Contains(body, "timer-compact") || strings.Contains(body, "Last happened") {
+		t.Errorf("Expected the compact widget, got %s", body)
+	}
+	if w := serveAPI(t, s, "GET", "/timer/1/widget?compact=maybe", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a Bad Request, got %v: %s", w.Code, w.Body.String())
+	}
+	if w := serveAPI(t, s, "GET", "/timer/2/widget", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected Not Found, got %v: %s", w.Code, w.Body.String())
+	}
+	if w := serveAPI(t, s, "GET", "/timer/1", ""); w.Header().Get("Content-Security-Policy") != "" {
+		t.Errorf("Expected only the widget to be frameable, got %v", w.Header())
+	}
+}